@@ -7,6 +7,4 @@ else
     exit 1
 fi
 
-goose -dir migrations postgres "host=$DB_HOST port=$DB_PORT user=$DB_USER dbname=$DB_NAME password=$DB_PASSWORD sslmode=$DB_SSL_MODE" up
-
-cd ./src/cmd && go run .
\ No newline at end of file
+cd ./src/cmd && go run . migrate up && go run . serve
\ No newline at end of file
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE quote.api_keys
+(
+    id         uuid PRIMARY KEY,
+    name       text        NOT NULL,
+    key_hash   bytea       NOT NULL,
+    scopes     text[]      NOT NULL,
+    created_at timestamptz NOT NULL,
+    revoked_at timestamptz
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE quote.api_keys;
+-- +goose StatementEnd
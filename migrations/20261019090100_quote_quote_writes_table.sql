@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE quote.quote_writes
+(
+    id          bigserial PRIMARY KEY,
+    quote_id    uuid        NOT NULL,
+    action      text        NOT NULL,
+    api_key_id  uuid REFERENCES quote.api_keys (id),
+    occurred_at timestamptz NOT NULL DEFAULT now()
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX index_quote_quote_writes_quote_id ON quote.quote_writes (quote_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE quote.quote_writes;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE quote.quotes
+    ADD COLUMN created_by text,
+    ADD COLUMN updated_by text;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE quote.quotes
+    DROP COLUMN created_by,
+    DROP COLUMN updated_by;
+-- +goose StatementEnd
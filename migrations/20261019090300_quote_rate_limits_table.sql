@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The buckets are cheap to lose on a crash, so the table skips the WAL.
+CREATE UNLOGGED TABLE quote.rate_limits
+(
+    key        text PRIMARY KEY,
+    tokens     double precision NOT NULL,
+    updated_at timestamptz      NOT NULL,
+    full_at    timestamptz      NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX index_quote_rate_limits_full_at ON quote.rate_limits (full_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE quote.rate_limits;
+-- +goose StatementEnd
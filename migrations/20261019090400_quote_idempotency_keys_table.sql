@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE quote.idempotency_keys
+(
+    scope        text        NOT NULL,
+    key          text        NOT NULL,
+    fingerprint  bytea       NOT NULL,
+    -- The response columns are NULL while the request is in flight.
+    status_code  integer,
+    header       jsonb,
+    body         bytea,
+    locked_until timestamptz,
+    expires_at   timestamptz NOT NULL,
+    PRIMARY KEY (scope, key)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX index_quote_idempotency_keys_expires_at ON quote.idempotency_keys (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE quote.idempotency_keys;
+-- +goose StatementEnd
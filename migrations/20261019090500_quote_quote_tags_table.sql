@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE quote.quote_tags
+(
+    quote_id uuid NOT NULL REFERENCES quote.quotes (id) ON DELETE CASCADE,
+    tag      text NOT NULL,
+    PRIMARY KEY (quote_id, tag)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX index_quote_quote_tags_tag ON quote.quote_tags (tag);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE quote.quote_tags;
+-- +goose StatementEnd
@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE quote.webhooks
+(
+    id         uuid PRIMARY KEY,
+    url        text        NOT NULL,
+    secret     text        NOT NULL,
+    events     text[]      NOT NULL,
+    active     boolean     NOT NULL,
+    created_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE quote.webhook_deliveries
+(
+    id               bigserial PRIMARY KEY,
+    webhook_id       uuid        NOT NULL REFERENCES quote.webhooks (id) ON DELETE CASCADE,
+    event_id         uuid        NOT NULL,
+    event_type       text        NOT NULL,
+    payload          jsonb       NOT NULL,
+    status           text        NOT NULL DEFAULT 'pending',
+    attempts         integer     NOT NULL DEFAULT 0,
+    next_attempt_at  timestamptz NOT NULL DEFAULT now(),
+    last_status_code integer,
+    last_error       text,
+    created_at       timestamptz NOT NULL DEFAULT now(),
+    delivered_at     timestamptz
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX index_quote_webhook_deliveries_pending ON quote.webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX index_quote_webhook_deliveries_webhook_id ON quote.webhook_deliveries (webhook_id, id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX index_quote_webhook_deliveries_finished ON quote.webhook_deliveries (created_at) WHERE status <> 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE quote.webhook_deliveries;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE quote.webhooks;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE quote.outbox_events
+(
+    sequence     bigserial PRIMARY KEY,
+    id           uuid        NOT NULL UNIQUE,
+    event_type   text        NOT NULL,
+    aggregate_id uuid        NOT NULL,
+    data         jsonb       NOT NULL,
+    occurred_at  timestamptz NOT NULL DEFAULT now(),
+    published_at timestamptz
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX index_quote_outbox_events_unpublished ON quote.outbox_events (sequence) WHERE published_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX index_quote_outbox_events_published_at ON quote.outbox_events (published_at) WHERE published_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE quote.outbox_events;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE quote.quotes
+    ADD COLUMN created_at timestamptz NOT NULL DEFAULT now();
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Quotes created since the writes are recorded get the time of their create, older ones the time of the migration.
+UPDATE quote.quotes q
+SET created_at = w.occurred_at
+FROM (SELECT quote_id, min(occurred_at) AS occurred_at FROM quote.quote_writes WHERE action = 'create' GROUP BY quote_id) w
+WHERE w.quote_id = q.id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX index_quote_quotes_created_at ON quote.quotes (created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX quote.index_quote_quotes_created_at;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE quote.quotes
+    DROP COLUMN created_at;
+-- +goose StatementEnd
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/BernsteinMondy/quote-service/src/internal/auth"
+	"github.com/BernsteinMondy/quote-service/src/internal/impl"
+	"github.com/google/uuid"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const apiKeyUsage = `Usage:
+  apikey create -name <name> -scopes <scope>[,<scope>...]
+  apikey list
+  apikey revoke <id>
+
+Scopes: quotes:read, quotes:write, quotes:delete, admin`
+
+func runAPIKey(args []string) error {
+	if len(args) == 0 {
+		return errors.New(apiKeyUsage)
+	}
+
+	switch args[0] {
+	case "create":
+		return runAPIKeyCreate(args[1:])
+	case "list":
+		return withKeyService(printAPIKeys)
+	case "revoke":
+		if len(args) != 2 {
+			return errors.New(apiKeyUsage)
+		}
+		id, err := uuid.Parse(args[1])
+		if err != nil {
+			return fmt.Errorf("parse key id: %w", err)
+		}
+		return withKeyService(func(ctx context.Context, keys *auth.KeyService) error {
+			err := keys.RevokeAPIKey(ctx, id)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("API key %s revoked\n", id)
+			return nil
+		})
+	case "help", "-h", "--help":
+		fmt.Println(apiKeyUsage)
+		return nil
+	default:
+		return fmt.Errorf("unknown apikey command %q\n\n%s", args[0], apiKeyUsage)
+	}
+}
+
+func runAPIKeyCreate(args []string) error {
+	flags := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+	name := flags.String("name", "", "human readable name of the key owner")
+	rawScopes := flags.String("scopes", "", "comma separated list of scopes")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *name == "" || *rawScopes == "" {
+		return errors.New(apiKeyUsage)
+	}
+	scopes, err := auth.ParseScopes(strings.Split(*rawScopes, ","))
+	if err != nil {
+		return fmt.Errorf("parse scopes: %w", err)
+	}
+
+	return withKeyService(func(ctx context.Context, keys *auth.KeyService) error {
+		key, plaintext, err := keys.CreateAPIKey(ctx, *name, scopes)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("API key %s created, store it now, it can not be shown again:\n%s\n", key.ID, plaintext)
+		return nil
+	})
+}
+
+func printAPIKeys(ctx context.Context, keys *auth.KeyService) error {
+	list, err := keys.ListAPIKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tNAME\tSCOPES\tCREATED AT\tREVOKED AT")
+	for _, key := range list {
+		scopes := make([]string, len(key.Scopes))
+		for i, scope := range key.Scopes {
+			scopes[i] = string(scope)
+		}
+		revokedAt := "-"
+		if key.RevokedAt != nil {
+			revokedAt = key.RevokedAt.Format(time.RFC3339)
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			key.ID, key.Name, strings.Join(scopes, ","), key.CreatedAt.Format(time.RFC3339), revokedAt)
+	}
+
+	return w.Flush()
+}
+
+func withKeyService(fn func(ctx context.Context, keys *auth.KeyService) error) error {
+	return withDB(func(ctx context.Context, db *sql.DB) error {
+		return fn(ctx, auth.NewKeyService(impl.NewAPIKeyRepository(db)))
+	})
+}
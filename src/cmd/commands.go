@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/BernsteinMondy/quote-service/migrations"
+	"github.com/BernsteinMondy/quote-service/src/pkg/database"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+// version is set at build time with -ldflags "-X main.version=<version>".
+var version = "dev"
+
+const usage = `Usage: quote-service <command> [arguments]
+
+Commands:
+  serve                          Run the HTTP server (default when no command is given)
+  migrate up|down|status|redo    Manage the database schema
+  apikey create|list|revoke      Manage API keys, see "apikey help"
+  version                        Print the build version`
+
+func runCommand(args []string) error {
+	if len(args) == 0 {
+		return run()
+	}
+
+	switch args[0] {
+	case "serve":
+		return run()
+	case "migrate":
+		return runMigrate(args[1:])
+	case "apikey":
+		return runAPIKey(args[1:])
+	case "version":
+		fmt.Println(version)
+		return nil
+	case "help", "-h", "--help":
+		fmt.Println(usage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", args[0], usage)
+	}
+}
+
+func runMigrate(args []string) error {
+	if len(args) != 1 {
+		return errors.New("migrate expects exactly one of: up, down, status, redo")
+	}
+
+	return withDB(func(ctx context.Context, db *sql.DB) error {
+		migrator, err := database.NewMigrator(db, migrations.FS)
+		if err != nil {
+			return fmt.Errorf("new migrator: %w", err)
+		}
+
+		switch args[0] {
+		case "up":
+			return migrator.Up(ctx)
+		case "down":
+			return migrator.Down(ctx)
+		case "redo":
+			return migrator.Redo(ctx)
+		case "status":
+			return printMigrationStatus(ctx, migrator)
+		default:
+			return fmt.Errorf("unknown migrate command %q", args[0])
+		}
+	})
+}
+
+// withDB runs fn with a database connection built from the DB_* environment variables.
+func withDB(fn func(ctx context.Context, db *sql.DB) error) (err error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := loadDBConfigFromEnv()
+	if err != nil {
+		return fmt.Errorf("load db config from env: %w", err)
+	}
+
+	db, err := sqlDB(cfg)
+	if err != nil {
+		return fmt.Errorf("new sql database: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, db.Close())
+	}()
+
+	err = database.WaitForConnection(ctx, db, cfg.StartupTimeout)
+	if err != nil {
+		return fmt.Errorf("wait for database connection: %w", err)
+	}
+
+	return fn(ctx, db)
+}
+
+func printMigrationStatus(ctx context.Context, migrator *database.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "APPLIED AT\tMIGRATION")
+	for _, status := range statuses {
+		appliedAt := "Pending"
+		if status.Applied {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\n", appliedAt, status.Name)
+	}
+
+	return w.Flush()
+}
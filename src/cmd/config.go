@@ -3,11 +3,28 @@ package main
 import (
 	"fmt"
 	"github.com/caarlos0/env/v11"
+	"log/slog"
+	"time"
 )
 
 type Config struct {
-	Server HTTPServer `envPrefix:"HTTP_SERVER_"`
-	DB     DB         `envPrefix:"DB_"`
+	Server        HTTPServer    `envPrefix:"HTTP_SERVER_"`
+	GRPC          GRPC          `envPrefix:"GRPC_"`
+	GraphQL       GraphQL       `envPrefix:"GRAPHQL_"`
+	Stream        Stream        `envPrefix:"STREAM_"`
+	Rotation      Rotation      `envPrefix:"ROTATION_"`
+	Cards         Cards         `envPrefix:"CARDS_"`
+	SlashCommands SlashCommands `envPrefix:"SLASH_COMMANDS_"`
+	Webhooks      Webhooks      `envPrefix:"WEBHOOKS_"`
+	Outbox        Outbox        `envPrefix:"OUTBOX_"`
+	DB            DB            `envPrefix:"DB_"`
+	Metrics       Metrics       `envPrefix:"METRICS_"`
+	Tracing       Tracing       `envPrefix:"TRACING_"`
+	Auth          Auth          `envPrefix:"AUTH_"`
+	RateLimit     RateLimit     `envPrefix:"RATE_LIMIT_"`
+	Validation    Validation    `envPrefix:"VALIDATION_"`
+	CORS          CORS          `envPrefix:"CORS_"`
+	Log           Log           `envPrefix:"LOG_"`
 }
 
 type DB struct {
@@ -17,10 +34,201 @@ type DB struct {
 	Host     string `env:"HOST,notEmpty"`
 	Port     int    `env:"PORT,notEmpty"`
 	SSLMode  string `env:"SSL_MODE,notEmpty"`
+	// AutoMigrate applies pending migrations on startup before the HTTP server is launched.
+	AutoMigrate bool `env:"AUTO_MIGRATE" envDefault:"false"`
+
+	MaxOpenConns    int           `env:"MAX_OPEN_CONNS" envDefault:"25"`
+	MaxIdleConns    int           `env:"MAX_IDLE_CONNS" envDefault:"25"`
+	ConnMaxLifetime time.Duration `env:"CONN_MAX_LIFETIME" envDefault:"30m"`
+	ConnMaxIdleTime time.Duration `env:"CONN_MAX_IDLE_TIME" envDefault:"5m"`
+	// StartupTimeout bounds how long the database is waited for before giving up.
+	StartupTimeout time.Duration `env:"STARTUP_TIMEOUT" envDefault:"30s"`
+	// PoolStatsInterval is the period of the pool stats log line, 0 disables it.
+	PoolStatsInterval time.Duration `env:"POOL_STATS_INTERVAL" envDefault:"1m"`
 }
 
 type HTTPServer struct {
 	Port string `env:"PORT,notEmpty"`
+	// HealthToken unlocks the per-check breakdown of /readyz?verbose, empty disables it.
+	HealthToken        string        `env:"HEALTH_TOKEN"`
+	HealthCheckTimeout time.Duration `env:"HEALTH_CHECK_TIMEOUT" envDefault:"2s"`
+	// ShutdownDrainDelay is how long /readyz fails before the server stops accepting new connections.
+	ShutdownDrainDelay time.Duration `env:"SHUTDOWN_DRAIN_DELAY" envDefault:"5s"`
+	ShutdownTimeout    time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"15s"`
+	// IdempotencyTTL is how long responses to requests with an Idempotency-Key are replayed.
+	IdempotencyTTL time.Duration `env:"IDEMPOTENCY_TTL" envDefault:"24h"`
+
+	// MaxBodyBytes bounds the request bodies of the write endpoints, MaxImportBodyBytes the one of POST /quotes/import.
+	MaxBodyBytes       int64         `env:"MAX_BODY_BYTES" envDefault:"65536"`
+	MaxImportBodyBytes int64         `env:"MAX_IMPORT_BODY_BYTES" envDefault:"4194304"`
+	ReadHeaderTimeout  time.Duration `env:"READ_HEADER_TIMEOUT" envDefault:"5s"`
+	ReadTimeout        time.Duration `env:"READ_TIMEOUT" envDefault:"30s"`
+	WriteTimeout       time.Duration `env:"WRITE_TIMEOUT" envDefault:"60s"`
+	IdleTimeout        time.Duration `env:"IDLE_TIMEOUT" envDefault:"120s"`
+	// HandlerTimeout is the deadline of every request, RouteTimeouts overrides it per route,
+	// e.g. "POST /quotes/import:30s,GET /quotes:5s". A timeout of 0 disables the deadline.
+	HandlerTimeout time.Duration            `env:"HANDLER_TIMEOUT" envDefault:"10s"`
+	RouteTimeouts  map[string]time.Duration `env:"ROUTE_TIMEOUTS"`
+	// AccessLog emits one log line per request.
+	AccessLog bool `env:"ACCESS_LOG" envDefault:"true"`
+	// PublicURL is the scheme and host the links of the feeds and embeds start with, e.g. "https://quotes.example.com".
+	// They start with the scheme and host of the request if it is empty, and are only cached by the clients then.
+	PublicURL string `env:"PUBLIC_URL"`
+}
+
+type GRPC struct {
+	// Port of the gRPC listener, the gRPC API is disabled if it is empty.
+	Port string `env:"PORT"`
+}
+
+type GraphQL struct {
+	// Enabled mounts the GraphQL API at POST /graphql of the HTTP server.
+	Enabled bool `env:"ENABLED" envDefault:"true"`
+	// MaxDepth bounds the nesting of the fields of an operation, MaxComplexity its estimated cost.
+	MaxDepth      int `env:"MAX_DEPTH" envDefault:"8"`
+	MaxComplexity int `env:"MAX_COMPLEXITY" envDefault:"1000"`
+}
+
+type Stream struct {
+	// Enabled serves the quote events at GET /quotes/stream. They are read from the outbox, which is
+	// relayed for the stream even if the Outbox is disabled, and exchanged between the replicas with
+	// Postgres LISTEN/NOTIFY.
+	Enabled bool `env:"ENABLED" envDefault:"true"`
+	// ReplaySize is the number of events kept for clients resuming with Last-Event-ID.
+	ReplaySize        int           `env:"REPLAY_SIZE" envDefault:"1000"`
+	HeartbeatInterval time.Duration `env:"HEARTBEAT_INTERVAL" envDefault:"15s"`
+	// ListenRetryInterval is the delay before the notification listener reconnects to the database.
+	ListenRetryInterval time.Duration `env:"LISTEN_RETRY_INTERVAL" envDefault:"5s"`
+}
+
+type Rotation struct {
+	// Enabled serves the WebSocket endpoint GET /quotes/rotation pushing random quotes.
+	Enabled        bool `env:"ENABLED" envDefault:"true"`
+	MaxConnections int  `env:"MAX_CONNECTIONS" envDefault:"500"`
+	// Interval is the rotation interval of clients not choosing one.
+	Interval     time.Duration `env:"INTERVAL" envDefault:"30s"`
+	PingInterval time.Duration `env:"PING_INTERVAL" envDefault:"30s"`
+}
+
+type Cards struct {
+	// Enabled serves the quote cards at GET /quotes/{id}/card.svg and GET /quotes/{id}/card.png.
+	Enabled bool `env:"ENABLED" envDefault:"true"`
+	// CacheSize is the number of rendered cards kept in memory.
+	CacheSize int `env:"CACHE_SIZE" envDefault:"256"`
+}
+
+type SlashCommands struct {
+	// SigningSecret verifies the Slack requests and MattermostToken the Mattermost ones, the
+	// endpoint POST /slash-commands is served if either is set.
+	SigningSecret   string `env:"SIGNING_SECRET"`
+	MattermostToken string `env:"MATTERMOST_TOKEN"`
+	// ReplayWindow bounds the age of signed Slack requests.
+	ReplayWindow time.Duration `env:"REPLAY_WINDOW" envDefault:"5m"`
+}
+
+type Webhooks struct {
+	// Enabled serves the /webhooks endpoints, queues the deliveries with every quote write and
+	// dispatches them.
+	Enabled      bool          `env:"ENABLED" envDefault:"true"`
+	PollInterval time.Duration `env:"POLL_INTERVAL" envDefault:"1s"`
+	BatchSize    int           `env:"BATCH_SIZE" envDefault:"20"`
+	// MaxAttempts is the number of attempts before a delivery is dead, the delay between them
+	// doubles from RetryBaseDelay up to RetryMaxDelay.
+	MaxAttempts    int           `env:"MAX_ATTEMPTS" envDefault:"8"`
+	RetryBaseDelay time.Duration `env:"RETRY_BASE_DELAY" envDefault:"10s"`
+	RetryMaxDelay  time.Duration `env:"RETRY_MAX_DELAY" envDefault:"1h"`
+	// Timeout bounds one delivery attempt.
+	Timeout time.Duration `env:"TIMEOUT" envDefault:"10s"`
+	// Retention is how long delivered and dead deliveries are kept, zero keeps them forever.
+	Retention time.Duration `env:"RETENTION" envDefault:"168h"`
+}
+
+type Outbox struct {
+	// Enabled records the domain events with every quote write and relays them to the Publisher.
+	Enabled bool `env:"ENABLED" envDefault:"true"`
+	// Publisher is one of "log", "stdout", "file" or "nats". "stdout" and "file" write newline
+	// delimited JSON, "file" appends to FilePath.
+	Publisher string `env:"PUBLISHER" envDefault:"log"`
+	FilePath  string `env:"FILE_PATH" envDefault:"outbox-events.ndjson"`
+	// NATSURL is used by the nats publisher, the events are published to NATSSubjectPrefix
+	// followed by the event type.
+	NATSURL           string        `env:"NATS_URL" envDefault:"nats://localhost:4222"`
+	NATSSubjectPrefix string        `env:"NATS_SUBJECT_PREFIX" envDefault:"quotes.events."`
+	PollInterval      time.Duration `env:"POLL_INTERVAL" envDefault:"1s"`
+	BatchSize         int           `env:"BATCH_SIZE" envDefault:"100"`
+	// Retention is how long published events are kept, zero keeps them forever.
+	Retention time.Duration `env:"RETENTION" envDefault:"168h"`
+}
+
+type Metrics struct {
+	// Port of the separate metrics listener, /metrics is served on the public port if empty.
+	Port string `env:"PORT"`
+}
+
+type Tracing struct {
+	// Exporter is one of "none", "stdout" or "otlp". The OTLP exporter is configured
+	// with the standard OTEL_EXPORTER_OTLP_* variables.
+	Exporter    string  `env:"EXPORTER" envDefault:"none"`
+	SampleRatio float64 `env:"SAMPLE_RATIO" envDefault:"1"`
+}
+
+type Auth struct {
+	// RequireRead guards the read endpoints with the "quotes:read" scope, they are public otherwise.
+	RequireRead bool `env:"REQUIRE_READ" envDefault:"false"`
+
+	// JWTJWKS is a file path or an http(s) URL of the issuer's key set, bearer JWTs are rejected when it is empty.
+	JWTJWKS                string        `env:"JWT_JWKS"`
+	JWTJWKSRefreshInterval time.Duration `env:"JWT_JWKS_REFRESH_INTERVAL" envDefault:"1h"`
+	JWTIssuer              string        `env:"JWT_ISSUER"`
+	JWTAudience            string        `env:"JWT_AUDIENCE"`
+	JWTScopesClaim         string        `env:"JWT_SCOPES_CLAIM" envDefault:"scope"`
+	JWTLeeway              time.Duration `env:"JWT_LEEWAY" envDefault:"30s"`
+}
+
+type RateLimit struct {
+	// Store is one of "none", "memory", "postgres" or "redis". The memory store limits every
+	// replica on its own, the others share the buckets between replicas.
+	Store string `env:"STORE" envDefault:"memory"`
+	// RedisURL is used by the redis store, e.g. "redis://localhost:6379/0".
+	RedisURL string `env:"REDIS_URL"`
+	// ReadRate and WriteRate are the requests per second a client may sustain, the bursts are the bucket sizes.
+	ReadRate   float64 `env:"READ_RATE" envDefault:"10"`
+	ReadBurst  int     `env:"READ_BURST" envDefault:"20"`
+	WriteRate  float64 `env:"WRITE_RATE" envDefault:"1"`
+	WriteBurst int     `env:"WRITE_BURST" envDefault:"5"`
+	// AuthRate and AuthBurst limit the requests with credentials per client IP, they are taken
+	// before the credentials are verified.
+	AuthRate  float64 `env:"AUTH_RATE" envDefault:"20"`
+	AuthBurst int     `env:"AUTH_BURST" envDefault:"40"`
+	// TrustForwardedFor keys anonymous clients by X-Forwarded-For, enable it only behind a proxy setting the header.
+	TrustForwardedFor bool `env:"TRUST_FORWARDED_FOR" envDefault:"false"`
+}
+
+type Validation struct {
+	MaxQuoteLength  int `env:"MAX_QUOTE_LENGTH" envDefault:"1000"`
+	MaxAuthorLength int `env:"MAX_AUTHOR_LENGTH" envDefault:"100"`
+	// BannedWords is a comma separated list, BannedWordsFile a file with one word per line.
+	// The words of both are banned.
+	BannedWords     []string `env:"BANNED_WORDS" envSeparator:","`
+	BannedWordsFile string   `env:"BANNED_WORDS_FILE"`
+}
+
+type Log struct {
+	// Level is one of "debug", "info", "warn" or "error".
+	Level slog.Level `env:"LEVEL" envDefault:"info"`
+	// Format is either "text" or "json".
+	Format string `env:"FORMAT" envDefault:"text"`
+}
+
+type CORS struct {
+	// AllowedOrigins are origins like "https://example.com" or patterns like "https://*.example.com",
+	// "*" allows every origin. CORS is disabled if it is empty.
+	AllowedOrigins   []string      `env:"ALLOWED_ORIGINS" envSeparator:","`
+	AllowedMethods   []string      `env:"ALLOWED_METHODS" envSeparator:"," envDefault:"GET,POST,PUT,DELETE"`
+	AllowedHeaders   []string      `env:"ALLOWED_HEADERS" envSeparator:"," envDefault:"Authorization,Content-Type,Idempotency-Key,X-API-Key,X-Request-ID"`
+	ExposedHeaders   []string      `env:"EXPOSED_HEADERS" envSeparator:"," envDefault:"Location,Idempotent-Replayed,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,Retry-After,X-Request-ID"`
+	AllowCredentials bool          `env:"ALLOW_CREDENTIALS" envDefault:"false"`
+	MaxAge           time.Duration `env:"MAX_AGE" envDefault:"10m"`
 }
 
 func loadConfigFromEnv() (Config, error) {
@@ -31,3 +239,21 @@ func loadConfigFromEnv() (Config, error) {
 
 	return cfg, nil
 }
+
+func loadLogConfigFromEnv() (Log, error) {
+	cfg, err := env.ParseAsWithOptions[Log](env.Options{Prefix: "LOG_"})
+	if err != nil {
+		return Log{}, fmt.Errorf("failed to parse log config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+func loadDBConfigFromEnv() (DB, error) {
+	cfg, err := env.ParseAsWithOptions[DB](env.Options{Prefix: "DB_"})
+	if err != nil {
+		return DB{}, fmt.Errorf("failed to parse db config: %w", err)
+	}
+
+	return cfg, nil
+}
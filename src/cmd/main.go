@@ -5,26 +5,75 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/BernsteinMondy/quote-service/migrations"
+	"github.com/BernsteinMondy/quote-service/src/internal/auth"
+	"github.com/BernsteinMondy/quote-service/src/internal/cards"
+	"github.com/BernsteinMondy/quote-service/src/internal/events"
+	"github.com/BernsteinMondy/quote-service/src/internal/graphqlapi"
+	"github.com/BernsteinMondy/quote-service/src/internal/grpcserver"
+	"github.com/BernsteinMondy/quote-service/src/internal/health"
 	"github.com/BernsteinMondy/quote-service/src/internal/httpserver"
 	"github.com/BernsteinMondy/quote-service/src/internal/impl"
+	"github.com/BernsteinMondy/quote-service/src/internal/metrics"
+	"github.com/BernsteinMondy/quote-service/src/internal/outbox"
+	"github.com/BernsteinMondy/quote-service/src/internal/ratelimit"
 	"github.com/BernsteinMondy/quote-service/src/internal/service"
+	"github.com/BernsteinMondy/quote-service/src/internal/slashcmd"
+	"github.com/BernsteinMondy/quote-service/src/internal/telemetry"
+	"github.com/BernsteinMondy/quote-service/src/internal/webhooks"
 	"github.com/BernsteinMondy/quote-service/src/pkg/database"
 	"github.com/gorilla/mux"
+	"github.com/nats-io/nats.go"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
+	"time"
 )
 
+const serviceName = "quote-service"
+
 func main() {
-	err := run()
+	slog.SetDefault(slog.New(telemetry.NewLogHandler(slog.NewTextHandler(os.Stderr, nil))))
+
+	err := setupLogger()
+	if err != nil {
+		slog.Error("setupLogger() returned error", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	err = runCommand(os.Args[1:])
 	if err != nil {
-		slog.Error("run() returned error", slog.String("error", err.Error()))
+		slog.Error("runCommand() returned error", slog.String("error", err.Error()))
+		os.Exit(1)
 	}
 }
 
+// setupLogger replaces the default logger with the one configured by the LOG_* variables.
+func setupLogger() error {
+	cfg, err := loadLogConfigFromEnv()
+	if err != nil {
+		return fmt.Errorf("load log config from env: %w", err)
+	}
+
+	logger, err := telemetry.NewLogger(os.Stderr, telemetry.LogConfig{
+		Level:  cfg.Level,
+		Format: cfg.Format,
+	})
+	if err != nil {
+		return fmt.Errorf("new logger: %w", err)
+	}
+	slog.SetDefault(logger)
+
+	return nil
+}
+
 func run() (err error) {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM, syscall.SIGSTOP)
 	defer stop()
@@ -36,6 +85,21 @@ func run() (err error) {
 	}
 	slog.Info("Config loaded")
 
+	shutdownTracing, err := telemetry.SetupTracing(ctx, telemetry.TracingConfig{
+		Exporter:       cfg.Tracing.Exporter,
+		SampleRatio:    cfg.Tracing.SampleRatio,
+		ServiceName:    serviceName,
+		ServiceVersion: version,
+	})
+	if err != nil {
+		return fmt.Errorf("setup tracing: %w", err)
+	}
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+		defer cancel()
+		err = errors.Join(err, shutdownTracing(shutdownCtx))
+	}()
+
 	select {
 	case <-ctx.Done():
 		return nil
@@ -47,54 +111,439 @@ func run() (err error) {
 	if err != nil {
 		return fmt.Errorf("new sql database: %w", err)
 	}
-	slog.Info("Connected to database")
 	defer func() {
 		slog.Info("Closing database connection")
 		err = errors.Join(err, db.Close())
 		slog.Info("Database connection closed")
 	}()
 
+	err = database.WaitForConnection(ctx, db, cfg.DB.StartupTimeout)
+	if err != nil {
+		return fmt.Errorf("wait for database connection: %w", err)
+	}
+	slog.Info("Connected to database")
+
 	select {
 	case <-ctx.Done():
 		return nil
 	default:
 	}
 
-	quoteRepo := impl.NewQuoteRepository(db)
-	quoteService := service.New(quoteRepo)
+	migrator, err := database.NewMigrator(db, migrations.FS)
+	if err != nil {
+		return fmt.Errorf("new migrator: %w", err)
+	}
+
+	if cfg.DB.AutoMigrate {
+		slog.Info("Applying database migrations")
+		err = migrator.Up(ctx)
+		if err != nil {
+			return fmt.Errorf("apply migrations: %w", err)
+		}
+		slog.Info("Database migrations applied")
+	}
+
+	registry := metrics.NewRegistry()
+	metrics.RegisterDBStats(registry, db, cfg.DB.Name)
+
+	// The quote stream is fed from the outbox, so the events are recorded for it as well.
+	quoteRepo := impl.NewQuoteRepository(db, metrics.NewRepository(registry), impl.EventRecording{
+		Outbox:   cfg.Outbox.Enabled || cfg.Stream.Enabled,
+		Webhooks: cfg.Webhooks.Enabled,
+	})
+	validation, err := validationPolicy(cfg.Validation)
+	if err != nil {
+		return fmt.Errorf("validation policy: %w", err)
+	}
+	quoteService := service.New(quoteRepo, validation)
+
+	var eventHub *events.Hub
+	if cfg.Stream.Enabled {
+		eventHub = events.NewHub(cfg.Stream.ReplaySize)
+	}
+
+	metrics.RegisterQuoteStats(registry, quoteService)
+
+	rateLimitStore, rateLimitChecks, closeRateLimitStore, err := newRateLimitStore(cfg.RateLimit, db)
+	if err != nil {
+		return fmt.Errorf("new rate limit store: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, closeRateLimitStore())
+	}()
+
+	checks := append([]health.Check{health.DatabaseCheck(db), health.MigrationCheck(migrator)}, rateLimitChecks...)
+	for i, check := range checks {
+		checks[i] = withTimeout(check, cfg.Server.HealthCheckTimeout)
+	}
+	checker := health.New(checks...)
 
 	router := mux.NewRouter()
-	server := httpserver.New(quoteService, router, cfg.Server.Port)
+	keyService := auth.NewKeyService(impl.NewAPIKeyRepository(db))
+
+	authenticator, err := newAuthenticator(ctx, cfg.Auth, keyService)
+	if err != nil {
+		return fmt.Errorf("new authenticator: %w", err)
+	}
+
+	serverCfg := httpserver.Config{
+		ListenAddr:         cfg.Server.Port,
+		HealthToken:        cfg.Server.HealthToken,
+		Metrics:            metrics.NewHTTP(registry),
+		Authenticator:      authenticator,
+		APIKeys:            keyService,
+		RequireReadAuth:    cfg.Auth.RequireRead,
+		TrustForwardedFor:  cfg.RateLimit.TrustForwardedFor,
+		Idempotency:        impl.NewIdempotencyStore(db),
+		IdempotencyTTL:     cfg.Server.IdempotencyTTL,
+		MaxBodyBytes:       cfg.Server.MaxBodyBytes,
+		MaxImportBodyBytes: cfg.Server.MaxImportBodyBytes,
+		ReadHeaderTimeout:  cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:        cfg.Server.ReadTimeout,
+		WriteTimeout:       cfg.Server.WriteTimeout,
+		IdleTimeout:        cfg.Server.IdleTimeout,
+		HandlerTimeout:     cfg.Server.HandlerTimeout,
+		RouteTimeouts:      cfg.Server.RouteTimeouts,
+		AccessLog:          cfg.Server.AccessLog,
+		PublicURL:          cfg.Server.PublicURL,
+	}
+	if rateLimitStore != nil {
+		serverCfg.RateLimiter, err = ratelimit.NewLimiter(rateLimitStore, map[ratelimit.Class]ratelimit.Limit{
+			ratelimit.ClassRead:  {Rate: cfg.RateLimit.ReadRate, Burst: cfg.RateLimit.ReadBurst},
+			ratelimit.ClassWrite: {Rate: cfg.RateLimit.WriteRate, Burst: cfg.RateLimit.WriteBurst},
+			ratelimit.ClassAuth:  {Rate: cfg.RateLimit.AuthRate, Burst: cfg.RateLimit.AuthBurst},
+		})
+		if err != nil {
+			return fmt.Errorf("new rate limiter: %w", err)
+		}
+	}
+	if len(cfg.CORS.AllowedOrigins) > 0 {
+		serverCfg.CORS, err = httpserver.NewCORSPolicy(httpserver.CORSConfig{
+			AllowedOrigins:   cfg.CORS.AllowedOrigins,
+			AllowedMethods:   cfg.CORS.AllowedMethods,
+			AllowedHeaders:   cfg.CORS.AllowedHeaders,
+			ExposedHeaders:   cfg.CORS.ExposedHeaders,
+			AllowCredentials: cfg.CORS.AllowCredentials,
+			MaxAge:           cfg.CORS.MaxAge,
+		})
+		if err != nil {
+			return fmt.Errorf("new cors policy: %w", err)
+		}
+	}
+	if cfg.GraphQL.Enabled {
+		serverCfg.GraphQL, err = graphqlapi.New(quoteService, graphqlapi.Config{
+			RequireReadAuth: cfg.Auth.RequireRead,
+			MaxDepth:        cfg.GraphQL.MaxDepth,
+			MaxComplexity:   cfg.GraphQL.MaxComplexity,
+		})
+		if err != nil {
+			return fmt.Errorf("new graphql executor: %w", err)
+		}
+	}
+	if eventHub != nil {
+		serverCfg.Events = eventHub
+		serverCfg.StreamHeartbeat = cfg.Stream.HeartbeatInterval
+	}
+	if cfg.Rotation.Enabled {
+		serverCfg.Rotation = httpserver.NewQuoteRotation(httpserver.RotationConfig{
+			MaxConnections: cfg.Rotation.MaxConnections,
+			Interval:       cfg.Rotation.Interval,
+			PingInterval:   cfg.Rotation.PingInterval,
+		})
+	}
+	if cfg.Cards.Enabled {
+		serverCfg.Cards = cards.NewRenderer(cfg.Cards.CacheSize)
+	}
+	if cfg.SlashCommands.SigningSecret != "" || cfg.SlashCommands.MattermostToken != "" {
+		serverCfg.SlashCommands = slashcmd.New(quoteService, slashcmd.NewVerifier(slashcmd.VerifierConfig{
+			SigningSecret:   cfg.SlashCommands.SigningSecret,
+			MattermostToken: cfg.SlashCommands.MattermostToken,
+			ReplayWindow:    cfg.SlashCommands.ReplayWindow,
+		}))
+	}
+	var webhookDispatcher *webhooks.Dispatcher
+	if cfg.Webhooks.Enabled {
+		webhookRepo := impl.NewWebhookRepository(db)
+		serverCfg.Webhooks = webhooks.NewService(webhookRepo)
+		webhookDispatcher = webhooks.NewDispatcher(webhookRepo, nil, webhooks.DispatcherConfig{
+			PollInterval: cfg.Webhooks.PollInterval,
+			BatchSize:    cfg.Webhooks.BatchSize,
+			MaxAttempts:  cfg.Webhooks.MaxAttempts,
+			BaseDelay:    cfg.Webhooks.RetryBaseDelay,
+			MaxDelay:     cfg.Webhooks.RetryMaxDelay,
+			Timeout:      cfg.Webhooks.Timeout,
+			Retention:    cfg.Webhooks.Retention,
+		})
+	}
+	var outboxPublishers outbox.MultiPublisher
+	if cfg.Outbox.Enabled {
+		var (
+			publisher      outbox.Publisher
+			closePublisher func() error
+		)
+		publisher, closePublisher, err = newOutboxPublisher(cfg.Outbox)
+		if err != nil {
+			return fmt.Errorf("new outbox publisher: %w", err)
+		}
+		defer func() {
+			err = errors.Join(err, closePublisher())
+		}()
+		outboxPublishers = append(outboxPublishers, publisher)
+	}
+	if eventHub != nil {
+		// The events are notified to the listeners of every replica, see listenQuoteEvents.
+		outboxPublishers = append(outboxPublishers, impl.NewQuoteEventNotifier(db))
+	}
+	var outboxRelay *outbox.Relay
+	if len(outboxPublishers) > 0 {
+		outboxRelay = outbox.NewRelay(impl.NewOutboxStore(db), outboxPublishers, outbox.RelayConfig{
+			PollInterval: cfg.Outbox.PollInterval,
+			BatchSize:    cfg.Outbox.BatchSize,
+			Retention:    cfg.Outbox.Retention,
+		})
+	}
+	if cfg.Metrics.Port == "" {
+		serverCfg.MetricsHandler = metrics.Handler(registry)
+	}
+	server := httpserver.New(quoteService, checker, router, serverCfg)
 	stopWg := sync.WaitGroup{}
 
+	if cfg.DB.PoolStatsInterval > 0 {
+		stopWg.Add(1)
+		go func(ctx context.Context) {
+			defer stopWg.Done()
+			database.LogPoolStats(ctx, db, cfg.DB.PoolStatsInterval)
+		}(ctx)
+	}
+
+	if eventHub != nil {
+		stopWg.Add(1)
+		go func(ctx context.Context) {
+			defer stopWg.Done()
+			listenQuoteEvents(ctx, dbConfig(cfg.DB), cfg.Stream.ListenRetryInterval, eventHub)
+		}(ctx)
+	}
+
+	if outboxRelay != nil {
+		stopWg.Add(1)
+		go func(ctx context.Context) {
+			defer stopWg.Done()
+			outboxRelay.Run(ctx)
+		}(ctx)
+	}
+
+	if webhookDispatcher != nil {
+		stopWg.Add(1)
+		go func(ctx context.Context) {
+			defer stopWg.Done()
+			webhookDispatcher.Run(ctx)
+		}(ctx)
+	}
+
 	stopWg.Add(1)
 	go func(ctx context.Context) {
 		defer stopWg.Done()
-		httpSrvErr := launchHTTPServer(ctx, server)
+		var closeSockets func(context.Context) error
+		if serverCfg.Rotation != nil {
+			closeSockets = serverCfg.Rotation.Shutdown
+		}
+		httpSrvErr := launchHTTPServer(ctx, server, closeSockets, cfg.Server.ShutdownDrainDelay, cfg.Server.ShutdownTimeout)
 		if httpSrvErr != nil {
 			slog.Error("launchHTTPServer() returned error", slog.String("error", httpSrvErr.Error()))
 		}
 	}(ctx)
 
+	if cfg.GRPC.Port != "" {
+		grpcServer := grpcserver.New(quoteService, grpcserver.Config{
+			Authenticator:   authenticator,
+			RateLimiter:     serverCfg.RateLimiter,
+			RequireReadAuth: cfg.Auth.RequireRead,
+			AccessLog:       cfg.Server.AccessLog,
+		})
+
+		stopWg.Add(1)
+		go func(ctx context.Context) {
+			defer stopWg.Done()
+			grpcSrvErr := launchGRPCServer(ctx, grpcServer, ":"+cfg.GRPC.Port, cfg.Server.ShutdownDrainDelay, cfg.Server.ShutdownTimeout)
+			if grpcSrvErr != nil {
+				slog.Error("launchGRPCServer() returned error", slog.String("error", grpcSrvErr.Error()))
+			}
+		}(ctx)
+	}
+
+	if cfg.Metrics.Port != "" {
+		metricsServer := httpserver.NewMetricsServer(metrics.Handler(registry), cfg.Metrics.Port)
+
+		stopWg.Add(1)
+		go func(ctx context.Context) {
+			defer stopWg.Done()
+			metricsSrvErr := launchHTTPServer(ctx, metricsServer, nil, 0, cfg.Server.ShutdownTimeout)
+			if metricsSrvErr != nil {
+				slog.Error("launchHTTPServer() for metrics returned error", slog.String("error", metricsSrvErr.Error()))
+			}
+		}(ctx)
+	}
+
+	checker.MarkStarted()
+
 	<-ctx.Done()
+	checker.MarkShuttingDown()
 	stopWg.Wait()
 	return nil
 }
 
+func withTimeout(check health.Check, timeout time.Duration) health.Check {
+	check.Timeout = timeout
+	return check
+}
+
+// newAuthenticator accepts API keys, and bearer JWTs as well when a JWKS is configured.
+func newAuthenticator(ctx context.Context, cfg Auth, keyService *auth.KeyService) (*auth.Authenticator, error) {
+	authenticator := &auth.Authenticator{APIKeys: keyService}
+	if cfg.JWTJWKS == "" {
+		return authenticator, nil
+	}
+
+	jwks := auth.NewJWKS(cfg.JWTJWKS, cfg.JWTJWKSRefreshInterval)
+	err := jwks.Refresh(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load jwks: %w", err)
+	}
+
+	validator, err := auth.NewJWTValidator(auth.JWTConfig{
+		Issuer:      cfg.JWTIssuer,
+		Audience:    cfg.JWTAudience,
+		ScopesClaim: cfg.JWTScopesClaim,
+		Leeway:      cfg.JWTLeeway,
+	}, jwks)
+	if err != nil {
+		return nil, fmt.Errorf("new jwt validator: %w", err)
+	}
+	authenticator.JWT = validator
+
+	return authenticator, nil
+}
+
+// newRateLimitStore returns a nil store if rate limiting is disabled. The returned checks make
+// readiness fail while an external store is unreachable, the returned func closes the connections
+// of the store.
+func newRateLimitStore(cfg RateLimit, db *sql.DB) (ratelimit.Store, []health.Check, func() error, error) {
+	noop := func() error { return nil }
+
+	switch cfg.Store {
+	case "none":
+		return nil, nil, noop, nil
+	case "memory":
+		return ratelimit.NewMemoryStore(), nil, noop, nil
+	case "postgres":
+		return impl.NewRateLimitStore(db), nil, noop, nil
+	case "redis":
+		opts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("parse redis url: %w", err)
+		}
+		client := redis.NewClient(opts)
+		check := health.Check{
+			Name: "redis",
+			Run: func(ctx context.Context) error {
+				return client.Ping(ctx).Err()
+			},
+		}
+		return impl.NewRedisRateLimitStore(client), []health.Check{check}, client.Close, nil
+	default:
+		return nil, nil, nil, fmt.Errorf("unknown rate limit store %q", cfg.Store)
+	}
+}
+
+func newOutboxPublisher(cfg Outbox) (outbox.Publisher, func() error, error) {
+	noop := func() error { return nil }
+
+	switch cfg.Publisher {
+	case "log":
+		return outbox.NewLogPublisher(slog.Default()), noop, nil
+	case "stdout":
+		return outbox.NewWriterPublisher(os.Stdout), noop, nil
+	case "file":
+		publisher, err := outbox.OpenFilePublisher(cfg.FilePath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open file publisher: %w", err)
+		}
+		return publisher, publisher.Close, nil
+	case "nats":
+		// The client reconnects on its own, publishing fails meanwhile and the events are retried.
+		conn, err := nats.Connect(cfg.NATSURL, nats.Name(serviceName), nats.MaxReconnects(-1))
+		if err != nil {
+			return nil, nil, fmt.Errorf("connect to nats: %w", err)
+		}
+		return outbox.NewNATSPublisher(conn, cfg.NATSSubjectPrefix), conn.Drain, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown outbox publisher %q", cfg.Publisher)
+	}
+}
+
+func validationPolicy(cfg Validation) (service.ValidationPolicy, error) {
+	policy := service.ValidationPolicy{
+		MaxQuoteLength:  cfg.MaxQuoteLength,
+		MaxAuthorLength: cfg.MaxAuthorLength,
+	}
+
+	for _, word := range cfg.BannedWords {
+		if word = strings.TrimSpace(word); word != "" {
+			policy.BannedWords = append(policy.BannedWords, word)
+		}
+	}
+
+	if cfg.BannedWordsFile != "" {
+		raw, err := os.ReadFile(cfg.BannedWordsFile)
+		if err != nil {
+			return service.ValidationPolicy{}, fmt.Errorf("read banned words file: %w", err)
+		}
+		for _, word := range strings.Split(string(raw), "\n") {
+			if word = strings.TrimSpace(word); word != "" && !strings.HasPrefix(word, "#") {
+				policy.BannedWords = append(policy.BannedWords, word)
+			}
+		}
+	}
+
+	return policy, nil
+}
+
+// listenQuoteEvents publishes the quote events notified by every replica to the hub until ctx is done.
+func listenQuoteEvents(ctx context.Context, cfg database.Config, retryInterval time.Duration, hub *events.Hub) {
+	database.Listen(ctx, cfg, impl.QuoteEventsChannel, retryInterval, func(payload string) {
+		event, err := impl.DecodeQuoteEvent(payload)
+		if err != nil {
+			slog.Warn("Failed to decode quote event", slog.String("error", err.Error()))
+			return
+		}
+		hub.Publish(event)
+	})
+}
+
 func sqlDB(cfg DB) (*sql.DB, error) {
-	dbConfig := database.Config{
+	return database.NewSQLDatabase(dbConfig(cfg))
+}
+
+func dbConfig(cfg DB) database.Config {
+	return database.Config{
 		User:     cfg.User,
 		Password: cfg.Password,
 		Name:     cfg.Name,
 		Host:     cfg.Host,
 		Port:     cfg.Port,
 		SSLMode:  cfg.SSLMode,
-	}
 
-	return database.NewSQLDatabase(dbConfig)
+		MaxOpenConns:    cfg.MaxOpenConns,
+		MaxIdleConns:    cfg.MaxIdleConns,
+		ConnMaxLifetime: cfg.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.ConnMaxIdleTime,
+	}
 }
 
-func launchHTTPServer(ctx context.Context, server *http.Server) (err error) {
+// launchHTTPServer keeps serving for drainDelay after ctx is done, so load balancers observe
+// the failing readiness probe before the server stops accepting new connections. closeSockets
+// is optional, it closes the connections hijacked from the server within the shutdown timeout.
+func launchHTTPServer(ctx context.Context, server *http.Server, closeSockets func(context.Context) error, drainDelay, shutdownTimeout time.Duration) (err error) {
 	var httpServerShutDownError error
 	defer func() {
 		err = errors.Join(err, httpServerShutDownError)
@@ -104,8 +553,19 @@ func launchHTTPServer(ctx context.Context, server *http.Server) (err error) {
 	go func(ctx context.Context) {
 		<-ctx.Done()
 
+		if drainDelay > 0 {
+			slog.Info("Draining http server", slog.String("addr", server.Addr), slog.Duration("delay", drainDelay))
+			time.Sleep(drainDelay)
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
 		slog.Info("Shutting down http server")
-		httpServerShutDownError = server.Shutdown(ctx)
+		httpServerShutDownError = server.Shutdown(shutdownCtx)
+		if closeSockets != nil {
+			httpServerShutDownError = errors.Join(httpServerShutDownError, closeSockets(shutdownCtx))
+		}
 		slog.Info("Http server shut down")
 
 		close(shutDownDone)
@@ -126,3 +586,46 @@ func launchHTTPServer(ctx context.Context, server *http.Server) (err error) {
 	<-shutDownDone
 	return nil
 }
+
+// launchGRPCServer reports the services as not serving to health checks for drainDelay after ctx
+// is done, then stops the server like launchHTTPServer does.
+func launchGRPCServer(ctx context.Context, server *grpcserver.Server, addr string, drainDelay, shutdownTimeout time.Duration) (err error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", addr, err)
+	}
+
+	var grpcServerShutDownError error
+	defer func() {
+		err = errors.Join(err, grpcServerShutDownError)
+	}()
+
+	shutDownDone := make(chan struct{})
+	go func(ctx context.Context) {
+		<-ctx.Done()
+
+		if drainDelay > 0 {
+			slog.Info("Draining grpc server", slog.String("addr", addr), slog.Duration("delay", drainDelay))
+			server.Drain()
+			time.Sleep(drainDelay)
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		slog.Info("Shutting down grpc server")
+		grpcServerShutDownError = server.Shutdown(shutdownCtx)
+		slog.Info("Grpc server shut down")
+
+		close(shutDownDone)
+	}(ctx)
+
+	slog.Info("Starting grpc server", slog.String("addr", addr))
+	err = server.Serve(lis)
+	if err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return fmt.Errorf("serve on %s: %w", addr, err)
+	}
+
+	<-shutDownDone
+	return nil
+}
@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+// API keys have the form "qs_<hex key id>_<secret>". Only the SHA-256 hash of the secret
+// is stored, the secret has 256 bits of entropy, so a slow password hash is not needed.
+const apiKeyPrefix = "qs_"
+
+var (
+	ErrUnauthenticated = errors.New("unauthenticated")
+	ErrAPIKeyNotFound  = errors.New("api key not found")
+
+	ErrRepoNotFound = errors.New("repository: not found")
+)
+
+type APIKey struct {
+	ID        uuid.UUID
+	Name      string
+	Scopes    []Scope
+	CreatedAt time.Time
+	// RevokedAt is nil while the key is active.
+	RevokedAt *time.Time
+}
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *APIKey, secretHash []byte) error
+	// GetAPIKeyByID must return ErrRepoNotFound if there is no key with the id.
+	GetAPIKeyByID(ctx context.Context, id uuid.UUID) (*APIKey, []byte, error)
+	ListAPIKeys(ctx context.Context) ([]APIKey, error)
+	// RevokeAPIKey must return ErrRepoNotFound if there is no active key with the id.
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) error
+}
+
+type KeyService struct {
+	Repository APIKeyRepository
+}
+
+func NewKeyService(repo APIKeyRepository) *KeyService {
+	return &KeyService{Repository: repo}
+}
+
+// CreateAPIKey returns the created key together with its plaintext form, which is not stored
+// anywhere and can not be recovered later.
+func (s *KeyService) CreateAPIKey(ctx context.Context, name string, scopes []Scope) (*APIKey, string, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return nil, "", fmt.Errorf("generate secret: %w", err)
+	}
+	encodedSecret := base64.RawURLEncoding.EncodeToString(secret)
+
+	key := &APIKey{
+		ID:        uuid.New(),
+		Name:      name,
+		Scopes:    scopes,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	err = s.Repository.CreateAPIKey(ctx, key, hashSecret(encodedSecret))
+	if err != nil {
+		return nil, "", fmt.Errorf("api key repository: create api key: %w", err)
+	}
+
+	plaintext := apiKeyPrefix + hex.EncodeToString(key.ID[:]) + "_" + encodedSecret
+	return key, plaintext, nil
+}
+
+func (s *KeyService) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	keys, err := s.Repository.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("api key repository: list api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (s *KeyService) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	err := s.Repository.RevokeAPIKey(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrRepoNotFound) {
+			return ErrAPIKeyNotFound
+		}
+		return fmt.Errorf("api key repository: revoke api key: %w", err)
+	}
+
+	return nil
+}
+
+// Authenticate returns ErrUnauthenticated if the key is malformed, unknown or revoked.
+func (s *KeyService) Authenticate(ctx context.Context, plaintext string) (*Principal, error) {
+	id, secret, ok := parseAPIKey(plaintext)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	key, secretHash, err := s.Repository.GetAPIKeyByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrRepoNotFound) {
+			return nil, ErrUnauthenticated
+		}
+		return nil, fmt.Errorf("api key repository: get api key by id: %w", err)
+	}
+
+	if subtle.ConstantTimeCompare(hashSecret(secret), secretHash) != 1 || key.RevokedAt != nil {
+		return nil, ErrUnauthenticated
+	}
+
+	return &Principal{
+		Subject: "apikey:" + key.ID.String(),
+		KeyID:   key.ID,
+		Scopes:  key.Scopes,
+	}, nil
+}
+
+// IsAPIKey reports whether the credential looks like an API key rather than a bearer token.
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, apiKeyPrefix)
+}
+
+func parseAPIKey(plaintext string) (uuid.UUID, string, bool) {
+	rest, ok := strings.CutPrefix(plaintext, apiKeyPrefix)
+	if !ok {
+		return uuid.Nil, "", false
+	}
+
+	rawID, secret, ok := strings.Cut(rest, "_")
+	if !ok || secret == "" {
+		return uuid.Nil, "", false
+	}
+
+	idBytes, err := hex.DecodeString(rawID)
+	if err != nil {
+		return uuid.Nil, "", false
+	}
+	id, err := uuid.FromBytes(idBytes)
+	if err != nil {
+		return uuid.Nil, "", false
+	}
+
+	return id, secret, true
+}
+
+func hashSecret(secret string) []byte {
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
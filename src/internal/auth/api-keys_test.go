@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"strings"
+	"testing"
+	"time"
+)
+
+type memoryKeyRepository struct {
+	keys   map[uuid.UUID]*APIKey
+	hashes map[uuid.UUID][]byte
+}
+
+func (m *memoryKeyRepository) CreateAPIKey(_ context.Context, key *APIKey, secretHash []byte) error {
+	m.keys[key.ID] = key
+	m.hashes[key.ID] = secretHash
+	return nil
+}
+
+func (m *memoryKeyRepository) GetAPIKeyByID(_ context.Context, id uuid.UUID) (*APIKey, []byte, error) {
+	key, ok := m.keys[id]
+	if !ok {
+		return nil, nil, ErrRepoNotFound
+	}
+	return key, m.hashes[id], nil
+}
+
+func (m *memoryKeyRepository) ListAPIKeys(context.Context) ([]APIKey, error) {
+	return nil, nil
+}
+
+func (m *memoryKeyRepository) RevokeAPIKey(_ context.Context, id uuid.UUID) error {
+	key, ok := m.keys[id]
+	if !ok || key.RevokedAt != nil {
+		return ErrRepoNotFound
+	}
+	now := time.Now()
+	key.RevokedAt = &now
+	return nil
+}
+
+func TestKeyService_Authenticate(t *testing.T) {
+	ctx := context.Background()
+	service := NewKeyService(&memoryKeyRepository{keys: map[uuid.UUID]*APIKey{}, hashes: map[uuid.UUID][]byte{}})
+
+	active, activePlaintext, err := service.CreateAPIKey(ctx, "active", []Scope{ScopeQuotesWrite})
+	if err != nil {
+		t.Fatal("Failed to create api key", err)
+	}
+	revoked, revokedPlaintext, err := service.CreateAPIKey(ctx, "revoked", []Scope{ScopeAdmin})
+	if err != nil {
+		t.Fatal("Failed to create api key", err)
+	}
+	err = service.RevokeAPIKey(ctx, revoked.ID)
+	if err != nil {
+		t.Fatal("Failed to revoke api key", err)
+	}
+
+	type testCase struct {
+		name      string
+		plaintext string
+		wantErr   error
+	}
+
+	testCases := []testCase{
+		{
+			name:      "Active key authenticates",
+			plaintext: activePlaintext,
+		},
+		{
+			name:      "Revoked key is rejected",
+			plaintext: revokedPlaintext,
+			wantErr:   ErrUnauthenticated,
+		},
+		{
+			name:      "Key with wrong secret is rejected",
+			plaintext: activePlaintext[:strings.LastIndex(activePlaintext, "_")+1] + "wrong-secret",
+			wantErr:   ErrUnauthenticated,
+		},
+		{
+			name:      "Unknown key is rejected",
+			plaintext: "qs_" + strings.Repeat("0", 32) + "_secret",
+			wantErr:   ErrUnauthenticated,
+		},
+		{
+			name:      "Malformed key is rejected",
+			plaintext: "qs_not-hex_secret",
+			wantErr:   ErrUnauthenticated,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			principal, err := service.Authenticate(ctx, tc.plaintext)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Authenticate() error = %v, want %v", err, tc.wantErr)
+			}
+			if tc.wantErr != nil {
+				return
+			}
+			if principal.KeyID != active.ID || !principal.HasScope(ScopeQuotesWrite) || principal.HasScope(ScopeQuotesDelete) {
+				t.Errorf("Authenticate() = %+v, want principal of key %s with its scopes", principal, active.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	jwksFetchTimeout = 10 * time.Second
+	// minJWKSRefreshInterval limits the refreshes forced by tokens with an unknown key id,
+	// so garbage tokens can not be used to hammer the key source.
+	minJWKSRefreshInterval = time.Minute
+)
+
+var errUnknownKeyID = errors.New("unknown key id")
+
+// JWKS is a cached JSON Web Key Set loaded from a local file or an http(s) URL.
+// The set is reloaded once it is older than the refresh interval, and earlier if a
+// token references a key id that is not in the set, which picks up rotated keys.
+type JWKS struct {
+	source          string
+	refreshInterval time.Duration
+	// minRefreshInterval limits the refreshes forced by unknown key ids.
+	minRefreshInterval time.Duration
+	client             *http.Client
+
+	refreshMu sync.Mutex
+
+	mu            sync.RWMutex
+	keys          map[string]crypto.PublicKey
+	fetchedAt     time.Time
+	lastRefreshAt time.Time
+}
+
+func NewJWKS(source string, refreshInterval time.Duration) *JWKS {
+	return &JWKS{
+		source:             source,
+		refreshInterval:    refreshInterval,
+		minRefreshInterval: minJWKSRefreshInterval,
+		client: &http.Client{
+			Timeout: jwksFetchTimeout,
+			// A redirect must not downgrade the fetch to plain http.
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 10 {
+					return errors.New("stopped after 10 redirects")
+				}
+				return checkJWKSURL(req.URL.String())
+			},
+		},
+	}
+}
+
+// Key returns the public key with the key id.
+func (j *JWKS) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	j.mu.RLock()
+	key, ok := j.keys[kid]
+	fetchedAt := j.fetchedAt
+	stale := time.Since(j.fetchedAt) > j.refreshInterval
+	canForce := time.Since(j.lastRefreshAt) > j.minRefreshInterval
+	j.mu.RUnlock()
+
+	if ok && !stale {
+		return key, nil
+	}
+	if !ok && !stale && !canForce {
+		return nil, errUnknownKeyID
+	}
+
+	err := j.refresh(ctx, fetchedAt)
+	if err != nil {
+		// A stale set is better than no set at all while the source is unavailable.
+		slog.WarnContext(ctx, "Failed to refresh JWKS", slog.String("source", j.source), slog.String("error", err.Error()))
+		if ok {
+			return key, nil
+		}
+		return nil, fmt.Errorf("refresh jwks: %w", err)
+	}
+
+	j.mu.RLock()
+	key, ok = j.keys[kid]
+	j.mu.RUnlock()
+	if !ok {
+		return nil, errUnknownKeyID
+	}
+
+	return key, nil
+}
+
+// Refresh reloads the key set from its source.
+func (j *JWKS) Refresh(ctx context.Context) error {
+	return j.refresh(ctx, time.Time{})
+}
+
+// refresh reloads the key set unless it was reloaded by a concurrent caller after seenFetchedAt.
+func (j *JWKS) refresh(ctx context.Context, seenFetchedAt time.Time) error {
+	j.refreshMu.Lock()
+	defer j.refreshMu.Unlock()
+
+	j.mu.Lock()
+	if !seenFetchedAt.IsZero() && j.fetchedAt.After(seenFetchedAt) {
+		j.mu.Unlock()
+		return nil
+	}
+	j.lastRefreshAt = time.Now()
+	j.mu.Unlock()
+
+	raw, err := j.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys, err := parseJWKS(raw)
+	if err != nil {
+		return fmt.Errorf("parse jwks: %w", err)
+	}
+
+	j.mu.Lock()
+	j.keys = keys
+	j.fetchedAt = time.Now()
+	j.mu.Unlock()
+
+	return nil
+}
+
+func (j *JWKS) fetch(ctx context.Context) (_ []byte, err error) {
+	if !strings.HasPrefix(j.source, "http://") && !strings.HasPrefix(j.source, "https://") {
+		raw, err := os.ReadFile(j.source)
+		if err != nil {
+			return nil, fmt.Errorf("read jwks file: %w", err)
+		}
+		return raw, nil
+	}
+	if err = checkJWKSURL(j.source); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, jwksFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.source, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get jwks: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, resp.Body.Close())
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get jwks: unexpected status code %d", resp.StatusCode)
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("read jwks response: %w", err)
+	}
+
+	return raw, nil
+}
+
+// checkJWKSURL rejects key sets fetched over plain http, anyone on the network path could
+// substitute their own keys and forge tokens. Loopback hosts are allowed for local setups.
+func checkJWKSURL(source string) error {
+	u, err := url.Parse(source)
+	if err != nil {
+		return fmt.Errorf("parse jwks url: %w", err)
+	}
+	if u.Scheme == "https" {
+		return nil
+	}
+
+	host := u.Hostname()
+	if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return nil
+	}
+	return errors.New("jwks url must use https")
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC and OKP
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS skips keys of unsupported types and keys that are not meant for signatures.
+func parseJWKS(raw []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	err := json.Unmarshal(raw, &set)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			slog.Warn("Skipping invalid JWK", slog.String("kid", jwk.Kid), slog.String("error", err.Error()))
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	return keys, nil
+}
+
+func (k *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("decode n: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("decode e: %w", err)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("decode x: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("decode y: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid x")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, errors.New("empty value")
+	}
+
+	return new(big.Int).SetBytes(raw), nil
+}
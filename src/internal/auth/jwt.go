@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"strings"
+	"time"
+)
+
+var jwtSigningMethods = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+type JWTConfig struct {
+	Issuer   string
+	Audience string
+	// ScopesClaim names the claim holding the scopes, either a space-delimited string
+	// like the OAuth 2.0 "scope" claim or an array of strings. Unknown scopes are ignored.
+	ScopesClaim string
+	// Leeway tolerates clock skew when checking "exp", "nbf" and "iat".
+	Leeway time.Duration
+}
+
+// JWTValidator authenticates bearer tokens signed with a key of the JWKS.
+type JWTValidator struct {
+	cfg    JWTConfig
+	keys   *JWKS
+	parser *jwt.Parser
+}
+
+func NewJWTValidator(cfg JWTConfig, keys *JWKS) (*JWTValidator, error) {
+	if cfg.Issuer == "" || cfg.Audience == "" {
+		return nil, errors.New("issuer and audience must be set")
+	}
+	if cfg.ScopesClaim == "" {
+		cfg.ScopesClaim = "scope"
+	}
+
+	return &JWTValidator{
+		cfg:  cfg,
+		keys: keys,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods(jwtSigningMethods),
+			jwt.WithIssuer(cfg.Issuer),
+			jwt.WithAudience(cfg.Audience),
+			jwt.WithExpirationRequired(),
+			jwt.WithLeeway(cfg.Leeway),
+		),
+	}, nil
+}
+
+// Authenticate returns an error wrapping ErrUnauthenticated if the token is not valid.
+func (v *JWTValidator) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	_, err := v.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.Key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnauthenticated, err)
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, fmt.Errorf("%w: missing \"sub\" claim", ErrUnauthenticated)
+	}
+
+	// The subject is only unique at the issuer and must not collide with the subjects of the
+	// other credentials, e.g. a "sub" of "apikey:<id>" must not own the quotes of that key.
+	return &Principal{
+		Subject: "jwt:" + v.cfg.Issuer + ":" + subject,
+		Scopes:  scopesFromClaim(claims[v.cfg.ScopesClaim]),
+	}, nil
+}
+
+func scopesFromClaim(claim any) []Scope {
+	var raw []string
+	switch claim := claim.(type) {
+	case string:
+		raw = strings.Fields(claim)
+	case []any:
+		for _, v := range claim {
+			if s, ok := v.(string); ok {
+				raw = append(raw, s)
+			}
+		}
+	}
+
+	scopes := make([]Scope, 0, len(raw))
+	for _, s := range raw {
+		parsed, err := ParseScopes([]string{s})
+		if err != nil {
+			continue
+		}
+		scopes = append(scopes, parsed...)
+	}
+
+	return scopes
+}
+
+type credentialAuthenticator interface {
+	Authenticate(ctx context.Context, credential string) (*Principal, error)
+}
+
+// Authenticator accepts API keys and, if JWT is set, bearer JWTs.
+type Authenticator struct {
+	APIKeys credentialAuthenticator
+	// JWT is nil if bearer tokens are not accepted.
+	JWT credentialAuthenticator
+}
+
+func (a *Authenticator) Authenticate(ctx context.Context, credential string) (*Principal, error) {
+	if IsAPIKey(credential) {
+		return a.APIKeys.Authenticate(ctx, credential)
+	}
+	if a.JWT == nil {
+		return nil, ErrUnauthenticated
+	}
+
+	return a.JWT.Authenticate(ctx, credential)
+}
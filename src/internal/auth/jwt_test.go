@@ -0,0 +1,293 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com"
+	testAudience = "quote-service"
+)
+
+func rsaJWK(t *testing.T, kid string, key *rsa.PrivateKey) map[string]string {
+	t.Helper()
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(t *testing.T, kid string, key *ecdsa.PrivateKey) map[string]string {
+	t.Helper()
+	return map[string]string{
+		"kty": "EC",
+		"kid": kid,
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+func marshalJWKS(t *testing.T, keys ...map[string]string) []byte {
+	t.Helper()
+	raw, err := json.Marshal(map[string]any{"keys": keys})
+	if err != nil {
+		t.Fatal("Failed to marshal jwks", err)
+	}
+	return raw
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal("Failed to sign token", err)
+	}
+	return signed
+}
+
+func validClaims(overrides jwt.MapClaims) jwt.MapClaims {
+	claims := jwt.MapClaims{
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"sub":   "user-1",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "openid quotes:read quotes:write",
+	}
+	for k, v := range overrides {
+		claims[k] = v
+	}
+	return claims
+}
+
+func TestJWTValidator_Authenticate(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("Failed to generate rsa key", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("Failed to generate ec key", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("Failed to generate rsa key", err)
+	}
+
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	err = os.WriteFile(jwksPath, marshalJWKS(t, rsaJWK(t, "rsa-1", rsaKey), ecJWK(t, "ec-1", ecKey)), 0o600)
+	if err != nil {
+		t.Fatal("Failed to write jwks", err)
+	}
+
+	validator, err := NewJWTValidator(JWTConfig{Issuer: testIssuer, Audience: testAudience}, NewJWKS(jwksPath, time.Hour))
+	if err != nil {
+		t.Fatal("Failed to create validator", err)
+	}
+
+	const wantSubject = "jwt:" + testIssuer + ":user-1"
+
+	type testCase struct {
+		name       string
+		token      string
+		wantErr    bool
+		wantScopes []Scope
+	}
+
+	testCases := []testCase{
+		{
+			name:       "RSA signed token is valid and maps known scopes",
+			token:      signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, validClaims(nil)),
+			wantScopes: []Scope{ScopeQuotesRead, ScopeQuotesWrite},
+		},
+		{
+			name:       "EC signed token with scopes array is valid",
+			token:      signToken(t, jwt.SigningMethodES256, "ec-1", ecKey, validClaims(jwt.MapClaims{"scope": []string{"quotes:delete"}})),
+			wantScopes: []Scope{ScopeQuotesDelete},
+		},
+		{
+			name:    "Token signed with a foreign key is rejected",
+			token:   signToken(t, jwt.SigningMethodRS256, "rsa-1", otherKey, validClaims(nil)),
+			wantErr: true,
+		},
+		{
+			name:    "Token with unknown key id is rejected",
+			token:   signToken(t, jwt.SigningMethodRS256, "rsa-2", rsaKey, validClaims(nil)),
+			wantErr: true,
+		},
+		{
+			name:    "Token of another issuer is rejected",
+			token:   signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, validClaims(jwt.MapClaims{"iss": "https://evil.example.com"})),
+			wantErr: true,
+		},
+		{
+			name:    "Token for another audience is rejected",
+			token:   signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, validClaims(jwt.MapClaims{"aud": "other-service"})),
+			wantErr: true,
+		},
+		{
+			name:    "Expired token is rejected",
+			token:   signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, validClaims(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()})),
+			wantErr: true,
+		},
+		{
+			name: "Token without expiry is rejected",
+			token: signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, func() jwt.MapClaims {
+				claims := validClaims(nil)
+				delete(claims, "exp")
+				return claims
+			}()),
+			wantErr: true,
+		},
+		{
+			name:    "Unsigned token is rejected",
+			token:   signToken(t, jwt.SigningMethodNone, "rsa-1", jwt.UnsafeAllowNoneSignatureType, validClaims(nil)),
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			principal, err := validator.Authenticate(context.Background(), tc.token)
+			if tc.wantErr {
+				if !errors.Is(err, ErrUnauthenticated) {
+					t.Fatalf("Authenticate() error = %v, want ErrUnauthenticated", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate() unexpected error = %v", err)
+			}
+			if principal.Subject != wantSubject || !slices.Equal(principal.Scopes, tc.wantScopes) {
+				t.Errorf("Authenticate() = %+v, want subject %s with scopes %v", principal, wantSubject, tc.wantScopes)
+			}
+		})
+	}
+}
+
+func TestJWKS_Rotation(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("Failed to generate rsa key", err)
+	}
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal("Failed to generate rsa key", err)
+	}
+
+	var (
+		current  atomic.Value
+		requests atomic.Int32
+	)
+	current.Store(marshalJWKS(t, rsaJWK(t, "old", oldKey)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write(current.Load().([]byte))
+	}))
+	defer server.Close()
+
+	jwks := NewJWKS(server.URL, time.Hour)
+	jwks.minRefreshInterval = 50 * time.Millisecond
+	validator, err := NewJWTValidator(JWTConfig{Issuer: testIssuer, Audience: testAudience}, jwks)
+	if err != nil {
+		t.Fatal("Failed to create validator", err)
+	}
+
+	_, err = validator.Authenticate(context.Background(), signToken(t, jwt.SigningMethodRS256, "old", oldKey, validClaims(nil)))
+	if err != nil {
+		t.Fatalf("Token signed with the initial key was rejected: %v", err)
+	}
+
+	_, err = validator.Authenticate(context.Background(), signToken(t, jwt.SigningMethodRS256, "old", oldKey, validClaims(nil)))
+	if err != nil || requests.Load() != 1 {
+		t.Fatalf("Cached key set was not used: error = %v, requests = %d", err, requests.Load())
+	}
+
+	// The issuer rotates its key, the first token with the new key id forces a reload.
+	time.Sleep(2 * jwks.minRefreshInterval)
+	current.Store(marshalJWKS(t, rsaJWK(t, "new", newKey)))
+	_, err = validator.Authenticate(context.Background(), signToken(t, jwt.SigningMethodRS256, "new", newKey, validClaims(nil)))
+	if err != nil {
+		t.Fatalf("Token signed with the rotated key was rejected: %v", err)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("Key set was fetched %d times, want 2", requests.Load())
+	}
+
+	// Unknown key ids do not force another reload right after the previous one.
+	_, err = validator.Authenticate(context.Background(), signToken(t, jwt.SigningMethodRS256, "unknown", newKey, validClaims(nil)))
+	if err == nil {
+		t.Fatal("Token with unknown key id was accepted")
+	}
+	if requests.Load() != 2 {
+		t.Errorf("Key set was fetched %d times, want 2", requests.Load())
+	}
+}
+
+type stubAuthenticator struct {
+	principal *Principal
+}
+
+func (s stubAuthenticator) Authenticate(context.Context, string) (*Principal, error) {
+	return s.principal, nil
+}
+
+func TestCheckJWKSURL(t *testing.T) {
+	testCases := map[string]bool{
+		"https://issuer.example.com/.well-known/jwks.json": true,
+		"http://127.0.0.1:8080/jwks.json":                  true,
+		"http://[::1]/jwks.json":                           true,
+		"http://localhost/jwks.json":                       true,
+		"http://issuer.example.com/.well-known/jwks.json":  false,
+		"http://10.0.0.1/jwks.json":                        false,
+	}
+
+	for source, wantOK := range testCases {
+		if err := checkJWKSURL(source); (err == nil) != wantOK {
+			t.Errorf("checkJWKSURL(%q) error = %v, want ok %v", source, err, wantOK)
+		}
+	}
+}
+
+func TestAuthenticator_Dispatch(t *testing.T) {
+	keyPrincipal := &Principal{Subject: "api-key"}
+	jwtPrincipal := &Principal{Subject: "jwt"}
+
+	authenticator := &Authenticator{APIKeys: stubAuthenticator{keyPrincipal}}
+	_, err := authenticator.Authenticate(context.Background(), "eyJhbGciOi.e30.sig")
+	if !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("Authenticate() of a JWT without validator error = %v, want ErrUnauthenticated", err)
+	}
+
+	authenticator.JWT = stubAuthenticator{jwtPrincipal}
+	got, err := authenticator.Authenticate(context.Background(), "qs_0011_secret")
+	if err != nil || got != keyPrincipal {
+		t.Errorf("Authenticate() of an API key = %v, %v, want API key principal", got, err)
+	}
+	got, err = authenticator.Authenticate(context.Background(), "eyJhbGciOi.e30.sig")
+	if err != nil || got != jwtPrincipal {
+		t.Errorf("Authenticate() of a JWT = %v, %v, want JWT principal", got, err)
+	}
+}
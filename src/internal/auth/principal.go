@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"slices"
+)
+
+type Scope string
+
+const (
+	ScopeQuotesRead   Scope = "quotes:read"
+	ScopeQuotesWrite  Scope = "quotes:write"
+	ScopeQuotesDelete Scope = "quotes:delete"
+	// ScopeQuotesModerate allows editing and deleting quotes created by anyone else,
+	// it implies "quotes:write" and "quotes:delete".
+	ScopeQuotesModerate Scope = "quotes:moderate"
+	// ScopeAdmin implies every other scope.
+	ScopeAdmin Scope = "admin"
+)
+
+var knownScopes = []Scope{ScopeQuotesRead, ScopeQuotesWrite, ScopeQuotesDelete, ScopeQuotesModerate, ScopeAdmin}
+
+func ParseScopes(raw []string) ([]Scope, error) {
+	ret := make([]Scope, 0, len(raw))
+	for _, s := range raw {
+		scope := Scope(s)
+		if !slices.Contains(knownScopes, scope) {
+			return nil, fmt.Errorf("unknown scope %q", s)
+		}
+		if !slices.Contains(ret, scope) {
+			ret = append(ret, scope)
+		}
+	}
+
+	return ret, nil
+}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	// Subject identifies the caller across credential types, e.g. "apikey:<id>" or
+	// "jwt:<issuer>:<sub>". Every credential type has its own prefix, so subjects never collide.
+	Subject string
+	// KeyID is uuid.Nil unless the caller authenticated with an API key.
+	KeyID  uuid.UUID
+	Scopes []Scope
+}
+
+func (p *Principal) HasScope(scope Scope) bool {
+	if slices.Contains(p.Scopes, scope) || slices.Contains(p.Scopes, ScopeAdmin) {
+		return true
+	}
+
+	return (scope == ScopeQuotesWrite || scope == ScopeQuotesDelete) && slices.Contains(p.Scopes, ScopeQuotesModerate)
+}
+
+type principalCtxKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalCtxKey{}, principal)
+}
+
+// PrincipalFromContext returns nil if the request is anonymous.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalCtxKey{}).(*Principal)
+	return principal
+}
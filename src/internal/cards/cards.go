@@ -0,0 +1,207 @@
+package cards
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image/color"
+	"sync"
+)
+
+// Format names an output format, it is the extension of the card path, e.g. "card.png".
+type Format string
+
+const (
+	SVG Format = "svg"
+	PNG Format = "png"
+)
+
+func (f Format) ContentType() string {
+	if f == PNG {
+		return "image/png"
+	}
+	return "image/svg+xml"
+}
+
+var (
+	ErrUnknownFormat = errors.New("unknown card format")
+	ErrUnknownTheme  = errors.New("unknown card theme")
+	ErrUnknownSize   = errors.New("unknown card size")
+)
+
+func ParseFormat(raw string) (Format, error) {
+	switch format := Format(raw); format {
+	case SVG, PNG:
+		return format, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+// Theme holds the colors of a card.
+type Theme struct {
+	Name       string
+	Background color.RGBA
+	Text       color.RGBA
+	// Accent colors the bar beside the quote and the author.
+	Accent color.RGBA
+}
+
+// Themes are the selectable themes by name, DefaultTheme is used if none is selected.
+var Themes = map[string]Theme{
+	"light": {Name: "light", Background: rgb(0xfa, 0xfa, 0xf7), Text: rgb(0x1f, 0x23, 0x28), Accent: rgb(0xd9, 0x48, 0x0f)},
+	"dark":  {Name: "dark", Background: rgb(0x11, 0x18, 0x27), Text: rgb(0xf9, 0xfa, 0xfb), Accent: rgb(0xfb, 0xbf, 0x24)},
+	"sepia": {Name: "sepia", Background: rgb(0xf4, 0xec, 0xd8), Text: rgb(0x43, 0x34, 0x22), Accent: rgb(0x8b, 0x5e, 0x34)},
+}
+
+const DefaultTheme = "light"
+
+func ParseTheme(raw string) (Theme, error) {
+	if raw == "" {
+		raw = DefaultTheme
+	}
+	theme, ok := Themes[raw]
+	if !ok {
+		return Theme{}, ErrUnknownTheme
+	}
+	return theme, nil
+}
+
+func rgb(r, g, b uint8) color.RGBA {
+	return color.RGBA{R: r, G: g, B: b, A: 0xff}
+}
+
+// Size holds the dimensions of a card in pixels.
+type Size struct {
+	Name          string
+	Width, Height int
+}
+
+// Sizes are the selectable sizes by name: the OpenGraph image size, a square post and a portrait
+// story. DefaultSize is used if none is selected.
+var Sizes = map[string]Size{
+	"og":     {Name: "og", Width: 1200, Height: 630},
+	"square": {Name: "square", Width: 1080, Height: 1080},
+	"story":  {Name: "story", Width: 1080, Height: 1920},
+}
+
+const DefaultSize = "og"
+
+func ParseSize(raw string) (Size, error) {
+	if raw == "" {
+		raw = DefaultSize
+	}
+	size, ok := Sizes[raw]
+	if !ok {
+		return Size{}, ErrUnknownSize
+	}
+	return size, nil
+}
+
+// Options select the rendering of a card.
+type Options struct {
+	Format Format
+	Theme  Theme
+	Size   Size
+}
+
+// DefaultCacheSize is the number of cards kept by a Renderer created with a non-positive size.
+const DefaultCacheSize = 256
+
+// Renderer renders the cards and keeps the latest rendered ones. The cards are cached by their
+// content, so a quote rendered again after an update misses the cache.
+type Renderer struct {
+	cacheSize int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// recent orders the cache entries latest used first.
+	recent *list.List
+}
+
+type cacheEntry struct {
+	key  string
+	data []byte
+}
+
+func NewRenderer(cacheSize int) *Renderer {
+	if cacheSize <= 0 {
+		cacheSize = DefaultCacheSize
+	}
+
+	return &Renderer{
+		cacheSize: cacheSize,
+		entries:   make(map[string]*list.Element),
+		recent:    list.New(),
+	}
+}
+
+// Render returns the card of the quote. The returned bytes are shared, they must not be modified.
+func (r *Renderer) Render(quote, author string, opts Options) ([]byte, error) {
+	key := cacheKey(quote, author, opts)
+	if data, ok := r.cached(key); ok {
+		return data, nil
+	}
+
+	c, err := layoutCard(quote, author, opts.Theme, opts.Size)
+	if err != nil {
+		return nil, fmt.Errorf("layout card: %w", err)
+	}
+
+	var data []byte
+	switch opts.Format {
+	case SVG:
+		data, err = c.svg()
+	case PNG:
+		data, err = c.png()
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, fmt.Errorf("render %s: %w", opts.Format, err)
+	}
+
+	r.store(key, data)
+	return data, nil
+}
+
+func (r *Renderer) cached(key string) ([]byte, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	elem, ok := r.entries[key]
+	if !ok {
+		return nil, false
+	}
+	r.recent.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).data, true
+}
+
+func (r *Renderer) store(key string, data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// A concurrent render of the same card may have stored it meanwhile.
+	if elem, ok := r.entries[key]; ok {
+		r.recent.MoveToFront(elem)
+		return
+	}
+
+	r.entries[key] = r.recent.PushFront(&cacheEntry{key: key, data: data})
+	for r.recent.Len() > r.cacheSize {
+		oldest := r.recent.Back()
+		r.recent.Remove(oldest)
+		delete(r.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func cacheKey(quote, author string, opts Options) string {
+	h := sha256.New()
+	for _, part := range []string{string(opts.Format), opts.Theme.Name, opts.Size.Name, author, quote} {
+		// The parts are length prefixed, so different splits of the same text differ.
+		_, _ = fmt.Fprintf(h, "%d:%s", len(part), part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
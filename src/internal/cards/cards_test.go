@@ -0,0 +1,158 @@
+package cards
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func options(t *testing.T, format Format, size string) Options {
+	t.Helper()
+
+	s, err := ParseSize(size)
+	if err != nil {
+		t.Fatal("Failed to parse size", err)
+	}
+	theme, err := ParseTheme("")
+	if err != nil {
+		t.Fatal("Failed to parse theme", err)
+	}
+	return Options{Format: format, Theme: theme, Size: s}
+}
+
+func TestRenderer_Render_png(t *testing.T) {
+	for name, size := range Sizes {
+		data, err := NewRenderer(0).Render("quote-1", "author-1", options(t, PNG, name))
+		if err != nil {
+			t.Fatalf("Failed to render %s card: %v", name, err)
+		}
+
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("Failed to decode %s card: %v", name, err)
+		}
+		if b := img.Bounds(); b.Dx() != size.Width || b.Dy() != size.Height {
+			t.Errorf("got %s card of %dx%d want %dx%d", name, b.Dx(), b.Dy(), size.Width, size.Height)
+		}
+	}
+}
+
+func TestRenderer_Render_svg(t *testing.T) {
+	data, err := NewRenderer(0).Render(`Fish & <chips>`, "author-1", options(t, SVG, "square"))
+	if err != nil {
+		t.Fatal("Failed to render card", err)
+	}
+
+	var svg struct {
+		XMLName xml.Name `xml:"svg"`
+		Width   int      `xml:"width,attr"`
+		Texts   []string `xml:"text"`
+	}
+	err = xml.Unmarshal(data, &svg)
+	if err != nil {
+		t.Fatal("Failed to decode card", err)
+	}
+	if svg.Width != 1080 || len(svg.Texts) != 2 || svg.Texts[0] != "“Fish & <chips>”" || svg.Texts[1] != "— author-1" {
+		t.Errorf("got card of width %d with texts %q want the quote and the author", svg.Width, svg.Texts)
+	}
+	if !bytes.Contains(data, []byte("data:font/ttf;base64,")) {
+		t.Error("got card without the embedded font")
+	}
+}
+
+func TestLayoutCard(t *testing.T) {
+	size := Sizes["og"]
+	short, err := layoutCard("Be yourself.", "author", Themes[DefaultTheme], size)
+	if err != nil {
+		t.Fatal("Failed to lay out card", err)
+	}
+	long, err := layoutCard(strings.Repeat("All work and no play makes Jack a dull boy. ", 60), strings.Repeat("author ", 40), Themes[DefaultTheme], size)
+	if err != nil {
+		t.Fatal("Failed to lay out card", err)
+	}
+
+	if short.lines[0].size <= long.lines[0].size {
+		t.Errorf("got font size %.1f for a short quote want more than %.1f for a long one", short.lines[0].size, long.lines[0].size)
+	}
+	f, err := parseFont()
+	if err != nil {
+		t.Fatal("Failed to parse font", err)
+	}
+	for _, l := range long.lines {
+		face, err := newFace(f, l.size)
+		if err != nil {
+			t.Fatal("Failed to create face", err)
+		}
+		if right := l.x + advance(face, l.text); right > float64(size.Width) {
+			t.Errorf("got line %q ending at %.1f past the width %d", l.text, right, size.Width)
+		}
+		if l.y > float64(size.Height) {
+			t.Errorf("got line %q at %.1f below the height %d", l.text, l.y, size.Height)
+		}
+	}
+	quoteLines := long.lines[:len(long.lines)-1]
+	if last := quoteLines[len(quoteLines)-1].text; !strings.HasSuffix(last, ellipsis) {
+		t.Errorf("got last line %q want the cut quote to end with an ellipsis", last)
+	}
+	if author := long.lines[len(long.lines)-1].text; !strings.HasSuffix(author, ellipsis) {
+		t.Errorf("got author %q want the long author cut to one line", author)
+	}
+}
+
+func TestWrap_longWord(t *testing.T) {
+	f, err := parseFont()
+	if err != nil {
+		t.Fatal("Failed to parse font", err)
+	}
+	face, err := newFace(f, 20)
+	if err != nil {
+		t.Fatal("Failed to create face", err)
+	}
+
+	lines := wrap(face, "a "+strings.Repeat("w", 40)+" b", 100)
+	if got := strings.ReplaceAll(strings.Join(lines, ""), " ", ""); got != "a"+strings.Repeat("w", 40)+"b" {
+		t.Errorf("got lines %q want the text broken without losing runes", lines)
+	}
+	for _, l := range lines {
+		if advance(face, l) > 100 {
+			t.Errorf("got line %q wider than 100", l)
+		}
+	}
+}
+
+func TestRenderer_cache(t *testing.T) {
+	r := NewRenderer(2)
+	opts := options(t, SVG, "og")
+
+	first, err := r.Render("quote-1", "author-1", opts)
+	if err != nil {
+		t.Fatal("Failed to render card", err)
+	}
+	again, _ := r.Render("quote-1", "author-1", opts)
+	if &first[0] != &again[0] {
+		t.Error("got the card rendered again want it from the cache")
+	}
+
+	_, _ = r.Render("quote-2", "author-1", opts)
+	_, _ = r.Render("quote-3", "author-1", opts)
+	if r.recent.Len() != 2 {
+		t.Errorf("got %d cached cards want 2", r.recent.Len())
+	}
+	if _, ok := r.cached(cacheKey("quote-1", "author-1", opts)); ok {
+		t.Error("got the least recently used card cached want it evicted")
+	}
+}
+
+func TestParse(t *testing.T) {
+	if _, err := ParseTheme("neon"); err != ErrUnknownTheme {
+		t.Errorf("ParseTheme(\"neon\") error = %v want ErrUnknownTheme", err)
+	}
+	if _, err := ParseSize("banner"); err != ErrUnknownSize {
+		t.Errorf("ParseSize(\"banner\") error = %v want ErrUnknownSize", err)
+	}
+	if _, err := ParseFormat("gif"); err != ErrUnknownFormat {
+		t.Errorf("ParseFormat(\"gif\") error = %v want ErrUnknownFormat", err)
+	}
+}
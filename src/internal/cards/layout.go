@@ -0,0 +1,198 @@
+package cards
+
+import (
+	"fmt"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"image/color"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// The font is embedded, so the cards render the same everywhere without system fonts.
+var parseFont = sync.OnceValues(func() (*opentype.Font, error) {
+	return opentype.Parse(goregular.TTF)
+})
+
+const (
+	// lineSpacing is the distance between the baselines of the quote lines relative to the font size.
+	lineSpacing = 1.3
+	// fontSizeStep is the decrement of the quote font size while the quote does not fit.
+	fontSizeStep = 2
+	ellipsis     = "…"
+)
+
+// card is the laid out card, it is rendered as SVG or PNG.
+type card struct {
+	width, height int
+	theme         Theme
+	// bar is the accent bar beside the quote.
+	bar   rect
+	lines []line
+}
+
+type rect struct {
+	x, y, width, height float64
+}
+
+// line is a line of text, x and y are the start of its baseline.
+type line struct {
+	text  string
+	x, y  float64
+	size  float64
+	color color.RGBA
+}
+
+// layoutCard sizes the quote to the largest font size its wrapped lines fit in, centered
+// vertically together with the author below. Quotes not fitting at the smallest size are cut
+// with an ellipsis.
+func layoutCard(quote, author string, theme Theme, size Size) (*card, error) {
+	f, err := parseFont()
+	if err != nil {
+		return nil, fmt.Errorf("parse font: %w", err)
+	}
+
+	w, h := float64(size.Width), float64(size.Height)
+	padding := min(w, h) * 0.1
+	barWidth := padding * 0.12
+	textX := padding + barWidth + padding*0.4
+	textWidth := w - textX - padding
+	authorSize := w / 30
+	authorGap := authorSize * 1.5
+	maxQuoteSize, minQuoteSize := w/12, w/45
+	maxQuoteHeight := h - 2*padding - authorGap - authorSize
+
+	text := "“" + strings.Join(strings.Fields(quote), " ") + "”"
+	var (
+		quoteSize  float64
+		quoteLines []string
+	)
+	for quoteSize = maxQuoteSize; ; quoteSize -= fontSizeStep {
+		face, err := newFace(f, quoteSize)
+		if err != nil {
+			return nil, err
+		}
+		maxLines := int(maxQuoteHeight / (quoteSize * lineSpacing))
+		quoteLines = wrap(face, text, textWidth)
+		if len(quoteLines) <= maxLines || quoteSize-fontSizeStep < minQuoteSize {
+			quoteLines = truncate(face, quoteLines, max(maxLines, 1), textWidth)
+			_ = face.Close()
+			break
+		}
+		_ = face.Close()
+	}
+
+	authorFace, err := newFace(f, authorSize)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = authorFace.Close() }()
+	authorLines := truncate(authorFace, wrap(authorFace, "— "+strings.Join(strings.Fields(author), " "), textWidth), 1, textWidth)
+
+	// The block spans from the cap height of the first quote line to the baseline of the author.
+	quoteHeight := quoteSize*lineSpacing*float64(len(quoteLines)-1) + quoteSize*0.75
+	blockHeight := quoteHeight + authorGap + authorSize*0.75
+	top := (h - blockHeight) / 2
+
+	c := &card{
+		width:  size.Width,
+		height: size.Height,
+		theme:  theme,
+		bar:    rect{x: padding, y: top, width: barWidth, height: blockHeight},
+	}
+	baseline := top + quoteSize*0.75
+	for _, text := range quoteLines {
+		c.lines = append(c.lines, line{text: text, x: textX, y: baseline, size: quoteSize, color: theme.Text})
+		baseline += quoteSize * lineSpacing
+	}
+	c.lines = append(c.lines, line{
+		text:  authorLines[0],
+		x:     textX,
+		y:     top + blockHeight,
+		size:  authorSize,
+		color: theme.Accent,
+	})
+	return c, nil
+}
+
+func newFace(f *opentype.Font, size float64) (font.Face, error) {
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingNone})
+	if err != nil {
+		return nil, fmt.Errorf("new font face: %w", err)
+	}
+	return face, nil
+}
+
+func advance(face font.Face, text string) float64 {
+	return fixedToFloat(font.MeasureString(face, text))
+}
+
+func fixedToFloat(x fixed.Int26_6) float64 {
+	return float64(x) / 64
+}
+
+// wrap breaks the text into lines at the spaces, words wider than a line are broken anywhere.
+func wrap(face font.Face, text string, width float64) []string {
+	var (
+		lines   []string
+		current string
+	)
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if advance(face, candidate) <= width {
+			current = candidate
+			continue
+		}
+		if current != "" {
+			lines = append(lines, current)
+		}
+		for advance(face, word) > width {
+			n := fitPrefix(face, word, width)
+			lines = append(lines, word[:n])
+			word = word[n:]
+		}
+		current = word
+	}
+	if current != "" || len(lines) == 0 {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// fitPrefix returns the length in bytes of the longest prefix of text fitting the width, at least
+// one rune.
+func fitPrefix(face font.Face, text string, width float64) int {
+	_, n := utf8.DecodeRuneInString(text)
+	for i := range text {
+		if i <= n {
+			continue
+		}
+		if advance(face, text[:i]) > width {
+			break
+		}
+		n = i
+	}
+	return n
+}
+
+// truncate cuts the lines to maxLines, ending the last one with an ellipsis if lines were cut.
+func truncate(face font.Face, lines []string, maxLines int, width float64) []string {
+	if len(lines) <= maxLines {
+		return lines
+	}
+
+	lines = lines[:maxLines]
+	last := lines[maxLines-1]
+	for last != "" && advance(face, last+ellipsis) > width {
+		_, n := utf8.DecodeLastRuneInString(last)
+		last = last[:len(last)-n]
+	}
+	lines[maxLines-1] = strings.TrimRight(last, " ") + ellipsis
+	return lines
+}
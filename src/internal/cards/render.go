@@ -0,0 +1,97 @@
+package cards
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/math/fixed"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"sync"
+)
+
+// fontDataURL embeds the font in the SVG cards, so their text is laid out like the PNG cards.
+var fontDataURL = sync.OnceValue(func() string {
+	return "data:font/ttf;base64," + base64.StdEncoding.EncodeToString(goregular.TTF)
+})
+
+func (c *card) svg() ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`,
+		c.width, c.height, c.width, c.height)
+	fmt.Fprintf(&buf, `<style>@font-face{font-family:"Go";src:url(%s) format("truetype")}text{font-family:"Go",sans-serif}</style>`,
+		fontDataURL())
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="%s"/>`, c.width, c.height, hexColor(c.theme.Background))
+	fmt.Fprintf(&buf, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`,
+		c.bar.x, c.bar.y, c.bar.width, c.bar.height, hexColor(c.theme.Accent))
+	for _, l := range c.lines {
+		fmt.Fprintf(&buf, `<text x="%.1f" y="%.1f" font-size="%.1f" fill="%s">`, l.x, l.y, l.size, hexColor(l.color))
+		err := xml.EscapeText(&buf, []byte(l.text))
+		if err != nil {
+			return nil, fmt.Errorf("escape text: %w", err)
+		}
+		buf.WriteString(`</text>`)
+	}
+	buf.WriteString(`</svg>`)
+
+	return buf.Bytes(), nil
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func (c *card) png() (_ []byte, err error) {
+	f, err := parseFont()
+	if err != nil {
+		return nil, fmt.Errorf("parse font: %w", err)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, c.width, c.height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c.theme.Background), image.Point{}, draw.Src)
+	bar := image.Rect(round(c.bar.x), round(c.bar.y), round(c.bar.x+c.bar.width), round(c.bar.y+c.bar.height))
+	draw.Draw(img, bar, image.NewUniform(c.theme.Accent), image.Point{}, draw.Src)
+
+	faces := make(map[float64]font.Face)
+	defer func() {
+		for _, face := range faces {
+			_ = face.Close()
+		}
+	}()
+	for _, l := range c.lines {
+		face, ok := faces[l.size]
+		if !ok {
+			face, err = newFace(f, l.size)
+			if err != nil {
+				return nil, err
+			}
+			faces[l.size] = face
+		}
+
+		d := font.Drawer{
+			Dst:  img,
+			Src:  image.NewUniform(l.color),
+			Face: face,
+			Dot:  fixed.Point26_6{X: fixed.Int26_6(l.x * 64), Y: fixed.Int26_6(l.y * 64)},
+		}
+		d.DrawString(l.text)
+	}
+
+	var buf bytes.Buffer
+	err = png.Encode(&buf, img)
+	if err != nil {
+		return nil, fmt.Errorf("encode png: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func round(x float64) int {
+	return int(math.Round(x))
+}
@@ -0,0 +1,141 @@
+package events
+
+import (
+	"github.com/BernsteinMondy/quote-service/src/internal/service"
+	"strconv"
+	"sync"
+)
+
+const (
+	DefaultReplaySize = 1000
+	// subscriptionBuffer bounds the events queued for one subscriber, subscribers falling further
+	// behind are closed and expected to resume from the replay buffer.
+	subscriptionBuffer = 64
+)
+
+// Hub fans the published quote events out to its subscribers and keeps the latest ones, so
+// reconnecting subscribers can resume after the last event they received.
+//
+// Events are replayed by position rather than by comparing IDs: the IDs are unique, but only the
+// order of delivery is the same on every replica.
+type Hub struct {
+	replaySize int
+
+	mu          sync.Mutex
+	replay      []service.QuoteEvent
+	subscribers map[*Subscription]struct{}
+	closed      bool
+}
+
+// NewHub returns a hub replaying up to replaySize events, DefaultReplaySize if it is not positive.
+func NewHub(replaySize int) *Hub {
+	if replaySize <= 0 {
+		replaySize = DefaultReplaySize
+	}
+
+	return &Hub{
+		replaySize:  replaySize,
+		replay:      make([]service.QuoteEvent, 0, replaySize),
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscription receives the events published after it was created. C is closed if the hub is
+// closed or the subscriber fell behind.
+type Subscription struct {
+	C <-chan service.QuoteEvent
+
+	hub *Hub
+	c   chan service.QuoteEvent
+}
+
+// Close stops the delivery of events to the subscription.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	s.hub.unsubscribe(s)
+}
+
+// Publish delivers the event to the subscribers without blocking. Events are published at least
+// once by the outbox relay, so an event with the ID of a buffered event is dropped.
+func (h *Hub) Publish(event service.QuoteEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+	for i := len(h.replay) - 1; i >= 0; i-- {
+		if h.replay[i].ID == event.ID {
+			return
+		}
+	}
+
+	if len(h.replay) == h.replaySize {
+		copy(h.replay, h.replay[1:])
+		h.replay = h.replay[:len(h.replay)-1]
+	}
+	h.replay = append(h.replay, event)
+
+	for sub := range h.subscribers {
+		select {
+		case sub.c <- event:
+		default:
+			h.unsubscribe(sub)
+		}
+	}
+}
+
+// Subscribe returns a subscription together with the buffered events published after the event
+// with lastEventID. Nothing is replayed for an empty lastEventID. resumed is false if the event is
+// not buffered anymore, the events published after it are lost then and nothing is replayed.
+func (h *Hub) Subscribe(lastEventID string) (sub *Subscription, replay []service.QuoteEvent, resumed bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c := make(chan service.QuoteEvent, subscriptionBuffer)
+	sub = &Subscription{C: c, hub: h, c: c}
+	if h.closed {
+		close(c)
+		return sub, nil, lastEventID == ""
+	}
+	h.subscribers[sub] = struct{}{}
+
+	if lastEventID == "" {
+		return sub, nil, true
+	}
+
+	id, err := strconv.ParseInt(lastEventID, 10, 64)
+	if err != nil {
+		return sub, nil, false
+	}
+	for i := len(h.replay) - 1; i >= 0; i-- {
+		if h.replay[i].ID == id {
+			return sub, append([]service.QuoteEvent(nil), h.replay[i+1:]...), true
+		}
+	}
+
+	return sub, nil, false
+}
+
+// Close closes every subscription, later subscriptions are closed right away.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for sub := range h.subscribers {
+		h.unsubscribe(sub)
+	}
+}
+
+// unsubscribe must be called with mu held.
+func (h *Hub) unsubscribe(sub *Subscription) {
+	if _, ok := h.subscribers[sub]; !ok {
+		return
+	}
+
+	delete(h.subscribers, sub)
+	close(sub.c)
+}
@@ -0,0 +1,122 @@
+package events
+
+import (
+	"github.com/BernsteinMondy/quote-service/src/internal/service"
+	"slices"
+	"testing"
+)
+
+func event(id int64) service.QuoteEvent {
+	return service.QuoteEvent{ID: id, Type: service.QuoteCreated}
+}
+
+func ids(events []service.QuoteEvent) []int64 {
+	ret := make([]int64, len(events))
+	for i, e := range events {
+		ret[i] = e.ID
+	}
+	return ret
+}
+
+func TestHub_Subscribe(t *testing.T) {
+	hub := NewHub(3)
+	// The IDs are unique but not ordered, the replay follows the order of delivery.
+	for _, id := range []int64{2, 1, 4, 3} {
+		hub.Publish(event(id))
+	}
+
+	type testCase struct {
+		name        string
+		lastEventID string
+		wantReplay  []int64
+		wantResumed bool
+	}
+
+	testCases := []testCase{
+		{
+			name:        "Empty last event ID replays nothing",
+			wantReplay:  []int64{},
+			wantResumed: true,
+		},
+		{
+			name:        "Buffered last event ID replays the later events",
+			lastEventID: "1",
+			wantReplay:  []int64{4, 3},
+			wantResumed: true,
+		},
+		{
+			name:        "Latest last event ID replays nothing",
+			lastEventID: "3",
+			wantReplay:  []int64{},
+			wantResumed: true,
+		},
+		{
+			name:        "Evicted last event ID is not resumed",
+			lastEventID: "2",
+			wantReplay:  []int64{},
+		},
+		{
+			name:        "Invalid last event ID is not resumed",
+			lastEventID: "abc",
+			wantReplay:  []int64{},
+		},
+	}
+
+	for _, tc := range testCases {
+		sub, replay, resumed := hub.Subscribe(tc.lastEventID)
+		sub.Close()
+
+		if got := ids(replay); !slices.Equal(got, tc.wantReplay) {
+			t.Errorf("%s: got replay %v want %v", tc.name, got, tc.wantReplay)
+		}
+		if resumed != tc.wantResumed {
+			t.Errorf("%s: got resumed %t want %t", tc.name, resumed, tc.wantResumed)
+		}
+	}
+}
+
+func TestHub_Publish(t *testing.T) {
+	hub := NewHub(0)
+
+	sub, _, _ := hub.Subscribe("")
+	slow, _, _ := hub.Subscribe("")
+
+	for i := range subscriptionBuffer + 1 {
+		hub.Publish(event(int64(i)))
+		if i < subscriptionBuffer {
+			if got := <-sub.C; got.ID != int64(i) {
+				t.Fatalf("got event %d want %d", got.ID, i)
+			}
+		}
+	}
+
+	received := 0
+	for range slow.C {
+		received++
+	}
+	if received != subscriptionBuffer {
+		t.Errorf("got %d events for the slow subscriber want %d before it was closed", received, subscriptionBuffer)
+	}
+
+	if got := <-sub.C; got.ID != subscriptionBuffer {
+		t.Errorf("got event %d want %d", got.ID, subscriptionBuffer)
+	}
+
+	hub.Publish(event(subscriptionBuffer))
+	select {
+	case got := <-sub.C:
+		t.Errorf("got event %d published again want it dropped", got.ID)
+	default:
+	}
+
+	hub.Close()
+	if _, ok := <-sub.C; ok {
+		t.Error("got open subscription after the hub was closed")
+	}
+	sub.Close()
+
+	late, _, _ := hub.Subscribe("")
+	if _, ok := <-late.C; ok {
+		t.Error("got open subscription from a closed hub")
+	}
+}
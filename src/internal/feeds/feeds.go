@@ -0,0 +1,100 @@
+package feeds
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/BernsteinMondy/quote-service/src/internal/service"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Format names a feed format, it is the extension of the feed path, e.g. "quotes.atom".
+type Format string
+
+const (
+	Atom Format = "atom"
+	RSS  Format = "rss"
+	// JSON is JSON Feed 1.1.
+	JSON Format = "json"
+)
+
+var ErrUnknownFormat = errors.New("unknown feed format")
+
+func ParseFormat(raw string) (Format, error) {
+	switch format := Format(raw); format {
+	case Atom, RSS, JSON:
+		return format, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case Atom:
+		return "application/atom+xml; charset=utf-8"
+	case RSS:
+		return "application/rss+xml; charset=utf-8"
+	default:
+		return "application/feed+json; charset=utf-8"
+	}
+}
+
+// Meta describes the feed, the URLs must be absolute.
+type Meta struct {
+	Title       string
+	Description string
+	// FeedURL is the URL the feed is served at.
+	FeedURL string
+	HomeURL string
+	// QuoteURL returns the URL of a quote.
+	QuoteURL func(id uuid.UUID) string
+}
+
+// epoch is the update time of empty feeds, so their content does not change between requests.
+var epoch = time.Unix(0, 0).UTC()
+
+// Build renders the quotes in the order given, they are expected latest created first. The quotes
+// are rendered from their fields only, the tags must be loaded with them.
+func Build(format Format, meta Meta, quotes []service.Quote) ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+	switch format {
+	case Atom:
+		data, err = xml.MarshalIndent(atomFeedFromQuotes(meta, quotes), "", "  ")
+	case RSS:
+		data, err = xml.MarshalIndent(rssFeedFromQuotes(meta, quotes), "", "  ")
+	case JSON:
+		return json.MarshalIndent(jsonFeedFromQuotes(meta, quotes), "", "  ")
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, fmt.Errorf("marshal %s feed: %w", format, err)
+	}
+
+	return append([]byte(xml.Header), data...), nil
+}
+
+// updated returns the latest creation time of the quotes.
+func updated(quotes []service.Quote) time.Time {
+	ret := epoch
+	for _, quote := range quotes {
+		if quote.CreatedAt.After(ret) {
+			ret = quote.CreatedAt.UTC()
+		}
+	}
+	return ret
+}
+
+func entryID(id uuid.UUID) string {
+	return id.URN()
+}
+
+func entryTitle(quote *service.Quote) string {
+	return "Quote by " + quote.Author
+}
@@ -0,0 +1,143 @@
+package feeds
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"github.com/BernsteinMondy/quote-service/src/internal/service"
+	"github.com/google/uuid"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+var metaFixture = Meta{
+	Title:    "Recently added quotes",
+	FeedURL:  "https://quotes.example.com/feeds/quotes.atom",
+	HomeURL:  "https://quotes.example.com/quotes",
+	QuoteURL: func(id uuid.UUID) string { return "https://quotes.example.com/quotes/" + id.String() },
+}
+
+var quotesFixture = []service.Quote{
+	{
+		ID:        uuid.MustParse("f48a5cda-ed11-4403-acaf-a770c05a9d6f"),
+		Author:    "Ada <Lovelace>",
+		Quote:     "The engine & the loom",
+		CreatedAt: time.Date(2026, 10, 2, 12, 0, 0, 0, time.UTC),
+		Tags:      []string{"science", "machines"},
+	},
+	{
+		ID:        uuid.MustParse("d45cd206-6495-414c-ab1d-f0b6468264be"),
+		Author:    "author-1",
+		Quote:     "quote-1",
+		CreatedAt: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+	},
+}
+
+func TestBuild_atom(t *testing.T) {
+	data, err := Build(Atom, metaFixture, quotesFixture)
+	if err != nil {
+		t.Fatal("Failed to build feed", err)
+	}
+
+	var feed atomFeed
+	err = xml.Unmarshal(data, &feed)
+	if err != nil {
+		t.Fatal("Failed to decode feed", err)
+	}
+	if feed.XMLName.Space != "http://www.w3.org/2005/Atom" || feed.Updated != "2026-10-02T12:00:00Z" {
+		t.Errorf("got feed %s updated %s want an Atom feed updated with the latest quote", feed.XMLName.Space, feed.Updated)
+	}
+	if len(feed.Entries) != 2 {
+		t.Fatalf("got %d entries want 2", len(feed.Entries))
+	}
+	entry := feed.Entries[0]
+	if entry.ID != "urn:uuid:f48a5cda-ed11-4403-acaf-a770c05a9d6f" || entry.Author.Name != "Ada <Lovelace>" || entry.Content.Text != "The engine & the loom" {
+		t.Errorf("got entry %+v want the first quote", entry)
+	}
+	if len(entry.Categories) != 2 || entry.Categories[1].Term != "machines" {
+		t.Errorf("got categories %+v want the tags", entry.Categories)
+	}
+	if entry.Link.Href != metaFixture.QuoteURL(quotesFixture[0].ID) {
+		t.Errorf("got link %s want the URL of the quote", entry.Link.Href)
+	}
+}
+
+func TestBuild_rss(t *testing.T) {
+	data, err := Build(RSS, metaFixture, quotesFixture)
+	if err != nil {
+		t.Fatal("Failed to build feed", err)
+	}
+
+	for _, want := range []string{
+		`<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/"`,
+		`<dc:creator>Ada &lt;Lovelace&gt;</dc:creator>`,
+		`<guid isPermaLink="false">urn:uuid:d45cd206-6495-414c-ab1d-f0b6468264be</guid>`,
+		`<pubDate>Thu, 01 Oct 2026 12:00:00 +0000</pubDate>`,
+		`<category>science</category>`,
+	} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Errorf("got feed %s want it to contain %s", data, want)
+		}
+	}
+
+	var feed rssFeed
+	err = xml.Unmarshal(data, &feed)
+	if err != nil || len(feed.Channel.Items) != 2 {
+		t.Errorf("got %d items, error %v want 2 items", len(feed.Channel.Items), err)
+	}
+}
+
+func TestBuild_json(t *testing.T) {
+	data, err := Build(JSON, metaFixture, quotesFixture)
+	if err != nil {
+		t.Fatal("Failed to build feed", err)
+	}
+
+	var feed jsonFeed
+	err = json.Unmarshal(data, &feed)
+	if err != nil {
+		t.Fatal("Failed to decode feed", err)
+	}
+	if feed.Version != "https://jsonfeed.org/version/1.1" || len(feed.Items) != 2 {
+		t.Fatalf("got feed %+v want a JSON Feed 1.1 with 2 items", feed)
+	}
+	item := feed.Items[0]
+	if item.DatePublished != "2026-10-02T12:00:00Z" || !slices.Equal(item.Tags, quotesFixture[0].Tags) || item.Authors[0].Name != "Ada <Lovelace>" {
+		t.Errorf("got item %+v want the first quote", item)
+	}
+	if strings.Contains(string(data), `"tags": null`) {
+		t.Errorf("got feed %s want the tags omitted for quotes without tags", data)
+	}
+}
+
+func TestBuild_empty(t *testing.T) {
+	for _, format := range []Format{Atom, RSS, JSON} {
+		first, err := Build(format, metaFixture, nil)
+		if err != nil {
+			t.Fatalf("Failed to build empty %s feed: %v", format, err)
+		}
+		second, _ := Build(format, metaFixture, nil)
+		if !bytes.Equal(first, second) {
+			t.Errorf("got %s and %s want the empty %s feed to be stable", first, second, format)
+		}
+	}
+
+	data, _ := Build(Atom, metaFixture, nil)
+	if !bytes.Contains(data, []byte("<updated>1970-01-01T00:00:00Z</updated>")) {
+		t.Errorf("got feed %s want the empty feed updated at the epoch", data)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, raw := range []string{"atom", "rss", "json"} {
+		format, err := ParseFormat(raw)
+		if err != nil || string(format) != raw {
+			t.Errorf("ParseFormat(%q) = %q, %v", raw, format, err)
+		}
+	}
+	if _, err := ParseFormat("xml"); err != ErrUnknownFormat {
+		t.Errorf("ParseFormat(\"xml\") error = %v want ErrUnknownFormat", err)
+	}
+}
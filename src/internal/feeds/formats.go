@@ -0,0 +1,186 @@
+package feeds
+
+import (
+	"encoding/xml"
+	"github.com/BernsteinMondy/quote-service/src/internal/service"
+	"time"
+)
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Author     atomPerson     `xml:"author"`
+	Link       atomLink       `xml:"link"`
+	Categories []atomCategory `xml:"category"`
+	Content    atomText       `xml:"content"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+func atomFeedFromQuotes(meta Meta, quotes []service.Quote) atomFeed {
+	feed := atomFeed{
+		ID:      meta.FeedURL,
+		Title:   meta.Title,
+		Updated: updated(quotes).Format(time.RFC3339),
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: meta.FeedURL},
+			{Rel: "alternate", Href: meta.HomeURL},
+		},
+		Entries: make([]atomEntry, len(quotes)),
+	}
+	for i, quote := range quotes {
+		createdAt := quote.CreatedAt.UTC().Format(time.RFC3339)
+		entry := atomEntry{
+			ID:        entryID(quote.ID),
+			Title:     entryTitle(&quote),
+			Published: createdAt,
+			// Updates of the quotes are not tracked, the entries keep their creation time.
+			Updated: createdAt,
+			Author:  atomPerson{Name: quote.Author},
+			Link:    atomLink{Rel: "alternate", Href: meta.QuoteURL(quote.ID)},
+			Content: atomText{Type: "text", Text: quote.Quote},
+		}
+		for _, tag := range quote.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		feed.Entries[i] = entry
+	}
+	return feed
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	DCNS    string     `xml:"xmlns:dc,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	AtomLink      atomLink  `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Creator     string   `xml:"dc:creator"`
+	Categories  []string `xml:"category"`
+	Description string   `xml:"description"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func rssFeedFromQuotes(meta Meta, quotes []service.Quote) rssFeed {
+	feed := rssFeed{
+		Version: "2.0",
+		DCNS:    "http://purl.org/dc/elements/1.1/",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:         meta.Title,
+			Link:          meta.HomeURL,
+			Description:   meta.Description,
+			AtomLink:      atomLink{Rel: "self", Type: "application/rss+xml", Href: meta.FeedURL},
+			LastBuildDate: updated(quotes).Format(time.RFC1123Z),
+			Items:         make([]rssItem, len(quotes)),
+		},
+	}
+	for i, quote := range quotes {
+		feed.Channel.Items[i] = rssItem{
+			Title:       entryTitle(&quote),
+			Link:        meta.QuoteURL(quote.ID),
+			GUID:        rssGUID{Value: entryID(quote.ID)},
+			PubDate:     quote.CreatedAt.UTC().Format(time.RFC1123Z),
+			Creator:     quote.Author,
+			Categories:  quote.Tags,
+			Description: quote.Quote,
+		}
+	}
+	return feed
+}
+
+// jsonFeedVersion is the version URL of JSON Feed 1.1.
+const jsonFeedVersion = "https://jsonfeed.org/version/1.1"
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url"`
+	Title         string           `json:"title"`
+	ContentText   string           `json:"content_text"`
+	DatePublished string           `json:"date_published"`
+	Authors       []jsonFeedAuthor `json:"authors"`
+	Tags          []string         `json:"tags,omitempty"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+func jsonFeedFromQuotes(meta Meta, quotes []service.Quote) jsonFeed {
+	feed := jsonFeed{
+		Version:     jsonFeedVersion,
+		Title:       meta.Title,
+		HomePageURL: meta.HomeURL,
+		FeedURL:     meta.FeedURL,
+		Description: meta.Description,
+		Items:       make([]jsonFeedItem, len(quotes)),
+	}
+	for i, quote := range quotes {
+		feed.Items[i] = jsonFeedItem{
+			ID:            entryID(quote.ID),
+			URL:           meta.QuoteURL(quote.ID),
+			Title:         entryTitle(&quote),
+			ContentText:   quote.Quote,
+			DatePublished: quote.CreatedAt.UTC().Format(time.RFC3339),
+			Authors:       []jsonFeedAuthor{{Name: quote.Author}},
+			Tags:          quote.Tags,
+		}
+	}
+	return feed
+}
@@ -0,0 +1,99 @@
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"github.com/BernsteinMondy/quote-service/src/internal/auth"
+	quoteService "github.com/BernsteinMondy/quote-service/src/internal/service"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"log/slog"
+)
+
+// The codes reported in the "code" extension of errors.
+const (
+	codeBadUserInput     = "BAD_USER_INPUT"
+	codeUnauthenticated  = "UNAUTHENTICATED"
+	codeForbidden        = "FORBIDDEN"
+	codeNotFound         = "NOT_FOUND"
+	codeAlreadyExists    = "ALREADY_EXISTS"
+	codeLimitExceeded    = "LIMIT_EXCEEDED"
+	codeDeadlineExceeded = "DEADLINE_EXCEEDED"
+	codeInternal         = "INTERNAL"
+)
+
+// apiError is reported with its code and, for validation errors, the invalid fields in the
+// extensions of the GraphQL error.
+type apiError struct {
+	code    string
+	message string
+	fields  []quoteService.FieldError
+}
+
+func newError(code, message string) *apiError {
+	return &apiError{code: code, message: message}
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+func (e *apiError) Extensions() map[string]any {
+	ext := map[string]any{"code": e.code}
+	if len(e.fields) > 0 {
+		fields := make([]map[string]string, len(e.fields))
+		for i, field := range e.fields {
+			fields[i] = map[string]string{"field": field.Field, "message": field.Message}
+		}
+		ext["fields"] = fields
+	}
+
+	return ext
+}
+
+// formatErrors keeps the extensions of errors returned outside of resolvers.
+func formatErrors(errs ...error) []gqlerrors.FormattedError {
+	ret := gqlerrors.FormatErrors(errs...)
+	for i, err := range errs {
+		var extended gqlerrors.ExtendedError
+		if errors.As(err, &extended) {
+			ret[i].Extensions = extended.Extensions()
+		}
+	}
+
+	return ret
+}
+
+// serviceError maps the errors of the service the way the HTTP handlers map them to status codes.
+// Unexpected errors are logged as "Failed to <action>" and hidden from the caller.
+func serviceError(ctx context.Context, action string, err error) error {
+	var verr *quoteService.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		return &apiError{code: codeBadUserInput, message: "invalid input fields", fields: verr.Fields}
+	case errors.Is(err, quoteService.ErrNotFound):
+		return newError(codeNotFound, "quote not found")
+	case errors.Is(err, quoteService.ErrAlreadyExists):
+		return newError(codeAlreadyExists, "quote with the id already exists")
+	case errors.Is(err, quoteService.ErrForbidden):
+		return newError(codeForbidden, "quote can only be modified by its creator or a moderator")
+	case errors.Is(err, context.DeadlineExceeded):
+		slog.WarnContext(ctx, "Failed to "+action+" before the deadline", slog.String("error", err.Error()))
+		return newError(codeDeadlineExceeded, action+": deadline exceeded")
+	}
+
+	slog.ErrorContext(ctx, "Failed to "+action, slog.String("error", err.Error()))
+	return newError(codeInternal, "service: "+action)
+}
+
+// requireScope fails like the HTTP server does for requests without credentials or the scope.
+func requireScope(ctx context.Context, scope auth.Scope) error {
+	principal := auth.PrincipalFromContext(ctx)
+	if principal == nil {
+		return newError(codeUnauthenticated, "unauthenticated")
+	}
+	if !principal.HasScope(scope) {
+		return newError(codeForbidden, "missing \""+string(scope)+"\" scope")
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package graphqlapi
+
+import (
+	"context"
+	"fmt"
+	"github.com/BernsteinMondy/quote-service/src/internal/auth"
+	quoteService "github.com/BernsteinMondy/quote-service/src/internal/service"
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+const (
+	DefaultMaxDepth      = 8
+	DefaultMaxComplexity = 1000
+)
+
+type Config struct {
+	// RequireReadAuth guards the queries with the "quotes:read" scope, they are public otherwise.
+	RequireReadAuth bool
+	// MaxDepth bounds the nesting of the fields of an operation, MaxComplexity its estimated cost,
+	// see complexity. They default to DefaultMaxDepth and DefaultMaxComplexity.
+	MaxDepth      int
+	MaxComplexity int
+}
+
+type QuoteService interface {
+	CreateNewQuoteWithTags(ctx context.Context, id uuid.UUID, author, quote string, tags []string) (*quoteService.Quote, error)
+	GetQuoteByID(ctx context.Context, id uuid.UUID) (*quoteService.Quote, error)
+	ListQuotes(ctx context.Context, filter quoteService.QuoteFilter) ([]quoteService.Quote, error)
+	GetRandomQuote(ctx context.Context) (*quoteService.Quote, error)
+	UpdateQuoteWithTags(ctx context.Context, id uuid.UUID, author, quote string, tags []string) (*quoteService.Quote, error)
+	DeleteQuoteByID(ctx context.Context, id uuid.UUID) error
+	GetTagsByQuoteIDs(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]string, error)
+	CountQuotesByAuthors(ctx context.Context, authors []string) (map[string]int64, error)
+	ListQuotesByAuthors(ctx context.Context, authors []string, limit int) (map[string][]quoteService.Quote, error)
+}
+
+// Executor runs the GraphQL operations of requests against the quote service.
+type Executor struct {
+	schema  graphql.Schema
+	service QuoteService
+	cfg     Config
+}
+
+func New(service QuoteService, cfg Config) (*Executor, error) {
+	if cfg.MaxDepth <= 0 {
+		cfg.MaxDepth = DefaultMaxDepth
+	}
+	if cfg.MaxComplexity <= 0 {
+		cfg.MaxComplexity = DefaultMaxComplexity
+	}
+
+	schema, err := newSchema(service)
+	if err != nil {
+		return nil, fmt.Errorf("new schema: %w", err)
+	}
+
+	return &Executor{schema: schema, service: service, cfg: cfg}, nil
+}
+
+type Request struct {
+	Query         string
+	OperationName string
+	Variables     map[string]any
+}
+
+// Operation is a parsed and validated operation of a request.
+type Operation struct {
+	doc       *ast.Document
+	def       *ast.OperationDefinition
+	variables map[string]any
+}
+
+// IsMutation reports whether the operation writes quotes.
+func (o *Operation) IsMutation() bool {
+	return o.def.Operation == ast.OperationTypeMutation
+}
+
+// Prepare parses the query of the request and validates it against the schema and the depth and
+// complexity limits. It returns the errors in a result if the request must not be executed.
+func (e *Executor) Prepare(req Request) (*Operation, *graphql.Result) {
+	doc, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		return nil, &graphql.Result{Errors: formatErrors(err)}
+	}
+
+	validation := graphql.ValidateDocument(&e.schema, doc, nil)
+	if !validation.IsValid {
+		return nil, &graphql.Result{Errors: validation.Errors}
+	}
+
+	def, err := selectOperation(doc, req.OperationName)
+	if err != nil {
+		return nil, &graphql.Result{Errors: formatErrors(err)}
+	}
+
+	op := &Operation{doc: doc, def: def, variables: req.Variables}
+	err = checkLimits(op, e.cfg.MaxDepth, e.cfg.MaxComplexity)
+	if err != nil {
+		return nil, &graphql.Result{Errors: formatErrors(err)}
+	}
+
+	return op, nil
+}
+
+// Execute runs a prepared operation. The fields of queries are loaded in batches per level of the
+// query, e.g. the tags of every quote of a page are loaded with one call of the service.
+func (e *Executor) Execute(ctx context.Context, op *Operation) *graphql.Result {
+	if e.cfg.RequireReadAuth && !op.IsMutation() {
+		err := requireScope(ctx, auth.ScopeQuotesRead)
+		if err != nil {
+			return &graphql.Result{Errors: formatErrors(err)}
+		}
+	}
+
+	operationName := ""
+	if op.def.Name != nil {
+		operationName = op.def.Name.Value
+	}
+
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        e.schema,
+		AST:           op.doc,
+		OperationName: operationName,
+		Args:          op.variables,
+		Context:       withLoaders(ctx, newLoaders(e.service)),
+	})
+}
+
+// selectOperation returns the operation named by the request, or the only operation of the
+// document if the request names none.
+func selectOperation(doc *ast.Document, name string) (*ast.OperationDefinition, error) {
+	var selected *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case name == "" && selected != nil:
+			return nil, newError(codeBadUserInput, "operationName is required if the document holds several operations")
+		case name == "" || (op.Name != nil && op.Name.Value == name):
+			selected = op
+		}
+	}
+
+	if selected == nil {
+		return nil, newError(codeBadUserInput, fmt.Sprintf("unknown operation %q", name))
+	}
+
+	return selected, nil
+}
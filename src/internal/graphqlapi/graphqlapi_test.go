@@ -0,0 +1,396 @@
+package graphqlapi_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/BernsteinMondy/quote-service/src/internal/auth"
+	"github.com/BernsteinMondy/quote-service/src/internal/graphqlapi"
+	"github.com/BernsteinMondy/quote-service/src/internal/service"
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// mockQuoteService keeps the quotes in memory and counts the calls of the batch methods. Like the
+// service, it returns the tags of quotes only from GetTagsByQuoteIDs.
+type mockQuoteService struct {
+	mu     sync.Mutex
+	quotes []service.Quote
+	calls  map[string]int
+}
+
+func newMockQuoteService(quotes ...service.Quote) *mockQuoteService {
+	slices.SortFunc(quotes, func(a, b service.Quote) int {
+		return bytes.Compare(a.ID[:], b.ID[:])
+	})
+	return &mockQuoteService{quotes: quotes, calls: map[string]int{}}
+}
+
+func (m *mockQuoteService) called(method string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls[method]++
+}
+
+func (m *mockQuoteService) CreateNewQuoteWithTags(ctx context.Context, id uuid.UUID, author, quote string, tags []string) (*service.Quote, error) {
+	author, quote, err := service.DefaultValidationPolicy().Normalize(author, quote)
+	if err != nil {
+		return nil, err
+	}
+	tags, err = service.DefaultValidationPolicy().NormalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	if id == uuid.Nil {
+		id = uuid.New()
+	}
+
+	created := service.Quote{ID: id, Author: author, Quote: quote, CreatedBy: auth.PrincipalFromContext(ctx).Subject, Tags: tags}
+	m.quotes = append(m.quotes, created)
+	return &created, nil
+}
+
+func (m *mockQuoteService) GetQuoteByID(_ context.Context, id uuid.UUID) (*service.Quote, error) {
+	for _, quote := range m.quotes {
+		if quote.ID == id {
+			quote.Tags = nil
+			return &quote, nil
+		}
+	}
+	return nil, service.ErrNotFound
+}
+
+func (m *mockQuoteService) ListQuotes(_ context.Context, filter service.QuoteFilter) ([]service.Quote, error) {
+	ret := make([]service.Quote, 0)
+	for _, quote := range m.quotes {
+		if len(ret) < filter.Limit && (filter.Author == "" || quote.Author == filter.Author) &&
+			(filter.Search == "" || strings.Contains(strings.ToLower(quote.Quote), strings.ToLower(filter.Search))) &&
+			bytes.Compare(quote.ID[:], filter.AfterID[:]) > 0 {
+			quote.Tags = nil
+			ret = append(ret, quote)
+		}
+	}
+	return ret, nil
+}
+
+func (m *mockQuoteService) GetRandomQuote(context.Context) (*service.Quote, error) {
+	return &m.quotes[0], nil
+}
+
+func (m *mockQuoteService) UpdateQuoteWithTags(context.Context, uuid.UUID, string, string, []string) (*service.Quote, error) {
+	return nil, service.ErrForbidden
+}
+
+func (m *mockQuoteService) DeleteQuoteByID(context.Context, uuid.UUID) error {
+	return service.ErrNotFound
+}
+
+func (m *mockQuoteService) GetTagsByQuoteIDs(_ context.Context, ids []uuid.UUID) (map[uuid.UUID][]string, error) {
+	m.called("GetTagsByQuoteIDs")
+
+	ret := make(map[uuid.UUID][]string)
+	for _, quote := range m.quotes {
+		if slices.Contains(ids, quote.ID) && len(quote.Tags) > 0 {
+			ret[quote.ID] = quote.Tags
+		}
+	}
+	return ret, nil
+}
+
+func (m *mockQuoteService) CountQuotesByAuthors(_ context.Context, authors []string) (map[string]int64, error) {
+	m.called("CountQuotesByAuthors")
+
+	ret := make(map[string]int64)
+	for _, quote := range m.quotes {
+		if slices.Contains(authors, quote.Author) {
+			ret[quote.Author]++
+		}
+	}
+	return ret, nil
+}
+
+func (m *mockQuoteService) ListQuotesByAuthors(ctx context.Context, authors []string, limit int) (map[string][]service.Quote, error) {
+	m.called("ListQuotesByAuthors")
+
+	ret := make(map[string][]service.Quote)
+	for _, author := range authors {
+		quotes, _ := m.ListQuotes(ctx, service.QuoteFilter{Author: author, Limit: limit})
+		if len(quotes) > 0 {
+			ret[author] = quotes
+		}
+	}
+	return ret, nil
+}
+
+func quotesFixture(n int) []service.Quote {
+	quotes := make([]service.Quote, n)
+	for i := range quotes {
+		quotes[i] = service.Quote{
+			ID:     uuid.New(),
+			Author: []string{"author one", "author two", "author three"}[i%3],
+			Quote:  "quote",
+			Tags:   []string{"tag"},
+		}
+	}
+	return quotes
+}
+
+// execute returns the JSON of the result, so tests can decode it into the shape they expect.
+func execute(t *testing.T, executor *graphqlapi.Executor, ctx context.Context, query string, variables map[string]any) (*graphql.Result, []byte) {
+	t.Helper()
+
+	op, res := executor.Prepare(graphqlapi.Request{Query: query, Variables: variables})
+	if res == nil {
+		res = executor.Execute(ctx, op)
+	}
+
+	raw, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal("Failed to encode result", err)
+	}
+	return res, raw
+}
+
+func errorCode(res *graphql.Result) any {
+	if len(res.Errors) == 0 {
+		return nil
+	}
+	return res.Errors[0].Extensions["code"]
+}
+
+func TestExecutor_BatchesNestedFields(t *testing.T) {
+	svc := newMockQuoteService(quotesFixture(9)...)
+	executor, err := graphqlapi.New(svc, graphqlapi.Config{})
+	if err != nil {
+		t.Fatal("Failed to create executor", err)
+	}
+
+	res, raw := execute(t, executor, context.Background(), `{
+		quotes(first: 9) {
+			nodes {
+				id
+				tags
+				author { name quoteCount quotes(first: 2) { id } }
+			}
+		}
+	}`, nil)
+	if len(res.Errors) > 0 {
+		t.Fatalf("Execute() returned errors %+v", res.Errors)
+	}
+
+	var data struct {
+		Data struct {
+			Quotes struct {
+				Nodes []struct {
+					Tags   []string
+					Author struct {
+						QuoteCount int
+						Quotes     []struct{ ID string }
+					}
+				}
+			}
+		}
+	}
+	_ = json.Unmarshal(raw, &data)
+
+	nodes := data.Data.Quotes.Nodes
+	if len(nodes) != 9 || !slices.Equal(nodes[0].Tags, []string{"tag"}) || nodes[0].Author.QuoteCount != 3 || len(nodes[0].Author.Quotes) != 2 {
+		t.Errorf("Execute() returned %s", raw)
+	}
+	for _, method := range []string{"GetTagsByQuoteIDs", "CountQuotesByAuthors", "ListQuotesByAuthors"} {
+		if svc.calls[method] != 1 {
+			t.Errorf("Execute() called %s %d times, want once", method, svc.calls[method])
+		}
+	}
+}
+
+func TestExecutor_Limits(t *testing.T) {
+	executor, err := graphqlapi.New(newMockQuoteService(), graphqlapi.Config{MaxDepth: 4, MaxComplexity: 100})
+	if err != nil {
+		t.Fatal("Failed to create executor", err)
+	}
+
+	type testCase struct {
+		name      string
+		query     string
+		variables map[string]any
+		wantCode  any
+	}
+
+	testCases := []testCase{
+		{
+			name:  "Query within the limits is executed",
+			query: `{ quotes(first: 10) { nodes { id author { name } } } }`,
+		},
+		{
+			name:     "Query nesting too deep is rejected",
+			query:    `{ quotes(first: 1) { nodes { author { quotes(first: 1) { id } } } } }`,
+			wantCode: "LIMIT_EXCEEDED",
+		},
+		{
+			name:     "Fields of fragments are counted",
+			query:    `{ quotes(first: 50) { nodes { ...fields } } } fragment fields on Quote { id quote tags }`,
+			wantCode: "LIMIT_EXCEEDED",
+		},
+		{
+			name:      "Page sizes of variables are counted",
+			query:     `query($first: Int) { quotes(first: $first) { nodes { id quote } } }`,
+			variables: map[string]any{"first": float64(50)},
+			wantCode:  "LIMIT_EXCEEDED",
+		},
+		{
+			name:     "Default page size is counted",
+			query:    `{ quotes { nodes { id quote tags createdBy updatedBy } } }`,
+			wantCode: "LIMIT_EXCEEDED",
+		},
+		{
+			name:  "Introspection is not counted",
+			query: `{ __schema { types { name fields { name type { name ofType { name ofType { name } } } } } } }`,
+		},
+		{
+			name:     "Mutation with aliased root fields is rejected",
+			query:    `mutation { a: deleteQuote(id: "1") b: deleteQuote(id: "2") }`,
+			wantCode: "LIMIT_EXCEEDED",
+		},
+		{
+			name:     "Root fields of fragments in mutations are counted",
+			query:    `mutation { deleteQuote(id: "1") ...more } fragment more on Mutation { other: deleteQuote(id: "2") }`,
+			wantCode: "LIMIT_EXCEEDED",
+		},
+		{
+			name:     "Page size above the maximum is rejected",
+			query:    `{ quotes(first: 101) { __typename } }`,
+			wantCode: "BAD_USER_INPUT",
+		},
+	}
+
+	for _, tc := range testCases {
+		res, _ := execute(t, executor, context.Background(), tc.query, tc.variables)
+		if got := errorCode(res); got != tc.wantCode {
+			t.Errorf("%s: got error code %v (%+v) want %v", tc.name, got, res.Errors, tc.wantCode)
+		}
+	}
+}
+
+func TestExecutor_Mutations(t *testing.T) {
+	executor, err := graphqlapi.New(newMockQuoteService(), graphqlapi.Config{})
+	if err != nil {
+		t.Fatal("Failed to create executor", err)
+	}
+
+	writer := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "writer", Scopes: []auth.Scope{auth.ScopeQuotesWrite}})
+
+	type testCase struct {
+		name       string
+		ctx        context.Context
+		query      string
+		wantCode   any
+		wantFields int
+	}
+
+	testCases := []testCase{
+		{
+			name:     "Create without credentials results in code UNAUTHENTICATED",
+			ctx:      context.Background(),
+			query:    `mutation { createQuote(input: {author: "author", quote: "quote"}) { id } }`,
+			wantCode: "UNAUTHENTICATED",
+		},
+		{
+			name:  "Create with the write scope creates the quote with its tags",
+			ctx:   writer,
+			query: `mutation { createQuote(input: {author: "author", quote: "quote", tags: ["Life"]}) { id tags createdBy } }`,
+		},
+		{
+			name:       "Invalid input results in code BAD_USER_INPUT with the fields",
+			ctx:        writer,
+			query:      `mutation { createQuote(input: {author: "author", quote: "quote", tags: ["two words", ""]}) { id } }`,
+			wantCode:   "BAD_USER_INPUT",
+			wantFields: 2,
+		},
+		{
+			name:     "service.ErrForbidden error results in code FORBIDDEN",
+			ctx:      writer,
+			query:    `mutation { updateQuote(id: "4937a248-cb08-46de-8789-493904914cc6", input: {author: "author", quote: "quote"}) { id } }`,
+			wantCode: "FORBIDDEN",
+		},
+		{
+			name:     "Delete without the delete scope results in code FORBIDDEN",
+			ctx:      writer,
+			query:    `mutation { deleteQuote(id: "4937a248-cb08-46de-8789-493904914cc6") }`,
+			wantCode: "FORBIDDEN",
+		},
+	}
+
+	for _, tc := range testCases {
+		res, raw := execute(t, executor, tc.ctx, tc.query, nil)
+		if got := errorCode(res); got != tc.wantCode {
+			t.Errorf("%s: got error code %v (%s) want %v", tc.name, got, raw, tc.wantCode)
+		}
+		if tc.wantFields > 0 {
+			if fields, _ := res.Errors[0].Extensions["fields"].([]map[string]string); len(fields) != tc.wantFields {
+				t.Errorf("%s: got fields %v want %d", tc.name, res.Errors[0].Extensions["fields"], tc.wantFields)
+			}
+		}
+		if tc.wantCode == nil && (!strings.Contains(string(raw), `"tags":["life"]`) || !strings.Contains(string(raw), `"createdBy":"writer"`)) {
+			t.Errorf("%s: got %s", tc.name, raw)
+		}
+	}
+}
+
+func TestExecutor_Paging(t *testing.T) {
+	executor, err := graphqlapi.New(newMockQuoteService(quotesFixture(7)...), graphqlapi.Config{RequireReadAuth: true})
+	if err != nil {
+		t.Fatal("Failed to create executor", err)
+	}
+
+	res, _ := execute(t, executor, context.Background(), `{ randomQuote { id } }`, nil)
+	if got := errorCode(res); got != "UNAUTHENTICATED" {
+		t.Errorf("Guarded query without credentials returned error code %v, want UNAUTHENTICATED", got)
+	}
+
+	reader := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "reader", Scopes: []auth.Scope{auth.ScopeQuotesRead}})
+
+	var (
+		seen  = map[string]bool{}
+		pages int
+		after any
+	)
+	for {
+		res, raw := execute(t, executor, reader, `query($after: String) {
+			quotes(first: 3, after: $after) { nodes { id } pageInfo { hasNextPage endCursor } }
+		}`, map[string]any{"after": after})
+		if len(res.Errors) > 0 {
+			t.Fatalf("Execute() returned errors %+v", res.Errors)
+		}
+
+		var data struct {
+			Data struct {
+				Quotes struct {
+					Nodes    []struct{ ID string }
+					PageInfo struct {
+						HasNextPage bool
+						EndCursor   string
+					}
+				}
+			}
+		}
+		_ = json.Unmarshal(raw, &data)
+
+		pages++
+		for _, node := range data.Data.Quotes.Nodes {
+			seen[node.ID] = true
+		}
+		if !data.Data.Quotes.PageInfo.HasNextPage {
+			break
+		}
+		after = data.Data.Quotes.PageInfo.EndCursor
+	}
+
+	if len(seen) != 7 || pages != 3 {
+		t.Errorf("Paging returned %d quotes in %d pages, want 7 in 3", len(seen), pages)
+	}
+}
@@ -0,0 +1,134 @@
+package graphqlapi
+
+import (
+	"fmt"
+	"github.com/graphql-go/graphql/language/ast"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// checkLimits rejects operations nesting fields deeper than maxDepth or costing more than
+// maxComplexity before they are executed. Mutations are rejected if they select more than one root
+// field, the operation takes a single write token of the rate limit however many quotes it writes.
+func checkLimits(op *Operation, maxDepth, maxComplexity int) error {
+	fragments := make(map[string]*ast.FragmentDefinition)
+	for _, def := range op.doc.Definitions {
+		if fragment, ok := def.(*ast.FragmentDefinition); ok {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	w := &limitWalker{fragments: fragments, variables: op.variables}
+	if op.IsMutation() {
+		if n := w.fields(op.def.SelectionSet, nil); n > 1 {
+			return newError(codeLimitExceeded, fmt.Sprintf("mutation selects %d fields, only one is allowed per operation", n))
+		}
+	}
+
+	depth, cost := w.walk(op.def.SelectionSet, nil)
+
+	if depth > maxDepth {
+		return newError(codeLimitExceeded, fmt.Sprintf("query depth %d exceeds the limit of %d", depth, maxDepth))
+	}
+	if cost > maxComplexity {
+		return newError(codeLimitExceeded, fmt.Sprintf("query complexity %d exceeds the limit of %d", cost, maxComplexity))
+	}
+
+	return nil
+}
+
+type limitWalker struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]any
+}
+
+// walk returns the depth and the complexity of the selection set. Every field costs 1, the
+// selections of list fields are multiplied by their "first" argument, e.g.
+// "quotes(first: 20) { nodes { id tags } }" costs 1 + 20 * (1 + 2) = 61. Introspection fields are
+// free. seen holds the fragments spread on the path, the validation rejects cyclic spreads already.
+func (w *limitWalker) walk(set *ast.SelectionSet, seen []string) (depth, cost int) {
+	if set == nil {
+		return 0, 0
+	}
+
+	for _, selection := range set.Selections {
+		var d, c int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name.Value, "__") {
+				continue
+			}
+			d, c = w.walk(selection.SelectionSet, seen)
+			d, c = d+1, 1+w.first(selection)*c
+		case *ast.InlineFragment:
+			d, c = w.walk(selection.SelectionSet, seen)
+		case *ast.FragmentSpread:
+			fragment, ok := w.fragments[selection.Name.Value]
+			if !ok || slices.Contains(seen, selection.Name.Value) {
+				continue
+			}
+			d, c = w.walk(fragment.SelectionSet, append(slices.Clip(seen), selection.Name.Value))
+		}
+
+		depth = max(depth, d)
+		cost += c
+	}
+
+	return depth, cost
+}
+
+// fields returns the number of fields selected by the set itself, including the fields of spread
+// fragments. Introspection fields are not counted.
+func (w *limitWalker) fields(set *ast.SelectionSet, seen []string) int {
+	var n int
+	for _, selection := range set.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if !strings.HasPrefix(selection.Name.Value, "__") {
+				n++
+			}
+		case *ast.InlineFragment:
+			n += w.fields(selection.SelectionSet, seen)
+		case *ast.FragmentSpread:
+			fragment, ok := w.fragments[selection.Name.Value]
+			if ok && !slices.Contains(seen, selection.Name.Value) {
+				n += w.fields(fragment.SelectionSet, append(slices.Clip(seen), selection.Name.Value))
+			}
+		}
+	}
+
+	return n
+}
+
+// first returns the page size requested from a list field, or 1 for other fields. Page sizes above
+// maxPageSize are rejected by the resolvers, so they do not need to be counted.
+func (w *limitWalker) first(field *ast.Field) int {
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != "first" {
+			continue
+		}
+
+		switch value := arg.Value.(type) {
+		case *ast.IntValue:
+			n, err := strconv.Atoi(value.Value)
+			if err == nil && n > 0 {
+				return min(n, maxPageSize)
+			}
+		case *ast.Variable:
+			switch n := w.variables[value.Name.Value].(type) {
+			case float64:
+				return min(max(int(n), 1), maxPageSize)
+			case int:
+				return min(max(n, 1), maxPageSize)
+			}
+			return defaultPageSize
+		}
+		return 1
+	}
+
+	if slices.Contains(pagedFields, field.Name.Value) {
+		return defaultPageSize
+	}
+	return 1
+}
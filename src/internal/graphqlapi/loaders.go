@@ -0,0 +1,134 @@
+package graphqlapi
+
+import (
+	"context"
+	quoteService "github.com/BernsteinMondy/quote-service/src/internal/service"
+	"github.com/google/uuid"
+	"slices"
+	"sync"
+)
+
+// loader batches the keys requested by the resolvers of one level of a query into one fetch.
+//
+// The resolvers return the thunk of load instead of the value. The executor calls the thunks only
+// after it resolved every field of the level, so the first thunk called fetches the values of all
+// keys collected until then.
+type loader[K comparable, V any] struct {
+	// fetch must omit the keys without values from the returned map.
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending []K
+	values  map[K]V
+	errs    map[K]error
+}
+
+func newLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		fetch:  fetch,
+		values: make(map[K]V),
+		errs:   make(map[K]error),
+	}
+}
+
+func (l *loader[K, V]) load(ctx context.Context, key K) func() (any, error) {
+	l.mu.Lock()
+	if !l.loaded(key) && !slices.Contains(l.pending, key) {
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (any, error) {
+		return l.get(ctx, key)
+	}
+}
+
+func (l *loader[K, V]) get(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.loaded(key) {
+		keys := l.pending
+		l.pending = nil
+
+		values, err := l.fetch(ctx, keys)
+		for _, k := range keys {
+			if err != nil {
+				l.errs[k] = err
+				continue
+			}
+			l.values[k] = values[k]
+		}
+	}
+
+	return l.values[key], l.errs[key]
+}
+
+func (l *loader[K, V]) loaded(key K) bool {
+	_, ok := l.values[key]
+	if !ok {
+		_, ok = l.errs[key]
+	}
+
+	return ok
+}
+
+// loaders are created per request, so values are never shared between callers.
+type loaders struct {
+	service QuoteService
+
+	tags        *loader[uuid.UUID, []string]
+	quoteCounts *loader[string, int64]
+
+	mu sync.Mutex
+	// authorQuotes are keyed by the page size, the authors of one level share it usually.
+	authorQuotes map[int]*loader[string, []quoteService.Quote]
+}
+
+func newLoaders(service QuoteService) *loaders {
+	return &loaders{
+		service: service,
+		tags: newLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]string, error) {
+			tags, err := service.GetTagsByQuoteIDs(ctx, ids)
+			if err != nil {
+				return nil, serviceError(ctx, "get tags by quote ids", err)
+			}
+			return tags, nil
+		}),
+		quoteCounts: newLoader(func(ctx context.Context, authors []string) (map[string]int64, error) {
+			counts, err := service.CountQuotesByAuthors(ctx, authors)
+			if err != nil {
+				return nil, serviceError(ctx, "count quotes by authors", err)
+			}
+			return counts, nil
+		}),
+		authorQuotes: make(map[int]*loader[string, []quoteService.Quote]),
+	}
+}
+
+func (l *loaders) authorQuotesLoader(limit int) *loader[string, []quoteService.Quote] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.authorQuotes[limit]; !ok {
+		l.authorQuotes[limit] = newLoader(func(ctx context.Context, authors []string) (map[string][]quoteService.Quote, error) {
+			quotes, err := l.service.ListQuotesByAuthors(ctx, authors, limit)
+			if err != nil {
+				return nil, serviceError(ctx, "list quotes by authors", err)
+			}
+			return quotes, nil
+		})
+	}
+
+	return l.authorQuotes[limit]
+}
+
+type loadersCtxKey struct{}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersCtxKey{}, l)
+}
+
+func loadersFromContext(ctx context.Context) *loaders {
+	return ctx.Value(loadersCtxKey{}).(*loaders)
+}
@@ -0,0 +1,490 @@
+package graphqlapi
+
+import (
+	"encoding/base64"
+	"errors"
+	"github.com/BernsteinMondy/quote-service/src/internal/auth"
+	quoteService "github.com/BernsteinMondy/quote-service/src/internal/service"
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// pagedFields take a "first" argument defaulting to defaultPageSize.
+var pagedFields = []string{"quotes", "searchQuotes"}
+
+type resolvers struct {
+	service QuoteService
+}
+
+func newSchema(service QuoteService) (graphql.Schema, error) {
+	r := &resolvers{service: service}
+
+	var quoteType, authorType *graphql.Object
+	quoteType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Quote",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.ID),
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						return p.Source.(*quoteService.Quote).ID.String(), nil
+					},
+				},
+				"quote": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.String),
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						return p.Source.(*quoteService.Quote).Quote, nil
+					},
+				},
+				"author": &graphql.Field{
+					Type: graphql.NewNonNull(authorType),
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						return p.Source.(*quoteService.Quote).Author, nil
+					},
+				},
+				"tags": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+					Resolve: r.quoteTags,
+				},
+				"createdBy": &graphql.Field{
+					Type:        graphql.String,
+					Description: "The subject of the principal that created the quote, null for quotes created before ownership was recorded.",
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						return nullString(p.Source.(*quoteService.Quote).CreatedBy), nil
+					},
+				},
+				"updatedBy": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						return nullString(p.Source.(*quoteService.Quote).UpdatedBy), nil
+					},
+				},
+			}
+		}),
+	})
+
+	authorType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Author",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"name": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.String),
+					Resolve: func(p graphql.ResolveParams) (any, error) {
+						return p.Source.(string), nil
+					},
+				},
+				"quoteCount": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.Int),
+					Resolve: r.authorQuoteCount,
+				},
+				"quotes": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(quoteType))),
+					Description: "The first quotes of the author ordered by ID.",
+					Args: graphql.FieldConfigArgument{
+						"first": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultPageSize},
+					},
+					Resolve: r.authorQuotes,
+				},
+			}
+		}),
+	})
+
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"endCursor":   &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	connectionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "QuoteConnection",
+		Fields: graphql.Fields{
+			"nodes":    &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(quoteType)))},
+			"pageInfo": &graphql.Field{Type: graphql.NewNonNull(pageInfoType)},
+		},
+	})
+
+	pageArgs := func(args graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+		args["first"] = &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultPageSize}
+		args["after"] = &graphql.ArgumentConfig{Type: graphql.String, Description: "The endCursor of the previous page."}
+		return args
+	}
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"quote": &graphql.Field{
+				Type: quoteType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: r.quote,
+			},
+			"quotes": &graphql.Field{
+				Type:        graphql.NewNonNull(connectionType),
+				Description: "A page of the quotes matching the filters ordered by ID.",
+				Args: pageArgs(graphql.FieldConfigArgument{
+					"author":    &graphql.ArgumentConfig{Type: graphql.String},
+					"tag":       &graphql.ArgumentConfig{Type: graphql.String},
+					"createdBy": &graphql.ArgumentConfig{Type: graphql.String},
+				}),
+				Resolve: r.quotes,
+			},
+			"searchQuotes": &graphql.Field{
+				Type:        graphql.NewNonNull(connectionType),
+				Description: "A page of the quotes with the text in their author or quote text, matched case-insensitively.",
+				Args: pageArgs(graphql.FieldConfigArgument{
+					"text": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				}),
+				Resolve: r.searchQuotes,
+			},
+			"randomQuote": &graphql.Field{
+				Type:    quoteType,
+				Resolve: r.randomQuote,
+			},
+			"author": &graphql.Field{
+				Type:        authorType,
+				Description: "The author with the name, null if there is no quote of the author.",
+				Args: graphql.FieldConfigArgument{
+					"name": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: r.author,
+			},
+		},
+	})
+
+	tagsArg := &graphql.InputObjectFieldConfig{
+		Type:        graphql.NewList(graphql.NewNonNull(graphql.String)),
+		Description: "The tags of the quote, they are kept on updates if omitted.",
+	}
+	createInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "CreateQuoteInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"id":     &graphql.InputObjectFieldConfig{Type: graphql.ID, Description: "The ID is generated if omitted."},
+			"author": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"quote":  &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"tags":   tagsArg,
+		},
+	})
+	updateInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "UpdateQuoteInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"author": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"quote":  &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"tags":   tagsArg,
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createQuote": &graphql.Field{
+				Type: graphql.NewNonNull(quoteType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(createInput)},
+				},
+				Resolve: r.createQuote,
+			},
+			"updateQuote": &graphql.Field{
+				Type: graphql.NewNonNull(quoteType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(updateInput)},
+				},
+				Resolve: r.updateQuote,
+			},
+			"deleteQuote": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.ID),
+				Description: "Deletes the quote and returns its ID.",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: r.deleteQuote,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+func (r *resolvers) quote(p graphql.ResolveParams) (any, error) {
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+
+	quote, err := r.service.GetQuoteByID(p.Context, id)
+	if err != nil {
+		if errors.Is(err, quoteService.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, serviceError(p.Context, "get quote by id", err)
+	}
+
+	return quote, nil
+}
+
+func (r *resolvers) quotes(p graphql.ResolveParams) (any, error) {
+	author, _ := p.Args["author"].(string)
+	tag, _ := p.Args["tag"].(string)
+	createdBy, _ := p.Args["createdBy"].(string)
+
+	return r.quoteConnection(p, quoteService.QuoteFilter{Author: author, Tag: tag, CreatedBy: createdBy})
+}
+
+func (r *resolvers) searchQuotes(p graphql.ResolveParams) (any, error) {
+	text, _ := p.Args["text"].(string)
+	if text == "" {
+		return nil, newError(codeBadUserInput, "text must not be empty")
+	}
+
+	return r.quoteConnection(p, quoteService.QuoteFilter{Search: text})
+}
+
+// quoteConnection reads one quote more than requested to learn whether there is a next page.
+func (r *resolvers) quoteConnection(p graphql.ResolveParams, filter quoteService.QuoteFilter) (any, error) {
+	first, err := pageSize(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	filter.AfterID, err = parseCursor(p.Args["after"])
+	if err != nil {
+		return nil, err
+	}
+	filter.Limit = first + 1
+
+	quotes, err := r.service.ListQuotes(p.Context, filter)
+	if err != nil {
+		return nil, serviceError(p.Context, "list quotes", err)
+	}
+
+	hasNextPage := len(quotes) > first
+	if hasNextPage {
+		quotes = quotes[:first]
+	}
+	var endCursor any
+	if len(quotes) > 0 {
+		endCursor = cursor(quotes[len(quotes)-1].ID)
+	}
+
+	return map[string]any{
+		"nodes": quotePointers(quotes),
+		"pageInfo": map[string]any{
+			"hasNextPage": hasNextPage,
+			"endCursor":   endCursor,
+		},
+	}, nil
+}
+
+func (r *resolvers) randomQuote(p graphql.ResolveParams) (any, error) {
+	quote, err := r.service.GetRandomQuote(p.Context)
+	if err != nil {
+		return nil, serviceError(p.Context, "get random quote", err)
+	}
+
+	return quote, nil
+}
+
+func (r *resolvers) author(p graphql.ResolveParams) (any, error) {
+	name, _ := p.Args["name"].(string)
+	count := loadersFromContext(p.Context).quoteCounts.load(p.Context, name)
+
+	return func() (any, error) {
+		n, err := count()
+		if err != nil || n.(int64) == 0 {
+			return nil, err
+		}
+		return name, nil
+	}, nil
+}
+
+func (r *resolvers) quoteTags(p graphql.ResolveParams) (any, error) {
+	quote := p.Source.(*quoteService.Quote)
+	if quote.Tags != nil {
+		return quote.Tags, nil
+	}
+
+	tags := loadersFromContext(p.Context).tags.load(p.Context, quote.ID)
+	return func() (any, error) {
+		v, err := tags()
+		if err != nil {
+			return nil, err
+		}
+		if v.([]string) == nil {
+			return []string{}, nil
+		}
+		return v, nil
+	}, nil
+}
+
+func (r *resolvers) authorQuoteCount(p graphql.ResolveParams) (any, error) {
+	return loadersFromContext(p.Context).quoteCounts.load(p.Context, p.Source.(string)), nil
+}
+
+func (r *resolvers) authorQuotes(p graphql.ResolveParams) (any, error) {
+	first, err := pageSize(p.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	quotes := loadersFromContext(p.Context).authorQuotesLoader(first).load(p.Context, p.Source.(string))
+	return func() (any, error) {
+		v, err := quotes()
+		if err != nil {
+			return nil, err
+		}
+		return quotePointers(v.([]quoteService.Quote)), nil
+	}, nil
+}
+
+func (r *resolvers) createQuote(p graphql.ResolveParams) (any, error) {
+	err := requireScope(p.Context, auth.ScopeQuotesWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	input, _ := p.Args["input"].(map[string]any)
+	var id uuid.UUID
+	if rawID, ok := input["id"]; ok && rawID != nil {
+		id, err = parseID(rawID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	author, _ := input["author"].(string)
+	quoteText, _ := input["quote"].(string)
+
+	quote, err := r.service.CreateNewQuoteWithTags(p.Context, id, author, quoteText, tagsFromInput(input))
+	if err != nil {
+		return nil, serviceError(p.Context, "create new quote", err)
+	}
+
+	return quote, nil
+}
+
+func (r *resolvers) updateQuote(p graphql.ResolveParams) (any, error) {
+	err := requireScope(p.Context, auth.ScopeQuotesWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+	input, _ := p.Args["input"].(map[string]any)
+	author, _ := input["author"].(string)
+	quoteText, _ := input["quote"].(string)
+
+	quote, err := r.service.UpdateQuoteWithTags(p.Context, id, author, quoteText, tagsFromInput(input))
+	if err != nil {
+		return nil, serviceError(p.Context, "update quote", err)
+	}
+
+	return quote, nil
+}
+
+func (r *resolvers) deleteQuote(p graphql.ResolveParams) (any, error) {
+	err := requireScope(p.Context, auth.ScopeQuotesDelete)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.service.DeleteQuoteByID(p.Context, id)
+	if err != nil {
+		return nil, serviceError(p.Context, "delete quote by id", err)
+	}
+
+	return id.String(), nil
+}
+
+// tagsFromInput returns nil if the input holds no tags.
+func tagsFromInput(input map[string]any) []string {
+	raw, ok := input["tags"].([]any)
+	if !ok {
+		return nil
+	}
+
+	tags := make([]string, 0, len(raw))
+	for _, tag := range raw {
+		if s, ok := tag.(string); ok {
+			tags = append(tags, s)
+		}
+	}
+
+	return tags
+}
+
+func pageSize(args map[string]any) (int, error) {
+	first, ok := args["first"].(int)
+	if !ok {
+		return defaultPageSize, nil
+	}
+	if first < 1 || first > maxPageSize {
+		return 0, newError(codeBadUserInput, "first must be between 1 and 100")
+	}
+
+	return first, nil
+}
+
+func parseID(raw any) (uuid.UUID, error) {
+	s, _ := raw.(string)
+	id, err := uuid.Parse(s)
+	if err != nil || id == uuid.Nil {
+		return uuid.Nil, newError(codeBadUserInput, "id must be a uuid")
+	}
+
+	return id, nil
+}
+
+// cursor encodes the ID of the last quote of a page, the next page starts after it.
+func cursor(lastID uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString(lastID[:])
+}
+
+func parseCursor(raw any) (uuid.UUID, error) {
+	s, _ := raw.(string)
+	if s == "" {
+		return uuid.Nil, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return uuid.Nil, newError(codeBadUserInput, "invalid cursor")
+	}
+	id, err := uuid.FromBytes(b)
+	if err != nil {
+		return uuid.Nil, newError(codeBadUserInput, "invalid cursor")
+	}
+
+	return id, nil
+}
+
+func quotePointers(quotes []quoteService.Quote) []*quoteService.Quote {
+	ret := make([]*quoteService.Quote, len(quotes))
+	for i := range quotes {
+		ret[i] = &quotes[i]
+	}
+
+	return ret
+}
+
+// nullString returns nil for empty strings, so they are reported as null.
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+
+	return s
+}
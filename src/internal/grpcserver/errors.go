@@ -0,0 +1,56 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	quoteService "github.com/BernsteinMondy/quote-service/src/internal/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log/slog"
+)
+
+// statusFromError maps the errors of the service to status codes the way the HTTP handlers map
+// them to status codes. Unexpected errors are logged as "Failed to <action>" and hidden from the
+// caller.
+func statusFromError(ctx context.Context, action string, err error) error {
+	var verr *quoteService.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		return validationStatus(verr)
+	case errors.Is(err, quoteService.ErrNotFound):
+		return status.Error(codes.NotFound, "quote not found")
+	case errors.Is(err, quoteService.ErrAlreadyExists):
+		return status.Error(codes.AlreadyExists, "quote with the id already exists")
+	case errors.Is(err, quoteService.ErrForbidden):
+		return status.Error(codes.PermissionDenied, "quote can only be modified by its creator or a moderator")
+	case errors.Is(err, context.DeadlineExceeded):
+		slog.WarnContext(ctx, "Failed to "+action+" before the deadline", slog.String("error", err.Error()))
+		return status.Error(codes.DeadlineExceeded, action+": deadline exceeded")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, action+": canceled")
+	}
+
+	slog.ErrorContext(ctx, "Failed to "+action, slog.String("error", err.Error()))
+	return status.Error(codes.Internal, "service: "+action)
+}
+
+// validationStatus lists the invalid fields in a google.rpc.BadRequest detail.
+func validationStatus(verr *quoteService.ValidationError) error {
+	details := &errdetails.BadRequest{
+		FieldViolations: make([]*errdetails.BadRequest_FieldViolation, len(verr.Fields)),
+	}
+	for i, field := range verr.Fields {
+		details.FieldViolations[i] = &errdetails.BadRequest_FieldViolation{
+			Field:       field.Field,
+			Description: field.Message,
+		}
+	}
+
+	st, err := status.New(codes.InvalidArgument, "invalid request fields").WithDetails(details)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, verr.Error())
+	}
+
+	return st.Err()
+}
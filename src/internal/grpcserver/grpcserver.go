@@ -0,0 +1,101 @@
+package grpcserver
+
+import (
+	"context"
+	"github.com/BernsteinMondy/quote-service/src/internal/auth"
+	"github.com/BernsteinMondy/quote-service/src/internal/ratelimit"
+	quoteService "github.com/BernsteinMondy/quote-service/src/internal/service"
+	quotesv1 "github.com/BernsteinMondy/quote-service/src/pkg/api/quotes/v1"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+type Config struct {
+	// Authenticator verifies call credentials, calls with credentials are rejected if it is nil.
+	Authenticator Authenticator
+	// RateLimiter limits the calls per principal or peer address, calls are not limited if it is nil.
+	RateLimiter RateLimiter
+	// RequireReadAuth guards the read methods with the "quotes:read" scope, they are public otherwise.
+	RequireReadAuth bool
+	// AccessLog enables one log line per call.
+	AccessLog bool
+}
+
+type Authenticator interface {
+	// Authenticate must return auth.ErrUnauthenticated if the credential is not valid.
+	Authenticate(ctx context.Context, credential string) (*auth.Principal, error)
+}
+
+type RateLimiter interface {
+	Allow(ctx context.Context, class ratelimit.Class, key string) (ratelimit.Result, error)
+}
+
+type QuoteService interface {
+	CreateNewQuote(ctx context.Context, id uuid.UUID, author, quote string) (*quoteService.Quote, error)
+	GetQuoteByID(ctx context.Context, id uuid.UUID) (*quoteService.Quote, error)
+	ListQuotes(ctx context.Context, filter quoteService.QuoteFilter) ([]quoteService.Quote, error)
+	GetRandomQuote(ctx context.Context) (*quoteService.Quote, error)
+	UpdateQuote(ctx context.Context, id uuid.UUID, author, quote string) (*quoteService.Quote, error)
+	DeleteQuoteByID(ctx context.Context, id uuid.UUID) error
+}
+
+// Server serves quotes.v1.QuoteService next to the gRPC health and reflection services.
+type Server struct {
+	*grpc.Server
+	health *health.Server
+}
+
+func New(service QuoteService, cfg Config) *Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			unaryRequestID,
+			unaryLog(cfg.AccessLog),
+			unaryAuth(cfg.Authenticator, cfg.RateLimiter, cfg.RequireReadAuth),
+			unaryRateLimit(cfg.RateLimiter),
+		),
+		grpc.ChainStreamInterceptor(
+			streamRequestID,
+			streamLog(cfg.AccessLog),
+			streamAuth(cfg.Authenticator, cfg.RateLimiter, cfg.RequireReadAuth),
+			streamRateLimit(cfg.RateLimiter),
+		),
+	)
+
+	quotesv1.RegisterQuoteServiceServer(server, &quoteServer{service: service})
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(quotesv1.QuoteService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	reflection.Register(server)
+
+	return &Server{Server: server, health: healthServer}
+}
+
+// Drain reports every service as not serving to health checks, calls are still served.
+func (s *Server) Drain() {
+	s.health.Shutdown()
+}
+
+// Shutdown waits for the calls in flight to finish, it cancels them once ctx is done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.health.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.Stop()
+		<-stopped
+		return ctx.Err()
+	}
+}
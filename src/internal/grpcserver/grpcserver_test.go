@@ -0,0 +1,444 @@
+package grpcserver_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/BernsteinMondy/quote-service/src/internal/auth"
+	"github.com/BernsteinMondy/quote-service/src/internal/grpcserver"
+	"github.com/BernsteinMondy/quote-service/src/internal/ratelimit"
+	"github.com/BernsteinMondy/quote-service/src/internal/service"
+	quotesv1 "github.com/BernsteinMondy/quote-service/src/pkg/api/quotes/v1"
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"io"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// mockQuoteService keeps the quotes in memory and applies the default validation policy like
+// the service does.
+type mockQuoteService struct {
+	mu     sync.Mutex
+	quotes []service.Quote
+}
+
+func (m *mockQuoteService) CreateNewQuote(ctx context.Context, id uuid.UUID, author, quote string) (*service.Quote, error) {
+	author, quote, err := service.DefaultValidationPolicy().Normalize(author, quote)
+	if err != nil {
+		return nil, err
+	}
+	if id == uuid.Nil {
+		id = uuid.New()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	created := service.Quote{ID: id, Author: author, Quote: quote}
+	if principal := auth.PrincipalFromContext(ctx); principal != nil {
+		created.CreatedBy = principal.Subject
+	}
+	m.quotes = append(m.quotes, created)
+	slices.SortFunc(m.quotes, func(a, b service.Quote) int {
+		return bytes.Compare(a.ID[:], b.ID[:])
+	})
+
+	return &created, nil
+}
+
+func (m *mockQuoteService) GetQuoteByID(_ context.Context, id uuid.UUID) (*service.Quote, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, quote := range m.quotes {
+		if quote.ID == id {
+			return &quote, nil
+		}
+	}
+	return nil, service.ErrNotFound
+}
+
+func (m *mockQuoteService) ListQuotes(_ context.Context, filter service.QuoteFilter) ([]service.Quote, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ret := make([]service.Quote, 0)
+	for _, quote := range m.quotes {
+		if len(ret) < filter.Limit && (filter.Author == "" || quote.Author == filter.Author) &&
+			bytes.Compare(quote.ID[:], filter.AfterID[:]) > 0 {
+			ret = append(ret, quote)
+		}
+	}
+	return ret, nil
+}
+
+func (m *mockQuoteService) GetRandomQuote(context.Context) (*service.Quote, error) {
+	return nil, errors.New("some error")
+}
+
+func (m *mockQuoteService) UpdateQuote(context.Context, uuid.UUID, string, string) (*service.Quote, error) {
+	return nil, service.ErrForbidden
+}
+
+func (m *mockQuoteService) DeleteQuoteByID(context.Context, uuid.UUID) error {
+	return service.ErrNotFound
+}
+
+type mockAuthenticator struct{}
+
+func (mockAuthenticator) Authenticate(_ context.Context, credential string) (*auth.Principal, error) {
+	switch credential {
+	case "reader":
+		return &auth.Principal{Subject: "reader", Scopes: []auth.Scope{auth.ScopeQuotesRead}}, nil
+	case "writer":
+		return &auth.Principal{Subject: "writer", Scopes: []auth.Scope{auth.ScopeQuotesRead, auth.ScopeQuotesWrite}}, nil
+	default:
+		return nil, auth.ErrUnauthenticated
+	}
+}
+
+func newTestServer(t *testing.T, svc grpcserver.QuoteService, cfg grpcserver.Config) (*grpcserver.Server, *grpc.ClientConn) {
+	lis := bufconn.Listen(1 << 20)
+	server := grpcserver.New(svc, cfg)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal("Failed to create client", err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+		server.Stop()
+	})
+
+	return server, conn
+}
+
+func withAPIKey(key string) context.Context {
+	if key == "" {
+		return context.Background()
+	}
+	return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+}
+
+func TestQuoteService_Codes(t *testing.T) {
+	_, conn := newTestServer(t, &mockQuoteService{}, grpcserver.Config{Authenticator: mockAuthenticator{}, RequireReadAuth: true})
+	client := quotesv1.NewQuoteServiceClient(conn)
+
+	type testCase struct {
+		name     string
+		apiKey   string
+		call     func(ctx context.Context) error
+		wantCode codes.Code
+	}
+
+	create := func(ctx context.Context) error {
+		_, err := client.CreateQuote(ctx, &quotesv1.CreateQuoteRequest{Author: "test author", Quote: "test quote"})
+		return err
+	}
+	testCases := []testCase{
+		{
+			name:     "Create without credentials results in code Unauthenticated",
+			call:     create,
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "Create with invalid credentials results in code Unauthenticated",
+			apiKey:   "unknown",
+			call:     create,
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "Create without the write scope results in code PermissionDenied",
+			apiKey:   "reader",
+			call:     create,
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "Create with the write scope results in code OK",
+			apiKey:   "writer",
+			call:     create,
+			wantCode: codes.OK,
+		},
+		{
+			name:   "Create with a non-uuid ID results in code InvalidArgument",
+			apiKey: "writer",
+			call: func(ctx context.Context) error {
+				_, err := client.CreateQuote(ctx, &quotesv1.CreateQuoteRequest{Id: "non-uuid", Author: "test author", Quote: "test quote"})
+				return err
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:   "Guarded read without credentials results in code Unauthenticated",
+			apiKey: "",
+			call: func(ctx context.Context) error {
+				_, err := client.GetQuote(ctx, &quotesv1.GetQuoteRequest{Id: uuid.NewString()})
+				return err
+			},
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:   "service.ErrNotFound error results in code NotFound",
+			apiKey: "reader",
+			call: func(ctx context.Context) error {
+				_, err := client.GetQuote(ctx, &quotesv1.GetQuoteRequest{Id: uuid.NewString()})
+				return err
+			},
+			wantCode: codes.NotFound,
+		},
+		{
+			name:   "service.ErrForbidden error results in code PermissionDenied",
+			apiKey: "writer",
+			call: func(ctx context.Context) error {
+				_, err := client.UpdateQuote(ctx, &quotesv1.UpdateQuoteRequest{Id: uuid.NewString(), Author: "test author", Quote: "test quote"})
+				return err
+			},
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:   "Unexpected service error results in code Internal",
+			apiKey: "reader",
+			call: func(ctx context.Context) error {
+				_, err := client.GetRandomQuote(ctx, &quotesv1.GetRandomQuoteRequest{})
+				return err
+			},
+			wantCode: codes.Internal,
+		},
+		{
+			name:   "Invalid page token results in code InvalidArgument",
+			apiKey: "reader",
+			call: func(ctx context.Context) error {
+				_, err := client.ListQuotes(ctx, &quotesv1.ListQuotesRequest{PageToken: "not a token"})
+				return err
+			},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range testCases {
+		err := tc.call(withAPIKey(tc.apiKey))
+		if code := status.Code(err); code != tc.wantCode {
+			t.Errorf("%s: got code %s want %s (%v)", tc.name, code, tc.wantCode, err)
+		}
+	}
+}
+
+func TestQuoteService_ValidationDetails(t *testing.T) {
+	_, conn := newTestServer(t, &mockQuoteService{}, grpcserver.Config{Authenticator: mockAuthenticator{}})
+	client := quotesv1.NewQuoteServiceClient(conn)
+
+	_, err := client.CreateQuote(withAPIKey("writer"), &quotesv1.CreateQuoteRequest{Author: "", Quote: ""})
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("CreateQuote returned code %s want %s", st.Code(), codes.InvalidArgument)
+	}
+
+	var fields []string
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.GetFieldViolations() {
+				fields = append(fields, violation.GetField())
+			}
+		}
+	}
+	if !slices.Equal(fields, []string{"author", "quote"}) {
+		t.Errorf("CreateQuote returned field violations %v, want [author quote]", fields)
+	}
+}
+
+func TestQuoteService_ListAndExport(t *testing.T) {
+	svc := &mockQuoteService{}
+	for i := 0; i < 7; i++ {
+		_, _ = svc.CreateNewQuote(context.Background(), uuid.Nil, "test author", "test quote")
+	}
+	_, _ = svc.CreateNewQuote(context.Background(), uuid.Nil, "another author", "test quote")
+
+	_, conn := newTestServer(t, svc, grpcserver.Config{})
+	client := quotesv1.NewQuoteServiceClient(conn)
+
+	var (
+		listed []string
+		pages  int
+		token  string
+	)
+	for {
+		resp, err := client.ListQuotes(context.Background(), &quotesv1.ListQuotesRequest{Author: "test author", PageSize: 3, PageToken: token})
+		if err != nil {
+			t.Fatal("ListQuotes returned error", err)
+		}
+		pages++
+		for _, quote := range resp.GetQuotes() {
+			listed = append(listed, quote.GetId())
+		}
+
+		token = resp.GetNextPageToken()
+		if token == "" {
+			break
+		}
+	}
+	if len(listed) != 7 || pages != 3 {
+		t.Errorf("ListQuotes returned %d quotes in %d pages, want 7 in 3", len(listed), pages)
+	}
+
+	stream, err := client.ExportQuotes(context.Background(), &quotesv1.ExportQuotesRequest{})
+	if err != nil {
+		t.Fatal("ExportQuotes returned error", err)
+	}
+	exported := 0
+	for {
+		_, err = stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal("ExportQuotes stream returned error", err)
+		}
+		exported++
+	}
+	if exported != 8 {
+		t.Errorf("ExportQuotes streamed %d quotes, want 8", exported)
+	}
+}
+
+func TestServer_RequestIDAndHealth(t *testing.T) {
+	server, conn := newTestServer(t, &mockQuoteService{}, grpcserver.Config{})
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "client-request-1")
+	_, _ = quotesv1.NewQuoteServiceClient(conn).GetQuote(ctx, &quotesv1.GetQuoteRequest{Id: uuid.NewString()}, grpc.Header(&header))
+	if got := header.Get("x-request-id"); !slices.Equal(got, []string{"client-request-1"}) {
+		t.Errorf("GetQuote returned x-request-id %v, want [client-request-1]", got)
+	}
+
+	healthClient := healthpb.NewHealthClient(conn)
+	for _, want := range []healthpb.HealthCheckResponse_ServingStatus{healthpb.HealthCheckResponse_SERVING, healthpb.HealthCheckResponse_NOT_SERVING} {
+		resp, err := healthClient.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "quotes.v1.QuoteService"})
+		if err != nil {
+			t.Fatal("Check returned error", err)
+		}
+		if resp.GetStatus() != want {
+			t.Errorf("Check returned status %s, want %s", resp.GetStatus(), want)
+		}
+
+		server.Drain()
+	}
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	limiter, err := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), map[ratelimit.Class]ratelimit.Limit{
+		ratelimit.ClassRead:  {Rate: 0.001, Burst: 1},
+		ratelimit.ClassWrite: {Rate: 0.001, Burst: 1},
+		ratelimit.ClassAuth:  {Rate: 0.001, Burst: 4},
+	})
+	if err != nil {
+		t.Fatal("Failed to create limiter", err)
+	}
+	_, conn := newTestServer(t, &mockQuoteService{}, grpcserver.Config{Authenticator: mockAuthenticator{}, RateLimiter: limiter})
+	client := quotesv1.NewQuoteServiceClient(conn)
+
+	exportCode := func(apiKey string) codes.Code {
+		stream, err := client.ExportQuotes(withAPIKey(apiKey), &quotesv1.ExportQuotesRequest{})
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		if errors.Is(err, io.EOF) {
+			return codes.OK
+		}
+		return status.Code(err)
+	}
+
+	type testCase struct {
+		name     string
+		call     func() codes.Code
+		wantCode codes.Code
+	}
+
+	testCases := []testCase{
+		{
+			name: "Anonymous read within the budget is served",
+			call: func() codes.Code {
+				_, err := client.GetQuote(context.Background(), &quotesv1.GetQuoteRequest{Id: uuid.NewString()})
+				return status.Code(err)
+			},
+			wantCode: codes.NotFound,
+		},
+		{
+			name: "Anonymous read over the budget results in RESOURCE_EXHAUSTED",
+			call: func() codes.Code {
+				var header metadata.MD
+				_, err := client.GetQuote(context.Background(), &quotesv1.GetQuoteRequest{Id: uuid.NewString()}, grpc.Header(&header))
+				if len(header.Get("retry-after")) == 0 {
+					t.Error("GetQuote over the budget returned no retry-after header")
+				}
+				return status.Code(err)
+			},
+			wantCode: codes.ResourceExhausted,
+		},
+		{
+			name:     "Principal has its own read budget",
+			call:     func() codes.Code { return exportCode("reader") },
+			wantCode: codes.OK,
+		},
+		{
+			name:     "Stream over the read budget results in RESOURCE_EXHAUSTED",
+			call:     func() codes.Code { return exportCode("reader") },
+			wantCode: codes.ResourceExhausted,
+		},
+		{
+			name: "Write within the budget is served",
+			call: func() codes.Code {
+				_, err := client.CreateQuote(withAPIKey("writer"), &quotesv1.CreateQuoteRequest{Author: "test author", Quote: "test quote"})
+				return status.Code(err)
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name: "Write over the budget results in RESOURCE_EXHAUSTED",
+			call: func() codes.Code {
+				_, err := client.UpdateQuote(withAPIKey("writer"), &quotesv1.UpdateQuoteRequest{Id: uuid.NewString(), Author: "test author", Quote: "test quote"})
+				return status.Code(err)
+			},
+			wantCode: codes.ResourceExhausted,
+		},
+		{
+			name: "Credentials over the auth budget are not verified",
+			call: func() codes.Code {
+				_, err := client.GetQuote(withAPIKey("unknown"), &quotesv1.GetQuoteRequest{Id: uuid.NewString()})
+				return status.Code(err)
+			},
+			wantCode: codes.ResourceExhausted,
+		},
+		{
+			name: "Health checks are not limited",
+			call: func() codes.Code {
+				_, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+				return status.Code(err)
+			},
+			wantCode: codes.OK,
+		},
+	}
+
+	for _, tc := range testCases {
+		if got := tc.call(); got != tc.wantCode {
+			t.Errorf("%s: got code %s want %s", tc.name, got, tc.wantCode)
+		}
+	}
+}
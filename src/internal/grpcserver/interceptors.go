@@ -0,0 +1,295 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"github.com/BernsteinMondy/quote-service/src/internal/auth"
+	"github.com/BernsteinMondy/quote-service/src/internal/ratelimit"
+	"github.com/BernsteinMondy/quote-service/src/internal/telemetry"
+	quotesv1 "github.com/BernsteinMondy/quote-service/src/pkg/api/quotes/v1"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"math"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	apiKeyMetadata    = "x-api-key"
+	requestIDMetadata = "x-request-id"
+)
+
+// methodScopes are the scopes the write methods require.
+var methodScopes = map[string]auth.Scope{
+	quotesv1.QuoteService_CreateQuote_FullMethodName: auth.ScopeQuotesWrite,
+	quotesv1.QuoteService_UpdateQuote_FullMethodName: auth.ScopeQuotesWrite,
+	quotesv1.QuoteService_DeleteQuote_FullMethodName: auth.ScopeQuotesDelete,
+}
+
+// readMethods require the "quotes:read" scope if reads are guarded.
+var readMethods = []string{
+	quotesv1.QuoteService_GetQuote_FullMethodName,
+	quotesv1.QuoteService_ListQuotes_FullMethodName,
+	quotesv1.QuoteService_GetRandomQuote_FullMethodName,
+	quotesv1.QuoteService_ExportQuotes_FullMethodName,
+}
+
+// serverStream replaces the context of a stream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func unaryRequestID(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return handler(withRequestID(ctx), req)
+}
+
+func streamRequestID(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &serverStream{ServerStream: ss, ctx: withRequestID(ss.Context())})
+}
+
+// withRequestID stores the x-request-id of the call in the context and echoes it in the response
+// header. A new ID is generated if the metadata is missing or invalid.
+func withRequestID(ctx context.Context) context.Context {
+	id := firstMetadata(ctx, requestIDMetadata)
+	if !telemetry.ValidRequestID(id) {
+		id = uuid.NewString()
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, id))
+	return telemetry.WithRequestID(ctx, id)
+}
+
+// callLogEntry collects the fields of the access log line known only to inner interceptors.
+type callLogEntry struct {
+	principal string
+}
+
+type callLogCtxKey struct{}
+
+func recordPrincipal(ctx context.Context, subject string) {
+	if entry, ok := ctx.Value(callLogCtxKey{}).(*callLogEntry); ok {
+		entry.principal = subject
+	}
+}
+
+func unaryLog(enabled bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !enabled {
+			return handler(ctx, req)
+		}
+
+		start := time.Now()
+		entry := &callLogEntry{}
+		resp, err := handler(context.WithValue(ctx, callLogCtxKey{}, entry), req)
+		logCall(ctx, info.FullMethod, start, entry, err)
+		return resp, err
+	}
+}
+
+func streamLog(enabled bool) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !enabled {
+			return handler(srv, ss)
+		}
+
+		start := time.Now()
+		entry := &callLogEntry{}
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: context.WithValue(ss.Context(), callLogCtxKey{}, entry)})
+		logCall(ss.Context(), info.FullMethod, start, entry, err)
+		return err
+	}
+}
+
+func logCall(ctx context.Context, method string, start time.Time, entry *callLogEntry, err error) {
+	slog.LogAttrs(ctx, slog.LevelInfo, "gRPC call",
+		slog.String("method", method),
+		slog.String("code", status.Code(err).String()),
+		slog.Duration("latency", time.Since(start)),
+		slog.String("principal", entry.principal),
+	)
+}
+
+func unaryAuth(authenticator Authenticator, limiter RateLimiter, requireReadAuth bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authorize(ctx, authenticator, limiter, requireReadAuth, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func streamAuth(authenticator Authenticator, limiter RateLimiter, requireReadAuth bool) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authorize(ss.Context(), authenticator, limiter, requireReadAuth, info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authorize stores the principal of the call credentials in the context, like the HTTP server
+// does. Calls of methods requiring a scope result in UNAUTHENTICATED without credentials and
+// in PERMISSION_DENIED if the principal lacks the scope. Calls with credentials take a token of the
+// peer address from the "auth" class of the rate limit first.
+func authorize(ctx context.Context, authenticator Authenticator, limiter RateLimiter, requireReadAuth bool, method string) (context.Context, error) {
+	var principal *auth.Principal
+	if credential := credentialFromMetadata(ctx); credential != "" {
+		err := allow(ctx, limiter, ratelimit.ClassAuth, "ip:"+peerIP(ctx))
+		if err != nil {
+			return nil, err
+		}
+		if authenticator == nil {
+			return nil, status.Error(codes.Unauthenticated, "unauthenticated")
+		}
+
+		principal, err = authenticator.Authenticate(ctx, credential)
+		if err != nil {
+			if errors.Is(err, auth.ErrUnauthenticated) {
+				return nil, status.Error(codes.Unauthenticated, "unauthenticated")
+			}
+
+			slog.ErrorContext(ctx, "Failed to authenticate call", slog.String("error", err.Error()))
+			return nil, status.Error(codes.Internal, "authenticate call")
+		}
+
+		recordPrincipal(ctx, principal.Subject)
+		ctx = auth.WithPrincipal(ctx, principal)
+	}
+
+	scope, ok := methodScopes[method]
+	if !ok && requireReadAuth && slices.Contains(readMethods, method) {
+		scope, ok = auth.ScopeQuotesRead, true
+	}
+	if !ok {
+		return ctx, nil
+	}
+
+	if principal == nil {
+		return nil, status.Error(codes.Unauthenticated, "unauthenticated")
+	}
+	if !principal.HasScope(scope) {
+		return nil, status.Error(codes.PermissionDenied, "missing \""+string(scope)+"\" scope")
+	}
+
+	return ctx, nil
+}
+
+func unaryRateLimit(limiter RateLimiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		err := rateLimit(ctx, limiter, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func streamRateLimit(limiter RateLimiter) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		err := rateLimit(ss.Context(), limiter, info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+// rateLimit takes a token of the write class for the write methods and of the read class for the
+// read methods, like the HTTP server does. Authenticated calls are keyed by their principal,
+// anonymous calls by the peer address. The health and reflection services are not limited.
+func rateLimit(ctx context.Context, limiter RateLimiter, method string) error {
+	var class ratelimit.Class
+	switch {
+	case methodScopes[method] != "":
+		class = ratelimit.ClassWrite
+	case slices.Contains(readMethods, method):
+		class = ratelimit.ClassRead
+	default:
+		return nil
+	}
+
+	key := "ip:" + peerIP(ctx)
+	if principal := auth.PrincipalFromContext(ctx); principal != nil {
+		key = "principal:" + principal.Subject
+	}
+
+	return allow(ctx, limiter, class, key)
+}
+
+// allow results in RESOURCE_EXHAUSTED with a retry-after header in seconds if the bucket of key is
+// empty. Calls are let through if the limiter fails, an unavailable store must not take the API down.
+func allow(ctx context.Context, limiter RateLimiter, class ratelimit.Class, key string) error {
+	if limiter == nil {
+		return nil
+	}
+
+	res, err := limiter.Allow(ctx, class, key)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to apply rate limit", slog.String("error", err.Error()))
+		return nil
+	}
+	if res.Allowed {
+		return nil
+	}
+
+	retryAfter := strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds())))
+	_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", retryAfter))
+	return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+}
+
+// peerIP returns the address of the client without the port. Unlike the HTTP server, forwarded
+// addresses are not trusted, gRPC clients connect to the service directly.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	return host
+}
+
+func credentialFromMetadata(ctx context.Context) string {
+	if key := firstMetadata(ctx, apiKeyMetadata); key != "" {
+		return key
+	}
+
+	scheme, token, ok := strings.Cut(firstMetadata(ctx, "authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
+
+func firstMetadata(ctx context.Context, key string) string {
+	values := metadata.ValueFromIncomingContext(ctx, key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
@@ -0,0 +1,202 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/base64"
+	quoteService "github.com/BernsteinMondy/quote-service/src/internal/service"
+	quotesv1 "github.com/BernsteinMondy/quote-service/src/pkg/api/quotes/v1"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPageSize = 100
+	// exportBatchSize is the number of quotes ExportQuotes reads per query.
+	exportBatchSize = 500
+)
+
+type quoteServer struct {
+	quotesv1.UnimplementedQuoteServiceServer
+
+	service QuoteService
+}
+
+func (s *quoteServer) CreateQuote(ctx context.Context, req *quotesv1.CreateQuoteRequest) (*quotesv1.Quote, error) {
+	var id uuid.UUID
+	if req.GetId() != "" {
+		var err error
+		id, err = parseID(req.GetId())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	quote, err := s.service.CreateNewQuote(ctx, id, req.GetAuthor(), req.GetQuote())
+	if err != nil {
+		return nil, statusFromError(ctx, "create new quote", err)
+	}
+
+	return quoteFromDomainToProto(quote), nil
+}
+
+func (s *quoteServer) GetQuote(ctx context.Context, req *quotesv1.GetQuoteRequest) (*quotesv1.Quote, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	quote, err := s.service.GetQuoteByID(ctx, id)
+	if err != nil {
+		return nil, statusFromError(ctx, "get quote by id", err)
+	}
+
+	return quoteFromDomainToProto(quote), nil
+}
+
+func (s *quoteServer) ListQuotes(ctx context.Context, req *quotesv1.ListQuotesRequest) (*quotesv1.ListQuotesResponse, error) {
+	pageSize := int(req.GetPageSize())
+	switch {
+	case pageSize < 0:
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	case pageSize == 0:
+		pageSize = defaultPageSize
+	case pageSize > quoteService.MaxPageSize:
+		pageSize = quoteService.MaxPageSize
+	}
+
+	afterID, err := parsePageToken(req.GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+
+	quotes, err := s.service.ListQuotes(ctx, quoteService.QuoteFilter{
+		Author:    req.GetAuthor(),
+		CreatedBy: req.GetCreatedBy(),
+		AfterID:   afterID,
+		Limit:     pageSize,
+	})
+	if err != nil {
+		return nil, statusFromError(ctx, "list quotes", err)
+	}
+
+	resp := &quotesv1.ListQuotesResponse{
+		Quotes: make([]*quotesv1.Quote, len(quotes)),
+	}
+	for i := range quotes {
+		resp.Quotes[i] = quoteFromDomainToProto(&quotes[i])
+	}
+	if len(quotes) == pageSize {
+		resp.NextPageToken = pageToken(quotes[len(quotes)-1].ID)
+	}
+
+	return resp, nil
+}
+
+func (s *quoteServer) GetRandomQuote(ctx context.Context, _ *quotesv1.GetRandomQuoteRequest) (*quotesv1.Quote, error) {
+	quote, err := s.service.GetRandomQuote(ctx)
+	if err != nil {
+		return nil, statusFromError(ctx, "get random quote", err)
+	}
+
+	return quoteFromDomainToProto(quote), nil
+}
+
+func (s *quoteServer) UpdateQuote(ctx context.Context, req *quotesv1.UpdateQuoteRequest) (*quotesv1.Quote, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	quote, err := s.service.UpdateQuote(ctx, id, req.GetAuthor(), req.GetQuote())
+	if err != nil {
+		return nil, statusFromError(ctx, "update quote", err)
+	}
+
+	return quoteFromDomainToProto(quote), nil
+}
+
+func (s *quoteServer) DeleteQuote(ctx context.Context, req *quotesv1.DeleteQuoteRequest) (*quotesv1.DeleteQuoteResponse, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.service.DeleteQuoteByID(ctx, id)
+	if err != nil {
+		return nil, statusFromError(ctx, "delete quote by id", err)
+	}
+
+	return &quotesv1.DeleteQuoteResponse{}, nil
+}
+
+// ExportQuotes pages through the quotes, so no query holds a connection for the whole stream.
+func (s *quoteServer) ExportQuotes(req *quotesv1.ExportQuotesRequest, stream grpc.ServerStreamingServer[quotesv1.Quote]) error {
+	ctx := stream.Context()
+	filter := quoteService.QuoteFilter{
+		Author:    req.GetAuthor(),
+		CreatedBy: req.GetCreatedBy(),
+		Limit:     exportBatchSize,
+	}
+
+	for {
+		quotes, err := s.service.ListQuotes(ctx, filter)
+		if err != nil {
+			return statusFromError(ctx, "list quotes", err)
+		}
+
+		for i := range quotes {
+			err = stream.Send(quoteFromDomainToProto(&quotes[i]))
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(quotes) < exportBatchSize {
+			return nil
+		}
+		filter.AfterID = quotes[len(quotes)-1].ID
+	}
+}
+
+func quoteFromDomainToProto(quote *quoteService.Quote) *quotesv1.Quote {
+	return &quotesv1.Quote{
+		Id:        quote.ID.String(),
+		Author:    quote.Author,
+		Quote:     quote.Quote,
+		CreatedBy: quote.CreatedBy,
+		UpdatedBy: quote.UpdatedBy,
+	}
+}
+
+func parseID(raw string) (uuid.UUID, error) {
+	id, err := uuid.Parse(raw)
+	if err != nil || id == uuid.Nil {
+		return uuid.Nil, status.Error(codes.InvalidArgument, "id must be a uuid")
+	}
+
+	return id, nil
+}
+
+// pageToken encodes the ID of the last quote of a page, the next page starts after it.
+func pageToken(lastID uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString(lastID[:])
+}
+
+func parsePageToken(token string) (uuid.UUID, error) {
+	if token == "" {
+		return uuid.Nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return uuid.Nil, status.Error(codes.InvalidArgument, "invalid page_token")
+	}
+	id, err := uuid.FromBytes(raw)
+	if err != nil {
+		return uuid.Nil, status.Error(codes.InvalidArgument, "invalid page_token")
+	}
+
+	return id, nil
+}
@@ -0,0 +1,39 @@
+package health
+
+import (
+	"context"
+	"fmt"
+)
+
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+type MigrationVersioner interface {
+	Versions(ctx context.Context) (current, latest int64, err error)
+}
+
+func DatabaseCheck(db Pinger) Check {
+	return Check{
+		Name: "database",
+		Run:  db.PingContext,
+	}
+}
+
+// MigrationCheck fails while the database schema is behind the migrations embedded into the binary.
+func MigrationCheck(migrator MigrationVersioner) Check {
+	return Check{
+		Name: "migrations",
+		Run: func(ctx context.Context) error {
+			current, latest, err := migrator.Versions(ctx)
+			if err != nil {
+				return fmt.Errorf("get migration versions: %w", err)
+			}
+			if current < latest {
+				return fmt.Errorf("schema version %d is behind the latest migration %d", current, latest)
+			}
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,117 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+const defaultCheckTimeout = 2 * time.Second
+
+type Check struct {
+	Name string
+	// Timeout bounds a single run of the check, defaults to 2 seconds.
+	Timeout time.Duration
+	Run     func(ctx context.Context) error
+}
+
+type CheckResult struct {
+	Name     string        `json:"name"`
+	Status   string        `json:"status"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks,omitempty"`
+}
+
+// Checker tracks the lifecycle of the process and runs the readiness checks.
+type Checker struct {
+	checks       []Check
+	started      atomic.Bool
+	shuttingDown atomic.Bool
+}
+
+func New(checks ...Check) *Checker {
+	return &Checker{checks: checks}
+}
+
+// MarkStarted must be called once the initialization of the process is complete.
+func (c *Checker) MarkStarted() {
+	c.started.Store(true)
+}
+
+// MarkShuttingDown makes readiness fail, so load balancers stop routing traffic
+// to the process before the HTTP server is shut down.
+func (c *Checker) MarkShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+func (c *Checker) Started() bool {
+	return c.started.Load()
+}
+
+// Ready runs all checks concurrently. The report fails if the process has not started yet,
+// is shutting down or any of the checks failed.
+func (c *Checker) Ready(ctx context.Context) Report {
+	switch {
+	case !c.started.Load():
+		return Report{Status: StatusFail, Checks: []CheckResult{{Name: "startup", Status: StatusFail, Error: "not started"}}}
+	case c.shuttingDown.Load():
+		return Report{Status: StatusFail, Checks: []CheckResult{{Name: "shutdown", Status: StatusFail, Error: "shutting down"}}}
+	}
+
+	results := make([]CheckResult, len(c.checks))
+
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runCheck(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: results}
+	for _, result := range results {
+		if result.Status != StatusOK {
+			report.Status = StatusFail
+			break
+		}
+	}
+
+	return report
+}
+
+func runCheck(ctx context.Context, check Check) CheckResult {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = defaultCheckTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(ctx)
+	result := CheckResult{
+		Name:     check.Name,
+		Status:   StatusOK,
+		Duration: time.Since(start),
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -0,0 +1,91 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChecker_Ready(t *testing.T) {
+	okCheck := Check{Name: "ok", Run: func(context.Context) error { return nil }}
+	failingCheck := Check{Name: "failing", Run: func(context.Context) error { return errors.New("unreachable") }}
+	hangingCheck := Check{
+		Name:    "hanging",
+		Timeout: 10 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+
+	type testCase struct {
+		name         string
+		checker      func() *Checker
+		wantStatus   string
+		wantFailures []string
+	}
+
+	testCases := []testCase{
+		{
+			name: "Started checker with passing checks is ready",
+			checker: func() *Checker {
+				c := New(okCheck)
+				c.MarkStarted()
+				return c
+			},
+			wantStatus: StatusOK,
+		},
+		{
+			name:         "Checker that has not started is not ready",
+			checker:      func() *Checker { return New(okCheck) },
+			wantStatus:   StatusFail,
+			wantFailures: []string{"startup"},
+		},
+		{
+			name: "Shutting down checker is not ready",
+			checker: func() *Checker {
+				c := New(okCheck)
+				c.MarkStarted()
+				c.MarkShuttingDown()
+				return c
+			},
+			wantStatus:   StatusFail,
+			wantFailures: []string{"shutdown"},
+		},
+		{
+			name: "Failing and timed out checks are reported",
+			checker: func() *Checker {
+				c := New(okCheck, failingCheck, hangingCheck)
+				c.MarkStarted()
+				return c
+			},
+			wantStatus:   StatusFail,
+			wantFailures: []string{"failing", "hanging"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			report := tc.checker().Ready(context.Background())
+			if report.Status != tc.wantStatus {
+				t.Errorf("Ready() status = %s, want %s", report.Status, tc.wantStatus)
+			}
+
+			var gotFailures []string
+			for _, check := range report.Checks {
+				if check.Status != StatusOK {
+					gotFailures = append(gotFailures, check.Name)
+				}
+			}
+			if len(gotFailures) != len(tc.wantFailures) {
+				t.Fatalf("Ready() failed checks = %v, want %v", gotFailures, tc.wantFailures)
+			}
+			for i := range gotFailures {
+				if gotFailures[i] != tc.wantFailures[i] {
+					t.Errorf("Ready() failed checks = %v, want %v", gotFailures, tc.wantFailures)
+				}
+			}
+		})
+	}
+}
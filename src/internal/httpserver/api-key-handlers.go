@@ -0,0 +1,113 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/BernsteinMondy/quote-service/src/internal/auth"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+)
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, name string, scopes []auth.Scope) (*auth.APIKey, string, error)
+	ListAPIKeys(ctx context.Context) ([]auth.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) error
+}
+
+func mapAPIKeyHandlers(router *mux.Router, service APIKeyService, maxBodyBytes int64) {
+	keysGroup := router.PathPrefix("/api-keys").Subrouter()
+	keysGroup.Handle("", requireScope(auth.ScopeAdmin, limitBody(maxBodyBytes, PostAPIKeyHandler(service)))).Methods("POST")
+	keysGroup.Handle("", requireScope(auth.ScopeAdmin, GetAPIKeysHandler(service))).Methods("GET")
+	keysGroup.Handle("/{id}", requireScope(auth.ScopeAdmin, DeleteAPIKeyHandler(service))).Methods("DELETE")
+}
+
+func PostAPIKeyHandler(service APIKeyService) http.HandlerFunc {
+	type request = apiKeyCreateDTO
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+
+		if len(req.Name) == 0 {
+			http.Error(w, "\"name\" request field can not be empty", http.StatusBadRequest)
+			return
+		}
+		scopes, err := auth.ParseScopes(req.Scopes)
+		if err != nil || len(scopes) == 0 {
+			http.Error(w, "\"scopes\" request field must contain known scopes", http.StatusBadRequest)
+			return
+		}
+
+		key, plaintext, err := service.CreateAPIKey(r.Context(), req.Name, scopes)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to create api key", slog.String("error", err.Error()))
+			http.Error(w, "service: create api key", http.StatusInternalServerError)
+			return
+		}
+
+		resp := apiKeyCreatedDTO{
+			apiKeyReadDTO: apiKeyFromDomainToReadDTO(key),
+			Key:           plaintext,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func GetAPIKeysHandler(service APIKeyService) http.HandlerFunc {
+	type response struct {
+		Keys []apiKeyReadDTO `json:"keys"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys, err := service.ListAPIKeys(r.Context())
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to list api keys", slog.String("error", err.Error()))
+			http.Error(w, "service: list api keys", http.StatusInternalServerError)
+			return
+		}
+
+		resp := response{
+			Keys: make([]apiKeyReadDTO, len(keys)),
+		}
+		for i, key := range keys {
+			resp.Keys[i] = apiKeyFromDomainToReadDTO(&key)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(resp)
+		if err != nil {
+			http.Error(w, "failed to encode response: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func DeleteAPIKeyHandler(service APIKeyService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "invalid \"id\" parameter", http.StatusBadRequest)
+			return
+		}
+
+		err = service.RevokeAPIKey(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, auth.ErrAPIKeyNotFound) {
+				http.Error(w, "api key not found", http.StatusNotFound)
+				return
+			}
+
+			slog.ErrorContext(r.Context(), "Failed to revoke api key", slog.String("error", err.Error()))
+			http.Error(w, "service: revoke api key", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,93 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"github.com/BernsteinMondy/quote-service/src/internal/auth"
+	"github.com/BernsteinMondy/quote-service/src/internal/ratelimit"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+const apiKeyHeader = "X-API-Key"
+
+type Authenticator interface {
+	// Authenticate must return auth.ErrUnauthenticated if the credential is not valid.
+	Authenticate(ctx context.Context, credential string) (*auth.Principal, error)
+}
+
+// authenticate stores the principal of the request credentials in the request context.
+// Requests without credentials are passed on anonymously, routes that need a principal
+// are guarded with requireScope. Requests with credentials take a token of the client IP from
+// the "auth" class of the rate limit first, so credentials can not be guessed unthrottled.
+func authenticate(cfg Config) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		authenticated := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cfg.Authenticator == nil {
+				writeUnauthorized(w)
+				return
+			}
+
+			principal, err := cfg.Authenticator.Authenticate(r.Context(), credentialFromRequest(r))
+			if err != nil {
+				if errors.Is(err, auth.ErrUnauthenticated) {
+					writeUnauthorized(w)
+					return
+				}
+
+				slog.ErrorContext(r.Context(), "Failed to authenticate request", slog.String("error", err.Error()))
+				http.Error(w, "authenticate request", http.StatusInternalServerError)
+				return
+			}
+
+			recordPrincipal(r.Context(), principal.Subject)
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+		limited := rateLimit(cfg.RateLimiter, ratelimit.ClassAuth, cfg.TrustForwardedFor, authenticated)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if credentialFromRequest(r) == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			limited.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requireScope rejects anonymous requests with 401 and requests of principals lacking the scope with 403.
+func requireScope(scope auth.Scope, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal := auth.PrincipalFromContext(r.Context())
+		if principal == nil {
+			writeUnauthorized(w)
+			return
+		}
+		if !principal.HasScope(scope) {
+			http.Error(w, "missing \""+string(scope)+"\" scope", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func credentialFromRequest(r *http.Request) string {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		return key
+	}
+
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
+
+func writeUnauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+}
@@ -0,0 +1,140 @@
+package httpserver_test
+
+import (
+	"github.com/BernsteinMondy/quote-service/src/internal/auth"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver/testhelpers"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var authenticatorFixture = &testhelpers.MockAuthenticator{
+	Principals: map[string]*auth.Principal{
+		"reader":    {Subject: "reader", Scopes: []auth.Scope{auth.ScopeQuotesRead}},
+		"writer":    {Subject: "writer", Scopes: []auth.Scope{auth.ScopeQuotesRead, auth.ScopeQuotesWrite}},
+		"moderator": {Subject: "moderator", Scopes: []auth.Scope{auth.ScopeQuotesModerate}},
+		"admin":     {Subject: "admin", Scopes: []auth.Scope{auth.ScopeAdmin}},
+	},
+}
+
+func TestRouteScopes(t *testing.T) {
+	type testCase struct {
+		name               string
+		requireReadAuth    bool
+		method             string
+		path               string
+		apiKey             string
+		bearerToken        string
+		wantRespStatusCode int
+	}
+
+	testCases := []testCase{
+		{
+			name:               "Anonymous read is allowed by default",
+			method:             http.MethodGet,
+			path:               "/quotes/random",
+			wantRespStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Anonymous read results in status code 401 if read auth is required",
+			requireReadAuth:    true,
+			method:             http.MethodGet,
+			path:               "/quotes/random",
+			wantRespStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Read with \"quotes:read\" scope is allowed if read auth is required",
+			requireReadAuth:    true,
+			method:             http.MethodGet,
+			path:               "/quotes",
+			apiKey:             "reader",
+			wantRespStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Anonymous delete results in status code 401",
+			method:             http.MethodDelete,
+			path:               "/quotes/4937a248-cb08-46de-8789-493904914cc6",
+			wantRespStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Invalid credential results in status code 401",
+			method:             http.MethodGet,
+			path:               "/quotes/random",
+			apiKey:             "unknown",
+			wantRespStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Delete without \"quotes:delete\" scope results in status code 403",
+			method:             http.MethodDelete,
+			path:               "/quotes/4937a248-cb08-46de-8789-493904914cc6",
+			bearerToken:        "writer",
+			wantRespStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Update with \"quotes:write\" scope is allowed",
+			method:             http.MethodPut,
+			path:               "/quotes/4937a248-cb08-46de-8789-493904914cc6",
+			apiKey:             "writer",
+			wantRespStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Delete with \"quotes:moderate\" scope is allowed",
+			method:             http.MethodDelete,
+			path:               "/quotes/4937a248-cb08-46de-8789-493904914cc6",
+			apiKey:             "moderator",
+			wantRespStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Delete with \"admin\" scope is allowed",
+			method:             http.MethodDelete,
+			path:               "/quotes/4937a248-cb08-46de-8789-493904914cc6",
+			apiKey:             "admin",
+			wantRespStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Admin endpoint without \"admin\" scope results in status code 403",
+			method:             http.MethodGet,
+			path:               "/admin/api-keys",
+			apiKey:             "writer",
+			wantRespStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Admin endpoint with \"admin\" scope is allowed",
+			method:             http.MethodGet,
+			path:               "/admin/api-keys",
+			apiKey:             "admin",
+			wantRespStatusCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		server := httptest.NewServer(httpserver.New(&testhelpers.MockQuoteService{}, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{
+			Authenticator:   authenticatorFixture,
+			APIKeys:         &testhelpers.MockAPIKeyService{},
+			RequireReadAuth: tc.requireReadAuth,
+		}).Handler)
+
+		req, err := http.NewRequest(tc.method, server.URL+tc.path, strings.NewReader(`{"author":"test author","quote":"test quote"}`))
+		if err != nil {
+			t.Fatal("Failed to create request", err)
+		}
+		if tc.apiKey != "" {
+			req.Header.Set("X-API-Key", tc.apiKey)
+		}
+		if tc.bearerToken != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.bearerToken)
+		}
+
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatal("Failed to make test request", err)
+		}
+
+		if resp.StatusCode != tc.wantRespStatusCode {
+			t.Errorf("%s: got status code %d want %d", tc.name, resp.StatusCode, tc.wantRespStatusCode)
+		}
+	}
+}
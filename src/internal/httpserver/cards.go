@@ -0,0 +1,72 @@
+package httpserver
+
+import (
+	"errors"
+	"github.com/BernsteinMondy/quote-service/src/internal/cards"
+	quoteService "github.com/BernsteinMondy/quote-service/src/internal/service"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+)
+
+// cardMaxAge is how long clients and proxies may cache a card without revalidating it, the card
+// changes only if the quote is updated.
+const cardMaxAge = "max-age=300"
+
+type CardRenderer interface {
+	Render(quote, author string, opts cards.Options) ([]byte, error)
+}
+
+// QuoteCardHandler renders the quote as an SVG or PNG image for sharing, in the theme and size
+// selected by the "theme" and "size" parameters. Responses carry an ETag computed from the card.
+func QuoteCardHandler(service QuoteService, renderer CardRenderer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, err := uuid.Parse(vars["id"])
+		if err != nil {
+			http.Error(w, "invalid \"id\" parameter", http.StatusBadRequest)
+			return
+		}
+
+		opts := cards.Options{}
+		opts.Format, err = cards.ParseFormat(vars["format"])
+		if err != nil {
+			http.Error(w, "unknown card format", http.StatusNotFound)
+			return
+		}
+		query := r.URL.Query()
+		opts.Theme, err = cards.ParseTheme(query.Get("theme"))
+		if err != nil {
+			http.Error(w, "invalid \"theme\" parameter", http.StatusBadRequest)
+			return
+		}
+		opts.Size, err = cards.ParseSize(query.Get("size"))
+		if err != nil {
+			http.Error(w, "invalid \"size\" parameter", http.StatusBadRequest)
+			return
+		}
+
+		quote, err := service.GetQuoteByID(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, quoteService.ErrNotFound) {
+				http.Error(w, "quote not found", http.StatusNotFound)
+				return
+			}
+
+			writeServiceError(w, r, "get quote by id", err)
+			return
+		}
+
+		data, err := renderer.Render(quote.Quote, quote.Author, opts)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to render card", slog.String("error", err.Error()))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		// The SVG cards are opened as documents too, they must not load or run anything.
+		w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; font-src data:")
+		writeCacheable(w, r, data, opts.Format.ContentType(), cardMaxAge)
+	}
+}
@@ -0,0 +1,119 @@
+package httpserver_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/BernsteinMondy/quote-service/src/internal/cards"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver/testhelpers"
+	"github.com/BernsteinMondy/quote-service/src/internal/service"
+	"github.com/gorilla/mux"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQuoteCardHandler(t *testing.T) {
+	cardPath := "/quotes/" + testhelpers.QuotesArrayFixture[0].ID.String() + "/card"
+
+	type testCase struct {
+		name               string
+		service            httpserver.QuoteService
+		path               string
+		wantRespStatusCode int
+		wantContentType    string
+	}
+
+	testCases := []testCase{
+		{
+			name:               "PNG card results in status code 200",
+			path:               cardPath + ".png?theme=dark&size=square",
+			wantRespStatusCode: http.StatusOK,
+			wantContentType:    "image/png",
+		},
+		{
+			name:               "SVG card results in status code 200",
+			path:               cardPath + ".svg",
+			wantRespStatusCode: http.StatusOK,
+			wantContentType:    "image/svg+xml",
+		},
+		{
+			name:               "Unknown theme results in status code 400",
+			path:               cardPath + ".png?theme=neon",
+			wantRespStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Unknown size results in status code 400",
+			path:               cardPath + ".png?size=banner",
+			wantRespStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Non-uuid id results in status code 400",
+			path:               "/quotes/non-uuid/card.png",
+			wantRespStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Unknown quote results in status code 404",
+			service:            &testhelpers.MockQuoteService{RetError: service.ErrNotFound},
+			path:               cardPath + ".svg",
+			wantRespStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "Service call ended with error results in status code 500",
+			service:            &testhelpers.MockQuoteService{RetError: errors.New("some error")},
+			path:               cardPath + ".svg",
+			wantRespStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		if tc.service == nil {
+			tc.service = &testhelpers.MockQuoteService{}
+		}
+		server := httptest.NewServer(httpserver.New(tc.service, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{
+			Cards: cards.NewRenderer(0),
+		}).Handler)
+
+		resp, err := server.Client().Get(server.URL + tc.path)
+		if err != nil {
+			t.Fatal("Failed to make test request", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			t.Fatal("Failed to read response", err)
+		}
+
+		if resp.StatusCode != tc.wantRespStatusCode {
+			t.Errorf("%s: got status code %d want %d", tc.name, resp.StatusCode, tc.wantRespStatusCode)
+		}
+		if tc.wantContentType != "" && resp.Header.Get("Content-Type") != tc.wantContentType {
+			t.Errorf("%s: got content type %s want %s", tc.name, resp.Header.Get("Content-Type"), tc.wantContentType)
+		}
+		if tc.wantContentType == "image/png" {
+			img, err := png.Decode(bytes.NewReader(body))
+			if err != nil || img.Bounds().Dx() != cards.Sizes["square"].Width {
+				t.Errorf("%s: got image decoding with error %v want a square card", tc.name, err)
+			}
+		}
+
+		server.Close()
+	}
+}
+
+func TestQuoteCardHandler_disabled(t *testing.T) {
+	server := httptest.NewServer(httpserver.New(&testhelpers.MockQuoteService{}, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{}).Handler)
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL + "/quotes/" + testhelpers.QuotesArrayFixture[0].ID.String() + "/card.png")
+	if err != nil {
+		t.Fatal("Failed to make test request", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("got status code %d want %d without a card renderer", resp.StatusCode, http.StatusNotFound)
+	}
+}
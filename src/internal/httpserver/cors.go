@@ -0,0 +1,179 @@
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CORSConfig struct {
+	// AllowedOrigins are origins like "https://example.com" or patterns like "https://*.example.com",
+	// "*" allows every origin.
+	AllowedOrigins []string
+	// AllowedMethods are offered to preflight requests if the route handles them.
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache preflight responses, zero leaves it to the browser.
+	MaxAge time.Duration
+}
+
+// CORSPolicy answers preflight requests and adds the CORS headers to the responses of
+// allowed origins.
+type CORSPolicy struct {
+	anyOrigin      bool
+	origins        []string
+	methods        []string
+	allowedHeaders string
+	exposedHeaders string
+	credentials    bool
+	maxAge         string
+}
+
+func NewCORSPolicy(cfg CORSConfig) (*CORSPolicy, error) {
+	p := &CORSPolicy{
+		allowedHeaders: strings.Join(cfg.AllowedHeaders, ", "),
+		exposedHeaders: strings.Join(cfg.ExposedHeaders, ", "),
+		credentials:    cfg.AllowCredentials,
+	}
+	if cfg.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	}
+
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			p.anyOrigin = true
+			continue
+		}
+		if _, err := path.Match(origin, ""); err != nil {
+			return nil, fmt.Errorf("invalid origin pattern %q: %w", origin, err)
+		}
+		p.origins = append(p.origins, strings.ToLower(strings.TrimSuffix(origin, "/")))
+	}
+	if p.anyOrigin && p.credentials {
+		return nil, errors.New("credentials can not be allowed for every origin")
+	}
+
+	for _, method := range cfg.AllowedMethods {
+		p.methods = append(p.methods, strings.ToUpper(method))
+	}
+
+	return p, nil
+}
+
+func (p *CORSPolicy) allowsOrigin(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	for _, pattern := range p.origins {
+		// path.Match keeps "*" from matching a "/", so patterns match hosts only.
+		if ok, _ := path.Match(pattern, origin); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cors applies the policy to every route of the router. Preflight requests are answered with the
+// allowed methods the route matching their path handles. Responses vary by the Origin header
+// unless every origin is allowed without credentials.
+func cors(router *mux.Router, policy *CORSPolicy, next http.Handler) http.Handler {
+	if policy == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		if !policy.anyOrigin {
+			w.Header().Add("Vary", "Origin")
+		}
+		if origin == "" || !policy.allowsOrigin(origin) {
+			if preflight {
+				http.Error(w, "origin is not allowed", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if policy.anyOrigin {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		if policy.credentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if policy.exposedHeaders != "" {
+				w.Header().Set("Access-Control-Expose-Headers", policy.exposedHeaders)
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+		methods, found := routeMethods(router, r, policy.methods)
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		if !slices.Contains(methods, strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))) {
+			w.Header().Set("Allow", strings.Join(methods, ", "))
+			http.Error(w, "method is not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+		if policy.allowedHeaders != "" {
+			w.Header().Set("Access-Control-Allow-Headers", policy.allowedHeaders)
+		}
+		if policy.maxAge != "" {
+			w.Header().Set("Access-Control-Max-Age", policy.maxAge)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// routeMethods returns the methods of candidates the router handles for the path of r, it
+// reports whether the router handles the path with any method. The methods are probed one by
+// one, the match error of the router does not tell a method mismatch on subrouters reliably.
+func routeMethods(router *mux.Router, r *http.Request, candidates []string) ([]string, bool) {
+	found := false
+	methods := make([]string, 0, len(candidates))
+	for _, method := range probedMethods {
+		req := r.Clone(r.Context())
+		req.Method = method
+
+		var match mux.RouteMatch
+		if !router.Match(req, &match) || match.MatchErr != nil {
+			continue
+		}
+
+		found = true
+		if slices.Contains(candidates, method) {
+			methods = append(methods, method)
+		}
+	}
+
+	return methods, found
+}
+
+var probedMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+}
@@ -0,0 +1,172 @@
+package httpserver_test
+
+import (
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver/testhelpers"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	policy, err := httpserver.NewCORSPolicy(httpserver.CORSConfig{
+		AllowedOrigins:   []string{"https://widgets.example.org", "https://*.example.com"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE"},
+		AllowedHeaders:   []string{"Content-Type", "X-API-Key"},
+		ExposedHeaders:   []string{"Location"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+	if err != nil {
+		t.Fatal("Failed to create CORS policy", err)
+	}
+
+	server := httptest.NewServer(httpserver.New(&testhelpers.MockQuoteService{}, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{
+		CORS: policy,
+	}).Handler)
+	defer server.Close()
+
+	type testCase struct {
+		name               string
+		method             string
+		path               string
+		origin             string
+		requestMethod      string
+		wantRespStatusCode int
+		wantHeader         http.Header
+	}
+
+	testCases := []testCase{
+		{
+			name:               "Request of an allowed origin gets the CORS headers",
+			method:             http.MethodGet,
+			path:               "/quotes/random",
+			origin:             "https://widgets.example.org",
+			wantRespStatusCode: http.StatusOK,
+			wantHeader: http.Header{
+				"Access-Control-Allow-Origin":      {"https://widgets.example.org"},
+				"Access-Control-Allow-Credentials": {"true"},
+				"Access-Control-Expose-Headers":    {"Location"},
+				"Vary":                             {"Origin"},
+			},
+		},
+		{
+			name:               "Request of an origin matching a pattern gets the CORS headers",
+			method:             http.MethodGet,
+			path:               "/quotes/random",
+			origin:             "https://blog.example.com",
+			wantRespStatusCode: http.StatusOK,
+			wantHeader: http.Header{
+				"Access-Control-Allow-Origin": {"https://blog.example.com"},
+				"Vary":                        {"Origin"},
+			},
+		},
+		{
+			name:               "Request of another origin gets no CORS headers",
+			method:             http.MethodGet,
+			path:               "/quotes/random",
+			origin:             "https://example.com.evil.org",
+			wantRespStatusCode: http.StatusOK,
+			wantHeader: http.Header{
+				"Access-Control-Allow-Origin": nil,
+				"Vary":                        {"Origin"},
+			},
+		},
+		{
+			name:               "Request without an origin varies by origin",
+			method:             http.MethodGet,
+			path:               "/quotes/random",
+			wantRespStatusCode: http.StatusOK,
+			wantHeader: http.Header{
+				"Access-Control-Allow-Origin": nil,
+				"Vary":                        {"Origin"},
+			},
+		},
+		{
+			name:               "Preflight lists the allowed methods of the route",
+			method:             http.MethodOptions,
+			path:               "/quotes/4937a248-cb08-46de-8789-493904914cc6",
+			origin:             "https://widgets.example.org",
+			requestMethod:      http.MethodPut,
+			wantRespStatusCode: http.StatusNoContent,
+			wantHeader: http.Header{
+				"Access-Control-Allow-Origin":  {"https://widgets.example.org"},
+				"Access-Control-Allow-Methods": {"GET, PUT, DELETE"},
+				"Access-Control-Allow-Headers": {"Content-Type, X-API-Key"},
+				"Access-Control-Max-Age":       {"600"},
+				"Vary":                         {"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+			},
+		},
+		{
+			name:               "Preflight for a method the route does not handle results in status code 405",
+			method:             http.MethodOptions,
+			path:               "/quotes",
+			origin:             "https://widgets.example.org",
+			requestMethod:      http.MethodDelete,
+			wantRespStatusCode: http.StatusMethodNotAllowed,
+			wantHeader: http.Header{
+				"Allow": {"GET, POST"},
+			},
+		},
+		{
+			name:               "Preflight for an unknown path results in status code 404",
+			method:             http.MethodOptions,
+			path:               "/unknown",
+			origin:             "https://widgets.example.org",
+			requestMethod:      http.MethodGet,
+			wantRespStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "Preflight of another origin results in status code 403",
+			method:             http.MethodOptions,
+			path:               "/quotes",
+			origin:             "https://evil.org",
+			requestMethod:      http.MethodPost,
+			wantRespStatusCode: http.StatusForbidden,
+			wantHeader: http.Header{
+				"Access-Control-Allow-Origin": nil,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		req, err := http.NewRequest(tc.method, server.URL+tc.path, nil)
+		if err != nil {
+			t.Fatal("Failed to create request", err)
+		}
+		if tc.origin != "" {
+			req.Header.Set("Origin", tc.origin)
+		}
+		if tc.requestMethod != "" {
+			req.Header.Set("Access-Control-Request-Method", tc.requestMethod)
+		}
+
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatal("Failed to make test request", err)
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != tc.wantRespStatusCode {
+			t.Errorf("%s: got status code %d want %d", tc.name, resp.StatusCode, tc.wantRespStatusCode)
+		}
+		for name, want := range tc.wantHeader {
+			if got := resp.Header.Values(name); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: got %s header %q want %q", tc.name, name, got, want)
+			}
+		}
+	}
+}
+
+func TestNewCORSPolicy_RejectsCredentialsForEveryOrigin(t *testing.T) {
+	_, err := httpserver.NewCORSPolicy(httpserver.CORSConfig{
+		AllowedOrigins:   []string{"*"},
+		AllowCredentials: true,
+	})
+	if err == nil {
+		t.Error("NewCORSPolicy returned no error for credentials allowed for every origin")
+	}
+}
@@ -1,23 +1,226 @@
 package httpserver
 
-import "github.com/BernsteinMondy/quote-service/src/internal/service"
+import (
+	"encoding/json"
+	"github.com/BernsteinMondy/quote-service/src/internal/auth"
+	"github.com/BernsteinMondy/quote-service/src/internal/service"
+	"github.com/BernsteinMondy/quote-service/src/internal/webhooks"
+	"time"
+)
 
 type (
 	quoteReadDTO struct {
-		ID     string `json:"id"`
+		ID        string `json:"id"`
+		Author    string `json:"author"`
+		Quote     string `json:"quote"`
+		CreatedBy string `json:"created_by,omitempty"`
+		UpdatedBy string `json:"updated_by,omitempty"`
+	}
+	// quoteEventDTO is the data of the quote events, the tags are known for every event.
+	quoteEventDTO struct {
+		quoteReadDTO
+		Tags []string `json:"tags"`
+	}
+	quoteCreateDTO struct {
+		// ID is optional, it is generated if empty.
+		ID     string `json:"id,omitempty"`
 		Author string `json:"author"`
 		Quote  string `json:"quote"`
 	}
-	quoteCreateDTO struct {
+	quoteUpdateDTO struct {
 		Author string `json:"author"`
 		Quote  string `json:"quote"`
 	}
+	rotationClientMessage struct {
+		Type string `json:"type"`
+		// The settings of a "configure" message are kept if nil.
+		IntervalSeconds *int    `json:"interval_seconds,omitempty"`
+		Author          *string `json:"author,omitempty"`
+		Tag             *string `json:"tag,omitempty"`
+	}
+	rotationServerMessage struct {
+		Type  string            `json:"type"`
+		Quote *quoteReadDTO     `json:"quote,omitempty"`
+		State *rotationStateDTO `json:"state,omitempty"`
+		Error string            `json:"error,omitempty"`
+	}
+	rotationStateDTO struct {
+		IntervalSeconds int    `json:"interval_seconds"`
+		Author          string `json:"author,omitempty"`
+		Tag             string `json:"tag,omitempty"`
+		Paused          bool   `json:"paused"`
+	}
+	graphQLRequestDTO struct {
+		Query         string         `json:"query"`
+		OperationName string         `json:"operationName,omitempty"`
+		Variables     map[string]any `json:"variables,omitempty"`
+		// Extensions are accepted for clients sending them, e.g. persisted query hashes, and ignored.
+		Extensions map[string]any `json:"extensions,omitempty"`
+	}
 )
 
 func quoteFromDomainToReadDTO(quote *service.Quote) quoteReadDTO {
 	return quoteReadDTO{
-		ID:     quote.ID.String(),
-		Author: quote.Author,
-		Quote:  quote.Quote,
+		ID:        quote.ID.String(),
+		Author:    quote.Author,
+		Quote:     quote.Quote,
+		CreatedBy: quote.CreatedBy,
+		UpdatedBy: quote.UpdatedBy,
+	}
+}
+
+func quoteFromDomainToEventDTO(quote *service.Quote) quoteEventDTO {
+	tags := quote.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return quoteEventDTO{
+		quoteReadDTO: quoteFromDomainToReadDTO(quote),
+		Tags:         tags,
 	}
 }
+
+type (
+	validationErrorDTO struct {
+		Error  string          `json:"error"`
+		Fields []fieldErrorDTO `json:"fields"`
+	}
+	fieldErrorDTO struct {
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	}
+)
+
+func validationErrorFromDomainToDTO(verr *service.ValidationError) validationErrorDTO {
+	fields := make([]fieldErrorDTO, len(verr.Fields))
+	for i, field := range verr.Fields {
+		fields[i] = fieldErrorDTO{Field: field.Field, Message: field.Message}
+	}
+
+	return validationErrorDTO{
+		Error:  "invalid request fields",
+		Fields: fields,
+	}
+}
+
+type (
+	apiKeyReadDTO struct {
+		ID        string     `json:"id"`
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		CreatedAt time.Time  `json:"created_at"`
+		RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	}
+	apiKeyCreateDTO struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	}
+	// apiKeyCreatedDTO is the only response containing the plaintext key.
+	apiKeyCreatedDTO struct {
+		apiKeyReadDTO
+		Key string `json:"key"`
+	}
+)
+
+func apiKeyFromDomainToReadDTO(key *auth.APIKey) apiKeyReadDTO {
+	scopes := make([]string, len(key.Scopes))
+	for i, scope := range key.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	return apiKeyReadDTO{
+		ID:        key.ID.String(),
+		Name:      key.Name,
+		Scopes:    scopes,
+		CreatedAt: key.CreatedAt,
+		RevokedAt: key.RevokedAt,
+	}
+}
+
+type (
+	webhookReadDTO struct {
+		ID        string    `json:"id"`
+		URL       string    `json:"url"`
+		Events    []string  `json:"events"`
+		Active    bool      `json:"active"`
+		CreatedAt time.Time `json:"created_at"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+	webhookWriteDTO struct {
+		URL string `json:"url"`
+		// Events subscribes the webhook to every event if empty.
+		Events []string `json:"events"`
+		// Active is ignored on create, webhooks are created active.
+		Active *bool `json:"active,omitempty"`
+	}
+	// webhookCreatedDTO is the only response containing the signing secret.
+	webhookCreatedDTO struct {
+		webhookReadDTO
+		Secret string `json:"secret"`
+	}
+	webhookDeliveryDTO struct {
+		ID             int64           `json:"id"`
+		EventID        string          `json:"event_id"`
+		EventType      string          `json:"event_type"`
+		Payload        json.RawMessage `json:"payload"`
+		Status         string          `json:"status"`
+		Attempts       int             `json:"attempts"`
+		NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+		LastStatusCode int             `json:"last_status_code,omitempty"`
+		LastError      string          `json:"last_error,omitempty"`
+		CreatedAt      time.Time       `json:"created_at"`
+		DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	}
+	// oEmbedDTO is a "rich" oEmbed response, see https://oembed.com.
+	oEmbedDTO struct {
+		Type         string `json:"type"`
+		Version      string `json:"version"`
+		Title        string `json:"title"`
+		AuthorName   string `json:"author_name"`
+		AuthorURL    string `json:"author_url"`
+		ProviderName string `json:"provider_name"`
+		ProviderURL  string `json:"provider_url"`
+		CacheAge     int    `json:"cache_age"`
+		HTML         string `json:"html"`
+		Width        int    `json:"width"`
+		Height       int    `json:"height"`
+	}
+)
+
+func webhookFromDomainToReadDTO(webhook *webhooks.Webhook) webhookReadDTO {
+	events := make([]string, len(webhook.Events))
+	for i, event := range webhook.Events {
+		events[i] = string(event)
+	}
+
+	return webhookReadDTO{
+		ID:        webhook.ID.String(),
+		URL:       webhook.URL,
+		Events:    events,
+		Active:    webhook.Active,
+		CreatedAt: webhook.CreatedAt,
+		UpdatedAt: webhook.UpdatedAt,
+	}
+}
+
+func webhookDeliveryFromDomainToDTO(delivery *webhooks.Delivery) webhookDeliveryDTO {
+	dto := webhookDeliveryDTO{
+		ID:             delivery.ID,
+		EventID:        delivery.EventID.String(),
+		EventType:      string(delivery.EventType),
+		Payload:        delivery.Payload,
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt,
+		DeliveredAt:    delivery.DeliveredAt,
+	}
+	// The next attempt is meaningful for pending deliveries only.
+	if delivery.Status == webhooks.StatusPending {
+		dto.NextAttemptAt = &delivery.NextAttemptAt
+	}
+
+	return dto
+}
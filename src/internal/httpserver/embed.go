@@ -0,0 +1,350 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/BernsteinMondy/quote-service/src/internal/cards"
+	quoteService "github.com/BernsteinMondy/quote-service/src/internal/service"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultEmbedWidth and DefaultEmbedHeight are the dimensions of the oEmbed responses, unless
+	// the consumer asks for smaller ones with the "maxwidth" and "maxheight" parameters.
+	DefaultEmbedWidth  = 550
+	DefaultEmbedHeight = 220
+	// embedMaxAge is how long the embeds of a quote may be cached, it is the "cache_age" of the
+	// oEmbed responses too.
+	embedMaxAge = 300
+	// embedPolicy forbids scripts and external resources while allowing the page in any frame.
+	embedPolicy = "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors *"
+)
+
+// mapEmbedHandlers mounts GET /oembed and the /embed pages, they are guarded like the read
+// endpoints of /quotes.
+func mapEmbedHandlers(router *mux.Router, service QuoteService, cfg Config) {
+	links := linksFromConfig(cfg)
+	router.Handle("/oembed", authenticate(cfg)(guardRead(cfg, OEmbedHandler(service, links)))).Methods("GET")
+
+	embedGroup := router.PathPrefix("/embed").Subrouter()
+	embedGroup.Use(authenticate(cfg))
+	embedGroup.Handle("/random", guardRead(cfg, EmbedRandomQuoteHandler(service, links))).Methods("GET")
+	embedGroup.Handle("/{id}", guardRead(cfg, EmbedQuoteHandler(service, links))).Methods("GET")
+}
+
+// OEmbedHandler answers oEmbed requests for the URLs of the quotes and their embed pages with a
+// "rich" response, its HTML is the quote as a styled blockquote. Only the JSON format is supported.
+func OEmbedHandler(service QuoteService, links Links) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if format := query.Get("format"); format != "" && format != "json" {
+			http.Error(w, "only the json format is supported", http.StatusNotImplemented)
+			return
+		}
+		rawURL := query.Get("url")
+		if rawURL == "" {
+			http.Error(w, "empty \"url\" parameter", http.StatusBadRequest)
+			return
+		}
+		theme, err := cards.ParseTheme(query.Get("theme"))
+		if err != nil {
+			http.Error(w, "invalid \"theme\" parameter", http.StatusBadRequest)
+			return
+		}
+		width, ok := parseMaxDimension(w, query, "maxwidth", DefaultEmbedWidth)
+		if !ok {
+			return
+		}
+		height, ok := parseMaxDimension(w, query, "maxheight", DefaultEmbedHeight)
+		if !ok {
+			return
+		}
+
+		base := links.base(r)
+		id, ok := quoteIDFromURL(rawURL, base)
+		if !ok {
+			http.Error(w, "url is not the url of a quote", http.StatusNotFound)
+			return
+		}
+
+		quote, err := service.GetQuoteByID(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, quoteService.ErrNotFound) {
+				http.Error(w, "quote not found", http.StatusNotFound)
+				return
+			}
+
+			writeServiceError(w, r, "get quote by id", err)
+			return
+		}
+
+		var html bytes.Buffer
+		err = embedTemplates.ExecuteTemplate(&html, "quote", newEmbedView(quote, theme, base, width))
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to render embed", slog.String("error", err.Error()))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		resp := oEmbedDTO{
+			Type:         "rich",
+			Version:      "1.0",
+			Title:        "Quote by " + quote.Author,
+			AuthorName:   quote.Author,
+			AuthorURL:    base + "/quotes?" + url.Values{"author": {quote.Author}}.Encode(),
+			ProviderName: "Quote Service",
+			ProviderURL:  base,
+			CacheAge:     embedMaxAge,
+			HTML:         html.String(),
+			Width:        width,
+			Height:       height,
+		}
+		body, err := json.Marshal(resp)
+		if err != nil {
+			http.Error(w, "failed to encode response: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeCacheable(w, r, body, "application/json", links.cacheControl(w, fmt.Sprintf("max-age=%d", embedMaxAge)))
+	}
+}
+
+// parseMaxDimension returns the parameter if it is below def, it responds with status code 400 and
+// reports false if the parameter is invalid.
+func parseMaxDimension(w http.ResponseWriter, query url.Values, name string, def int) (int, bool) {
+	raw := query.Get(name)
+	if raw == "" {
+		return def, true
+	}
+
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		http.Error(w, fmt.Sprintf("invalid %q parameter", name), http.StatusBadRequest)
+		return 0, false
+	}
+	return min(n, def), true
+}
+
+// quoteIDFromURL returns the ID of the quote of a /quotes/{id} or /embed/{id} URL on the host of
+// base. Without a public URL, base is the host of the request, so the responses about the URL must
+// vary by Host, see Links.
+func quoteIDFromURL(rawURL, base string) (uuid.UUID, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return uuid.Nil, false
+	}
+	public, err := url.Parse(base)
+	if err != nil || !strings.EqualFold(u.Host, public.Host) {
+		return uuid.Nil, false
+	}
+
+	for _, prefix := range []string{"/quotes/", "/embed/"} {
+		if rawID, ok := strings.CutPrefix(u.Path, prefix); ok {
+			id, err := uuid.Parse(rawID)
+			return id, err == nil && id != uuid.Nil
+		}
+	}
+	return uuid.Nil, false
+}
+
+// EmbedQuoteHandler serves the quote as a standalone HTML page for iframes, in the theme of the
+// "theme" parameter.
+func EmbedQuoteHandler(service QuoteService, links Links) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "invalid \"id\" parameter", http.StatusBadRequest)
+			return
+		}
+		theme, err := cards.ParseTheme(r.URL.Query().Get("theme"))
+		if err != nil {
+			http.Error(w, "invalid \"theme\" parameter", http.StatusBadRequest)
+			return
+		}
+
+		quote, err := service.GetQuoteByID(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, quoteService.ErrNotFound) {
+				http.Error(w, "quote not found", http.StatusNotFound)
+				return
+			}
+
+			writeServiceError(w, r, "get quote by id", err)
+			return
+		}
+
+		page, ok := renderEmbedPage(w, r, quote, theme, links.base(r))
+		if !ok {
+			return
+		}
+		w.Header().Set("Content-Security-Policy", embedPolicy)
+		writeCacheable(w, r, page, "text/html; charset=utf-8", links.cacheControl(w, fmt.Sprintf("max-age=%d", embedMaxAge)))
+	}
+}
+
+// EmbedRandomQuoteHandler serves a random quote like EmbedQuoteHandler, optionally of the author
+// of the "author" parameter or with the tag of the "tag" parameter. The page is not cached.
+func EmbedRandomQuoteHandler(service QuoteService, links Links) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		theme, err := cards.ParseTheme(query.Get("theme"))
+		if err != nil {
+			http.Error(w, "invalid \"theme\" parameter", http.StatusBadRequest)
+			return
+		}
+
+		quote, err := service.GetRandomQuoteWithFilter(r.Context(), quoteService.RandomQuoteFilter{
+			Author: query.Get("author"),
+			Tag:    strings.ToLower(strings.TrimSpace(query.Get("tag"))),
+		})
+		if err != nil {
+			if errors.Is(err, quoteService.ErrNotFound) {
+				http.Error(w, "no quote matches the filter", http.StatusNotFound)
+				return
+			}
+
+			writeServiceError(w, r, "get random quote", err)
+			return
+		}
+
+		page, ok := renderEmbedPage(w, r, quote, theme, links.base(r))
+		if !ok {
+			return
+		}
+		w.Header().Set("Content-Security-Policy", embedPolicy)
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, err = w.Write(page)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to write response", slog.String("error", err.Error()))
+		}
+	}
+}
+
+// renderEmbedPage responds with status code 500 and reports false if the page fails to render.
+func renderEmbedPage(w http.ResponseWriter, r *http.Request, quote *quoteService.Quote, theme cards.Theme, base string) ([]byte, bool) {
+	view := newEmbedView(quote, theme, base, 0)
+	var page bytes.Buffer
+	err := embedTemplates.ExecuteTemplate(&page, "page", view)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to render embed", slog.String("error", err.Error()))
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, false
+	}
+
+	w.Header().Add("Link", oEmbedLink(view.URL, base))
+	return page.Bytes(), true
+}
+
+func oEmbedEndpoint(quoteURL, base string) string {
+	return base + "/oembed?" + url.Values{"url": {quoteURL}, "format": {"json"}}.Encode()
+}
+
+// oEmbedLink is the Link header announcing the oEmbed endpoint for the quote URL to consumers.
+func oEmbedLink(quoteURL, base string) string {
+	return "<" + oEmbedEndpoint(quoteURL, base) + `>; rel="alternate"; type="application/json+oembed"`
+}
+
+// withOEmbedLink adds the oEmbed Link header to the responses of GET /quotes/{id} with status code
+// 200, errors do not announce an embed.
+func withOEmbedLink(links Links, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(mux.Vars(r)["id"])
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		base := links.base(r)
+		next.ServeHTTP(&linkWriter{ResponseWriter: w, link: oEmbedLink(base+quoteLocation(id), base), vary: links.PublicURL == ""}, r)
+	})
+}
+
+// linkWriter adds the Link header to the response if its status code is 200.
+type linkWriter struct {
+	http.ResponseWriter
+	link string
+	// vary marks the response as varying by Host, as the link is built from it.
+	vary        bool
+	wroteHeader bool
+}
+
+func (w *linkWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if statusCode == http.StatusOK {
+			w.Header().Add("Link", w.link)
+			if w.vary {
+				w.Header().Add("Vary", "Host")
+			}
+		}
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *linkWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.ResponseWriter.Write(p)
+}
+
+type embedView struct {
+	Quote, Author string
+	// URL is the canonical URL of the quote.
+	URL                      string
+	OEmbedURL                string
+	Background, Text, Accent string
+	// MaxWidth bounds the width of the blockquote in pixels, zero leaves it unbounded.
+	MaxWidth int
+}
+
+func newEmbedView(quote *quoteService.Quote, theme cards.Theme, base string, maxWidth int) embedView {
+	quoteURL := base + quoteLocation(quote.ID)
+	return embedView{
+		Quote:      quote.Quote,
+		Author:     quote.Author,
+		URL:        quoteURL,
+		OEmbedURL:  oEmbedEndpoint(quoteURL, base),
+		Background: hexColor(theme.Background.R, theme.Background.G, theme.Background.B),
+		Text:       hexColor(theme.Text.R, theme.Text.G, theme.Text.B),
+		Accent:     hexColor(theme.Accent.R, theme.Accent.G, theme.Accent.B),
+		MaxWidth:   maxWidth,
+	}
+}
+
+func hexColor(r, g, b uint8) string {
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+}
+
+// embedTemplates escape the quotes, so the embeds hold no markup of their authors.
+var embedTemplates = template.Must(template.New("").Parse(`
+{{- define "quote" -}}
+<blockquote class="quote-embed" cite="{{.URL}}" style="margin:0;padding:16px 20px;border-left:4px solid {{.Accent}};background:{{.Background}};color:{{.Text}};font-family:Georgia,serif;{{if .MaxWidth}}max-width:{{.MaxWidth}}px;{{end}}">
+<p style="margin:0 0 12px;font-size:20px;line-height:1.4">“{{.Quote}}”</p>
+<footer style="color:{{.Accent}};font-size:15px">— <a href="{{.URL}}" target="_blank" rel="noopener" style="color:inherit">{{.Author}}</a></footer>
+</blockquote>
+{{- end -}}
+{{- define "page" -}}
+<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Quote by {{.Author}}</title>
+<link rel="alternate" type="application/json+oembed" href="{{.OEmbedURL}}">
+<style>html,body{margin:0;background:{{.Background}}}</style>
+</head>
+<body>
+{{template "quote" .}}
+</body>
+</html>
+{{end -}}
+`))
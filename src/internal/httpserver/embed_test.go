@@ -0,0 +1,294 @@
+package httpserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver/testhelpers"
+	"github.com/BernsteinMondy/quote-service/src/internal/service"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// markupQuoteService returns quotes holding markup.
+type markupQuoteService struct {
+	testhelpers.MockQuoteService
+}
+
+func (markupQuoteService) GetQuoteByID(_ context.Context, id uuid.UUID) (*service.Quote, error) {
+	return &service.Quote{ID: id, Author: "<b>author</b>", Quote: `<script>alert("quote")</script>`}, nil
+}
+
+const publicURLFixture = "https://quotes.example.com"
+
+func TestEmbedHandlers(t *testing.T) {
+	quoteURL := publicURLFixture + "/quotes/" + testhelpers.QuotesArrayFixture[0].ID.String()
+
+	type testCase struct {
+		name               string
+		service            httpserver.QuoteService
+		path               string
+		wantRespStatusCode int
+		wantContentType    string
+	}
+
+	testCases := []testCase{
+		{
+			name:               "oEmbed of a quote url results in status code 200",
+			path:               "/oembed?url=" + url.QueryEscape(quoteURL),
+			wantRespStatusCode: http.StatusOK,
+			wantContentType:    "application/json",
+		},
+		{
+			name:               "oEmbed of an embed url results in status code 200",
+			path:               "/oembed?format=json&theme=dark&url=" + url.QueryEscape(publicURLFixture+"/embed/"+testhelpers.QuotesArrayFixture[0].ID.String()),
+			wantRespStatusCode: http.StatusOK,
+			wantContentType:    "application/json",
+		},
+		{
+			name:               "oEmbed in xml format results in status code 501",
+			path:               "/oembed?format=xml&url=" + url.QueryEscape(quoteURL),
+			wantRespStatusCode: http.StatusNotImplemented,
+		},
+		{
+			name:               "oEmbed without url results in status code 400",
+			path:               "/oembed",
+			wantRespStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "oEmbed of a url on another host results in status code 404",
+			path:               "/oembed?url=" + url.QueryEscape("https://example.com/quotes/"+testhelpers.QuotesArrayFixture[0].ID.String()),
+			wantRespStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "oEmbed of an unknown quote results in status code 404",
+			service:            &testhelpers.MockQuoteService{RetError: service.ErrNotFound},
+			path:               "/oembed?url=" + url.QueryEscape(quoteURL),
+			wantRespStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "oEmbed with invalid maxwidth results in status code 400",
+			path:               "/oembed?maxwidth=wide&url=" + url.QueryEscape(quoteURL),
+			wantRespStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Embed page results in status code 200",
+			path:               "/embed/" + testhelpers.QuotesArrayFixture[0].ID.String() + "?theme=sepia",
+			wantRespStatusCode: http.StatusOK,
+			wantContentType:    "text/html; charset=utf-8",
+		},
+		{
+			name:               "Embed page with unknown theme results in status code 400",
+			path:               "/embed/" + testhelpers.QuotesArrayFixture[0].ID.String() + "?theme=neon",
+			wantRespStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Random embed page results in status code 200",
+			path:               "/embed/random?author=author-2",
+			wantRespStatusCode: http.StatusOK,
+			wantContentType:    "text/html; charset=utf-8",
+		},
+		{
+			name:               "Random embed page of an unknown author results in status code 404",
+			path:               "/embed/random?author=nobody",
+			wantRespStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "Service call ended with error results in status code 500",
+			service:            &testhelpers.MockQuoteService{RetError: errors.New("some error")},
+			path:               "/embed/" + testhelpers.QuotesArrayFixture[0].ID.String(),
+			wantRespStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		if tc.service == nil {
+			tc.service = &testhelpers.MockQuoteService{}
+		}
+		server := httptest.NewServer(httpserver.New(tc.service, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{
+			PublicURL: publicURLFixture,
+		}).Handler)
+
+		resp, err := server.Client().Get(server.URL + tc.path)
+		if err != nil {
+			t.Fatal("Failed to make test request", err)
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != tc.wantRespStatusCode {
+			t.Errorf("%s: got status code %d want %d", tc.name, resp.StatusCode, tc.wantRespStatusCode)
+		}
+		if tc.wantContentType != "" && resp.Header.Get("Content-Type") != tc.wantContentType {
+			t.Errorf("%s: got content type %s want %s", tc.name, resp.Header.Get("Content-Type"), tc.wantContentType)
+		}
+
+		server.Close()
+	}
+}
+
+func TestOEmbedHandler_SanitizesHTML(t *testing.T) {
+	server := httptest.NewServer(httpserver.New(&markupQuoteService{}, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{}).Handler)
+	defer server.Close()
+
+	quoteURL := server.URL + "/quotes/" + testhelpers.QuotesArrayFixture[0].ID.String()
+	resp, err := server.Client().Get(server.URL + "/oembed?maxwidth=300&url=" + url.QueryEscape(quoteURL))
+	if err != nil {
+		t.Fatal("Failed to make test request", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var body struct {
+		Type   string `json:"type"`
+		HTML   string `json:"html"`
+		Width  int    `json:"width"`
+		Height int    `json:"height"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		t.Fatal("Failed to decode response", err)
+	}
+	if body.Type != "rich" || body.Width != 300 || body.Height != httpserver.DefaultEmbedHeight {
+		t.Errorf("got response %+v want a rich embed of width 300", body)
+	}
+	if strings.Contains(body.HTML, "<script>") || strings.Contains(body.HTML, "<b>") {
+		t.Errorf("got html %s want the markup of the quote escaped", body.HTML)
+	}
+	if !strings.Contains(body.HTML, "&lt;script&gt;") || !strings.Contains(body.HTML, `href="`+quoteURL+`"`) {
+		t.Errorf("got html %s want the escaped quote linking to its url", body.HTML)
+	}
+}
+
+func TestEmbedQuoteHandler_Page(t *testing.T) {
+	server := httptest.NewServer(httpserver.New(&markupQuoteService{}, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{
+		PublicURL: publicURLFixture,
+	}).Handler)
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL + "/embed/" + testhelpers.QuotesArrayFixture[0].ID.String() + "?theme=dark")
+	if err != nil {
+		t.Fatal("Failed to make test request", err)
+	}
+	page, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		t.Fatal("Failed to read response", err)
+	}
+
+	if strings.Contains(string(page), "<script>") || !strings.Contains(string(page), "#111827") {
+		t.Errorf("got page %s want the escaped quote in the dark theme", page)
+	}
+	if !strings.Contains(string(page), `type="application/json+oembed"`) {
+		t.Errorf("got page %s want the oEmbed discovery link", page)
+	}
+	if csp := resp.Header.Get("Content-Security-Policy"); !strings.Contains(csp, "default-src 'none'") {
+		t.Errorf("got Content-Security-Policy %q want scripts forbidden", csp)
+	}
+}
+
+func TestGetQuoteHandler_OEmbedLink(t *testing.T) {
+	server := httptest.NewServer(httpserver.New(&testhelpers.MockQuoteService{}, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{
+		PublicURL: publicURLFixture,
+	}).Handler)
+	defer server.Close()
+
+	id := testhelpers.QuotesArrayFixture[0].ID.String()
+	resp, err := server.Client().Get(server.URL + "/quotes/" + id)
+	if err != nil {
+		t.Fatal("Failed to make test request", err)
+	}
+	_ = resp.Body.Close()
+
+	want := "<" + publicURLFixture + "/oembed?format=json&url=" + url.QueryEscape(publicURLFixture+"/quotes/"+id) + `>; rel="alternate"; type="application/json+oembed"`
+	if link := resp.Header.Get("Link"); link != want {
+		t.Errorf("got Link header %s want %s", link, want)
+	}
+}
+
+func TestGetQuoteHandler_OEmbedLinkOnSuccess(t *testing.T) {
+	server := httptest.NewServer(httpserver.New(&testhelpers.MockQuoteService{RetError: service.ErrNotFound}, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{}).Handler)
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL + "/quotes/" + testhelpers.QuotesArrayFixture[0].ID.String())
+	if err != nil {
+		t.Fatal("Failed to make test request", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("got status code %d want %d", resp.StatusCode, http.StatusNotFound)
+	}
+	if link := resp.Header.Get("Link"); link != "" {
+		t.Errorf("got Link header %s for a missing quote want none", link)
+	}
+}
+
+func TestEmbedHandlers_CacheControl(t *testing.T) {
+	id := testhelpers.QuotesArrayFixture[0].ID.String()
+
+	type testCase struct {
+		name             string
+		publicURL        string
+		path             string
+		wantCacheControl string
+		wantVaryHost     bool
+	}
+
+	testCases := []testCase{
+		{
+			name:             "Embed page with a public url is cached by shared caches",
+			publicURL:        publicURLFixture,
+			path:             "/embed/" + id,
+			wantCacheControl: "max-age=300",
+		},
+		{
+			name:             "Embed page with links built from the host is cached by the client only",
+			path:             "/embed/" + id,
+			wantCacheControl: "private, max-age=300",
+			wantVaryHost:     true,
+		},
+		{
+			name:             "oEmbed response with links built from the host is cached by the client only",
+			path:             "/oembed?url=" + url.QueryEscape("http://attacker.example.com/quotes/"+id),
+			wantCacheControl: "private, max-age=300",
+			wantVaryHost:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		server := httptest.NewServer(httpserver.New(&testhelpers.MockQuoteService{}, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{
+			PublicURL: tc.publicURL,
+		}).Handler)
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+tc.path, nil)
+		if err != nil {
+			t.Fatal("Failed to create request", err)
+		}
+		req.Host = "attacker.example.com"
+
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatal("Failed to make test request", err)
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: got status code %d want %d", tc.name, resp.StatusCode, http.StatusOK)
+		}
+		if got := resp.Header.Get("Cache-Control"); got != tc.wantCacheControl {
+			t.Errorf("%s: got Cache-Control %q want %q", tc.name, got, tc.wantCacheControl)
+		}
+		if got := slices.Contains(resp.Header.Values("Vary"), "Host"); got != tc.wantVaryHost {
+			t.Errorf("%s: got Vary %v want Host %t", tc.name, resp.Header.Values("Vary"), tc.wantVaryHost)
+		}
+
+		server.Close()
+	}
+}
@@ -0,0 +1,40 @@
+package httpserver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// writeCacheable writes the body with an ETag computed from it, so clients revalidating with
+// If-None-Match get status code 304 while the body is unchanged.
+func writeCacheable(w http.ResponseWriter, r *http.Request, body []byte, contentType, cacheControl string) {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", cacheControl)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	_, err := w.Write(body)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to write response", slog.String("error", err.Error()))
+	}
+}
+
+// etagMatches reports whether the If-None-Match header lists the ETag, weak ETags match too since
+// the comparison is weak for GET requests.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
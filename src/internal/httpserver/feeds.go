@@ -0,0 +1,148 @@
+package httpserver
+
+import (
+	"github.com/BernsteinMondy/quote-service/src/internal/feeds"
+	quoteService "github.com/BernsteinMondy/quote-service/src/internal/service"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultFeedSize is the number of quotes of a feed without the "limit" parameter.
+	DefaultFeedSize = 20
+	// feedMaxAge is how long clients and proxies may cache a feed without revalidating it.
+	feedMaxAge = "max-age=60"
+)
+
+// mapFeedHandlers mounts the /feeds endpoints, they are guarded like the read endpoints of /quotes.
+func mapFeedHandlers(router *mux.Router, service QuoteService, cfg Config) {
+	feedsGroup := router.PathPrefix("/feeds").Subrouter()
+	feedsGroup.Use(authenticate(cfg))
+
+	handler := guardRead(cfg, QuoteFeedHandler(service, linksFromConfig(cfg)))
+	feedsGroup.Handle("/quotes.{format:atom|rss|json}", handler).Methods("GET")
+	feedsGroup.Handle("/authors/{author}/quotes.{format:atom|rss|json}", handler).Methods("GET")
+	feedsGroup.Handle("/tags/{tag}/quotes.{format:atom|rss|json}", handler).Methods("GET")
+}
+
+// QuoteFeedHandler serves the recently added quotes as Atom, RSS or JSON Feed, narrowed by the
+// "author" or "tag" path variable. The feed links are absolute, see Links. Responses carry an ETag
+// computed from the feed, so clients revalidating with If-None-Match get status code 304 while the
+// feed is unchanged.
+func QuoteFeedHandler(service QuoteService, links Links) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		format, err := feeds.ParseFormat(vars["format"])
+		if err != nil {
+			http.Error(w, "unknown feed format", http.StatusNotFound)
+			return
+		}
+
+		filter := quoteService.RecentQuoteFilter{
+			Author: vars["author"],
+			Tag:    strings.ToLower(strings.TrimSpace(vars["tag"])),
+			Limit:  DefaultFeedSize,
+		}
+		if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
+			filter.Limit, err = strconv.Atoi(rawLimit)
+			if err != nil || filter.Limit < 1 || filter.Limit > quoteService.MaxRecentQuotes {
+				http.Error(w, "invalid \"limit\" parameter", http.StatusBadRequest)
+				return
+			}
+		}
+
+		quotes, err := service.ListRecentQuotes(r.Context(), filter)
+		if err != nil {
+			writeServiceError(w, r, "list recent quotes", err)
+			return
+		}
+
+		data, err := feeds.Build(format, feedMeta(links.base(r), r.URL, filter), quotes)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to build feed", slog.String("error", err.Error()))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		writeCacheable(w, r, data, format.ContentType(), links.cacheControl(w, feedMaxAge))
+	}
+}
+
+func feedMeta(baseURL string, requestURL *url.URL, filter quoteService.RecentQuoteFilter) feeds.Meta {
+	meta := feeds.Meta{
+		Title:       "Recently added quotes",
+		Description: "The quotes latest added to the quote service.",
+		FeedURL:     baseURL + requestURL.RequestURI(),
+		HomeURL:     baseURL + "/quotes",
+		QuoteURL: func(id uuid.UUID) string {
+			return baseURL + quoteLocation(id)
+		},
+	}
+	switch {
+	case filter.Author != "":
+		meta.Title = "Quotes by " + filter.Author
+		meta.Description = "The quotes by " + filter.Author + " latest added to the quote service."
+		meta.HomeURL += "?" + url.Values{"author": {filter.Author}}.Encode()
+	case filter.Tag != "":
+		meta.Title = "Quotes tagged " + filter.Tag
+		meta.Description = "The quotes tagged " + filter.Tag + " latest added to the quote service."
+	}
+	return meta
+}
+
+// Links configures the absolute links of the feeds and embeds back to the service, and the caching
+// of the responses holding them.
+type Links struct {
+	// PublicURL is the scheme and host the links start with. If it is empty, they start with the
+	// scheme and host the request was sent to, and the responses are only cached by the client, as a
+	// forged Host header would poison shared caches otherwise.
+	PublicURL string
+	// TrustForwardedFor trusts the X-Forwarded-Proto header for the scheme of the links, it is only
+	// used without a PublicURL.
+	TrustForwardedFor bool
+	// Private keeps the responses out of shared caches, e.g. as reading them requires authentication.
+	Private bool
+}
+
+func linksFromConfig(cfg Config) Links {
+	return Links{PublicURL: cfg.PublicURL, TrustForwardedFor: cfg.TrustForwardedFor, Private: cfg.RequireReadAuth}
+}
+
+// base returns PublicURL without a trailing slash, or the scheme and host the request was sent to if
+// it is empty.
+func (l Links) base(r *http.Request) string {
+	if l.PublicURL != "" {
+		return strings.TrimSuffix(l.PublicURL, "/")
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); l.TrustForwardedFor && (proto == "http" || proto == "https") {
+		scheme = proto
+	}
+
+	return scheme + "://" + r.Host
+}
+
+// cacheControl returns the Cache-Control header of a response holding links with the max-age
+// directive. The response varies by the headers the links are built from if there is no PublicURL.
+func (l Links) cacheControl(w http.ResponseWriter, maxAge string) string {
+	if l.PublicURL == "" {
+		w.Header().Add("Vary", "Host")
+		if l.TrustForwardedFor {
+			w.Header().Add("Vary", "X-Forwarded-Proto")
+		}
+	}
+	if l.PublicURL == "" || l.Private {
+		return "private, " + maxAge
+	}
+
+	return maxAge
+}
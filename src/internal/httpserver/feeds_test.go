@@ -0,0 +1,202 @@
+package httpserver_test
+
+import (
+	"errors"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver/testhelpers"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestQuoteFeedHandler(t *testing.T) {
+	type testCase struct {
+		name               string
+		service            httpserver.QuoteService
+		path               string
+		requireReadAuth    bool
+		apiKey             string
+		wantRespStatusCode int
+		wantContentType    string
+	}
+
+	testCases := []testCase{
+		{
+			name:               "Atom feed results in status code 200",
+			path:               "/feeds/quotes.atom",
+			wantRespStatusCode: http.StatusOK,
+			wantContentType:    "application/atom+xml; charset=utf-8",
+		},
+		{
+			name:               "RSS feed of an author results in status code 200",
+			path:               "/feeds/authors/author-1/quotes.rss",
+			wantRespStatusCode: http.StatusOK,
+			wantContentType:    "application/rss+xml; charset=utf-8",
+		},
+		{
+			name:               "JSON feed of a tag results in status code 200",
+			path:               "/feeds/tags/Life/quotes.json?limit=5",
+			wantRespStatusCode: http.StatusOK,
+			wantContentType:    "application/feed+json; charset=utf-8",
+		},
+		{
+			name:               "Unknown format results in status code 404",
+			path:               "/feeds/quotes.xml",
+			wantRespStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "Invalid limit results in status code 400",
+			path:               "/feeds/quotes.atom?limit=101",
+			wantRespStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Anonymous request with required read auth results in status code 401",
+			path:               "/feeds/quotes.atom",
+			requireReadAuth:    true,
+			wantRespStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Service call ended with error results in status code 500",
+			service:            &testhelpers.MockQuoteService{RetError: errors.New("some error")},
+			path:               "/feeds/quotes.json",
+			wantRespStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		if tc.service == nil {
+			tc.service = &testhelpers.MockQuoteService{}
+		}
+		server := httptest.NewServer(httpserver.New(tc.service, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{
+			Authenticator:   authenticatorFixture,
+			RequireReadAuth: tc.requireReadAuth,
+		}).Handler)
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+tc.path, nil)
+		if err != nil {
+			t.Fatal("Failed to create request", err)
+		}
+		if tc.apiKey != "" {
+			req.Header.Set("X-API-Key", tc.apiKey)
+		}
+
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatal("Failed to make test request", err)
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != tc.wantRespStatusCode {
+			t.Errorf("%s: got status code %d want %d", tc.name, resp.StatusCode, tc.wantRespStatusCode)
+		}
+		if tc.wantContentType != "" && resp.Header.Get("Content-Type") != tc.wantContentType {
+			t.Errorf("%s: got content type %s want %s", tc.name, resp.Header.Get("Content-Type"), tc.wantContentType)
+		}
+
+		server.Close()
+	}
+}
+
+func TestQuoteFeedHandler_ETag(t *testing.T) {
+	server := httptest.NewServer(httpserver.New(&testhelpers.MockQuoteService{}, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{
+		PublicURL: "https://quotes.example.com/",
+	}).Handler)
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL + "/feeds/quotes.atom")
+	if err != nil {
+		t.Fatal("Failed to make test request", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		t.Fatal("Failed to read response", err)
+	}
+
+	etag := resp.Header.Get("ETag")
+	if etag == "" || resp.Header.Get("Cache-Control") != "max-age=60" {
+		t.Fatalf("got ETag %q and Cache-Control %q want a cacheable response", etag, resp.Header.Get("Cache-Control"))
+	}
+	if want := "https://quotes.example.com/quotes/" + testhelpers.QuotesArrayFixture[0].ID.String(); !strings.Contains(string(body), want) {
+		t.Errorf("got feed %s want the links to start with the public url", body)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/feeds/quotes.atom", nil)
+	if err != nil {
+		t.Fatal("Failed to create request", err)
+	}
+	req.Header.Set("If-None-Match", `"other", W/`+etag)
+
+	resp, err = server.Client().Do(req)
+	if err != nil {
+		t.Fatal("Failed to make test request", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("got status code %d want %d for a matching ETag", resp.StatusCode, http.StatusNotModified)
+	}
+}
+
+func TestQuoteFeedHandler_CacheControl(t *testing.T) {
+	type testCase struct {
+		name             string
+		publicURL        string
+		requireReadAuth  bool
+		wantCacheControl string
+		wantVaryHost     bool
+	}
+
+	testCases := []testCase{
+		{
+			name:             "Public url is cached by shared caches",
+			publicURL:        "https://quotes.example.com",
+			wantCacheControl: "max-age=60",
+		},
+		{
+			name:             "Links built from the host are cached by the client only",
+			wantCacheControl: "private, max-age=60",
+			wantVaryHost:     true,
+		},
+		{
+			name:             "Required read auth is cached by the client only",
+			publicURL:        "https://quotes.example.com",
+			requireReadAuth:  true,
+			wantCacheControl: "private, max-age=60",
+		},
+	}
+
+	for _, tc := range testCases {
+		server := httptest.NewServer(httpserver.New(&testhelpers.MockQuoteService{}, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{
+			Authenticator:   authenticatorFixture,
+			RequireReadAuth: tc.requireReadAuth,
+			PublicURL:       tc.publicURL,
+		}).Handler)
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/feeds/quotes.atom", nil)
+		if err != nil {
+			t.Fatal("Failed to create request", err)
+		}
+		req.Header.Set("X-API-Key", "writer")
+		req.Host = "attacker.example.com"
+
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatal("Failed to make test request", err)
+		}
+		_ = resp.Body.Close()
+
+		if got := resp.Header.Get("Cache-Control"); got != tc.wantCacheControl {
+			t.Errorf("%s: got Cache-Control %q want %q", tc.name, got, tc.wantCacheControl)
+		}
+		if got := slices.Contains(resp.Header.Values("Vary"), "Host"); got != tc.wantVaryHost {
+			t.Errorf("%s: got Vary %v want Host %t", tc.name, resp.Header.Values("Vary"), tc.wantVaryHost)
+		}
+
+		server.Close()
+	}
+}
@@ -0,0 +1,68 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/BernsteinMondy/quote-service/src/internal/graphqlapi"
+	"github.com/BernsteinMondy/quote-service/src/internal/ratelimit"
+	"github.com/gorilla/mux"
+	"github.com/graphql-go/graphql"
+	"log/slog"
+	"net/http"
+)
+
+type GraphQLExecutor interface {
+	// Prepare returns the errors in a result if the request must not be executed.
+	Prepare(req graphqlapi.Request) (*graphqlapi.Operation, *graphql.Result)
+	Execute(ctx context.Context, op *graphqlapi.Operation) *graphql.Result
+}
+
+// mapGraphQLHandler mounts the executor at POST /graphql. Scopes are checked per operation by the
+// executor. Every request takes a read token before it is parsed, mutations take a write token too,
+// the executor rejects mutations writing more than one quote.
+func mapGraphQLHandler(router *mux.Router, executor GraphQLExecutor, cfg Config) {
+	h := limitBody(cfg.MaxBodyBytes, GraphQLHandler(executor, cfg.RateLimiter, cfg.TrustForwardedFor))
+	router.Handle("/graphql", authenticate(cfg)(h)).Methods("POST")
+}
+
+// GraphQLHandler responds with status code 400 to requests that could not be prepared and with
+// status code 200 to executed requests, their field errors are reported in the result. The read
+// token is taken before the request is decoded, so throttled clients can not spend the CPU of
+// parsing and validating large documents.
+func GraphQLHandler(executor GraphQLExecutor, limiter RateLimiter, trustForwardedFor bool) http.HandlerFunc {
+	prepare := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequestDTO
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+
+		op, res := executor.Prepare(graphqlapi.Request{
+			Query:         req.Query,
+			OperationName: req.OperationName,
+			Variables:     req.Variables,
+		})
+		if res != nil {
+			writeGraphQLResult(w, r, http.StatusBadRequest, res)
+			return
+		}
+
+		var execute http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeGraphQLResult(w, r, http.StatusOK, executor.Execute(r.Context(), op))
+		})
+		if op.IsMutation() {
+			execute = rateLimit(limiter, ratelimit.ClassWrite, trustForwardedFor, execute)
+		}
+		execute.ServeHTTP(w, r)
+	})
+
+	return rateLimit(limiter, ratelimit.ClassRead, trustForwardedFor, prepare).ServeHTTP
+}
+
+func writeGraphQLResult(w http.ResponseWriter, r *http.Request, statusCode int, res *graphql.Result) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	err := json.NewEncoder(w).Encode(res)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to encode response", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,184 @@
+package httpserver_test
+
+import (
+	"encoding/json"
+	"github.com/BernsteinMondy/quote-service/src/internal/graphqlapi"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver/testhelpers"
+	"github.com/BernsteinMondy/quote-service/src/internal/ratelimit"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGraphQLHandler(t *testing.T) {
+	executor, err := graphqlapi.New(&testhelpers.MockQuoteService{}, graphqlapi.Config{})
+	if err != nil {
+		t.Fatal("Failed to create executor", err)
+	}
+	limiter, err := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), map[ratelimit.Class]ratelimit.Limit{
+		ratelimit.ClassRead:  {Rate: 0.001, Burst: 100},
+		ratelimit.ClassWrite: {Rate: 0.001, Burst: 1},
+	})
+	if err != nil {
+		t.Fatal("Failed to create limiter", err)
+	}
+
+	server := httptest.NewServer(httpserver.New(&testhelpers.MockQuoteService{}, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{
+		Authenticator: authenticatorFixture,
+		RateLimiter:   limiter,
+		GraphQL:       executor,
+	}).Handler)
+	defer server.Close()
+
+	const createMutation = `{"query":"mutation { createQuote(input: {author: \"test author\", quote: \"test quote\", tags: [\"Test\"]}) { id tags } }"}`
+
+	type testCase struct {
+		name               string
+		body               string
+		contentType        string
+		apiKey             string
+		wantRespStatusCode int
+		wantErrorCode      string
+	}
+
+	testCases := []testCase{
+		{
+			name:               "Query results in status code 200",
+			body:               `{"query":"{ quotes(first: 2) { nodes { id quote author { name } } pageInfo { hasNextPage } } }"}`,
+			wantRespStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Syntax error results in status code 400",
+			body:               `{"query":"{ quotes("}`,
+			wantRespStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Unknown field results in status code 400",
+			body:               `{"query":"{ quotes { unknown } }"}`,
+			wantRespStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Query over the complexity limit results in status code 400",
+			body:               `{"query":"{ quotes(first: 100) { nodes { author { quotes(first: 100) { id } } } } }"}`,
+			wantRespStatusCode: http.StatusBadRequest,
+			wantErrorCode:      "LIMIT_EXCEEDED",
+		},
+		{
+			name:               "Mutation with aliased root fields results in status code 400",
+			body:               `{"query":"mutation { a: deleteQuote(id: \"1\") b: deleteQuote(id: \"2\") c: deleteQuote(id: \"3\") }"}`,
+			apiKey:             "writer",
+			wantRespStatusCode: http.StatusBadRequest,
+			wantErrorCode:      "LIMIT_EXCEEDED",
+		},
+		{
+			name:               "Non-JSON body results in status code 415",
+			body:               `{ quotes { nodes { id } } }`,
+			contentType:        "application/graphql",
+			wantRespStatusCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:               "Mutation without credentials results in an error with code UNAUTHENTICATED",
+			body:               createMutation,
+			wantRespStatusCode: http.StatusOK,
+			wantErrorCode:      "UNAUTHENTICATED",
+		},
+		{
+			name:               "Mutation with the write scope results in status code 200",
+			body:               createMutation,
+			apiKey:             "writer",
+			wantRespStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Mutation over the write budget results in status code 429",
+			body:               createMutation,
+			apiKey:             "writer",
+			wantRespStatusCode: http.StatusTooManyRequests,
+		},
+		{
+			name:               "Query has its own budget",
+			body:               `{"query":"{ randomQuote { id } }"}`,
+			apiKey:             "writer",
+			wantRespStatusCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/graphql", strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal("Failed to create request", err)
+		}
+		if tc.contentType != "" {
+			req.Header.Set("Content-Type", tc.contentType)
+		}
+		if tc.apiKey != "" {
+			req.Header.Set("X-API-Key", tc.apiKey)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal("Failed to send request", err)
+		}
+
+		var result struct {
+			Errors []struct {
+				Extensions map[string]any `json:"extensions"`
+			} `json:"errors"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&result)
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != tc.wantRespStatusCode {
+			t.Errorf("%s: got status code %d want %d", tc.name, resp.StatusCode, tc.wantRespStatusCode)
+		}
+		if tc.wantErrorCode != "" && (len(result.Errors) == 0 || result.Errors[0].Extensions["code"] != tc.wantErrorCode) {
+			t.Errorf("%s: got errors %+v want code %s", tc.name, result.Errors, tc.wantErrorCode)
+		}
+		if tc.wantErrorCode == "" && tc.wantRespStatusCode == http.StatusOK && len(result.Errors) > 0 {
+			t.Errorf("%s: got errors %+v want none", tc.name, result.Errors)
+		}
+	}
+}
+
+func TestGraphQLHandler_RateLimitBeforeParsing(t *testing.T) {
+	executor, err := graphqlapi.New(&testhelpers.MockQuoteService{}, graphqlapi.Config{})
+	if err != nil {
+		t.Fatal("Failed to create executor", err)
+	}
+	limiter, err := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), map[ratelimit.Class]ratelimit.Limit{
+		ratelimit.ClassRead: {Rate: 0.001, Burst: 1},
+	})
+	if err != nil {
+		t.Fatal("Failed to create limiter", err)
+	}
+
+	server := httptest.NewServer(httpserver.New(&testhelpers.MockQuoteService{}, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{
+		RateLimiter: limiter,
+		GraphQL:     executor,
+	}).Handler)
+	defer server.Close()
+
+	for i, wantRespStatusCode := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		resp, err := http.Post(server.URL+"/graphql", "application/json", strings.NewReader(`{"query":"{ randomQuote { id } }"}`))
+		if err != nil {
+			t.Fatal("Failed to send request", err)
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != wantRespStatusCode {
+			t.Errorf("request %d: got status code %d want %d", i, resp.StatusCode, wantRespStatusCode)
+		}
+	}
+
+	// The malformed document would result in status code 400 if it was parsed.
+	resp, err := http.Post(server.URL+"/graphql", "application/json", strings.NewReader(`{"query":"{ quotes("}`))
+	if err != nil {
+		t.Fatal("Failed to send request", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("malformed request: got status code %d want %d", resp.StatusCode, http.StatusTooManyRequests)
+	}
+}
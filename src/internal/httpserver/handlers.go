@@ -4,13 +4,18 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"github.com/BernsteinMondy/quote-service/src/internal/auth"
+	"github.com/BernsteinMondy/quote-service/src/internal/ratelimit"
 	quoteService "github.com/BernsteinMondy/quote-service/src/internal/service"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"log/slog"
 	"net/http"
 )
 
-func mapHandlers(router *mux.Router, service QuoteService) {
+// mapHandlers mounts the /quotes endpoints, the quote streams end when streamsDone is closed.
+func mapHandlers(router *mux.Router, service QuoteService, cfg Config, streamsDone <-chan struct{}) {
 	// The current endpoint structure follows the technical requirements, but in production
 	// systems it's strongly recommended to implement API versioning from the start.
 	//
@@ -25,16 +30,55 @@ func mapHandlers(router *mux.Router, service QuoteService) {
 	// Current implementation processes all requests directly, but adding caching
 	// (using Redis, Memcached or in-memory cache) could significantly improve performance.
 	quotesGroup := router.PathPrefix("/quotes").Subrouter()
-	quotesGroup.Handle("", PostQuoteHandler(service)).Methods("POST")
-	quotesGroup.Handle("", GetQuotesHandler(service)).Methods("GET")
-	quotesGroup.Handle("/random", GetRandomQuoteHandler(service)).Methods("GET")
-	quotesGroup.Handle("/{id}", DeleteQuoteHandler(service)).Methods("DELETE")
+	quotesGroup.Use(authenticate(cfg))
+
+	read := func(h http.Handler) http.Handler {
+		return guardRead(cfg, h)
+	}
+	write := func(scope auth.Scope, maxBodyBytes int64, h http.Handler) http.Handler {
+		h = requireScope(scope, limitBody(maxBodyBytes, h))
+		return rateLimit(cfg.RateLimiter, ratelimit.ClassWrite, cfg.TrustForwardedFor, h)
+	}
+	idempotentWrite := func(scope auth.Scope, maxBodyBytes int64, h http.Handler) http.Handler {
+		return write(scope, maxBodyBytes, idempotent(cfg.Idempotency, cfg.IdempotencyTTL, h))
+	}
+
+	quotesGroup.Handle("", idempotentWrite(auth.ScopeQuotesWrite, cfg.MaxBodyBytes, PostQuoteHandler(service))).Methods("POST")
+	quotesGroup.Handle("/import", idempotentWrite(auth.ScopeQuotesWrite, cfg.MaxImportBodyBytes, ImportQuotesHandler(service))).Methods("POST")
+	quotesGroup.Handle("", read(GetQuotesHandler(service))).Methods("GET")
+	quotesGroup.Handle("/random", read(GetRandomQuoteHandler(service))).Methods("GET")
+	if cfg.Rotation != nil {
+		quotesGroup.Handle("/rotation", read(QuoteRotationHandler(service, cfg.Rotation, cfg.CORS))).Methods("GET")
+	}
+	if cfg.Events != nil {
+		quotesGroup.Handle("/stream", read(StreamQuotesHandler(cfg.Events, cfg.StreamHeartbeat, streamsDone))).Methods("GET")
+	}
+	if cfg.Cards != nil {
+		quotesGroup.Handle("/{id}/card.{format:svg|png}", read(QuoteCardHandler(service, cfg.Cards))).Methods("GET")
+	}
+	quotesGroup.Handle("/{id}", read(withOEmbedLink(linksFromConfig(cfg), GetQuoteHandler(service)))).Methods("GET")
+	quotesGroup.Handle("/{id}", write(auth.ScopeQuotesWrite, cfg.MaxBodyBytes, PutQuoteHandler(service))).Methods("PUT")
+	quotesGroup.Handle("/{id}", write(auth.ScopeQuotesDelete, cfg.MaxBodyBytes, DeleteQuoteHandler(service))).Methods("DELETE")
+}
+
+// guardRead requires the "quotes:read" scope if the config requires it and takes read tokens
+// from the rate limit.
+func guardRead(cfg Config, h http.Handler) http.Handler {
+	if cfg.RequireReadAuth {
+		h = requireScope(auth.ScopeQuotesRead, h)
+	}
+	return rateLimit(cfg.RateLimiter, ratelimit.ClassRead, cfg.TrustForwardedFor, h)
 }
 
 type QuoteService interface {
-	CreateNewQuote(ctx context.Context, author, quote string) error
+	CreateNewQuote(ctx context.Context, id uuid.UUID, author, quote string) (*quoteService.Quote, error)
+	ImportQuotes(ctx context.Context, quotes []quoteService.Quote) ([]quoteService.Quote, error)
+	GetQuoteByID(ctx context.Context, id uuid.UUID) (*quoteService.Quote, error)
 	GetQuotesWithFilter(ctx context.Context, author string) ([]quoteService.Quote, error)
 	GetRandomQuote(ctx context.Context) (*quoteService.Quote, error)
+	GetRandomQuoteWithFilter(ctx context.Context, filter quoteService.RandomQuoteFilter) (*quoteService.Quote, error)
+	ListRecentQuotes(ctx context.Context, filter quoteService.RecentQuoteFilter) ([]quoteService.Quote, error)
+	UpdateQuote(ctx context.Context, id uuid.UUID, author, quote string) (*quoteService.Quote, error)
 	DeleteQuoteByID(ctx context.Context, id uuid.UUID) error
 }
 
@@ -42,31 +86,137 @@ func PostQuoteHandler(service QuoteService) http.HandlerFunc {
 	type request = quoteCreateDTO
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req request
-		err := json.NewDecoder(r.Body).Decode(&req)
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+
+		id, err := parseClientID(req.ID)
+		if err != nil {
+			http.Error(w, "invalid \"id\" request field", http.StatusBadRequest)
+			return
+		}
+
+		quote, err := service.CreateNewQuote(r.Context(), id, req.Author, req.Quote)
+		if err != nil {
+			if writeValidationError(w, err) {
+				return
+			}
+			if errors.Is(err, quoteService.ErrAlreadyExists) {
+				http.Error(w, "quote with the id already exists", http.StatusConflict)
+				return
+			}
+
+			writeServiceError(w, r, "create new quote", err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", quoteLocation(quote.ID))
+		w.WriteHeader(http.StatusCreated)
+		err = json.NewEncoder(w).Encode(quoteFromDomainToReadDTO(quote))
 		if err != nil {
-			http.Error(w, "failed to parse request body", http.StatusBadRequest)
+			slog.ErrorContext(r.Context(), "Failed to encode response", slog.String("error", err.Error()))
+		}
+	}
+}
+
+// writeValidationError responds with the field errors and status code 400 if err is a
+// *service.ValidationError, it reports whether it did.
+func writeValidationError(w http.ResponseWriter, err error) bool {
+	var verr *quoteService.ValidationError
+	if !errors.As(err, &verr) {
+		return false
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(validationErrorFromDomainToDTO(verr))
+	return true
+}
+
+// parseClientID returns uuid.Nil for an empty ID, the service generates the ID then.
+func parseClientID(raw string) (uuid.UUID, error) {
+	if raw == "" {
+		return uuid.Nil, nil
+	}
+
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if id == uuid.Nil {
+		return uuid.Nil, errors.New("nil uuid")
+	}
+
+	return id, nil
+}
+
+func quoteLocation(id uuid.UUID) string {
+	return "/quotes/" + id.String()
+}
+
+// maxImportQuotes bounds the quotes of one import, they are created in a single transaction.
+const maxImportQuotes = 1000
+
+func ImportQuotesHandler(service QuoteService) http.HandlerFunc {
+	type request struct {
+		Quotes []quoteCreateDTO `json:"quotes"`
+	}
+	type response struct {
+		Quotes []quoteReadDTO `json:"quotes"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 
-		if len(req.Quote) == 0 {
-			http.Error(w, "\"quote\" request field can not be empty", http.StatusBadRequest)
+		if len(req.Quotes) == 0 {
+			http.Error(w, "\"quotes\" request field can not be empty", http.StatusBadRequest)
+			return
 		}
-		if len(req.Author) == 0 {
-			http.Error(w, "\"author\" request field can not be empty", http.StatusBadRequest)
+		if len(req.Quotes) > maxImportQuotes {
+			http.Error(w, fmt.Sprintf("\"quotes\" request field can not hold more than %d quotes", maxImportQuotes), http.StatusBadRequest)
+			return
+		}
+
+		quotes := make([]quoteService.Quote, len(req.Quotes))
+		for i, quote := range req.Quotes {
+			id, err := parseClientID(quote.ID)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid \"quotes[%d].id\" request field", i), http.StatusBadRequest)
+				return
+			}
+			quotes[i] = quoteService.Quote{ID: id, Author: quote.Author, Quote: quote.Quote}
 		}
 
-		err = service.CreateNewQuote(r.Context(), req.Author, req.Quote)
+		created, err := service.ImportQuotes(r.Context(), quotes)
 		if err != nil {
+			if writeValidationError(w, err) {
+				return
+			}
 			if errors.Is(err, quoteService.ErrAlreadyExists) {
 				w.WriteHeader(http.StatusConflict)
 				return
 			}
 
-			http.Error(w, "service: create new quote", http.StatusInternalServerError)
+			writeServiceError(w, r, "import quotes", err)
 			return
 		}
 
+		resp := response{
+			Quotes: make([]quoteReadDTO, len(created)),
+		}
+		for i, quote := range created {
+			resp.Quotes[i] = quoteFromDomainToReadDTO(&quote)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
+		err = json.NewEncoder(w).Encode(resp)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to encode response", slog.String("error", err.Error()))
+		}
 	}
 }
 
@@ -79,7 +229,7 @@ func GetQuotesHandler(service QuoteService) http.HandlerFunc {
 
 		quotes, err := service.GetQuotesWithFilter(r.Context(), authorFilter)
 		if err != nil {
-			http.Error(w, "service: get quotes: "+err.Error(), http.StatusInternalServerError)
+			writeServiceError(w, r, "get quotes", err)
 			return
 		}
 
@@ -99,11 +249,37 @@ func GetQuotesHandler(service QuoteService) http.HandlerFunc {
 	}
 }
 
+func GetQuoteHandler(service QuoteService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "invalid \"id\" parameter", http.StatusBadRequest)
+			return
+		}
+
+		quote, err := service.GetQuoteByID(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, quoteService.ErrNotFound) {
+				http.Error(w, "quote not found", http.StatusNotFound)
+				return
+			}
+
+			writeServiceError(w, r, "get quote by id", err)
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(quoteFromDomainToReadDTO(quote))
+		if err != nil {
+			http.Error(w, "failed to encode response: "+err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
 func GetRandomQuoteHandler(service QuoteService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		quote, err := service.GetRandomQuote(r.Context())
 		if err != nil {
-			http.Error(w, "service: get random quote: "+err.Error(), http.StatusInternalServerError)
+			writeServiceError(w, r, "get random quote", err)
 			return
 		}
 
@@ -113,8 +289,43 @@ func GetRandomQuoteHandler(service QuoteService) http.HandlerFunc {
 		if err != nil {
 			http.Error(w, "failed to encode response: "+err.Error(), http.StatusInternalServerError)
 		}
+	}
+}
 
-		w.WriteHeader(http.StatusOK)
+func PutQuoteHandler(service QuoteService) http.HandlerFunc {
+	type request = quoteUpdateDTO
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := uuid.Parse(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "invalid \"id\" parameter", http.StatusBadRequest)
+			return
+		}
+
+		var req request
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+
+		quote, err := service.UpdateQuote(r.Context(), id, req.Author, req.Quote)
+		if err != nil {
+			if writeValidationError(w, err) {
+				return
+			}
+			switch {
+			case errors.Is(err, quoteService.ErrNotFound):
+				http.Error(w, "quote not found", http.StatusNotFound)
+			case errors.Is(err, quoteService.ErrForbidden):
+				http.Error(w, "quote can only be modified by its creator or a moderator", http.StatusForbidden)
+			default:
+				writeServiceError(w, r, "update quote", err)
+			}
+			return
+		}
+
+		err = json.NewEncoder(w).Encode(quoteFromDomainToReadDTO(quote))
+		if err != nil {
+			http.Error(w, "failed to encode response: "+err.Error(), http.StatusInternalServerError)
+		}
 	}
 }
 
@@ -134,7 +345,16 @@ func DeleteQuoteHandler(service QuoteService) http.HandlerFunc {
 
 		err = service.DeleteQuoteByID(r.Context(), id)
 		if err != nil {
-			http.Error(w, "service: delete quote by id: "+err.Error(), http.StatusInternalServerError)
+			if errors.Is(err, quoteService.ErrNotFound) {
+				http.Error(w, "quote not found", http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, quoteService.ErrForbidden) {
+				http.Error(w, "quote can only be deleted by its creator or a moderator", http.StatusForbidden)
+				return
+			}
+
+			writeServiceError(w, r, "delete quote by id", err)
 			return
 		}
 
@@ -2,7 +2,9 @@ package httpserver_test
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"github.com/BernsteinMondy/quote-service/src/internal/httpserver"
 	"github.com/BernsteinMondy/quote-service/src/internal/httpserver/testhelpers"
 	"github.com/BernsteinMondy/quote-service/src/internal/service"
@@ -40,6 +42,18 @@ func TestDeleteQuoteHandler(t *testing.T) {
 			wantRespStatusCode: http.StatusBadRequest,
 			quoteID:            "non-uuid",
 		},
+		{
+			name:               "service.ErrNotFound error returned from Service results in status code 404",
+			service:            &testhelpers.MockQuoteService{RetError: service.ErrNotFound},
+			wantRespStatusCode: http.StatusNotFound,
+			quoteID:            "4937a248-cb08-46de-8789-493904914cc6",
+		},
+		{
+			name:               "service.ErrForbidden error returned from Service results in status code 403",
+			service:            &testhelpers.MockQuoteService{RetError: service.ErrForbidden},
+			wantRespStatusCode: http.StatusForbidden,
+			quoteID:            "4937a248-cb08-46de-8789-493904914cc6",
+		},
 		{
 			name:               "Service call ended with error results in status code 500",
 			service:            &testhelpers.MockQuoteService{RetError: errors.New("some error")},
@@ -77,6 +91,7 @@ func TestPostQuoteHandler(t *testing.T) {
 		service            httpserver.QuoteService
 		wantRespStatusCode int
 		body               []byte
+		contentType        string
 	}
 
 	testCases := []testCase{
@@ -86,12 +101,50 @@ func TestPostQuoteHandler(t *testing.T) {
 			wantRespStatusCode: http.StatusCreated,
 			body:               []byte(`{"author":"test author","quote":"test quote"}`),
 		},
+		{
+			name:               "Client supplied ID is accepted",
+			service:            &testhelpers.MockQuoteService{},
+			wantRespStatusCode: http.StatusCreated,
+			body:               []byte(`{"id":"4937a248-cb08-46de-8789-493904914cc6","author":"test author","quote":"test quote"}`),
+		},
+		{
+			name:               "Non-uuid client supplied ID results in status code 400",
+			service:            &testhelpers.MockQuoteService{},
+			wantRespStatusCode: http.StatusBadRequest,
+			body:               []byte(`{"id":"non-uuid","author":"test author","quote":"test quote"}`),
+		},
 		{
 			name:               "Invalid request body results in status code 400",
 			service:            &testhelpers.MockQuoteService{},
 			wantRespStatusCode: http.StatusBadRequest,
 			body:               []byte(`????WHAT????{"author":"test author","quote":"test quote"}`),
 		},
+		{
+			name:               "Unknown request field results in status code 400",
+			service:            &testhelpers.MockQuoteService{},
+			wantRespStatusCode: http.StatusBadRequest,
+			body:               []byte(`{"author":"test author","quote":"test quote","year":1900}`),
+		},
+		{
+			name:               "Data after the request object results in status code 400",
+			service:            &testhelpers.MockQuoteService{},
+			wantRespStatusCode: http.StatusBadRequest,
+			body:               []byte(`{"author":"test author","quote":"test quote"}{"author":"test author","quote":"test quote"}`),
+		},
+		{
+			name:               "JSON Content-Type with parameters is accepted",
+			service:            &testhelpers.MockQuoteService{},
+			wantRespStatusCode: http.StatusCreated,
+			body:               []byte(`{"author":"test author","quote":"test quote"}`),
+			contentType:        "application/json; charset=utf-8",
+		},
+		{
+			name:               "Non-JSON Content-Type results in status code 415",
+			service:            &testhelpers.MockQuoteService{},
+			wantRespStatusCode: http.StatusUnsupportedMediaType,
+			body:               []byte(`author=test+author&quote=test+quote`),
+			contentType:        "application/x-www-form-urlencoded",
+		},
 		{
 			name:               "Empty \"author\" request field results in status code 400",
 			service:            &testhelpers.MockQuoteService{},
@@ -116,6 +169,12 @@ func TestPostQuoteHandler(t *testing.T) {
 			wantRespStatusCode: http.StatusInternalServerError,
 			body:               []byte(`{"author":"test author","quote":"test quote"}`),
 		},
+		{
+			name:               "Service call exceeding the deadline results in status code 503",
+			service:            &testhelpers.MockQuoteService{RetError: fmt.Errorf("quote repository: %w", context.DeadlineExceeded)},
+			wantRespStatusCode: http.StatusServiceUnavailable,
+			body:               []byte(`{"author":"test author","quote":"test quote"}`),
+		},
 	}
 
 	for _, tc := range testCases {
@@ -128,6 +187,9 @@ func TestPostQuoteHandler(t *testing.T) {
 		if err != nil {
 			t.Fatal("Failed to create request", err)
 		}
+		if tc.contentType != "" {
+			req.Header.Set("Content-Type", tc.contentType)
+		}
 
 		resp, err := server.Client().Do(req)
 		if err != nil {
@@ -140,6 +202,257 @@ func TestPostQuoteHandler(t *testing.T) {
 	}
 }
 
+func TestPostQuoteHandler_ReturnsCreatedQuote(t *testing.T) {
+	router := mux.NewRouter()
+	router.Handle("/quotes", httpserver.PostQuoteHandler(&testhelpers.MockQuoteService{})).Methods("POST")
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	body := []byte(`{"id":"4937a248-cb08-46de-8789-493904914cc6","author":"test author","quote":"test quote"}`)
+	resp, err := server.Client().Post(server.URL+"/quotes", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal("Failed to make test request", err)
+	}
+
+	if got := resp.Header.Get("Location"); got != "/quotes/4937a248-cb08-46de-8789-493904914cc6" {
+		t.Errorf("PostQuoteHandler returned Location %q", got)
+	}
+
+	got, err := testhelpers.ParseResponseBody[map[string]string](resp)
+	if err != nil {
+		t.Fatal("Failed to parse response", err)
+	}
+	want := map[string]string{"id": "4937a248-cb08-46de-8789-493904914cc6", "author": "test author", "quote": "test quote"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PostQuoteHandler returned body %v want %v", got, want)
+	}
+}
+
+func TestPostQuoteHandler_ReturnsFieldErrors(t *testing.T) {
+	router := mux.NewRouter()
+	router.Handle("/quotes", httpserver.PostQuoteHandler(&testhelpers.MockQuoteService{})).Methods("POST")
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	body := []byte(`{"author":"","quote":"   "}`)
+	resp, err := server.Client().Post(server.URL+"/quotes", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal("Failed to make test request", err)
+	}
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("PostQuoteHandler returned wrong status code: got %d want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	type fieldError struct {
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	}
+	got, err := testhelpers.ParseResponseBody[struct {
+		Fields []fieldError `json:"fields"`
+	}](resp)
+	if err != nil {
+		t.Fatal("Failed to parse response", err)
+	}
+	want := []fieldError{{Field: "author", Message: "must not be empty"}, {Field: "quote", Message: "must not be empty"}}
+	if !reflect.DeepEqual(got.Fields, want) {
+		t.Errorf("PostQuoteHandler returned fields %v want %v", got.Fields, want)
+	}
+}
+
+func TestGetQuoteHandler(t *testing.T) {
+	type testCase struct {
+		name               string
+		service            httpserver.QuoteService
+		wantRespStatusCode int
+		quoteID            string
+	}
+
+	testCases := []testCase{
+		{
+			name:               "Smoke test",
+			service:            &testhelpers.MockQuoteService{},
+			wantRespStatusCode: http.StatusOK,
+			quoteID:            "4937a248-cb08-46de-8789-493904914cc6",
+		},
+		{
+			name:               "Non-uuid quote id results in status code 400",
+			service:            &testhelpers.MockQuoteService{},
+			wantRespStatusCode: http.StatusBadRequest,
+			quoteID:            "non-uuid",
+		},
+		{
+			name:               "service.ErrNotFound error returned from Service results in status code 404",
+			service:            &testhelpers.MockQuoteService{RetError: service.ErrNotFound},
+			wantRespStatusCode: http.StatusNotFound,
+			quoteID:            "4937a248-cb08-46de-8789-493904914cc6",
+		},
+		{
+			name:               "Service call ended with error results in status code 500",
+			service:            &testhelpers.MockQuoteService{RetError: errors.New("some error")},
+			wantRespStatusCode: http.StatusInternalServerError,
+			quoteID:            "4937a248-cb08-46de-8789-493904914cc6",
+		},
+	}
+
+	for _, tc := range testCases {
+		router := mux.NewRouter()
+		router.Handle("/{id}", httpserver.GetQuoteHandler(tc.service)).Methods("GET")
+
+		server := httptest.NewServer(router)
+
+		resp, err := server.Client().Get(server.URL + "/" + tc.quoteID)
+		if err != nil {
+			t.Fatal("Failed to make test request", err)
+		}
+
+		if resp.StatusCode != tc.wantRespStatusCode {
+			t.Errorf("%s: GetQuoteHandler returned wrong status code: got %d want %d", tc.name, resp.StatusCode, tc.wantRespStatusCode)
+		}
+	}
+}
+
+func TestPutQuoteHandler(t *testing.T) {
+	type testCase struct {
+		name               string
+		service            httpserver.QuoteService
+		wantRespStatusCode int
+		quoteID            string
+		body               []byte
+	}
+
+	testCases := []testCase{
+		{
+			name:               "Smoke test",
+			service:            &testhelpers.MockQuoteService{},
+			wantRespStatusCode: http.StatusOK,
+			quoteID:            "4937a248-cb08-46de-8789-493904914cc6",
+			body:               []byte(`{"author":"test author","quote":"test quote"}`),
+		},
+		{
+			name:               "Non-uuid quote id results in status code 400",
+			service:            &testhelpers.MockQuoteService{},
+			wantRespStatusCode: http.StatusBadRequest,
+			quoteID:            "non-uuid",
+			body:               []byte(`{"author":"test author","quote":"test quote"}`),
+		},
+		{
+			name:               "Empty \"quote\" request field results in status code 400",
+			service:            &testhelpers.MockQuoteService{},
+			wantRespStatusCode: http.StatusBadRequest,
+			quoteID:            "4937a248-cb08-46de-8789-493904914cc6",
+			body:               []byte(`{"author":"test author","quote":""}`),
+		},
+		{
+			name:               "service.ErrNotFound error returned from Service results in status code 404",
+			service:            &testhelpers.MockQuoteService{RetError: service.ErrNotFound},
+			wantRespStatusCode: http.StatusNotFound,
+			quoteID:            "4937a248-cb08-46de-8789-493904914cc6",
+			body:               []byte(`{"author":"test author","quote":"test quote"}`),
+		},
+		{
+			name:               "service.ErrForbidden error returned from Service results in status code 403",
+			service:            &testhelpers.MockQuoteService{RetError: service.ErrForbidden},
+			wantRespStatusCode: http.StatusForbidden,
+			quoteID:            "4937a248-cb08-46de-8789-493904914cc6",
+			body:               []byte(`{"author":"test author","quote":"test quote"}`),
+		},
+		{
+			name:               "Service call ended with error results in status code 500",
+			service:            &testhelpers.MockQuoteService{RetError: errors.New("some error")},
+			wantRespStatusCode: http.StatusInternalServerError,
+			quoteID:            "4937a248-cb08-46de-8789-493904914cc6",
+			body:               []byte(`{"author":"test author","quote":"test quote"}`),
+		},
+	}
+
+	for _, tc := range testCases {
+		router := mux.NewRouter()
+		router.Handle("/{id}", httpserver.PutQuoteHandler(tc.service)).Methods("PUT")
+
+		server := httptest.NewServer(router)
+
+		req, err := http.NewRequest(http.MethodPut, server.URL+"/"+tc.quoteID, bytes.NewReader(tc.body))
+		if err != nil {
+			t.Fatal("Failed to create request", err)
+		}
+
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatal("Failed to make test request", err)
+		}
+
+		if resp.StatusCode != tc.wantRespStatusCode {
+			t.Errorf("%s: PutQuoteHandler returned wrong status code: got %d want %d", tc.name, resp.StatusCode, tc.wantRespStatusCode)
+		}
+	}
+}
+
+func TestImportQuotesHandler(t *testing.T) {
+	type testCase struct {
+		name               string
+		service            httpserver.QuoteService
+		wantRespStatusCode int
+		body               []byte
+	}
+
+	testCases := []testCase{
+		{
+			name:               "Smoke test",
+			service:            &testhelpers.MockQuoteService{},
+			wantRespStatusCode: http.StatusCreated,
+			body:               []byte(`{"quotes":[{"author":"author-1","quote":"quote-1"},{"author":"author-2","quote":"quote-2"}]}`),
+		},
+		{
+			name:               "Empty \"quotes\" request field results in status code 400",
+			service:            &testhelpers.MockQuoteService{},
+			wantRespStatusCode: http.StatusBadRequest,
+			body:               []byte(`{"quotes":[]}`),
+		},
+		{
+			name:               "Empty \"author\" request field of a quote results in status code 400",
+			service:            &testhelpers.MockQuoteService{},
+			wantRespStatusCode: http.StatusBadRequest,
+			body:               []byte(`{"quotes":[{"author":"author-1","quote":"quote-1"},{"author":"","quote":"quote-2"}]}`),
+		},
+		{
+			name:               "service.ErrAlreadyExists error returned from Service results in status code 409",
+			service:            &testhelpers.MockQuoteService{RetError: service.ErrAlreadyExists},
+			wantRespStatusCode: http.StatusConflict,
+			body:               []byte(`{"quotes":[{"author":"author-1","quote":"quote-1"}]}`),
+		},
+		{
+			name:               "Service call ended with error results in status code 500",
+			service:            &testhelpers.MockQuoteService{RetError: errors.New("some error")},
+			wantRespStatusCode: http.StatusInternalServerError,
+			body:               []byte(`{"quotes":[{"author":"author-1","quote":"quote-1"}]}`),
+		},
+	}
+
+	for _, tc := range testCases {
+		router := mux.NewRouter()
+		router.Handle("/import", httpserver.ImportQuotesHandler(tc.service)).Methods("POST")
+
+		server := httptest.NewServer(router)
+
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/import", bytes.NewReader(tc.body))
+		if err != nil {
+			t.Fatal("Failed to create request", err)
+		}
+
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatal("Failed to make test request", err)
+		}
+
+		if resp.StatusCode != tc.wantRespStatusCode {
+			t.Errorf("%s: ImportQuotesHandler returned wrong status code: got %d want %d", tc.name, resp.StatusCode, tc.wantRespStatusCode)
+		}
+	}
+}
+
 func TestGetQuotesHandler(t *testing.T) {
 	type (
 		quote struct {
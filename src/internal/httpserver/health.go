@@ -0,0 +1,76 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"github.com/BernsteinMondy/quote-service/src/internal/health"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+const healthTokenHeader = "X-Health-Token"
+
+type HealthChecker interface {
+	Started() bool
+	Ready(ctx context.Context) health.Report
+}
+
+func mapHealthHandlers(router *mux.Router, checker HealthChecker, token string) {
+	router.Handle("/healthz", LivenessHandler()).Methods("GET")
+	router.Handle("/readyz", ReadinessHandler(checker, token)).Methods("GET")
+	router.Handle("/startupz", StartupHandler(checker)).Methods("GET")
+}
+
+// LivenessHandler reports that the process is able to serve HTTP requests at all.
+func LivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeHealthReport(w, health.Report{Status: health.StatusOK})
+	}
+}
+
+// ReadinessHandler runs the readiness checks. The per-check breakdown is only included
+// when the "verbose" query parameter is set and the request carries a valid token,
+// an empty token disables the breakdown completely.
+func ReadinessHandler(checker HealthChecker, token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := checker.Ready(r.Context())
+
+		if !r.URL.Query().Has("verbose") || !validHealthToken(r, token) {
+			report.Checks = nil
+		}
+
+		writeHealthReport(w, report)
+	}
+}
+
+// StartupHandler reports whether the initialization of the process is complete.
+func StartupHandler(checker HealthChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := health.Report{Status: health.StatusOK}
+		if !checker.Started() {
+			report.Status = health.StatusFail
+		}
+
+		writeHealthReport(w, report)
+	}
+}
+
+func validHealthToken(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+
+	got := r.Header.Get(healthTokenHeader)
+	return subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+func writeHealthReport(w http.ResponseWriter, report health.Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status != health.StatusOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	_ = json.NewEncoder(w).Encode(report)
+}
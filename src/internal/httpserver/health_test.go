@@ -0,0 +1,133 @@
+package httpserver_test
+
+import (
+	"github.com/BernsteinMondy/quote-service/src/internal/health"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver/testhelpers"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+var failedReportFixture = health.Report{
+	Status: health.StatusFail,
+	Checks: []health.CheckResult{
+		{Name: "database", Status: health.StatusFail, Error: "connection refused"},
+	},
+}
+
+func TestReadinessHandler(t *testing.T) {
+	const token = "secret"
+
+	type testCase struct {
+		name               string
+		checker            httpserver.HealthChecker
+		queryParams        string
+		token              string
+		wantRespStatusCode int
+		wantRespBody       health.Report
+	}
+
+	testCases := []testCase{
+		{
+			name:               "Smoke test",
+			checker:            &testhelpers.MockHealthChecker{Report: health.Report{Status: health.StatusOK}},
+			wantRespStatusCode: http.StatusOK,
+			wantRespBody:       health.Report{Status: health.StatusOK},
+		},
+		{
+			name:               "Failed check results in status code 503 without breakdown",
+			checker:            &testhelpers.MockHealthChecker{Report: failedReportFixture},
+			queryParams:        "verbose",
+			wantRespStatusCode: http.StatusServiceUnavailable,
+			wantRespBody:       health.Report{Status: health.StatusFail},
+		},
+		{
+			name:               "Invalid token results in report without breakdown",
+			checker:            &testhelpers.MockHealthChecker{Report: failedReportFixture},
+			queryParams:        "verbose",
+			token:              "wrong",
+			wantRespStatusCode: http.StatusServiceUnavailable,
+			wantRespBody:       health.Report{Status: health.StatusFail},
+		},
+		{
+			name:               "Valid token results in report with breakdown",
+			checker:            &testhelpers.MockHealthChecker{Report: failedReportFixture},
+			queryParams:        "verbose",
+			token:              token,
+			wantRespStatusCode: http.StatusServiceUnavailable,
+			wantRespBody:       failedReportFixture,
+		},
+	}
+
+	for _, tc := range testCases {
+		router := mux.NewRouter()
+		router.Handle("/", httpserver.ReadinessHandler(tc.checker, token)).Methods("GET")
+
+		server := httptest.NewServer(router)
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/?"+tc.queryParams, http.NoBody)
+		if err != nil {
+			t.Fatal("Failed to create request", err)
+		}
+		if tc.token != "" {
+			req.Header.Set("X-Health-Token", tc.token)
+		}
+
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatal("Failed to make test request", err)
+		}
+
+		if resp.StatusCode != tc.wantRespStatusCode {
+			t.Errorf("ReadinessHandler returned wrong status code: got %d want %d", resp.StatusCode, tc.wantRespStatusCode)
+		}
+
+		gotResp, err := testhelpers.ParseResponseBody[health.Report](resp)
+		if err != nil {
+			t.Fatalf("Error parsing response body: %v", err)
+		}
+		if !reflect.DeepEqual(tc.wantRespBody, gotResp) {
+			t.Fatalf("Did not get desired response body: got %v want %v", gotResp, tc.wantRespBody)
+		}
+	}
+}
+
+func TestStartupHandler(t *testing.T) {
+	type testCase struct {
+		name               string
+		checker            httpserver.HealthChecker
+		wantRespStatusCode int
+	}
+
+	testCases := []testCase{
+		{
+			name:               "Smoke test",
+			checker:            &testhelpers.MockHealthChecker{},
+			wantRespStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Not started process results in status code 503",
+			checker:            &testhelpers.MockHealthChecker{NotStarted: true},
+			wantRespStatusCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		router := mux.NewRouter()
+		router.Handle("/", httpserver.StartupHandler(tc.checker)).Methods("GET")
+
+		server := httptest.NewServer(router)
+
+		resp, err := server.Client().Get(server.URL + "/")
+		if err != nil {
+			t.Fatal("Failed to make test request", err)
+		}
+
+		if resp.StatusCode != tc.wantRespStatusCode {
+			t.Errorf("StartupHandler returned wrong status code: got %d want %d", resp.StatusCode, tc.wantRespStatusCode)
+		}
+	}
+}
@@ -3,15 +3,139 @@ package httpserver
 import (
 	"github.com/gorilla/mux"
 	"net/http"
+	"time"
 )
 
-func New(service QuoteService, router *mux.Router, listenAddr string) *http.Server {
+type Config struct {
+	ListenAddr string
+	// HealthToken unlocks the detailed readiness report, empty disables it.
+	HealthToken string
+	// Metrics is optional, requests are not instrumented if it is nil.
+	Metrics HTTPMetrics
+	// MetricsHandler is mounted at /metrics if set, leave it nil if the metrics are served on a separate listener.
+	MetricsHandler http.Handler
+	// Authenticator verifies request credentials, requests with credentials are rejected if it is nil.
+	Authenticator Authenticator
+	// APIKeys enables the /admin/api-keys endpoints if set.
+	APIKeys APIKeyService
+	// Webhooks enables the /webhooks endpoints if set.
+	Webhooks WebhookService
+	// RequireReadAuth guards the read endpoints with the "quotes:read" scope, they are public otherwise.
+	RequireReadAuth bool
+	// RateLimiter limits the /quotes endpoints per client if set.
+	RateLimiter RateLimiter
+	// TrustForwardedFor keys anonymous clients by the X-Forwarded-For header instead of the peer address.
+	TrustForwardedFor bool
+	// Idempotency enables the Idempotency-Key header on POST /quotes and POST /quotes/import if set.
+	Idempotency IdempotencyStore
+	// IdempotencyTTL is how long the responses to idempotent requests are replayed.
+	IdempotencyTTL time.Duration
+	// MaxBodyBytes and MaxImportBodyBytes bound the request bodies of the write endpoints and of
+	// POST /quotes/import, they default to DefaultMaxBodyBytes and DefaultMaxImportBodyBytes.
+	MaxBodyBytes       int64
+	MaxImportBodyBytes int64
+	// The timeouts of the http.Server, zero disables a timeout.
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// HandlerTimeout is the deadline of the request context, RouteTimeouts overrides it for the
+	// routes keyed by method and path template, e.g. "GET /quotes/{id}". Zero disables a deadline.
+	HandlerTimeout time.Duration
+	RouteTimeouts  map[string]time.Duration
+	// GraphQL is mounted at /graphql if set.
+	GraphQL GraphQLExecutor
+	// Events enables GET /quotes/stream if set, StreamHeartbeat defaults to DefaultStreamHeartbeat.
+	Events          QuoteEventStream
+	StreamHeartbeat time.Duration
+	// Rotation enables the WebSocket endpoint GET /quotes/rotation if set, its sockets must be
+	// closed with QuoteRotation.Shutdown.
+	Rotation *QuoteRotation
+	// Cards enables GET /quotes/{id}/card.svg and GET /quotes/{id}/card.png if set.
+	Cards CardRenderer
+	// SlashCommands enables POST /slash-commands for Slack and Mattermost if set.
+	SlashCommands SlashCommands
+	// CORS enables cross-origin requests from the origins of the policy if set.
+	CORS *CORSPolicy
+	// PublicURL is the scheme and host the links of the feeds and embeds start with, e.g.
+	// "https://quotes.example.com". They start with the scheme and host of the request if it is
+	// empty, and the responses are kept out of shared caches then, see Links.
+	PublicURL string
+	// AccessLog enables one log line per request.
+	AccessLog bool
+}
+
+// metricsReadHeaderTimeout bounds the request headers sent to the metrics server.
+const metricsReadHeaderTimeout = 5 * time.Second
+
+func New(service QuoteService, checker HealthChecker, router *mux.Router, cfg Config) *http.Server {
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if cfg.MaxImportBodyBytes <= 0 {
+		cfg.MaxImportBodyBytes = DefaultMaxImportBodyBytes
+	}
+
 	server := &http.Server{
-		Addr:    ":" + listenAddr,
-		Handler: router,
+		Addr:              ":" + cfg.ListenAddr,
+		Handler:           router,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
 	}
 
-	mapHandlers(router, service)
+	mapHealthHandlers(router, checker, cfg.HealthToken)
+	if cfg.MetricsHandler != nil {
+		router.Handle("/metrics", cfg.MetricsHandler).Methods("GET")
+	}
+	// The streams are never idle, so they have to end for Shutdown to return.
+	streamsDone := make(chan struct{})
+	server.RegisterOnShutdown(func() {
+		close(streamsDone)
+	})
+
+	mapHandlers(router, service, cfg, streamsDone)
+	mapFeedHandlers(router, service, cfg)
+	mapEmbedHandlers(router, service, cfg)
+	if cfg.GraphQL != nil {
+		mapGraphQLHandler(router, cfg.GraphQL, cfg)
+	}
+	if cfg.SlashCommands != nil {
+		mapSlashCommandHandler(router, cfg.SlashCommands, cfg)
+	}
+	if cfg.APIKeys != nil {
+		adminGroup := router.PathPrefix("/admin").Subrouter()
+		adminGroup.Use(authenticate(cfg))
+		mapAPIKeyHandlers(adminGroup, cfg.APIKeys, cfg.MaxBodyBytes)
+	}
+	if cfg.Webhooks != nil {
+		webhooksGroup := router.PathPrefix("/webhooks").Subrouter()
+		webhooksGroup.Use(authenticate(cfg))
+		mapWebhookHandlers(webhooksGroup, cfg.Webhooks, cfg.MaxBodyBytes)
+	}
+
+	server.Handler = withDeadlines(router, cfg.HandlerTimeout, cfg.RouteTimeouts, router)
+	server.Handler = cors(router, cfg.CORS, server.Handler)
+	if cfg.AccessLog {
+		server.Handler = logRequests(router, server.Handler)
+	}
+	server.Handler = requestID(traceRequests(router, server.Handler))
+	if cfg.Metrics != nil {
+		server.Handler = instrument(router, server.Handler, cfg.Metrics)
+	}
 
 	return server
 }
+
+// NewMetricsServer returns a server exposing only the metrics handler at /metrics.
+func NewMetricsServer(handler http.Handler, listenAddr string) *http.Server {
+	router := mux.NewRouter()
+	router.Handle("/metrics", handler).Methods("GET")
+
+	return &http.Server{
+		Addr:              ":" + listenAddr,
+		Handler:           router,
+		ReadHeaderTimeout: metricsReadHeaderTimeout,
+	}
+}
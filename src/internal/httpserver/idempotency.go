@@ -0,0 +1,152 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"github.com/BernsteinMondy/quote-service/src/internal/auth"
+	"github.com/BernsteinMondy/quote-service/src/internal/idempotency"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	maxIdempotencyKeyLen = 255
+	// idempotencyLockTTL bounds how long a request holds its key, a retry after it takes the key over.
+	idempotencyLockTTL = time.Minute
+)
+
+type IdempotencyStore = idempotency.Store
+
+// idempotent replays the stored response to requests repeating the Idempotency-Key of an earlier
+// request with the same method, path and body. Keys are scoped to the principal. A key reused
+// for another request results in 422, a key of a request still in flight in 409. Server errors
+// are not stored, so the request can be retried.
+func idempotent(store IdempotencyStore, ttl time.Duration, next http.Handler) http.Handler {
+	if store == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := r.Header.Get(idempotencyKeyHeader)
+		if value == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(value) > maxIdempotencyKeyLen {
+			http.Error(w, "\"Idempotency-Key\" header is too long", http.StatusBadRequest)
+			return
+		}
+
+		// The body is bounded by limitBody before it is buffered for the fingerprint.
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			if isBodyTooLarge(err) {
+				http.Error(w, "request body is too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		key := idempotency.Key{Value: value}
+		if principal := auth.PrincipalFromContext(r.Context()); principal != nil {
+			key.Scope = principal.Subject
+		}
+
+		stored, err := store.Lock(r.Context(), key, requestFingerprint(r, body), idempotencyLockTTL)
+		switch {
+		case errors.Is(err, idempotency.ErrKeyMismatch):
+			http.Error(w, "\"Idempotency-Key\" was used for a different request", http.StatusUnprocessableEntity)
+			return
+		case errors.Is(err, idempotency.ErrInFlight):
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "a request with the same \"Idempotency-Key\" is in flight", http.StatusConflict)
+			return
+		case err != nil:
+			slog.ErrorContext(r.Context(), "Failed to lock idempotency key", slog.String("error", err.Error()))
+			http.Error(w, "lock idempotency key", http.StatusInternalServerError)
+			return
+		case stored != nil:
+			writeStoredResponse(w, stored)
+			return
+		}
+
+		rec := &responseRecorder{header: http.Header{}, statusCode: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		// The response must be stored even if the client went away, the retry is going to ask for it.
+		ctx := context.WithoutCancel(r.Context())
+		resp := rec.response()
+		if resp.StatusCode >= http.StatusInternalServerError {
+			err = store.Release(ctx, key)
+		} else {
+			err = store.Complete(ctx, key, resp, ttl)
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to store idempotent response", slog.String("error", err.Error()))
+		}
+
+		copyHeader(w.Header(), resp.Header)
+		w.WriteHeader(resp.StatusCode)
+		_, _ = w.Write(resp.Body)
+	})
+}
+
+func requestFingerprint(r *http.Request, body []byte) []byte {
+	h := sha256.New()
+	_, _ = io.WriteString(h, r.Method+" "+r.URL.Path+"\n")
+	_, _ = h.Write(body)
+	return h.Sum(nil)
+}
+
+func writeStoredResponse(w http.ResponseWriter, resp *idempotency.Response) {
+	copyHeader(w.Header(), resp.Header)
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(resp.StatusCode)
+	_, _ = w.Write(resp.Body)
+}
+
+func copyHeader(dst, src http.Header) {
+	for name, values := range src {
+		dst[name] = values
+	}
+}
+
+// responseRecorder buffers a response, so it can be stored before it is sent.
+type responseRecorder struct {
+	header      http.Header
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if r.wroteHeader {
+		return
+	}
+	r.wroteHeader = true
+	r.statusCode = statusCode
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(p)
+}
+
+func (r *responseRecorder) response() *idempotency.Response {
+	return &idempotency.Response{
+		StatusCode: r.statusCode,
+		Header:     r.header.Clone(),
+		Body:       r.body.Bytes(),
+	}
+}
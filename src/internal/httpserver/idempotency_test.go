@@ -0,0 +1,100 @@
+package httpserver_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver/testhelpers"
+	"github.com/BernsteinMondy/quote-service/src/internal/idempotency"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	store := &testhelpers.MockIdempotencyStore{}
+	quoteService := &testhelpers.MockQuoteService{}
+	server := httptest.NewServer(httpserver.New(quoteService, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{
+		Authenticator:  authenticatorFixture,
+		Idempotency:    store,
+		IdempotencyTTL: time.Hour,
+	}).Handler)
+	defer server.Close()
+
+	post := func(apiKey, key, body string) (*http.Response, []byte) {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/quotes/import", bytes.NewReader([]byte(body)))
+		if err != nil {
+			t.Fatal("Failed to create request", err)
+		}
+		req.Header.Set("X-API-Key", apiKey)
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatal("Failed to make test request", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal("Failed to read response body", err)
+		}
+		return resp, respBody
+	}
+
+	const body = `{"quotes":[{"author":"test author","quote":"test quote"}]}`
+
+	first, firstBody := post("writer", "key-1", body)
+	if first.StatusCode != http.StatusCreated {
+		t.Fatalf("First request: got status code %d want %d", first.StatusCode, http.StatusCreated)
+	}
+
+	retry, retryBody := post("writer", "key-1", body)
+	if retry.StatusCode != http.StatusCreated || !bytes.Equal(retryBody, firstBody) {
+		t.Errorf("Retry: got %d %s, want the replayed %d %s", retry.StatusCode, retryBody, first.StatusCode, firstBody)
+	}
+	if retry.Header.Get("Idempotent-Replayed") != "true" || retry.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Retry: got headers %v, want replayed headers", retry.Header)
+	}
+
+	otherPrincipal, otherPrincipalBody := post("admin", "key-1", body)
+	if otherPrincipal.StatusCode != http.StatusCreated || bytes.Equal(otherPrincipalBody, firstBody) {
+		t.Errorf("Same key of another principal: got %d %s, want a new response", otherPrincipal.StatusCode, otherPrincipalBody)
+	}
+
+	mismatch, _ := post("writer", "key-1", `{"quotes":[{"author":"other author","quote":"test quote"}]}`)
+	if mismatch.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Reused key: got status code %d want %d", mismatch.StatusCode, http.StatusUnprocessableEntity)
+	}
+
+	fingerprint := sha256.Sum256([]byte("POST /quotes/import\n" + body))
+	_, err := store.Lock(context.Background(), idempotency.Key{Scope: "writer", Value: "key-2"}, fingerprint[:], time.Minute)
+	if err != nil {
+		t.Fatal("Failed to lock key", err)
+	}
+	inFlight, _ := post("writer", "key-2", body)
+	if inFlight.StatusCode != http.StatusConflict || inFlight.Header.Get("Retry-After") == "" {
+		t.Errorf("In flight key: got status code %d want %d with Retry-After", inFlight.StatusCode, http.StatusConflict)
+	}
+
+	quoteService.RetError = errors.New("some error")
+	failed, _ := post("writer", "key-3", body)
+	quoteService.RetError = nil
+	retried, _ := post("writer", "key-3", body)
+	if failed.StatusCode != http.StatusInternalServerError || retried.StatusCode != http.StatusCreated {
+		t.Errorf("Retry after server error: got status codes %d, %d want %d, %d",
+			failed.StatusCode, retried.StatusCode, http.StatusInternalServerError, http.StatusCreated)
+	}
+
+	withoutKey, _ := post("writer", "", body)
+	if withoutKey.StatusCode != http.StatusCreated || withoutKey.Header.Get("Idempotent-Replayed") != "" {
+		t.Errorf("Request without key: got status code %d want %d", withoutKey.StatusCode, http.StatusCreated)
+	}
+}
@@ -0,0 +1,106 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"slices"
+	"time"
+)
+
+const (
+	DefaultMaxBodyBytes       = 64 << 10
+	DefaultMaxImportBodyBytes = 4 << 20
+)
+
+// limitBody responds with 413 to request bodies longer than maxBytes once they are decoded.
+func limitBody(maxBytes int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// decodeJSON decodes the request body into dst, it must hold a single JSON object without unknown
+// fields. Requests without a Content-Type are accepted as JSON. It responds with 415, 413 or 400
+// if the body can not be decoded and reports whether it could.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || mediaType != "application/json" {
+			http.Error(w, "\"Content-Type\" must be \"application/json\"", http.StatusUnsupportedMediaType)
+			return false
+		}
+	}
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(dst)
+	if err == nil {
+		// A second value after the object must not be silently ignored.
+		err = dec.Decode(&struct{}{})
+		if errors.Is(err, io.EOF) {
+			return true
+		}
+		if !isBodyTooLarge(err) {
+			http.Error(w, "request body must hold a single JSON object", http.StatusBadRequest)
+			return false
+		}
+	}
+
+	if isBodyTooLarge(err) {
+		http.Error(w, "request body is too large", http.StatusRequestEntityTooLarge)
+		return false
+	}
+
+	http.Error(w, "failed to parse request body: "+err.Error(), http.StatusBadRequest)
+	return false
+}
+
+func isBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+// withDeadlines bounds the context of every request by the timeout of its route. routeTimeouts
+// is keyed by the method and path template, e.g. "GET /quotes/{id}", other routes get
+// defaultTimeout, except for the streamingRoutes. A timeout of zero or less disables the deadline
+// of a route.
+func withDeadlines(router *mux.Router, defaultTimeout time.Duration, routeTimeouts map[string]time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := r.Method + " " + routeTemplate(router, r)
+		timeout, ok := routeTimeouts[route]
+		if !ok && !slices.Contains(streamingRoutes, route) {
+			timeout = defaultTimeout
+		}
+		if timeout <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// writeServiceError responds to a failed service call with 503 if the deadline of the request
+// passed and with 500 otherwise. The failure is logged as "Failed to <action>".
+func writeServiceError(w http.ResponseWriter, r *http.Request, action string, err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		slog.WarnContext(r.Context(), "Failed to "+action+" before the deadline", slog.String("error", err.Error()))
+		http.Error(w, fmt.Sprintf("service: %s: deadline exceeded", action), http.StatusServiceUnavailable)
+		return
+	}
+
+	slog.ErrorContext(r.Context(), "Failed to "+action, slog.String("error", err.Error()))
+	http.Error(w, "service: "+action, http.StatusInternalServerError)
+}
@@ -0,0 +1,172 @@
+package httpserver_test
+
+import (
+	"context"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver/testhelpers"
+	"github.com/BernsteinMondy/quote-service/src/internal/service"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBodyLimits(t *testing.T) {
+	server := httptest.NewServer(httpserver.New(&testhelpers.MockQuoteService{}, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{
+		Authenticator:      authenticatorFixture,
+		APIKeys:            &testhelpers.MockAPIKeyService{},
+		Webhooks:           &testhelpers.MockWebhookService{},
+		Idempotency:        &testhelpers.MockIdempotencyStore{},
+		IdempotencyTTL:     time.Hour,
+		MaxBodyBytes:       64,
+		MaxImportBodyBytes: 256,
+	}).Handler)
+	defer server.Close()
+
+	type testCase struct {
+		name               string
+		path               string
+		body               string
+		apiKey             string
+		idempotencyKey     string
+		wantRespStatusCode int
+	}
+
+	testCases := []testCase{
+		{
+			name:               "Body within the limit is accepted",
+			path:               "/quotes",
+			body:               `{"author":"test author","quote":"test quote"}`,
+			wantRespStatusCode: http.StatusCreated,
+		},
+		{
+			name:               "Body over the limit results in status code 413",
+			path:               "/quotes",
+			body:               `{"author":"test author","quote":"` + strings.Repeat("a", 64) + `"}`,
+			wantRespStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:               "Idempotent body over the limit results in status code 413",
+			path:               "/quotes",
+			body:               `{"author":"test author","quote":"` + strings.Repeat("a", 64) + `"}`,
+			idempotencyKey:     "too-large",
+			wantRespStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:               "Import body within the import limit is accepted",
+			path:               "/quotes/import",
+			body:               `{"quotes":[{"author":"test author","quote":"` + strings.Repeat("a", 64) + `"}]}`,
+			wantRespStatusCode: http.StatusCreated,
+		},
+		{
+			name:               "Import body over the import limit results in status code 413",
+			path:               "/quotes/import",
+			body:               `{"quotes":[{"author":"test author","quote":"` + strings.Repeat("a", 256) + `"}]}`,
+			wantRespStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:               "API key body over the limit results in status code 413",
+			path:               "/admin/api-keys",
+			body:               `{"name":"` + strings.Repeat("a", 64) + `","scopes":["quotes:read"]}`,
+			apiKey:             "admin",
+			wantRespStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:               "Webhook body over the limit results in status code 413",
+			path:               "/webhooks",
+			body:               `{"url":"https://example.com/` + strings.Repeat("a", 64) + `"}`,
+			apiKey:             "admin",
+			wantRespStatusCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tc := range testCases {
+		req, err := http.NewRequest(http.MethodPost, server.URL+tc.path, strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal("Failed to create request", err)
+		}
+		if tc.apiKey == "" {
+			tc.apiKey = "writer"
+		}
+		req.Header.Set("X-API-Key", tc.apiKey)
+		if tc.idempotencyKey != "" {
+			req.Header.Set("Idempotency-Key", tc.idempotencyKey)
+		}
+
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatal("Failed to make test request", err)
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != tc.wantRespStatusCode {
+			t.Errorf("%s: got status code %d want %d", tc.name, resp.StatusCode, tc.wantRespStatusCode)
+		}
+	}
+}
+
+// deadlineQuoteService waits for the deadline of the context to pass if it has one.
+type deadlineQuoteService struct {
+	testhelpers.MockQuoteService
+}
+
+func (s *deadlineQuoteService) GetRandomQuote(ctx context.Context) (*service.Quote, error) {
+	if _, ok := ctx.Deadline(); ok {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	return s.MockQuoteService.GetRandomQuote(ctx)
+}
+
+func TestHandlerDeadlines(t *testing.T) {
+	type testCase struct {
+		name               string
+		handlerTimeout     time.Duration
+		routeTimeouts      map[string]time.Duration
+		wantRespStatusCode int
+	}
+
+	testCases := []testCase{
+		{
+			name:               "Request exceeding the handler timeout results in status code 503",
+			handlerTimeout:     10 * time.Millisecond,
+			wantRespStatusCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:               "Request exceeding the route timeout results in status code 503",
+			routeTimeouts:      map[string]time.Duration{"GET /quotes/random": 10 * time.Millisecond},
+			wantRespStatusCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:               "Route timeout of zero disables the handler timeout",
+			handlerTimeout:     10 * time.Millisecond,
+			routeTimeouts:      map[string]time.Duration{"GET /quotes/random": 0},
+			wantRespStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Request without a timeout has no deadline",
+			wantRespStatusCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		server := httptest.NewServer(httpserver.New(&deadlineQuoteService{}, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{
+			HandlerTimeout: tc.handlerTimeout,
+			RouteTimeouts:  tc.routeTimeouts,
+		}).Handler)
+
+		resp, err := server.Client().Get(server.URL + "/quotes/random")
+		if err != nil {
+			t.Fatal("Failed to make test request", err)
+		}
+		_ = resp.Body.Close()
+		server.Close()
+
+		if resp.StatusCode != tc.wantRespStatusCode {
+			t.Errorf("%s: got status code %d want %d", tc.name, resp.StatusCode, tc.wantRespStatusCode)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package httpserver
+
+import (
+	"context"
+	"github.com/BernsteinMondy/quote-service/src/internal/telemetry"
+	"github.com/felixge/httpsnoop"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// requestID stores the X-Request-ID of the request in the context and echoes it in the response.
+// A new ID is generated if the header is missing or not a short printable ASCII string.
+func requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !telemetry.ValidRequestID(id) {
+			id = uuid.NewString()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(telemetry.WithRequestID(r.Context(), id)))
+	})
+}
+
+// accessLogEntry collects the fields of the access log line known only to inner handlers.
+type accessLogEntry struct {
+	principal string
+}
+
+type accessLogCtxKey struct{}
+
+// recordPrincipal adds the subject of the authenticated principal to the access log line.
+func recordPrincipal(ctx context.Context, subject string) {
+	if entry, ok := ctx.Value(accessLogCtxKey{}).(*accessLogEntry); ok {
+		entry.principal = subject
+	}
+}
+
+// logRequests emits one access log line per request. It must be wrapped by requestID and
+// traceRequests, so the line carries the request and trace IDs.
+func logRequests(router *mux.Router, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entry := &accessLogEntry{}
+		ctx := context.WithValue(r.Context(), accessLogCtxKey{}, entry)
+
+		m := httpsnoop.CaptureMetrics(next, w, r.WithContext(ctx))
+
+		slog.LogAttrs(r.Context(), slog.LevelInfo, "HTTP request",
+			slog.String("method", r.Method),
+			slog.String("route", routeTemplate(router, r)),
+			slog.Int("status", m.Code),
+			slog.Duration("latency", m.Duration),
+			slog.Int64("bytes", m.Written),
+			slog.String("principal", entry.principal),
+		)
+	})
+}
@@ -0,0 +1,142 @@
+package httpserver_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver/testhelpers"
+	"github.com/BernsteinMondy/quote-service/src/internal/telemetry"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// captureLogs replaces the default logger with one writing JSON records to the returned buffer
+// until the test ends.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(telemetry.NewLogHandler(slog.NewJSONHandler(&buf, nil))))
+	t.Cleanup(func() {
+		slog.SetDefault(defaultLogger)
+	})
+
+	return &buf
+}
+
+func decodeLogRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Failed to decode log record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+
+	return records
+}
+
+func TestAccessLog(t *testing.T) {
+	type testCase struct {
+		name          string
+		path          string
+		requestID     string
+		apiKey        string
+		service       httpserver.QuoteService
+		wantRequestID string
+		wantStatus    float64
+		wantPrincipal string
+		wantRecords   []string
+	}
+
+	testCases := []testCase{
+		{
+			name:          "Request ID of the client is echoed and logged",
+			path:          "/quotes/random",
+			requestID:     "client-request-1",
+			apiKey:        "reader",
+			service:       &testhelpers.MockQuoteService{},
+			wantRequestID: "client-request-1",
+			wantStatus:    http.StatusOK,
+			wantPrincipal: "reader",
+			wantRecords:   []string{"HTTP request"},
+		},
+		{
+			name:        "Invalid request ID is replaced",
+			path:        "/quotes/random",
+			requestID:   "contains spaces",
+			service:     &testhelpers.MockQuoteService{},
+			wantStatus:  http.StatusOK,
+			wantRecords: []string{"HTTP request"},
+		},
+		{
+			name:          "Log lines of the handler carry the request ID",
+			path:          "/quotes/random",
+			requestID:     "client-request-2",
+			service:       &testhelpers.MockQuoteService{RetError: errors.New("some error")},
+			wantRequestID: "client-request-2",
+			wantStatus:    http.StatusInternalServerError,
+			wantRecords:   []string{"Failed to get random quote", "HTTP request"},
+		},
+	}
+
+	for _, tc := range testCases {
+		buf := captureLogs(t)
+		server := httptest.NewServer(httpserver.New(tc.service, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{
+			Authenticator: authenticatorFixture,
+			AccessLog:     true,
+		}).Handler)
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+tc.path, http.NoBody)
+		if err != nil {
+			t.Fatal("Failed to create request", err)
+		}
+		req.Header.Set("X-Request-ID", tc.requestID)
+		if tc.apiKey != "" {
+			req.Header.Set("X-API-Key", tc.apiKey)
+		}
+
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatal("Failed to make test request", err)
+		}
+		_ = resp.Body.Close()
+		// Close waits for the handler to return, the request is logged after the response is written.
+		server.Close()
+
+		requestID := resp.Header.Get("X-Request-ID")
+		if tc.wantRequestID != "" && requestID != tc.wantRequestID {
+			t.Errorf("%s: got X-Request-ID %q want %q", tc.name, requestID, tc.wantRequestID)
+		}
+		if requestID == "" || requestID == tc.requestID && tc.wantRequestID == "" {
+			t.Errorf("%s: got X-Request-ID %q want a generated one", tc.name, requestID)
+		}
+
+		records := decodeLogRecords(t, buf)
+		if len(records) != len(tc.wantRecords) {
+			t.Fatalf("%s: got %d log records want %d: %v", tc.name, len(records), len(tc.wantRecords), records)
+		}
+		for i, record := range records {
+			if record["msg"] != tc.wantRecords[i] || record["request_id"] != requestID {
+				t.Errorf("%s: got log record %v want message %q with request ID %q", tc.name, record, tc.wantRecords[i], requestID)
+			}
+		}
+
+		accessLog := records[len(records)-1]
+		if accessLog["method"] != http.MethodGet || accessLog["route"] != "/quotes/random" ||
+			accessLog["status"] != tc.wantStatus || accessLog["principal"] != tc.wantPrincipal {
+			t.Errorf("%s: got access log record %v", tc.name, accessLog)
+		}
+		if _, ok := accessLog["latency"]; !ok {
+			t.Errorf("%s: access log record %v has no latency", tc.name, accessLog)
+		}
+		if bytes, ok := accessLog["bytes"].(float64); !ok || bytes == 0 {
+			t.Errorf("%s: access log record %v has no bytes written", tc.name, accessLog)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package httpserver
+
+import (
+	"github.com/felixge/httpsnoop"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"time"
+)
+
+const unmatchedRoute = "unmatched"
+
+type HTTPMetrics interface {
+	TrackInFlight(route string) func()
+	ObserveRequest(method, route string, status int, duration time.Duration)
+}
+
+// instrument wraps the whole router instead of being registered with router.Use,
+// so requests that match no route are observed as well.
+func instrument(router *mux.Router, next http.Handler, metrics HTTPMetrics) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeTemplate(router, r)
+
+		done := metrics.TrackInFlight(route)
+		defer done()
+
+		m := httpsnoop.CaptureMetrics(next, w, r)
+		metrics.ObserveRequest(r.Method, route, m.Code, m.Duration)
+	})
+}
+
+// traceRequests starts a server span for every request, continuing the W3C trace context
+// of the caller. Spans are named after the route template, e.g. "GET /quotes/{id}".
+func traceRequests(router *mux.Router, next http.Handler) http.Handler {
+	routeAttr := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("http.route", routeTemplate(router, r)))
+		next.ServeHTTP(w, r)
+	})
+
+	return otelhttp.NewHandler(routeAttr, "http.server",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + routeTemplate(router, r)
+		}),
+	)
+}
+
+// routeTemplate returns the path template of the route matching r, e.g. "/quotes/{id}".
+func routeTemplate(router *mux.Router, r *http.Request) string {
+	var match mux.RouteMatch
+	if !router.Match(r, &match) || match.Route == nil {
+		return unmatchedRoute
+	}
+
+	tpl, err := match.Route.GetPathTemplate()
+	if err != nil {
+		return unmatchedRoute
+	}
+
+	return tpl
+}
@@ -0,0 +1,122 @@
+package httpserver_test
+
+import (
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver/testhelpers"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestServerMetrics(t *testing.T) {
+	type testCase struct {
+		name        string
+		method      string
+		path        string
+		wantRequest testhelpers.ObservedRequest
+	}
+
+	testCases := []testCase{
+		{
+			name:   "Request is labelled with the route template",
+			method: http.MethodDelete,
+			path:   "/quotes/4937a248-cb08-46de-8789-493904914cc6",
+			wantRequest: testhelpers.ObservedRequest{
+				Method: http.MethodDelete,
+				Route:  "/quotes/{id}",
+				Status: http.StatusUnauthorized,
+			},
+		},
+		{
+			name:   "Request to a static route is labelled with its path",
+			method: http.MethodGet,
+			path:   "/quotes/random",
+			wantRequest: testhelpers.ObservedRequest{
+				Method: http.MethodGet,
+				Route:  "/quotes/random",
+				Status: http.StatusOK,
+			},
+		},
+		{
+			name:   "Request matching no route is labelled as unmatched",
+			method: http.MethodGet,
+			path:   "/unknown/path",
+			wantRequest: testhelpers.ObservedRequest{
+				Method: http.MethodGet,
+				Route:  "unmatched",
+				Status: http.StatusNotFound,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		metrics := &testhelpers.MockHTTPMetrics{}
+		server := httptest.NewServer(httpserver.New(&testhelpers.MockQuoteService{}, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{
+			Metrics: metrics,
+		}).Handler)
+
+		req, err := http.NewRequest(tc.method, server.URL+tc.path, http.NoBody)
+		if err != nil {
+			t.Fatal("Failed to create request", err)
+		}
+
+		_, err = server.Client().Do(req)
+		if err != nil {
+			t.Fatal("Failed to make test request", err)
+		}
+		// Close waits for the handler to return, the request is observed after the response is written.
+		server.Close()
+
+		if len(metrics.Requests) != 1 || !reflect.DeepEqual(metrics.Requests[0], tc.wantRequest) {
+			t.Errorf("%s: observed requests %v, want [%v]", tc.name, metrics.Requests, tc.wantRequest)
+		}
+		if metrics.InFlight[tc.wantRequest.Route] != 0 {
+			t.Errorf("%s: in-flight gauge was not decremented", tc.name)
+		}
+	}
+}
+
+func TestServerTracing(t *testing.T) {
+	const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	server := httptest.NewServer(httpserver.New(&testhelpers.MockQuoteService{}, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{}).Handler)
+
+	req, err := http.NewRequest(http.MethodDelete, server.URL+"/quotes/4937a248-cb08-46de-8789-493904914cc6", http.NoBody)
+	if err != nil {
+		t.Fatal("Failed to create request", err)
+	}
+	req.Header.Set("traceparent", traceParent)
+
+	_, err = server.Client().Do(req)
+	if err != nil {
+		t.Fatal("Failed to make test request", err)
+	}
+	server.Close()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Recorded %d spans, want 1", len(spans))
+	}
+	if got, want := spans[0].Name(), "DELETE /quotes/{id}"; got != want {
+		t.Errorf("Span name = %q, want %q", got, want)
+	}
+	if got, want := spans[0].SpanContext().TraceID().String(), "4bf92f3577b34da6a3ce929d0e0e4736"; got != want {
+		t.Errorf("Span trace ID = %s, want the propagated %s", got, want)
+	}
+}
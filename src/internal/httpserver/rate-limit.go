@@ -0,0 +1,79 @@
+package httpserver
+
+import (
+	"context"
+	"github.com/BernsteinMondy/quote-service/src/internal/auth"
+	"github.com/BernsteinMondy/quote-service/src/internal/ratelimit"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type RateLimiter interface {
+	Allow(ctx context.Context, class ratelimit.Class, key string) (ratelimit.Result, error)
+}
+
+// rateLimit takes a token of the class from the bucket of the client. Authenticated clients are
+// keyed by their principal, anonymous clients by their IP address. Requests are let through if
+// the limiter fails, an unavailable store must not take the API down.
+func rateLimit(limiter RateLimiter, class ratelimit.Class, trustForwardedFor bool, next http.Handler) http.Handler {
+	if limiter == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res, err := limiter.Allow(r.Context(), class, rateLimitKey(r, trustForwardedFor))
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to apply rate limit", slog.String("error", err.Error()))
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if res.Limit > 0 {
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("RateLimit-Reset", ceilSeconds(res.Reset))
+		}
+		if !res.Allowed {
+			w.Header().Set("Retry-After", ceilSeconds(res.RetryAfter))
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func rateLimitKey(r *http.Request, trustForwardedFor bool) string {
+	if principal := auth.PrincipalFromContext(r.Context()); principal != nil {
+		return "principal:" + principal.Subject
+	}
+
+	return "ip:" + clientIP(r, trustForwardedFor)
+}
+
+// clientIP returns the first X-Forwarded-For address if trustForwardedFor is set, the header
+// can be forged by clients unless a proxy in front of the service overwrites it.
+func clientIP(r *http.Request, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		first, _, _ := strings.Cut(r.Header.Get("X-Forwarded-For"), ",")
+		if ip := strings.TrimSpace(first); ip != "" {
+			return ip
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,150 @@
+package httpserver_test
+
+import (
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver/testhelpers"
+	"github.com/BernsteinMondy/quote-service/src/internal/ratelimit"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRateLimit(t *testing.T) {
+	limiter, err := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), map[ratelimit.Class]ratelimit.Limit{
+		ratelimit.ClassRead:  {Rate: 0.5, Burst: 2},
+		ratelimit.ClassWrite: {Rate: 0.5, Burst: 1},
+		ratelimit.ClassAuth:  {Rate: 0.5, Burst: 2},
+	})
+	if err != nil {
+		t.Fatal("Failed to create limiter", err)
+	}
+
+	server := httptest.NewServer(httpserver.New(&testhelpers.MockQuoteService{}, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{
+		Authenticator:     authenticatorFixture,
+		RateLimiter:       limiter,
+		TrustForwardedFor: true,
+	}).Handler)
+	defer server.Close()
+
+	type step struct {
+		name               string
+		method             string
+		path               string
+		forwardedFor       string
+		apiKey             string
+		wantRespStatusCode int
+		wantRemaining      string
+		wantRetryAfter     string
+	}
+
+	steps := []step{
+		{
+			name:               "First read of a client is allowed",
+			method:             http.MethodGet,
+			path:               "/quotes/random",
+			forwardedFor:       "203.0.113.1",
+			wantRespStatusCode: http.StatusOK,
+			wantRemaining:      "1",
+		},
+		{
+			name:               "Second read of a client is allowed",
+			method:             http.MethodGet,
+			path:               "/quotes",
+			forwardedFor:       "203.0.113.1, 10.0.0.1",
+			wantRespStatusCode: http.StatusOK,
+			wantRemaining:      "0",
+		},
+		{
+			name:               "Read over budget results in status code 429",
+			method:             http.MethodGet,
+			path:               "/quotes/random",
+			forwardedFor:       "203.0.113.1",
+			wantRespStatusCode: http.StatusTooManyRequests,
+			wantRemaining:      "0",
+			wantRetryAfter:     "2",
+		},
+		{
+			name:               "Read of another client is allowed",
+			method:             http.MethodGet,
+			path:               "/quotes/random",
+			forwardedFor:       "203.0.113.2",
+			wantRespStatusCode: http.StatusOK,
+			wantRemaining:      "1",
+		},
+		{
+			name:               "Write has its own budget",
+			method:             http.MethodDelete,
+			path:               "/quotes/4937a248-cb08-46de-8789-493904914cc6",
+			forwardedFor:       "203.0.113.1",
+			apiKey:             "admin",
+			wantRespStatusCode: http.StatusOK,
+			wantRemaining:      "0",
+		},
+		{
+			name:               "Write over budget results in status code 429",
+			method:             http.MethodDelete,
+			path:               "/quotes/4937a248-cb08-46de-8789-493904914cc6",
+			forwardedFor:       "203.0.113.2",
+			apiKey:             "admin",
+			wantRespStatusCode: http.StatusTooManyRequests,
+			wantRemaining:      "0",
+			wantRetryAfter:     "2",
+		},
+		{
+			name:               "Invalid credentials take from the auth budget of the IP",
+			method:             http.MethodGet,
+			path:               "/quotes/random",
+			forwardedFor:       "203.0.113.3",
+			apiKey:             "guess-1",
+			wantRespStatusCode: http.StatusUnauthorized,
+			wantRemaining:      "1",
+		},
+		{
+			name:               "Invalid credentials of another route take from the same budget",
+			method:             http.MethodDelete,
+			path:               "/quotes/4937a248-cb08-46de-8789-493904914cc6",
+			forwardedFor:       "203.0.113.3",
+			apiKey:             "guess-2",
+			wantRespStatusCode: http.StatusUnauthorized,
+			wantRemaining:      "0",
+		},
+		{
+			name:               "Credentials over the auth budget are not verified and result in status code 429",
+			method:             http.MethodGet,
+			path:               "/quotes/random",
+			forwardedFor:       "203.0.113.3",
+			apiKey:             "admin",
+			wantRespStatusCode: http.StatusTooManyRequests,
+			wantRemaining:      "0",
+			wantRetryAfter:     "2",
+		},
+	}
+
+	for _, s := range steps {
+		req, err := http.NewRequest(s.method, server.URL+s.path, http.NoBody)
+		if err != nil {
+			t.Fatal("Failed to create request", err)
+		}
+		req.Header.Set("X-Forwarded-For", s.forwardedFor)
+		if s.apiKey != "" {
+			req.Header.Set("X-API-Key", s.apiKey)
+		}
+
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatal("Failed to make test request", err)
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != s.wantRespStatusCode {
+			t.Errorf("%s: got status code %d want %d", s.name, resp.StatusCode, s.wantRespStatusCode)
+		}
+		if got := resp.Header.Get("RateLimit-Remaining"); got != s.wantRemaining {
+			t.Errorf("%s: got RateLimit-Remaining %q want %q", s.name, got, s.wantRemaining)
+		}
+		if got := resp.Header.Get("Retry-After"); got != s.wantRetryAfter {
+			t.Errorf("%s: got Retry-After %q want %q", s.name, got, s.wantRetryAfter)
+		}
+	}
+}
@@ -0,0 +1,409 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	quoteService "github.com/BernsteinMondy/quote-service/src/internal/service"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultMaxRotationConnections = 500
+	DefaultRotationInterval       = 30 * time.Second
+	DefaultRotationPingInterval   = 30 * time.Second
+
+	// MinRotationInterval and MaxRotationInterval bound the interval chosen by the clients. Skipped
+	// quotes are not pushed more often than MinRotationInterval either.
+	MinRotationInterval = time.Second
+	MaxRotationInterval = time.Hour
+
+	// rotationWriteWait bounds every write, clients not reading for that long are disconnected.
+	rotationWriteWait = 10 * time.Second
+	// rotationCloseWait is how long the close frame of the client is awaited on shutdown.
+	rotationCloseWait = time.Second
+	// rotationFetchTimeout bounds the service call for one quote.
+	rotationFetchTimeout = 5 * time.Second
+	// maxRotationMessageBytes bounds the messages sent by the clients.
+	maxRotationMessageBytes = 4 << 10
+)
+
+type RotationConfig struct {
+	// MaxConnections bounds the open sockets, it defaults to DefaultMaxRotationConnections.
+	MaxConnections int
+	// Interval is used until the client chooses one, it defaults to DefaultRotationInterval.
+	Interval time.Duration
+	// PingInterval defaults to DefaultRotationPingInterval, clients not answering the pings for
+	// two intervals are disconnected.
+	PingInterval time.Duration
+}
+
+// QuoteRotation tracks the sockets of GET /quotes/rotation. The sockets are hijacked from the
+// http.Server, so its Shutdown neither waits for nor closes them, Shutdown of QuoteRotation does.
+type QuoteRotation struct {
+	cfg RotationConfig
+
+	mu      sync.Mutex
+	open    int
+	conns   map[*websocket.Conn]struct{}
+	closing chan struct{}
+	wg      sync.WaitGroup
+}
+
+func NewQuoteRotation(cfg RotationConfig) *QuoteRotation {
+	if cfg.MaxConnections <= 0 {
+		cfg.MaxConnections = DefaultMaxRotationConnections
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultRotationInterval
+	}
+	if cfg.PingInterval <= 0 {
+		cfg.PingInterval = DefaultRotationPingInterval
+	}
+
+	return &QuoteRotation{
+		cfg:     cfg,
+		conns:   make(map[*websocket.Conn]struct{}),
+		closing: make(chan struct{}),
+	}
+}
+
+// Shutdown asks the clients to close their sockets and waits for them until ctx is done, the
+// remaining sockets are closed then. New sockets are rejected once Shutdown is called.
+func (q *QuoteRotation) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	select {
+	case <-q.closing:
+	default:
+		close(q.closing)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	q.mu.Lock()
+	for conn := range q.conns {
+		_ = conn.Close()
+	}
+	q.mu.Unlock()
+	<-done
+
+	return ctx.Err()
+}
+
+// acquire reserves a connection, it reports false if the limit is reached or Shutdown was called.
+func (q *QuoteRotation) acquire() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	select {
+	case <-q.closing:
+		return false
+	default:
+	}
+	if q.open >= q.cfg.MaxConnections {
+		return false
+	}
+
+	q.open++
+	q.wg.Add(1)
+	return true
+}
+
+func (q *QuoteRotation) track(conn *websocket.Conn) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.conns[conn] = struct{}{}
+}
+
+// release frees a connection reserved by acquire, conn is nil if the upgrade failed.
+func (q *QuoteRotation) release(conn *websocket.Conn) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.conns, conn)
+	q.open--
+	q.wg.Done()
+}
+
+// rotationSettings are chosen by the client with the "interval", "author" and "tag" parameters and
+// changed with "configure" messages.
+type rotationSettings struct {
+	interval time.Duration
+	author   string
+	tag      string
+	paused   bool
+}
+
+func (s rotationSettings) toDTO() *rotationStateDTO {
+	return &rotationStateDTO{
+		IntervalSeconds: int(s.interval / time.Second),
+		Author:          s.author,
+		Tag:             s.tag,
+		Paused:          s.paused,
+	}
+}
+
+func parseRotationInterval(seconds int) (time.Duration, bool) {
+	interval := time.Duration(seconds) * time.Second
+	return interval, interval >= MinRotationInterval && interval <= MaxRotationInterval
+}
+
+// QuoteRotationHandler upgrades the request to a WebSocket pushing a random quote every interval.
+// The client controls the rotation with JSON messages:
+//
+//	{"type": "configure", "interval_seconds": 10, "author": "...", "tag": "..."}
+//	{"type": "pause"}, {"type": "resume"}, {"type": "skip"}, {"type": "ping"}
+//
+// The server sends {"type": "quote", "quote": {...}}, {"type": "state", "state": {...}} after
+// every message changing the settings, {"type": "pong"} and {"type": "error", "error": "..."}.
+// Cross-origin sockets are accepted from the origins allowed by policy only.
+func QuoteRotationHandler(service QuoteService, rotation *QuoteRotation, policy *CORSPolicy) http.HandlerFunc {
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				return true
+			}
+			if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+				return true
+			}
+			return policy != nil && policy.allowsOrigin(origin)
+		},
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		settings := rotationSettings{
+			interval: rotation.cfg.Interval,
+			author:   query.Get("author"),
+			tag:      strings.ToLower(query.Get("tag")),
+		}
+		if raw := query.Get("interval"); raw != "" {
+			seconds, err := strconv.Atoi(raw)
+			interval, ok := parseRotationInterval(seconds)
+			if err != nil || !ok {
+				http.Error(w, "invalid \"interval\" parameter", http.StatusBadRequest)
+				return
+			}
+			settings.interval = interval
+		}
+
+		if !rotation.acquire() {
+			w.Header().Set("Retry-After", "30")
+			http.Error(w, "too many open sockets", http.StatusServiceUnavailable)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// The upgrader responded already.
+			rotation.release(nil)
+			return
+		}
+		rotation.track(conn)
+		defer rotation.release(conn)
+		defer func() { _ = conn.Close() }()
+
+		rc := rotationConn{
+			conn:     conn,
+			service:  service,
+			rotation: rotation,
+			settings: settings,
+		}
+		err = rc.serve(r.Context())
+		if err != nil && !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+			slog.DebugContext(r.Context(), "Quote rotation closed", slog.String("error", err.Error()))
+		}
+	}
+}
+
+type rotationConn struct {
+	conn     *websocket.Conn
+	service  QuoteService
+	rotation *QuoteRotation
+	settings rotationSettings
+
+	timer    *time.Timer
+	lastPush time.Time
+	lastID   uuid.UUID
+}
+
+// serve runs the rotation until the client disconnects, stops answering or the rotation shuts
+// down. Writes are synchronous, so a slow client delays the next quote instead of queueing them,
+// and the messages of the client are read one at a time.
+func (rc *rotationConn) serve(ctx context.Context) error {
+	pongWait := 2 * rc.rotation.cfg.PingInterval
+	messages := make(chan []byte)
+	readErr := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+
+	rc.conn.SetReadLimit(maxRotationMessageBytes)
+	_ = rc.conn.SetReadDeadline(time.Now().Add(pongWait))
+	rc.conn.SetPongHandler(func(string) error {
+		return rc.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	go func() {
+		for {
+			_, msg, err := rc.conn.ReadMessage()
+			if err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case messages <- msg:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	rc.timer = time.NewTimer(0)
+	defer rc.timer.Stop()
+	ping := time.NewTicker(rc.rotation.cfg.PingInterval)
+	defer ping.Stop()
+
+	err := rc.write(rotationServerMessage{Type: "state", State: rc.settings.toDTO()})
+	for err == nil {
+		select {
+		case <-rc.rotation.closing:
+			_ = rc.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, "server is shutting down"),
+				time.Now().Add(rotationWriteWait))
+			// The client answers with a close frame, which ends the reader.
+			select {
+			case <-readErr:
+			case <-time.After(rotationCloseWait):
+			}
+			return nil
+		case err = <-readErr:
+		case msg := <-messages:
+			err = rc.handle(msg)
+		case <-ping.C:
+			err = rc.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(rotationWriteWait))
+		case <-rc.timer.C:
+			err = rc.push(ctx)
+		}
+	}
+
+	return err
+}
+
+func (rc *rotationConn) write(msg rotationServerMessage) error {
+	_ = rc.conn.SetWriteDeadline(time.Now().Add(rotationWriteWait))
+	return rc.conn.WriteJSON(msg)
+}
+
+func (rc *rotationConn) writeError(message string) error {
+	return rc.write(rotationServerMessage{Type: "error", Error: message})
+}
+
+// schedule pushes the next quote after delay, but not before MinRotationInterval passed since
+// the last one.
+func (rc *rotationConn) schedule(delay time.Duration) {
+	at := time.Now().Add(delay)
+	if earliest := rc.lastPush.Add(MinRotationInterval); at.Before(earliest) {
+		at = earliest
+	}
+	rc.timer.Reset(time.Until(at))
+}
+
+func (rc *rotationConn) push(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, rotationFetchTimeout)
+	defer cancel()
+
+	quote, err := rc.service.GetRandomQuoteWithFilter(ctx, quoteService.RandomQuoteFilter{
+		Author:    rc.settings.author,
+		Tag:       rc.settings.tag,
+		ExcludeID: rc.lastID,
+	})
+	rc.lastPush = time.Now()
+	if !rc.settings.paused {
+		rc.schedule(rc.settings.interval)
+	}
+
+	if err != nil {
+		if errors.Is(err, quoteService.ErrNotFound) {
+			return rc.writeError("no quote matches the filters")
+		}
+		slog.ErrorContext(ctx, "Failed to get random quote", slog.String("error", err.Error()))
+		return rc.writeError("failed to get a random quote")
+	}
+
+	rc.lastID = quote.ID
+	dto := quoteFromDomainToReadDTO(quote)
+	return rc.write(rotationServerMessage{Type: "quote", Quote: &dto})
+}
+
+func (rc *rotationConn) handle(raw []byte) error {
+	var msg rotationClientMessage
+	err := json.Unmarshal(raw, &msg)
+	if err != nil {
+		return rc.writeError("invalid message: " + err.Error())
+	}
+
+	switch msg.Type {
+	case "configure":
+		settings := rc.settings
+		if msg.IntervalSeconds != nil {
+			interval, ok := parseRotationInterval(*msg.IntervalSeconds)
+			if !ok {
+				return rc.writeError("\"interval_seconds\" must be between 1 and 3600")
+			}
+			settings.interval = interval
+		}
+		if msg.Author != nil {
+			settings.author = *msg.Author
+		}
+		if msg.Tag != nil {
+			settings.tag = strings.ToLower(*msg.Tag)
+		}
+
+		filtersChanged := settings.author != rc.settings.author || settings.tag != rc.settings.tag
+		rc.settings = settings
+		switch {
+		case filtersChanged:
+			rc.schedule(0)
+		case !settings.paused:
+			rc.schedule(time.Until(rc.lastPush.Add(settings.interval)))
+		}
+	case "pause":
+		rc.settings.paused = true
+		rc.timer.Stop()
+	case "resume":
+		if rc.settings.paused {
+			rc.settings.paused = false
+			rc.schedule(time.Until(rc.lastPush.Add(rc.settings.interval)))
+		}
+	case "skip":
+		rc.schedule(0)
+		return nil
+	case "ping":
+		return rc.write(rotationServerMessage{Type: "pong"})
+	default:
+		return rc.writeError("unknown message type " + strconv.Quote(msg.Type))
+	}
+
+	return rc.write(rotationServerMessage{Type: "state", State: rc.settings.toDTO()})
+}
@@ -0,0 +1,129 @@
+package httpserver_test
+
+import (
+	"context"
+	"errors"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver/testhelpers"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type rotationMessage struct {
+	Type  string `json:"type"`
+	Quote struct {
+		Author string `json:"author"`
+	} `json:"quote"`
+	State struct {
+		IntervalSeconds int    `json:"interval_seconds"`
+		Author          string `json:"author"`
+		Paused          bool   `json:"paused"`
+	} `json:"state"`
+	Error string `json:"error"`
+}
+
+func TestQuoteRotationHandler(t *testing.T) {
+	rotation := httpserver.NewQuoteRotation(httpserver.RotationConfig{MaxConnections: 1})
+	server := httptest.NewServer(httpserver.New(&testhelpers.MockQuoteService{}, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{
+		Rotation:  rotation,
+		Metrics:   &testhelpers.MockHTTPMetrics{},
+		AccessLog: true,
+	}).Handler)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/quotes/rotation"
+
+	_, resp, err := websocket.DefaultDialer.Dial(url+"?interval=0", nil)
+	if !errors.Is(err, websocket.ErrBadHandshake) || resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Dial() with invalid interval error = %v, want status code 400", err)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?interval=3600&author=author-1", nil)
+	if err != nil {
+		t.Fatal("Failed to dial", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	_, resp, err = websocket.DefaultDialer.Dial(url, nil)
+	if !errors.Is(err, websocket.ErrBadHandshake) || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Dial() over the connection limit error = %v, want status code 503", err)
+	}
+
+	next := func() rotationMessage {
+		t.Helper()
+
+		var msg rotationMessage
+		_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+		err := conn.ReadJSON(&msg)
+		if err != nil {
+			t.Fatal("Failed to read message", err)
+		}
+		return msg
+	}
+	send := func(msg string) {
+		t.Helper()
+
+		err := conn.WriteMessage(websocket.TextMessage, []byte(msg))
+		if err != nil {
+			t.Fatal("Failed to write message", err)
+		}
+	}
+
+	if msg := next(); msg.Type != "state" || msg.State.IntervalSeconds != 3600 || msg.State.Author != "author-1" {
+		t.Errorf("got first message %+v want the state", msg)
+	}
+	if msg := next(); msg.Type != "quote" || msg.Quote.Author != "author-1" {
+		t.Errorf("got message %+v want a quote of author-1 right away", msg)
+	}
+
+	send(`{"type":"pause"}`)
+	if msg := next(); msg.Type != "state" || !msg.State.Paused {
+		t.Errorf("got message %+v want the paused state", msg)
+	}
+
+	send(`{"type":"configure","author":"author-2"}`)
+	if msg := next(); msg.Type != "state" || msg.State.Author != "author-2" || !msg.State.Paused {
+		t.Errorf("got message %+v want the state with the new author", msg)
+	}
+	if msg := next(); msg.Type != "quote" || msg.Quote.Author != "author-2" {
+		t.Errorf("got message %+v want a quote of author-2 for the new filter", msg)
+	}
+
+	send(`{"type":"ping"}`)
+	if msg := next(); msg.Type != "pong" {
+		t.Errorf("got message %+v want pong", msg)
+	}
+
+	for _, invalid := range []string{`{"type":"rewind"}`, `{"type":"configure","interval_seconds":0}`, `not json`} {
+		send(invalid)
+		if msg := next(); msg.Type != "error" {
+			t.Errorf("got message %+v for %s want an error", msg, invalid)
+		}
+	}
+
+	send(`{"type":"configure","author":"unknown"}`)
+	next()
+	if msg := next(); msg.Type != "error" || msg.Error != "no quote matches the filters" {
+		t.Errorf("got message %+v want an error for the filter without quotes", msg)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- rotation.Shutdown(ctx)
+	}()
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("got error %v after shutdown want close going away", err)
+	}
+	if err := <-shutdownErr; err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+}
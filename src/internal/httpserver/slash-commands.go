@@ -0,0 +1,83 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/BernsteinMondy/quote-service/src/internal/auth"
+	"github.com/BernsteinMondy/quote-service/src/internal/ratelimit"
+	"github.com/BernsteinMondy/quote-service/src/internal/slashcmd"
+	"github.com/gorilla/mux"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+)
+
+type SlashCommands interface {
+	Verify(header http.Header, body []byte) (slashcmd.Platform, error)
+	Run(ctx context.Context, req slashcmd.Request) (*slashcmd.Response, error)
+}
+
+// mapSlashCommandHandler maps the endpoint of the slash commands. It is not authenticated like the
+// API, the requests are signed by the chat platform instead.
+func mapSlashCommandHandler(router *mux.Router, commands SlashCommands, cfg Config) {
+	router.Handle("/slash-commands", limitBody(cfg.MaxBodyBytes, SlashCommandHandler(commands, cfg.RateLimiter))).Methods("POST")
+}
+
+// SlashCommandHandler answers the slash commands of Slack and Mattermost. The signature covers the
+// raw body, so it is verified before the form is parsed. The platforms show the body of responses
+// other than 200 as an opaque failure, so errors of the service are answered with 200 too.
+//
+// The commands are limited like writes per chat user once the request is verified, the platforms
+// send the commands of all their users from the same addresses.
+func SlashCommandHandler(commands SlashCommands, limiter RateLimiter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			if isBodyTooLarge(err) {
+				http.Error(w, "request body is too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+
+		platform, err := commands.Verify(r.Header, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			http.Error(w, "request body must be form-encoded", http.StatusBadRequest)
+			return
+		}
+
+		req := slashcmd.Request{
+			Platform: platform,
+			Command:  form.Get("command"),
+			Text:     form.Get("text"),
+			TeamID:   form.Get("team_id"),
+			UserID:   form.Get("user_id"),
+		}
+		run := rateLimit(limiter, ratelimit.ClassWrite, false, runSlashCommand(commands, req))
+		run.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), req.Principal())))
+	}
+}
+
+func runSlashCommand(commands SlashCommands, req slashcmd.Request) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp, err := commands.Run(r.Context(), req)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to run slash command", slog.String("error", err.Error()))
+			resp = slashcmd.ErrorResponse()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(resp)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to encode response", slog.String("error", err.Error()))
+		}
+	}
+}
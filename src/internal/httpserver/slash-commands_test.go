@@ -0,0 +1,228 @@
+package httpserver_test
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver/testhelpers"
+	"github.com/BernsteinMondy/quote-service/src/internal/ratelimit"
+	"github.com/BernsteinMondy/quote-service/src/internal/slashcmd"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const slashCommandSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+func slashCommandBody(text string) string {
+	return url.Values{
+		"command": {"/quote"},
+		"text":    {text},
+		"team_id": {"T1DC2JH3J"},
+		"user_id": {"U2CERLKJA"},
+	}.Encode()
+}
+
+func TestSlashCommandHandler(t *testing.T) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	type testCase struct {
+		name               string
+		service            *testhelpers.MockQuoteService
+		body               string
+		header             http.Header
+		wantRespStatusCode int
+		wantResponseType   slashcmd.ResponseType
+		wantText           string
+	}
+
+	signed := func(timestamp, body string) http.Header {
+		header := http.Header{}
+		header.Set(slashcmd.TimestampHeader, timestamp)
+		header.Set(slashcmd.SignatureHeader, slashcmd.Sign(slashCommandSecret, timestamp, []byte(body)))
+		return header
+	}
+
+	testCases := []testCase{
+		{
+			name:               "Signed random command results in status code 200",
+			body:               slashCommandBody("random"),
+			header:             signed(now, slashCommandBody("random")),
+			wantRespStatusCode: http.StatusOK,
+			wantResponseType:   slashcmd.InChannel,
+			wantText:           testhelpers.QuotesArrayFixture[0].Author,
+		},
+		{
+			name:               "Mattermost add command results in status code 200",
+			body:               slashCommandBody(`add "Stay hungry." — Steve Jobs`),
+			header:             http.Header{"Authorization": {"Token mattermost-token"}},
+			wantRespStatusCode: http.StatusOK,
+			wantResponseType:   slashcmd.Ephemeral,
+			wantText:           "Added the quote:",
+		},
+		{
+			name:               "Unknown subcommand results in status code 200",
+			body:               slashCommandBody("delete"),
+			header:             signed(now, slashCommandBody("delete")),
+			wantRespStatusCode: http.StatusOK,
+			wantResponseType:   slashcmd.Ephemeral,
+			wantText:           "unknown subcommand",
+		},
+		{
+			name:               "Service call ended with error results in status code 200",
+			service:            &testhelpers.MockQuoteService{RetError: errors.New("some error")},
+			body:               slashCommandBody("random"),
+			header:             signed(now, slashCommandBody("random")),
+			wantRespStatusCode: http.StatusOK,
+			wantResponseType:   slashcmd.Ephemeral,
+			wantText:           "unavailable",
+		},
+		{
+			name:               "Tampered body results in status code 401",
+			body:               slashCommandBody("add \"Spam\" - Spammer"),
+			header:             signed(now, slashCommandBody("random")),
+			wantRespStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Replayed request results in status code 401",
+			body:               slashCommandBody("random"),
+			header:             signed(stale, slashCommandBody("random")),
+			wantRespStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Unsigned request results in status code 401",
+			body:               slashCommandBody("random"),
+			header:             http.Header{},
+			wantRespStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Body over the limit results in status code 413",
+			body:               slashCommandBody(strings.Repeat("a", 128)),
+			header:             http.Header{"Authorization": {"Token mattermost-token"}},
+			wantRespStatusCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tc := range testCases {
+		if tc.service == nil {
+			tc.service = &testhelpers.MockQuoteService{}
+		}
+		verifier := slashcmd.NewVerifier(slashcmd.VerifierConfig{
+			SigningSecret:   slashCommandSecret,
+			MattermostToken: "mattermost-token",
+		})
+		server := httptest.NewServer(httpserver.New(tc.service, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{
+			SlashCommands: slashcmd.New(tc.service, verifier),
+			MaxBodyBytes:  128,
+		}).Handler)
+
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/slash-commands", strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatalf("%s: failed to create request: %v", tc.name, err)
+		}
+		req.Header = tc.header
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatalf("%s: failed to send request: %v", tc.name, err)
+		}
+
+		if resp.StatusCode != tc.wantRespStatusCode {
+			t.Errorf("%s: expected status code %d, got %d", tc.name, tc.wantRespStatusCode, resp.StatusCode)
+		}
+		if resp.StatusCode == http.StatusOK {
+			var got slashcmd.Response
+			if err = json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatalf("%s: failed to decode response: %v", tc.name, err)
+			}
+			if got.ResponseType != tc.wantResponseType {
+				t.Errorf("%s: expected response type %q, got %q", tc.name, tc.wantResponseType, got.ResponseType)
+			}
+			if !strings.Contains(got.Text, tc.wantText) {
+				t.Errorf("%s: expected text to contain %q, got %q", tc.name, tc.wantText, got.Text)
+			}
+		}
+
+		resp.Body.Close()
+		server.Close()
+	}
+}
+
+func TestSlashCommandHandler_RateLimit(t *testing.T) {
+	limiter, err := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), map[ratelimit.Class]ratelimit.Limit{
+		ratelimit.ClassWrite: {Rate: 0.5, Burst: 1},
+	})
+	if err != nil {
+		t.Fatal("Failed to create limiter", err)
+	}
+
+	service := &testhelpers.MockQuoteService{}
+	verifier := slashcmd.NewVerifier(slashcmd.VerifierConfig{MattermostToken: "mattermost-token"})
+	server := httptest.NewServer(httpserver.New(service, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{
+		SlashCommands: slashcmd.New(service, verifier),
+		RateLimiter:   limiter,
+	}).Handler)
+	defer server.Close()
+
+	type step struct {
+		name               string
+		userID             string
+		token              string
+		wantRespStatusCode int
+	}
+
+	// Every request comes from the address of the test client, like the requests of a platform.
+	steps := []step{
+		{
+			name:               "Unverified command results in status code 401",
+			userID:             "U1",
+			token:              "forged",
+			wantRespStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "First command of a user is allowed",
+			userID:             "U1",
+			token:              "mattermost-token",
+			wantRespStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Command of a user over budget results in status code 429",
+			userID:             "U1",
+			token:              "mattermost-token",
+			wantRespStatusCode: http.StatusTooManyRequests,
+		},
+		{
+			name:               "Command of another user is allowed",
+			userID:             "U2",
+			token:              "mattermost-token",
+			wantRespStatusCode: http.StatusOK,
+		},
+	}
+
+	for _, s := range steps {
+		body := url.Values{"command": {"/quote"}, "text": {"random"}, "team_id": {"T1"}, "user_id": {s.userID}}.Encode()
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/slash-commands", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("%s: failed to create request: %v", s.name, err)
+		}
+		req.Header.Set("Authorization", "Token "+s.token)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatalf("%s: failed to send request: %v", s.name, err)
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != s.wantRespStatusCode {
+			t.Errorf("%s: expected status code %d, got %d", s.name, s.wantRespStatusCode, resp.StatusCode)
+		}
+	}
+}
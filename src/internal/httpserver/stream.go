@@ -0,0 +1,144 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/BernsteinMondy/quote-service/src/internal/events"
+	quoteService "github.com/BernsteinMondy/quote-service/src/internal/service"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultStreamHeartbeat is the interval of the comments keeping idle streams open through proxies.
+const DefaultStreamHeartbeat = 15 * time.Second
+
+// streamRetry is the reconnection delay suggested to the clients, e.g. after a shutdown.
+const streamRetry = 2 * time.Second
+
+// streamingRoutes are exempt from the handler timeout unless RouteTimeouts sets one.
+var streamingRoutes = []string{"GET /quotes/stream", "GET /quotes/rotation"}
+
+type QuoteEventStream interface {
+	// Subscribe replays the events after lastEventID, see events.Hub.Subscribe.
+	Subscribe(lastEventID string) (sub *events.Subscription, replay []quoteService.QuoteEvent, resumed bool)
+}
+
+type quoteEventFilter struct {
+	types     []quoteService.QuoteEventType
+	author    string
+	tag       string
+	createdBy string
+}
+
+func (f quoteEventFilter) matches(event quoteService.QuoteEvent) bool {
+	return (len(f.types) == 0 || slices.Contains(f.types, event.Type)) &&
+		(f.author == "" || event.Quote.Author == f.author) &&
+		(f.tag == "" || slices.Contains(event.Quote.Tags, f.tag)) &&
+		(f.createdBy == "" || event.Quote.CreatedBy == f.createdBy)
+}
+
+// StreamQuotesHandler streams the quote events matching the "type", "author", "tag" and
+// "created_by" parameters as Server-Sent Events. "type" is a comma separated list of "created",
+// "updated" and "deleted". Clients reconnecting with the Last-Event-ID header receive the events
+// they missed, or a "reset" event if they are not buffered anymore. The stream ends when the client
+// disconnects, falls behind or done is closed.
+func StreamQuotesHandler(stream QuoteEventStream, heartbeat time.Duration, done <-chan struct{}) http.HandlerFunc {
+	if heartbeat <= 0 {
+		heartbeat = DefaultStreamHeartbeat
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		filter := quoteEventFilter{
+			author:    query.Get("author"),
+			tag:       strings.ToLower(query.Get("tag")),
+			createdBy: query.Get("created_by"),
+		}
+		if rawTypes := query.Get("type"); rawTypes != "" {
+			for _, rawType := range strings.Split(rawTypes, ",") {
+				switch eventType := quoteService.QuoteEventType(strings.TrimSpace(rawType)); eventType {
+				case quoteService.QuoteCreated, quoteService.QuoteUpdated, quoteService.QuoteDeleted:
+					filter.types = append(filter.types, eventType)
+				default:
+					http.Error(w, "invalid \"type\" parameter", http.StatusBadRequest)
+					return
+				}
+			}
+		}
+
+		// The write timeout of the server would end every stream, the read timeout would cancel
+		// its context.
+		rc := http.NewResponseController(w)
+		for _, setDeadline := range []func(time.Time) error{rc.SetWriteDeadline, rc.SetReadDeadline} {
+			err := setDeadline(time.Time{})
+			if err != nil && !errors.Is(err, http.ErrNotSupported) {
+				slog.WarnContext(r.Context(), "Failed to clear the deadline of the stream", slog.String("error", err.Error()))
+			}
+		}
+
+		sub, replay, resumed := stream.Subscribe(r.Header.Get("Last-Event-ID"))
+		defer sub.Close()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		// Keeps reverse proxies like nginx from buffering the stream.
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		_, err := fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+		if err == nil && !resumed {
+			// The empty id clears the Last-Event-ID of the client.
+			_, err = io.WriteString(w, "id:\nevent: reset\ndata: {}\n\n")
+		}
+		for _, event := range replay {
+			if err == nil && filter.matches(event) {
+				err = writeQuoteEvent(w, event)
+			}
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+
+		for err == nil {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+				_, err = io.WriteString(w, ": heartbeat\n\n")
+			case event, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				if !filter.matches(event) {
+					continue
+				}
+				err = writeQuoteEvent(w, event)
+			}
+			if err == nil {
+				err = rc.Flush()
+			}
+		}
+
+		slog.DebugContext(r.Context(), "Quote stream closed", slog.String("error", err.Error()))
+	}
+}
+
+func writeQuoteEvent(w io.Writer, event quoteService.QuoteEvent) error {
+	data, err := json.Marshal(quoteFromDomainToEventDTO(&event.Quote))
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -0,0 +1,172 @@
+package httpserver_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/BernsteinMondy/quote-service/src/internal/events"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver/testhelpers"
+	"github.com/BernsteinMondy/quote-service/src/internal/service"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sseEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+// readSSEEvents sends the events read from the stream to the returned channel, comments and
+// fields other than id, event and data are skipped. The channel is closed at the end of the stream.
+func readSSEEvents(resp *http.Response) <-chan sseEvent {
+	c := make(chan sseEvent)
+	go func() {
+		defer close(c)
+		defer func() { _ = resp.Body.Close() }()
+
+		var event sseEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			field, value, _ := strings.Cut(scanner.Text(), ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "id":
+				event.id = value
+			case "event":
+				event.event = value
+			case "data":
+				event.data = value
+			case "":
+				if event != (sseEvent{}) {
+					c <- event
+				}
+				event = sseEvent{}
+			}
+		}
+	}()
+	return c
+}
+
+func nextSSEEvent(t *testing.T, c <-chan sseEvent) sseEvent {
+	t.Helper()
+
+	select {
+	case event, ok := <-c:
+		if !ok {
+			t.Fatal("Stream ended before the next event")
+		}
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for the next event")
+		return sseEvent{}
+	}
+}
+
+func TestStreamQuotesHandler(t *testing.T) {
+	hub := events.NewHub(10)
+	// The timeouts are shorter than the test, the stream must outlive them.
+	srv := httpserver.New(&testhelpers.MockQuoteService{}, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{
+		Events:          hub,
+		StreamHeartbeat: 50 * time.Millisecond,
+		ReadTimeout:     100 * time.Millisecond,
+		WriteTimeout:    100 * time.Millisecond,
+		HandlerTimeout:  100 * time.Millisecond,
+		Metrics:         &testhelpers.MockHTTPMetrics{},
+		AccessLog:       true,
+	})
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Failed to listen", err)
+	}
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	defer func() { _ = srv.Close() }()
+	url := "http://" + lis.Addr().String() + "/quotes/stream"
+
+	quote := func(author string, tags ...string) service.Quote {
+		return service.Quote{ID: uuid.New(), Author: author, Quote: "test quote", Tags: tags}
+	}
+	subscribe := func(query, lastEventID string) *http.Response {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodGet, url+query, nil)
+		if err != nil {
+			t.Fatal("Failed to create request", err)
+		}
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal("Failed to send request", err)
+		}
+		return resp
+	}
+
+	hub.Publish(service.QuoteEvent{ID: 1, Type: service.QuoteCreated, Quote: quote("author-1", "test")})
+
+	resp := subscribe("?author=author-1&type=updated,deleted", "")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("got status code %d and content type %q want 200 and text/event-stream", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	stream := readSSEEvents(resp)
+
+	time.Sleep(300 * time.Millisecond)
+	hub.Publish(service.QuoteEvent{ID: 2, Type: service.QuoteUpdated, Quote: quote("author-2")})
+	hub.Publish(service.QuoteEvent{ID: 3, Type: service.QuoteCreated, Quote: quote("author-1")})
+	updated := quote("author-1", "test")
+	hub.Publish(service.QuoteEvent{ID: 4, Type: service.QuoteUpdated, Quote: updated})
+
+	event := nextSSEEvent(t, stream)
+	if event.id != "4" || event.event != "updated" {
+		t.Fatalf("got event %q with id %q want updated with id 4", event.event, event.id)
+	}
+	var data struct {
+		ID   string   `json:"id"`
+		Tags []string `json:"tags"`
+	}
+	err = json.Unmarshal([]byte(event.data), &data)
+	if err != nil || data.ID != updated.ID.String() || len(data.Tags) != 1 {
+		t.Errorf("got data %s want quote %s with its tags", event.data, updated.ID)
+	}
+
+	t.Run("Last-Event-ID replays the missed events", func(t *testing.T) {
+		replayed := readSSEEvents(subscribe("?tag=Test", "1"))
+		if event := nextSSEEvent(t, replayed); event.id != "4" {
+			t.Errorf("got event with id %q want 4", event.id)
+		}
+	})
+
+	t.Run("Evicted Last-Event-ID results in a reset event", func(t *testing.T) {
+		reset := readSSEEvents(subscribe("", "100"))
+		if event := nextSSEEvent(t, reset); event.event != "reset" {
+			t.Errorf("got event %q want reset", event.event)
+		}
+	})
+
+	t.Run("Invalid type results in status code 400", func(t *testing.T) {
+		resp := subscribe("?type=created,unknown", "")
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("got status code %d want %d", resp.StatusCode, http.StatusBadRequest)
+		}
+	})
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	err = srv.Shutdown(shutdownCtx)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		t.Fatal("Failed to shut down with open streams", err)
+	}
+	for range stream {
+	}
+}
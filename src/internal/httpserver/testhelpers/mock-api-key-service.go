@@ -0,0 +1,31 @@
+package testhelpers
+
+import (
+	"context"
+	"github.com/BernsteinMondy/quote-service/src/internal/auth"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver"
+	"github.com/google/uuid"
+	"time"
+)
+
+type MockAPIKeyService struct {
+	RetError error
+}
+
+var _ httpserver.APIKeyService = (*MockAPIKeyService)(nil)
+
+func (m *MockAPIKeyService) CreateAPIKey(_ context.Context, name string, scopes []auth.Scope) (*auth.APIKey, string, error) {
+	if m.RetError != nil {
+		return nil, "", m.RetError
+	}
+
+	return &auth.APIKey{ID: uuid.New(), Name: name, Scopes: scopes, CreatedAt: time.Now()}, "qs_plaintext", nil
+}
+
+func (m *MockAPIKeyService) ListAPIKeys(context.Context) ([]auth.APIKey, error) {
+	return nil, m.RetError
+}
+
+func (m *MockAPIKeyService) RevokeAPIKey(context.Context, uuid.UUID) error {
+	return m.RetError
+}
@@ -0,0 +1,23 @@
+package testhelpers
+
+import (
+	"context"
+	"github.com/BernsteinMondy/quote-service/src/internal/auth"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver"
+)
+
+// MockAuthenticator authenticates the credentials present in Principals.
+type MockAuthenticator struct {
+	Principals map[string]*auth.Principal
+}
+
+var _ httpserver.Authenticator = (*MockAuthenticator)(nil)
+
+func (m *MockAuthenticator) Authenticate(_ context.Context, credential string) (*auth.Principal, error) {
+	principal, ok := m.Principals[credential]
+	if !ok {
+		return nil, auth.ErrUnauthenticated
+	}
+
+	return principal, nil
+}
@@ -0,0 +1,22 @@
+package testhelpers
+
+import (
+	"context"
+	"github.com/BernsteinMondy/quote-service/src/internal/health"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver"
+)
+
+type MockHealthChecker struct {
+	NotStarted bool
+	Report     health.Report
+}
+
+var _ httpserver.HealthChecker = (*MockHealthChecker)(nil)
+
+func (m *MockHealthChecker) Started() bool {
+	return !m.NotStarted
+}
+
+func (m *MockHealthChecker) Ready(context.Context) health.Report {
+	return m.Report
+}
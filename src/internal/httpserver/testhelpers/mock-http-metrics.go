@@ -0,0 +1,44 @@
+package testhelpers
+
+import (
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver"
+	"sync"
+	"time"
+)
+
+type ObservedRequest struct {
+	Method string
+	Route  string
+	Status int
+}
+
+type MockHTTPMetrics struct {
+	mu       sync.Mutex
+	Requests []ObservedRequest
+	InFlight map[string]int
+}
+
+var _ httpserver.HTTPMetrics = (*MockHTTPMetrics)(nil)
+
+func (m *MockHTTPMetrics) TrackInFlight(route string) func() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.InFlight == nil {
+		m.InFlight = make(map[string]int)
+	}
+	m.InFlight[route]++
+
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.InFlight[route]--
+	}
+}
+
+func (m *MockHTTPMetrics) ObserveRequest(method, route string, status int, _ time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Requests = append(m.Requests, ObservedRequest{Method: method, Route: route, Status: status})
+}
@@ -0,0 +1,62 @@
+package testhelpers
+
+import (
+	"bytes"
+	"context"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver"
+	"github.com/BernsteinMondy/quote-service/src/internal/idempotency"
+	"sync"
+	"time"
+)
+
+// MockIdempotencyStore keeps the keys in memory, locks do not expire.
+type MockIdempotencyStore struct {
+	mu      sync.Mutex
+	entries map[idempotency.Key]*mockIdempotencyEntry
+}
+
+type mockIdempotencyEntry struct {
+	fingerprint []byte
+	response    *idempotency.Response
+}
+
+var _ httpserver.IdempotencyStore = (*MockIdempotencyStore)(nil)
+
+func (m *MockIdempotencyStore) Lock(_ context.Context, key idempotency.Key, fingerprint []byte, _ time.Duration) (*idempotency.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.entries == nil {
+		m.entries = make(map[idempotency.Key]*mockIdempotencyEntry)
+	}
+
+	entry, ok := m.entries[key]
+	if !ok {
+		m.entries[key] = &mockIdempotencyEntry{fingerprint: fingerprint}
+		return nil, nil
+	}
+	if !bytes.Equal(entry.fingerprint, fingerprint) {
+		return nil, idempotency.ErrKeyMismatch
+	}
+	if entry.response == nil {
+		return nil, idempotency.ErrInFlight
+	}
+
+	return entry.response, nil
+}
+
+func (m *MockIdempotencyStore) Complete(_ context.Context, key idempotency.Key, resp *idempotency.Response, _ time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries[key].response = resp
+	return nil
+}
+
+func (m *MockIdempotencyStore) Release(_ context.Context, key idempotency.Key) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
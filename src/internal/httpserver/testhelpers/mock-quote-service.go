@@ -2,19 +2,65 @@ package testhelpers
 
 import (
 	"context"
+	"github.com/BernsteinMondy/quote-service/src/internal/graphqlapi"
 	"github.com/BernsteinMondy/quote-service/src/internal/httpserver"
 	"github.com/BernsteinMondy/quote-service/src/internal/service"
 	"github.com/google/uuid"
 )
 
+// MockQuoteService applies the default validation policy like the service does.
 type MockQuoteService struct {
 	RetError error
 }
 
-var _ httpserver.QuoteService = (*MockQuoteService)(nil)
+var (
+	_ httpserver.QuoteService = (*MockQuoteService)(nil)
+	_ graphqlapi.QuoteService = (*MockQuoteService)(nil)
+)
 
-func (m *MockQuoteService) CreateNewQuote(context.Context, string, string) error {
-	return m.RetError
+func (m *MockQuoteService) CreateNewQuote(_ context.Context, id uuid.UUID, author, quote string) (*service.Quote, error) {
+	author, quote, err := service.DefaultValidationPolicy().Normalize(author, quote)
+	if err != nil {
+		return nil, err
+	}
+	if m.RetError != nil {
+		return nil, m.RetError
+	}
+	if id == uuid.Nil {
+		id = uuid.New()
+	}
+
+	return &service.Quote{ID: id, Author: author, Quote: quote}, nil
+}
+
+func (m *MockQuoteService) GetQuoteByID(_ context.Context, id uuid.UUID) (*service.Quote, error) {
+	if m.RetError != nil {
+		return nil, m.RetError
+	}
+
+	return &service.Quote{ID: id, Author: QuotesArrayFixture[0].Author, Quote: QuotesArrayFixture[0].Quote}, nil
+}
+
+func (m *MockQuoteService) ImportQuotes(_ context.Context, quotes []service.Quote) ([]service.Quote, error) {
+	for _, quote := range quotes {
+		_, _, err := service.DefaultValidationPolicy().Normalize(quote.Author, quote.Quote)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if m.RetError != nil {
+		return nil, m.RetError
+	}
+
+	created := make([]service.Quote, len(quotes))
+	for i, quote := range quotes {
+		id := quote.ID
+		if id == uuid.Nil {
+			id = uuid.New()
+		}
+		created[i] = service.Quote{ID: id, Author: quote.Author, Quote: quote.Quote}
+	}
+	return created, nil
 }
 
 func (m *MockQuoteService) GetQuotesWithFilter(context.Context, string) ([]service.Quote, error) {
@@ -32,6 +78,93 @@ func (m *MockQuoteService) GetRandomQuote(context.Context) (*service.Quote, erro
 	return &QuotesArrayFixture[0], nil
 }
 
+// GetRandomQuoteWithFilter picks from QuotesArrayFixture, it ignores the tag.
+func (m *MockQuoteService) GetRandomQuoteWithFilter(_ context.Context, filter service.RandomQuoteFilter) (*service.Quote, error) {
+	if m.RetError != nil {
+		return nil, m.RetError
+	}
+
+	var excluded *service.Quote
+	for i, quote := range QuotesArrayFixture {
+		if filter.Author != "" && quote.Author != filter.Author {
+			continue
+		}
+		if quote.ID == filter.ExcludeID {
+			excluded = &QuotesArrayFixture[i]
+			continue
+		}
+		return &QuotesArrayFixture[i], nil
+	}
+	if excluded == nil {
+		return nil, service.ErrNotFound
+	}
+	return excluded, nil
+}
+
+// ListRecentQuotes filters QuotesArrayFixture by author, it ignores the tag.
+func (m *MockQuoteService) ListRecentQuotes(_ context.Context, filter service.RecentQuoteFilter) ([]service.Quote, error) {
+	if m.RetError != nil {
+		return nil, m.RetError
+	}
+
+	ret := make([]service.Quote, 0)
+	for _, quote := range QuotesArrayFixture {
+		if filter.Author == "" || quote.Author == filter.Author {
+			ret = append(ret, quote)
+		}
+	}
+	return ret[:min(len(ret), filter.Limit)], nil
+}
+
+func (m *MockQuoteService) UpdateQuote(_ context.Context, id uuid.UUID, author, quote string) (*service.Quote, error) {
+	author, quote, err := service.DefaultValidationPolicy().Normalize(author, quote)
+	if err != nil {
+		return nil, err
+	}
+	if m.RetError != nil {
+		return nil, m.RetError
+	}
+
+	return &service.Quote{ID: id, Author: author, Quote: quote}, nil
+}
+
 func (m *MockQuoteService) DeleteQuoteByID(ctx context.Context, id uuid.UUID) error {
 	return m.RetError
 }
+
+func (m *MockQuoteService) CreateNewQuoteWithTags(ctx context.Context, id uuid.UUID, author, quote string, tags []string) (*service.Quote, error) {
+	tags, err := service.DefaultValidationPolicy().NormalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := m.CreateNewQuote(ctx, id, author, quote)
+	if err != nil {
+		return nil, err
+	}
+	created.Tags = tags
+	return created, nil
+}
+
+func (m *MockQuoteService) UpdateQuoteWithTags(ctx context.Context, id uuid.UUID, author, quote string, _ []string) (*service.Quote, error) {
+	return m.UpdateQuote(ctx, id, author, quote)
+}
+
+func (m *MockQuoteService) ListQuotes(context.Context, service.QuoteFilter) ([]service.Quote, error) {
+	if m.RetError != nil {
+		return nil, m.RetError
+	}
+	return QuotesArrayFixture, nil
+}
+
+func (m *MockQuoteService) GetTagsByQuoteIDs(context.Context, []uuid.UUID) (map[uuid.UUID][]string, error) {
+	return nil, m.RetError
+}
+
+func (m *MockQuoteService) CountQuotesByAuthors(context.Context, []string) (map[string]int64, error) {
+	return nil, m.RetError
+}
+
+func (m *MockQuoteService) ListQuotesByAuthors(context.Context, []string, int) (map[string][]service.Quote, error) {
+	return nil, m.RetError
+}
@@ -0,0 +1,95 @@
+package testhelpers
+
+import (
+	"context"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver"
+	"github.com/BernsteinMondy/quote-service/src/internal/webhooks"
+	"github.com/google/uuid"
+	"time"
+)
+
+// WebhookIDFixture is the only webhook known to MockWebhookService, DeliveryIDFixture is its only
+// dead delivery.
+var (
+	WebhookIDFixture  = uuid.MustParse("0d7f3c2e-9a4b-4f7e-8c61-2b5a9e3d1f40")
+	DeliveryIDFixture = int64(7)
+)
+
+type MockWebhookService struct {
+	RetError error
+}
+
+var _ httpserver.WebhookService = (*MockWebhookService)(nil)
+
+func (m *MockWebhookService) CreateWebhook(_ context.Context, url string, events []webhooks.EventType) (*webhooks.Webhook, string, error) {
+	if m.RetError != nil {
+		return nil, "", m.RetError
+	}
+
+	now := time.Now()
+	return &webhooks.Webhook{ID: uuid.New(), URL: url, Events: events, Active: true, CreatedAt: now, UpdatedAt: now}, "whsec_secret", nil
+}
+
+func (m *MockWebhookService) GetWebhook(_ context.Context, id uuid.UUID) (*webhooks.Webhook, error) {
+	if m.RetError != nil {
+		return nil, m.RetError
+	}
+	if id != WebhookIDFixture {
+		return nil, webhooks.ErrWebhookNotFound
+	}
+
+	return &webhooks.Webhook{ID: id, URL: "https://example.com/hook", Active: true}, nil
+}
+
+func (m *MockWebhookService) ListWebhooks(context.Context) ([]webhooks.Webhook, error) {
+	return nil, m.RetError
+}
+
+func (m *MockWebhookService) UpdateWebhook(ctx context.Context, id uuid.UUID, url string, events []webhooks.EventType, active bool) (*webhooks.Webhook, error) {
+	webhook, err := m.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.URL, webhook.Events, webhook.Active = url, events, active
+	return webhook, nil
+}
+
+func (m *MockWebhookService) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	_, err := m.GetWebhook(ctx, id)
+	return err
+}
+
+func (m *MockWebhookService) ListDeliveries(ctx context.Context, webhookID uuid.UUID, filter webhooks.DeliveryFilter) ([]webhooks.Delivery, error) {
+	_, err := m.GetWebhook(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := webhooks.Delivery{
+		ID:        DeliveryIDFixture,
+		WebhookID: webhookID,
+		EventID:   uuid.New(),
+		EventType: webhooks.EventQuoteCreated,
+		Payload:   []byte(`{"type":"quote.created"}`),
+		Status:    webhooks.StatusDead,
+		Attempts:  8,
+	}
+	if filter.Status != "" && filter.Status != delivery.Status {
+		return []webhooks.Delivery{}, nil
+	}
+
+	return []webhooks.Delivery{delivery}, nil
+}
+
+func (m *MockWebhookService) RetryDelivery(ctx context.Context, webhookID uuid.UUID, deliveryID int64) error {
+	_, err := m.GetWebhook(ctx, webhookID)
+	if err != nil {
+		return webhooks.ErrDeliveryNotFound
+	}
+	if deliveryID != DeliveryIDFixture {
+		return webhooks.ErrDeliveryNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,295 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/BernsteinMondy/quote-service/src/internal/auth"
+	"github.com/BernsteinMondy/quote-service/src/internal/webhooks"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, url string, events []webhooks.EventType) (*webhooks.Webhook, string, error)
+	GetWebhook(ctx context.Context, id uuid.UUID) (*webhooks.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]webhooks.Webhook, error)
+	UpdateWebhook(ctx context.Context, id uuid.UUID, url string, events []webhooks.EventType, active bool) (*webhooks.Webhook, error)
+	DeleteWebhook(ctx context.Context, id uuid.UUID) error
+	ListDeliveries(ctx context.Context, webhookID uuid.UUID, filter webhooks.DeliveryFilter) ([]webhooks.Delivery, error)
+	RetryDelivery(ctx context.Context, webhookID uuid.UUID, deliveryID int64) error
+}
+
+// mapWebhookHandlers maps the handlers on the /webhooks subrouter.
+func mapWebhookHandlers(router *mux.Router, service WebhookService, maxBodyBytes int64) {
+	router.Handle("", requireScope(auth.ScopeAdmin, limitBody(maxBodyBytes, PostWebhookHandler(service)))).Methods("POST")
+	router.Handle("", requireScope(auth.ScopeAdmin, GetWebhooksHandler(service))).Methods("GET")
+	router.Handle("/{id}", requireScope(auth.ScopeAdmin, GetWebhookHandler(service))).Methods("GET")
+	router.Handle("/{id}", requireScope(auth.ScopeAdmin, limitBody(maxBodyBytes, PutWebhookHandler(service)))).Methods("PUT")
+	router.Handle("/{id}", requireScope(auth.ScopeAdmin, DeleteWebhookHandler(service))).Methods("DELETE")
+	router.Handle("/{id}/deliveries", requireScope(auth.ScopeAdmin, GetWebhookDeliveriesHandler(service))).Methods("GET")
+	router.Handle("/{id}/deliveries/{deliveryID}/retry", requireScope(auth.ScopeAdmin, PostWebhookDeliveryRetryHandler(service))).Methods("POST")
+}
+
+func PostWebhookHandler(service WebhookService) http.HandlerFunc {
+	type request = webhookWriteDTO
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req request
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+
+		events, ok := validateWebhookRequest(w, &req)
+		if !ok {
+			return
+		}
+
+		webhook, secret, err := service.CreateWebhook(r.Context(), req.URL, events)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to create webhook", slog.String("error", err.Error()))
+			http.Error(w, "service: create webhook", http.StatusInternalServerError)
+			return
+		}
+
+		resp := webhookCreatedDTO{
+			webhookReadDTO: webhookFromDomainToReadDTO(webhook),
+			Secret:         secret,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func GetWebhooksHandler(service WebhookService) http.HandlerFunc {
+	type response struct {
+		Webhooks []webhookReadDTO `json:"webhooks"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := service.ListWebhooks(r.Context())
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to list webhooks", slog.String("error", err.Error()))
+			http.Error(w, "service: list webhooks", http.StatusInternalServerError)
+			return
+		}
+
+		resp := response{
+			Webhooks: make([]webhookReadDTO, len(list)),
+		}
+		for i, webhook := range list {
+			resp.Webhooks[i] = webhookFromDomainToReadDTO(&webhook)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(resp)
+		if err != nil {
+			http.Error(w, "failed to encode response: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+func GetWebhookHandler(service WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := webhookIDFromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		webhook, err := service.GetWebhook(r.Context(), id)
+		if err != nil {
+			writeWebhookError(w, r, err, "get webhook")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(webhookFromDomainToReadDTO(webhook))
+	}
+}
+
+func PutWebhookHandler(service WebhookService) http.HandlerFunc {
+	type request = webhookWriteDTO
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := webhookIDFromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		var req request
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+
+		events, ok := validateWebhookRequest(w, &req)
+		if !ok {
+			return
+		}
+		// Webhooks are active unless disabled explicitly.
+		active := req.Active == nil || *req.Active
+
+		webhook, err := service.UpdateWebhook(r.Context(), id, req.URL, events, active)
+		if err != nil {
+			writeWebhookError(w, r, err, "update webhook")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(webhookFromDomainToReadDTO(webhook))
+	}
+}
+
+func DeleteWebhookHandler(service WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := webhookIDFromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		err := service.DeleteWebhook(r.Context(), id)
+		if err != nil {
+			writeWebhookError(w, r, err, "delete webhook")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetWebhookDeliveriesHandler returns the delivery log of the webhook latest first, filtered by the
+// "status" parameter. The page is bounded by the "limit" parameter, the next page starts before the
+// "next_before" of the response.
+func GetWebhookDeliveriesHandler(service WebhookService) http.HandlerFunc {
+	type response struct {
+		Deliveries []webhookDeliveryDTO `json:"deliveries"`
+		// NextBefore is omitted on the last page.
+		NextBefore int64 `json:"next_before,omitempty"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := webhookIDFromRequest(w, r)
+		if !ok {
+			return
+		}
+
+		var (
+			filter webhooks.DeliveryFilter
+			err    error
+		)
+		query := r.URL.Query()
+		if rawStatus := query.Get("status"); rawStatus != "" {
+			filter.Status, err = webhooks.ParseDeliveryStatus(rawStatus)
+			if err != nil {
+				http.Error(w, "invalid \"status\" parameter", http.StatusBadRequest)
+				return
+			}
+		}
+		if rawLimit := query.Get("limit"); rawLimit != "" {
+			filter.Limit, err = strconv.Atoi(rawLimit)
+			if err != nil || filter.Limit < 1 || filter.Limit > webhooks.MaxDeliveriesPageSize {
+				http.Error(w, "invalid \"limit\" parameter", http.StatusBadRequest)
+				return
+			}
+		}
+		if rawBefore := query.Get("before"); rawBefore != "" {
+			filter.BeforeID, err = strconv.ParseInt(rawBefore, 10, 64)
+			if err != nil || filter.BeforeID < 1 {
+				http.Error(w, "invalid \"before\" parameter", http.StatusBadRequest)
+				return
+			}
+		}
+
+		deliveries, err := service.ListDeliveries(r.Context(), id, filter)
+		if err != nil {
+			writeWebhookError(w, r, err, "list webhook deliveries")
+			return
+		}
+
+		resp := response{
+			Deliveries: make([]webhookDeliveryDTO, len(deliveries)),
+		}
+		for i, delivery := range deliveries {
+			resp.Deliveries[i] = webhookDeliveryFromDomainToDTO(&delivery)
+		}
+		pageSize := filter.Limit
+		if pageSize == 0 {
+			pageSize = webhooks.MaxDeliveriesPageSize
+		}
+		if len(deliveries) == pageSize {
+			resp.NextBefore = deliveries[len(deliveries)-1].ID
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(resp)
+		if err != nil {
+			http.Error(w, "failed to encode response: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// PostWebhookDeliveryRetryHandler queues a dead delivery again.
+func PostWebhookDeliveryRetryHandler(service WebhookService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := webhookIDFromRequest(w, r)
+		if !ok {
+			return
+		}
+		deliveryID, err := strconv.ParseInt(mux.Vars(r)["deliveryID"], 10, 64)
+		if err != nil {
+			http.Error(w, "invalid \"deliveryID\" parameter", http.StatusBadRequest)
+			return
+		}
+
+		err = service.RetryDelivery(r.Context(), id, deliveryID)
+		if err != nil {
+			if errors.Is(err, webhooks.ErrDeliveryNotFound) {
+				http.Error(w, "dead delivery not found", http.StatusNotFound)
+				return
+			}
+
+			slog.ErrorContext(r.Context(), "Failed to retry webhook delivery", slog.String("error", err.Error()))
+			http.Error(w, "service: retry webhook delivery", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func webhookIDFromRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid \"id\" parameter", http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
+func validateWebhookRequest(w http.ResponseWriter, req *webhookWriteDTO) ([]webhooks.EventType, bool) {
+	err := webhooks.ValidateURL(req.URL)
+	if err != nil {
+		http.Error(w, "\"url\" request field must be an absolute http or https url of a public host", http.StatusBadRequest)
+		return nil, false
+	}
+	events, err := webhooks.ParseEventTypes(req.Events)
+	if err != nil {
+		http.Error(w, "\"events\" request field must contain known event types", http.StatusBadRequest)
+		return nil, false
+	}
+
+	return events, true
+}
+
+func writeWebhookError(w http.ResponseWriter, r *http.Request, err error, operation string) {
+	if errors.Is(err, webhooks.ErrWebhookNotFound) {
+		http.Error(w, "webhook not found", http.StatusNotFound)
+		return
+	}
+
+	slog.ErrorContext(r.Context(), "Failed to "+operation, slog.String("error", err.Error()))
+	http.Error(w, "service: "+operation, http.StatusInternalServerError)
+}
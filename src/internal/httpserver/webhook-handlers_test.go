@@ -0,0 +1,199 @@
+package httpserver_test
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver"
+	"github.com/BernsteinMondy/quote-service/src/internal/httpserver/testhelpers"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWebhookHandlers(t *testing.T) {
+	webhookPath := "/webhooks/" + testhelpers.WebhookIDFixture.String()
+	retryPath := webhookPath + "/deliveries/" + strconv.FormatInt(testhelpers.DeliveryIDFixture, 10) + "/retry"
+
+	type testCase struct {
+		name               string
+		service            httpserver.WebhookService
+		method             string
+		path               string
+		apiKey             string
+		body               string
+		wantRespStatusCode int
+	}
+
+	testCases := []testCase{
+		{
+			name:               "Create with \"admin\" scope results in status code 201",
+			method:             http.MethodPost,
+			path:               "/webhooks",
+			apiKey:             "admin",
+			body:               `{"url":"https://example.com/hook","events":["quote.created"]}`,
+			wantRespStatusCode: http.StatusCreated,
+		},
+		{
+			name:               "Create without \"admin\" scope results in status code 403",
+			method:             http.MethodPost,
+			path:               "/webhooks",
+			apiKey:             "writer",
+			body:               `{"url":"https://example.com/hook"}`,
+			wantRespStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "Anonymous list results in status code 401",
+			method:             http.MethodGet,
+			path:               "/webhooks",
+			wantRespStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:               "Relative url results in status code 400",
+			method:             http.MethodPost,
+			path:               "/webhooks",
+			apiKey:             "admin",
+			body:               `{"url":"/hook"}`,
+			wantRespStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Private address url results in status code 400",
+			method:             http.MethodPost,
+			path:               "/webhooks",
+			apiKey:             "admin",
+			body:               `{"url":"http://169.254.169.254/latest/meta-data"}`,
+			wantRespStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Unknown event type results in status code 400",
+			method:             http.MethodPost,
+			path:               "/webhooks",
+			apiKey:             "admin",
+			body:               `{"url":"https://example.com/hook","events":["quote.liked"]}`,
+			wantRespStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Service call ended with error results in status code 500",
+			service:            &testhelpers.MockWebhookService{RetError: errors.New("some error")},
+			method:             http.MethodGet,
+			path:               "/webhooks",
+			apiKey:             "admin",
+			wantRespStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:               "Update results in status code 200",
+			method:             http.MethodPut,
+			path:               webhookPath,
+			apiKey:             "admin",
+			body:               `{"url":"https://example.com/hook","active":false}`,
+			wantRespStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Unknown webhook results in status code 404",
+			method:             http.MethodDelete,
+			path:               "/webhooks/4937a248-cb08-46de-8789-493904914cc6",
+			apiKey:             "admin",
+			wantRespStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "Non-uuid webhook id results in status code 400",
+			method:             http.MethodGet,
+			path:               "/webhooks/non-uuid",
+			apiKey:             "admin",
+			wantRespStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Delivery log results in status code 200",
+			method:             http.MethodGet,
+			path:               webhookPath + "/deliveries?status=dead&limit=10",
+			apiKey:             "admin",
+			wantRespStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Unknown delivery status results in status code 400",
+			method:             http.MethodGet,
+			path:               webhookPath + "/deliveries?status=lost",
+			apiKey:             "admin",
+			wantRespStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "Retry of a dead delivery results in status code 202",
+			method:             http.MethodPost,
+			path:               retryPath,
+			apiKey:             "admin",
+			wantRespStatusCode: http.StatusAccepted,
+		},
+		{
+			name:               "Retry of an unknown delivery results in status code 404",
+			method:             http.MethodPost,
+			path:               webhookPath + "/deliveries/1/retry",
+			apiKey:             "admin",
+			wantRespStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		if tc.service == nil {
+			tc.service = &testhelpers.MockWebhookService{}
+		}
+		server := httptest.NewServer(httpserver.New(&testhelpers.MockQuoteService{}, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{
+			Authenticator: authenticatorFixture,
+			Webhooks:      tc.service,
+		}).Handler)
+
+		req, err := http.NewRequest(tc.method, server.URL+tc.path, strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatal("Failed to create request", err)
+		}
+		if tc.apiKey != "" {
+			req.Header.Set("X-API-Key", tc.apiKey)
+		}
+
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatal("Failed to make test request", err)
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != tc.wantRespStatusCode {
+			t.Errorf("%s: got status code %d want %d", tc.name, resp.StatusCode, tc.wantRespStatusCode)
+		}
+
+		server.Close()
+	}
+}
+
+func TestPostWebhookHandler_ReturnsSecret(t *testing.T) {
+	server := httptest.NewServer(httpserver.New(&testhelpers.MockQuoteService{}, &testhelpers.MockHealthChecker{}, mux.NewRouter(), httpserver.Config{
+		Authenticator: authenticatorFixture,
+		Webhooks:      &testhelpers.MockWebhookService{},
+	}).Handler)
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/webhooks", strings.NewReader(`{"url":"https://example.com/hook"}`))
+	if err != nil {
+		t.Fatal("Failed to create request", err)
+	}
+	req.Header.Set("X-API-Key", "admin")
+
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatal("Failed to make test request", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var body struct {
+		Secret string   `json:"secret"`
+		Events []string `json:"events"`
+		Active bool     `json:"active"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		t.Fatal("Failed to decode response", err)
+	}
+	if body.Secret == "" || body.Events == nil || !body.Active {
+		t.Errorf("got response %+v want the secret, an empty event list and an active webhook", body)
+	}
+}
@@ -0,0 +1,40 @@
+// Package idempotency defines the storage of responses to requests carrying an Idempotency-Key,
+// so retries of a request replay the first response instead of repeating the write.
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrKeyMismatch is returned if the key was used for a request with another fingerprint.
+	ErrKeyMismatch = errors.New("idempotency key reused with a different request")
+	// ErrInFlight is returned if another request holds the lock of the key.
+	ErrInFlight = errors.New("request with the idempotency key is in flight")
+)
+
+// Key is scoped to the client, so clients can not replay the responses of each other.
+type Key struct {
+	Scope string
+	Value string
+}
+
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+type Store interface {
+	// Lock claims the key for a request with the fingerprint until lockTTL passes. If the key
+	// was already completed, the stored response is returned instead. Lock must return
+	// ErrKeyMismatch if the stored fingerprint differs and ErrInFlight if the key is locked.
+	Lock(ctx context.Context, key Key, fingerprint []byte, lockTTL time.Duration) (*Response, error)
+	// Complete stores the response of the locked key and keeps it for ttl.
+	Complete(ctx context.Context, key Key, resp *Response, ttl time.Duration) error
+	// Release drops the lock of a key that was not completed, so the request can be retried.
+	Release(ctx context.Context, key Key) error
+}
@@ -0,0 +1,131 @@
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/BernsteinMondy/quote-service/src/internal/auth"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"time"
+)
+
+type APIKeyRepository struct {
+	db      *sql.DB
+	typeMap *pgtype.Map
+}
+
+var _ auth.APIKeyRepository = (*APIKeyRepository)(nil)
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db, typeMap: pgtype.NewMap()}
+}
+
+func (a *APIKeyRepository) CreateAPIKey(ctx context.Context, key *auth.APIKey, secretHash []byte) error {
+	const query = `INSERT INTO quote.api_keys (id, name, key_hash, scopes, created_at) VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := a.db.ExecContext(ctx, query, key.ID, key.Name, secretHash, scopesToStrings(key.Scopes), key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("run sql query: %w", err)
+	}
+
+	return nil
+}
+
+func (a *APIKeyRepository) GetAPIKeyByID(ctx context.Context, id uuid.UUID) (*auth.APIKey, []byte, error) {
+	const query = `SELECT id, name, key_hash, scopes, created_at, revoked_at FROM quote.api_keys WHERE id = $1`
+
+	var (
+		key        auth.APIKey
+		secretHash []byte
+		scopes     []string
+		revokedAt  sql.NullTime
+	)
+	err := a.db.QueryRowContext(ctx, query, id).
+		Scan(&key.ID, &key.Name, &secretHash, a.typeMap.SQLScanner(&scopes), &key.CreatedAt, &revokedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, auth.ErrRepoNotFound
+		}
+		return nil, nil, fmt.Errorf("run sql query: %w", err)
+	}
+
+	key.Scopes = stringsToScopes(scopes)
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+
+	return &key, secretHash, nil
+}
+
+func (a *APIKeyRepository) ListAPIKeys(ctx context.Context) (_ []auth.APIKey, err error) {
+	const query = `SELECT id, name, scopes, created_at, revoked_at FROM quote.api_keys ORDER BY created_at`
+
+	rows, err := a.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("run sql query: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, rows.Close())
+	}()
+
+	ret := make([]auth.APIKey, 0)
+	for rows.Next() {
+		var (
+			key       auth.APIKey
+			scopes    []string
+			revokedAt sql.NullTime
+		)
+		err = rows.Scan(&key.ID, &key.Name, a.typeMap.SQLScanner(&scopes), &key.CreatedAt, &revokedAt)
+		if err != nil {
+			return nil, fmt.Errorf("scan into row: %w", err)
+		}
+
+		key.Scopes = stringsToScopes(scopes)
+		if revokedAt.Valid {
+			key.RevokedAt = &revokedAt.Time
+		}
+		ret = append(ret, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return ret, nil
+}
+
+func (a *APIKeyRepository) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	const query = `UPDATE quote.api_keys SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL`
+
+	res, err := a.db.ExecContext(ctx, query, id, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("run sql query: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return auth.ErrRepoNotFound
+	}
+
+	return nil
+}
+
+func scopesToStrings(scopes []auth.Scope) []string {
+	ret := make([]string, len(scopes))
+	for i, scope := range scopes {
+		ret[i] = string(scope)
+	}
+	return ret
+}
+
+func stringsToScopes(raw []string) []auth.Scope {
+	ret := make([]auth.Scope, len(raw))
+	for i, s := range raw {
+		ret[i] = auth.Scope(s)
+	}
+	return ret
+}
@@ -0,0 +1,139 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/BernsteinMondy/quote-service/src/internal/idempotency"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+const idempotencySweepInterval = time.Minute
+
+type IdempotencyStore struct {
+	db        *sql.DB
+	lastSweep atomic.Int64
+}
+
+var _ idempotency.Store = (*IdempotencyStore)(nil)
+
+func NewIdempotencyStore(db *sql.DB) *IdempotencyStore {
+	return &IdempotencyStore{db: db}
+}
+
+func (s *IdempotencyStore) Lock(ctx context.Context, key idempotency.Key, fingerprint []byte, lockTTL time.Duration) (*idempotency.Response, error) {
+	// Expired keys and keys whose lock holder gave up without releasing them are taken over.
+	const lockQuery = `
+		INSERT INTO quote.idempotency_keys AS k (scope, key, fingerprint, locked_until, expires_at)
+		VALUES ($1, $2, $3, now() + make_interval(secs => $4), now() + make_interval(secs => $4))
+		ON CONFLICT (scope, key) DO UPDATE
+		SET fingerprint  = EXCLUDED.fingerprint,
+		    status_code  = NULL,
+		    header       = NULL,
+		    body         = NULL,
+		    locked_until = EXCLUDED.locked_until,
+		    expires_at   = EXCLUDED.expires_at
+		WHERE k.expires_at < now() OR (k.status_code IS NULL AND k.locked_until < now())
+		RETURNING true`
+	const getQuery = `
+		SELECT fingerprint, status_code, header, body
+		FROM quote.idempotency_keys WHERE scope = $1 AND key = $2`
+
+	s.sweep(ctx)
+
+	var locked bool
+	err := s.db.QueryRowContext(ctx, lockQuery, key.Scope, key.Value, fingerprint, lockTTL.Seconds()).Scan(&locked)
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("run lock sql query: %w", err)
+	}
+
+	var (
+		storedFingerprint []byte
+		statusCode        sql.NullInt32
+		rawHeader         []byte
+		body              []byte
+	)
+	err = s.db.QueryRowContext(ctx, getQuery, key.Scope, key.Value).Scan(&storedFingerprint, &statusCode, &rawHeader, &body)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// The key was released or swept right after the lock attempt.
+			return nil, idempotency.ErrInFlight
+		}
+		return nil, fmt.Errorf("run get sql query: %w", err)
+	}
+
+	if !bytes.Equal(storedFingerprint, fingerprint) {
+		return nil, idempotency.ErrKeyMismatch
+	}
+	if !statusCode.Valid {
+		return nil, idempotency.ErrInFlight
+	}
+
+	resp := &idempotency.Response{StatusCode: int(statusCode.Int32), Body: body}
+	err = json.Unmarshal(rawHeader, &resp.Header)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal header: %w", err)
+	}
+
+	return resp, nil
+}
+
+func (s *IdempotencyStore) Complete(ctx context.Context, key idempotency.Key, resp *idempotency.Response, ttl time.Duration) error {
+	const query = `
+		UPDATE quote.idempotency_keys
+		SET status_code = $3, header = $4, body = $5, locked_until = NULL,
+		    expires_at = now() + make_interval(secs => $6)
+		WHERE scope = $1 AND key = $2`
+
+	header := resp.Header
+	if header == nil {
+		header = http.Header{}
+	}
+	rawHeader, err := json.Marshal(header)
+	if err != nil {
+		return fmt.Errorf("marshal header: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, query, key.Scope, key.Value, resp.StatusCode, rawHeader, resp.Body, ttl.Seconds())
+	if err != nil {
+		return fmt.Errorf("run sql query: %w", err)
+	}
+
+	return nil
+}
+
+func (s *IdempotencyStore) Release(ctx context.Context, key idempotency.Key) error {
+	const query = `DELETE FROM quote.idempotency_keys WHERE scope = $1 AND key = $2 AND status_code IS NULL`
+
+	_, err := s.db.ExecContext(ctx, query, key.Scope, key.Value)
+	if err != nil {
+		return fmt.Errorf("run sql query: %w", err)
+	}
+
+	return nil
+}
+
+// sweep deletes expired keys at most once per idempotencySweepInterval per replica.
+func (s *IdempotencyStore) sweep(ctx context.Context) {
+	const query = `DELETE FROM quote.idempotency_keys WHERE expires_at < now()`
+
+	last := s.lastSweep.Load()
+	now := time.Now().UnixNano()
+	if now-last < int64(idempotencySweepInterval) || !s.lastSweep.CompareAndSwap(last, now) {
+		return
+	}
+
+	_, err := s.db.ExecContext(ctx, query)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to sweep idempotency keys", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,117 @@
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/BernsteinMondy/quote-service/src/internal/outbox"
+	"time"
+)
+
+// OutboxStore reads the domain events recorded by the statements of QuoteRepository writing the
+// quotes.
+type OutboxStore struct {
+	db *sql.DB
+}
+
+var _ outbox.Store = (*OutboxStore)(nil)
+
+func NewOutboxStore(db *sql.DB) *OutboxStore {
+	return &OutboxStore{db: db}
+}
+
+// PublishPending keeps the events locked while they are published, so the relays of other replicas
+// skip them. The events are marked published in the same transaction.
+func (s *OutboxStore) PublishPending(ctx context.Context, limit int, publish func(context.Context, []outbox.Event) error) (_ int, err error) {
+	const selectQuery = `
+		SELECT sequence, id, event_type, aggregate_id, occurred_at, data
+		FROM quote.outbox_events
+		WHERE published_at IS NULL
+		ORDER BY sequence
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`
+	const markQuery = `UPDATE quote.outbox_events SET published_at = now() WHERE sequence = ANY ($1::bigint[])`
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, tx.Rollback())
+		}
+	}()
+
+	events, err := selectOutboxEvents(ctx, tx, selectQuery, limit)
+	if err != nil {
+		return 0, err
+	}
+	if len(events) > 0 {
+		err = publish(ctx, events)
+		if err != nil {
+			return 0, fmt.Errorf("publish events: %w", err)
+		}
+
+		sequences := make([]int64, len(events))
+		for i, event := range events {
+			sequences[i] = event.Sequence
+		}
+		_, err = tx.ExecContext(ctx, markQuery, sequences)
+		if err != nil {
+			return 0, fmt.Errorf("run mark sql query: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return len(events), nil
+}
+
+func selectOutboxEvents(ctx context.Context, tx *sql.Tx, query string, limit int) (_ []outbox.Event, err error) {
+	rows, err := tx.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("run select sql query: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, rows.Close())
+	}()
+
+	var ret []outbox.Event
+	for rows.Next() {
+		var (
+			event outbox.Event
+			data  []byte
+		)
+		err = rows.Scan(&event.Sequence, &event.ID, &event.Type, &event.AggregateID, &event.OccurredAt, &data)
+		if err != nil {
+			return nil, fmt.Errorf("scan into row: %w", err)
+		}
+		event.Data = data
+		ret = append(ret, event)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return ret, nil
+}
+
+func (s *OutboxStore) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	const query = `DELETE FROM quote.outbox_events WHERE published_at < $1`
+
+	res, err := s.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("run sql query: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,139 @@
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/BernsteinMondy/quote-service/src/internal/outbox"
+	"github.com/BernsteinMondy/quote-service/src/internal/service"
+	"github.com/google/uuid"
+	"log/slog"
+)
+
+// QuoteEventsChannel is the channel the quote events are notified on.
+const QuoteEventsChannel = "quote_events"
+
+// maxNotifyPayload is the limit of Postgres on the payload of a notification.
+const maxNotifyPayload = 8000
+
+// QuoteEventNotifier publishes the outbox events to the listeners of QuoteEventsChannel on every
+// replica, see database.Listen. Notifications are delivered to every listener in the same order.
+type QuoteEventNotifier struct {
+	db *sql.DB
+}
+
+var _ outbox.Publisher = (*QuoteEventNotifier)(nil)
+
+func NewQuoteEventNotifier(db *sql.DB) *QuoteEventNotifier {
+	return &QuoteEventNotifier{db: db}
+}
+
+type quoteEventPayload struct {
+	ID    int64  `json:"id"`
+	Type  string `json:"type"`
+	Quote struct {
+		ID        uuid.UUID `json:"id"`
+		Author    string    `json:"author"`
+		Quote     string    `json:"quote"`
+		CreatedBy string    `json:"created_by,omitempty"`
+		UpdatedBy string    `json:"updated_by,omitempty"`
+		Tags      []string  `json:"tags"`
+	} `json:"quote"`
+}
+
+// quoteEventTypes maps the outbox event types to the types of the quote events.
+var quoteEventTypes = map[outbox.EventType]service.QuoteEventType{
+	outbox.QuoteCreated: service.QuoteCreated,
+	outbox.QuoteUpdated: service.QuoteUpdated,
+	outbox.QuoteDeleted: service.QuoteDeleted,
+}
+
+// Publish notifies the events with their outbox sequence as ID. Events that can not be notified,
+// e.g. as they exceed the payload limit of a notification, are logged and skipped, as publishing
+// them again would fail the same way.
+func (n *QuoteEventNotifier) Publish(ctx context.Context, events []outbox.Event) error {
+	const query = `
+		SELECT pg_notify($1, payload)
+		FROM unnest($2::text[]) WITH ORDINALITY AS p (payload, n)
+		ORDER BY n`
+
+	payloads := make([]string, 0, len(events))
+	for _, event := range events {
+		raw, err := encodeQuoteEvent(event)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to notify quote event",
+				slog.Int64("sequence", event.Sequence),
+				slog.String("event_id", event.ID.String()),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+		payloads = append(payloads, raw)
+	}
+
+	if len(payloads) == 0 {
+		return nil
+	}
+
+	_, err := n.db.ExecContext(ctx, query, QuoteEventsChannel, payloads)
+	if err != nil {
+		return fmt.Errorf("run sql query: %w", err)
+	}
+
+	return nil
+}
+
+func encodeQuoteEvent(event outbox.Event) (string, error) {
+	eventType, ok := quoteEventTypes[event.Type]
+	if !ok {
+		return "", fmt.Errorf("unknown event type %q", event.Type)
+	}
+
+	payload := quoteEventPayload{ID: event.Sequence, Type: string(eventType)}
+	err := json.Unmarshal(event.Data, &payload.Quote)
+	if err != nil {
+		return "", fmt.Errorf("unmarshal event data: %w", err)
+	}
+	if payload.Quote.Tags == nil {
+		payload.Quote.Tags = []string{}
+	}
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("marshal payload: %w", err)
+	}
+	if len(raw) >= maxNotifyPayload {
+		return "", errors.New("event exceeds the notification payload limit")
+	}
+
+	return string(raw), nil
+}
+
+// DecodeQuoteEvent decodes the payload of a notification sent by QuoteEventNotifier.
+func DecodeQuoteEvent(payload string) (service.QuoteEvent, error) {
+	var p quoteEventPayload
+	err := json.Unmarshal([]byte(payload), &p)
+	if err != nil {
+		return service.QuoteEvent{}, fmt.Errorf("unmarshal payload: %w", err)
+	}
+
+	switch eventType := service.QuoteEventType(p.Type); eventType {
+	case service.QuoteCreated, service.QuoteUpdated, service.QuoteDeleted:
+		return service.QuoteEvent{
+			ID:   p.ID,
+			Type: eventType,
+			Quote: service.Quote{
+				ID:        p.Quote.ID,
+				Author:    p.Quote.Author,
+				Quote:     p.Quote.Quote,
+				CreatedBy: p.Quote.CreatedBy,
+				UpdatedBy: p.Quote.UpdatedBy,
+				Tags:      p.Quote.Tags,
+			},
+		}, nil
+	default:
+		return service.QuoteEvent{}, fmt.Errorf("unknown event type %q", p.Type)
+	}
+}
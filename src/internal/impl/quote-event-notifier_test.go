@@ -0,0 +1,91 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/BernsteinMondy/quote-service/src/internal/outbox"
+	"github.com/BernsteinMondy/quote-service/src/internal/service"
+	"github.com/google/uuid"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDecodeQuoteEvent(t *testing.T) {
+	type testCase struct {
+		name    string
+		payload string
+		wantErr bool
+	}
+
+	testCases := []testCase{
+		{
+			name:    "Valid payload",
+			payload: `{"id":7,"type":"deleted","quote":{"id":"d45cd206-6495-414c-ab1d-f0b6468264be","author":"author","quote":"quote","tags":["life"]}}`,
+		},
+		{
+			name:    "Unknown type",
+			payload: `{"id":7,"type":"moved","quote":{"id":"d45cd206-6495-414c-ab1d-f0b6468264be"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Malformed payload",
+			payload: `{"id":`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		event, err := DecodeQuoteEvent(tc.payload)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: got error %v want error %t", tc.name, err, tc.wantErr)
+			continue
+		}
+		if err == nil && (event.ID != 7 || event.Type != service.QuoteDeleted || event.Quote.Author != "author" || len(event.Quote.Tags) != 1) {
+			t.Errorf("%s: got event %+v", tc.name, event)
+		}
+	}
+}
+
+func TestEncodeQuoteEvent(t *testing.T) {
+	event := outbox.Event{
+		Sequence:    42,
+		ID:          uuid.New(),
+		Type:        outbox.QuoteUpdated,
+		AggregateID: uuid.MustParse("d45cd206-6495-414c-ab1d-f0b6468264be"),
+		Data:        json.RawMessage(`{"id":"d45cd206-6495-414c-ab1d-f0b6468264be","author":"author","quote":"quote","created_by":"owner","created_at":"2026-10-19T09:00:00Z","tags":["life"]}`),
+	}
+
+	payload, err := encodeQuoteEvent(event)
+	if err != nil {
+		t.Fatal("Failed to encode event", err)
+	}
+	decoded, err := DecodeQuoteEvent(payload)
+	if err != nil {
+		t.Fatal("Failed to decode event", err)
+	}
+
+	if decoded.ID != 42 || decoded.Type != service.QuoteUpdated || decoded.Quote.ID != event.AggregateID ||
+		decoded.Quote.CreatedBy != "owner" || !slices.Equal(decoded.Quote.Tags, []string{"life"}) {
+		t.Errorf("got event %+v", decoded)
+	}
+}
+
+func TestQuoteEventNotifier_SkipsOversizedEvent(t *testing.T) {
+	// No statement is run if no event fits into a notification, so the notifier needs no database.
+	notifier := NewQuoteEventNotifier(nil)
+
+	data, err := json.Marshal(map[string]any{"author": "author", "quote": strings.Repeat("q", maxNotifyPayload)})
+	if err != nil {
+		t.Fatal("Failed to marshal data", err)
+	}
+	event := outbox.Event{Sequence: 1, ID: uuid.New(), Type: outbox.QuoteCreated, Data: data}
+
+	if _, err = encodeQuoteEvent(event); err == nil || !strings.Contains(err.Error(), "payload limit") {
+		t.Errorf("got error %v want payload limit error", err)
+	}
+	// The relay would publish the event again and again if it failed.
+	if err = notifier.Publish(context.Background(), []outbox.Event{event}); err != nil {
+		t.Errorf("Publish() error = %v want the event skipped", err)
+	}
+}
@@ -5,26 +5,170 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/BernsteinMondy/quote-service/src/internal/outbox"
 	"github.com/BernsteinMondy/quote-service/src/internal/service"
+	"github.com/BernsteinMondy/quote-service/src/internal/webhooks"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"time"
 )
 
 // The data layer of the project can be covered with tests using the go testcontainers library.
 
+var tracer = otel.Tracer("github.com/BernsteinMondy/quote-service/src/internal/impl")
+
+type QueryObserver interface {
+	ObserveQuery(method string, duration time.Duration, err error)
+}
+
+// EventRecording selects what is recorded with every quote write. Nothing reads the records of a
+// disabled consumer, so they are not written at all rather than left to pile up.
+type EventRecording struct {
+	// Outbox records the domain events in quote.outbox_events for the outbox.Relay.
+	Outbox bool
+	// Webhooks queues the deliveries to the active webhooks for the webhooks.Dispatcher.
+	Webhooks bool
+}
+
 type QuoteRepository struct {
-	db *sql.DB
+	db       *sql.DB
+	observer QueryObserver
+	events   EventRecording
+	typeMap  *pgtype.Map
 }
 
 var _ service.QuoteRepository = (*QuoteRepository)(nil)
 
-func NewQuoteRepository(db *sql.DB) *QuoteRepository {
-	return &QuoteRepository{db: db}
+// NewQuoteRepository accepts a nil observer if the queries should not be observed.
+func NewQuoteRepository(db *sql.DB, observer QueryObserver, events EventRecording) *QuoteRepository {
+	return &QuoteRepository{db: db, observer: observer, events: events, typeMap: pgtype.NewMap()}
+}
+
+// instrument starts a client span for the SQL statement of a repository method. The returned func
+// ends the span and observes the query latency, it must be deferred with a pointer to the named
+// error result of the method.
+func (q *QuoteRepository) instrument(ctx context.Context, method, query string) (context.Context, func(err *error)) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "QuoteRepository."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation.name", method),
+			attribute.String("db.query.text", query),
+		),
+	)
+
+	return ctx, func(err *error) {
+		if *err != nil {
+			span.RecordError(*err)
+			span.SetStatus(codes.Error, (*err).Error())
+		}
+		span.End()
+
+		if q.observer != nil {
+			q.observer.ObserveQuery(method, time.Since(start), *err)
+		}
+	}
+}
+
+// createQuoteQuery records the write, the tags and the event in the same statement, so nothing is
+// recorded if the quote already exists.
+var createQuoteQuery = `
+	WITH inserted AS (
+		INSERT INTO quote.quotes (id, author, quote, created_by, updated_by, created_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $9)
+		ON CONFLICT (id) DO NOTHING
+		RETURNING id, author, quote, created_by, updated_by, created_at
+	), tagged AS (
+		INSERT INTO quote.quote_tags (quote_id, tag)
+		SELECT id, unnest($7::text[]) FROM inserted
+	), written AS (
+		SELECT inserted.*, COALESCE($7::text[], '{}') AS tags FROM inserted
+	), ` + recordQuoteEvent(outbox.QuoteCreated, webhooks.EventQuoteCreated, "$8", "$10", "$11") + `
+	INSERT INTO quote.quote_writes (quote_id, action, api_key_id)
+	SELECT id, 'create', $6::uuid FROM inserted`
+
+// writtenQuoteJSON is the JSON of the quote read from the "written" CTE as q, shaped like the
+// quotes of the API.
+const writtenQuoteJSON = `jsonb_strip_nulls(jsonb_build_object(
+		'id', q.id, 'author', q.author, 'quote', q.quote, 'created_by', q.created_by, 'updated_by', q.updated_by,
+		'created_at', q.created_at
+	)) || jsonb_build_object('tags', to_jsonb(q.tags))`
+
+// recordQuoteEvent returns the CTEs recording the event of a quote write in the domain event outbox
+// and queuing its webhook deliveries, so they are committed or rolled back together with the write.
+// The quote is read from the "written" CTE, which must return the quote columns and its tags. The
+// outbox event and the webhook deliveries share the event ID. The boolean outbox and webhooks
+// parameters enable the CTEs, see EventRecording.
+func recordQuoteEvent(eventType outbox.EventType, webhookEvent webhooks.EventType, eventIDParam, outboxParam, webhooksParam string) string {
+	return fmt.Sprintf(`outboxed AS (
+		INSERT INTO quote.outbox_events (id, event_type, aggregate_id, data)
+		SELECT %[3]s::uuid, '%[1]s', q.id, %[4]s
+		FROM written q
+		WHERE %[5]s::boolean
+	), queued AS (
+		INSERT INTO quote.webhook_deliveries (webhook_id, event_id, event_type, payload)
+		SELECT w.id, %[3]s::uuid, '%[2]s', jsonb_build_object(
+			'id', %[3]s::uuid,
+			'type', '%[2]s',
+			'occurred_at', now(),
+			'quote', %[4]s
+		)
+		FROM written q
+		JOIN quote.webhooks w ON w.active AND (cardinality(w.events) = 0 OR '%[2]s' = ANY (w.events))
+		WHERE %[6]s::boolean
+	)`, eventType, webhookEvent, eventIDParam, writtenQuoteJSON, outboxParam, webhooksParam)
+}
+
+// execer is implemented by both *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
 }
 
-func (q *QuoteRepository) CreateNewQuote(ctx context.Context, quote *service.Quote) error {
-	const query = `INSERT INTO quote.quotes (id, author, quote) VALUES ($1, $2, $3)`
+func (q *QuoteRepository) CreateNewQuote(ctx context.Context, quote *service.Quote, actor service.Actor) (err error) {
+	ctx, finish := q.instrument(ctx, "CreateNewQuote", createQuoteQuery)
+	defer finish(&err)
 
-	res, err := q.db.ExecContext(ctx, query, quote.ID, quote.Author, quote.Quote)
+	return createQuote(ctx, q.db, quote, actor, q.events)
+}
+
+func (q *QuoteRepository) CreateNewQuotes(ctx context.Context, quotes []service.Quote, actor service.Actor) (err error) {
+	ctx, finish := q.instrument(ctx, "CreateNewQuotes", createQuoteQuery)
+	defer finish(&err)
+
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, tx.Rollback())
+		}
+	}()
+
+	for i := range quotes {
+		err = createQuote(ctx, tx, &quotes[i], actor, q.events)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func createQuote(ctx context.Context, db execer, quote *service.Quote, actor service.Actor, events EventRecording) error {
+	res, err := db.ExecContext(ctx, createQuoteQuery,
+		quote.ID, quote.Author, quote.Quote, quote.CreatedBy, quote.UpdatedBy, nullUUID(actor.KeyID), quote.Tags, uuid.New(),
+		quote.CreatedAt, events.Outbox, events.Webhooks)
 	if err != nil {
 		return fmt.Errorf("run sql query: %w", err)
 	}
@@ -40,19 +184,110 @@ func (q *QuoteRepository) CreateNewQuote(ctx context.Context, quote *service.Quo
 	return nil
 }
 
-func (q *QuoteRepository) DeleteQuoteByID(ctx context.Context, id uuid.UUID) error {
-	const query = `DELETE FROM quote.quotes WHERE id = $1`
+func (q *QuoteRepository) UpdateQuote(ctx context.Context, quote *service.Quote, actor service.Actor) (err error) {
+	// The tags are kept if $6 is NULL. Tags already set are not inserted again, the statements of
+	// the query see the same snapshot. Only moderators ($10) may update quotes created by somebody
+	// else ($11), see service.Actor.CanModify.
+	var query = `
+		WITH updated AS (
+			UPDATE quote.quotes SET author = $2, quote = $3, updated_by = NULLIF($4, '')
+			WHERE id = $1 AND ($10::boolean OR created_by = NULLIF($11, ''))
+			RETURNING id, author, quote, created_by, updated_by, created_at
+		), untagged AS (
+			DELETE FROM quote.quote_tags
+			WHERE $6::text[] IS NOT NULL AND quote_id IN (SELECT id FROM updated) AND tag <> ALL ($6)
+		), tagged AS (
+			INSERT INTO quote.quote_tags (quote_id, tag)
+			SELECT id, unnest($6::text[]) FROM updated
+			ON CONFLICT DO NOTHING
+		), written AS (
+			SELECT updated.*, COALESCE($6::text[], ARRAY(
+				SELECT tag FROM quote.quote_tags WHERE quote_id = updated.id ORDER BY tag
+			)) AS tags
+			FROM updated
+		), ` + recordQuoteEvent(outbox.QuoteUpdated, webhooks.EventQuoteUpdated, "$7", "$8", "$9") + `
+		INSERT INTO quote.quote_writes (quote_id, action, api_key_id)
+		SELECT id, 'update', $5::uuid FROM updated`
+
+	ctx, finish := q.instrument(ctx, "UpdateQuote", query)
+	defer finish(&err)
 
-	_, err := q.db.ExecContext(ctx, query, id)
+	res, err := q.db.ExecContext(ctx, query,
+		quote.ID, quote.Author, quote.Quote, quote.UpdatedBy, nullUUID(actor.KeyID), quote.Tags, uuid.New(),
+		q.events.Outbox, q.events.Webhooks, actor.Moderator, actor.Subject)
 	if err != nil {
 		return fmt.Errorf("run sql query: %w", err)
 	}
 
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return service.ErrRepoNotFound
+	}
+
+	return nil
+}
+
+func (q *QuoteRepository) DeleteQuoteByID(ctx context.Context, id uuid.UUID, actor service.Actor) (err error) {
+	// The tags of the deleted quote are read from the snapshot of the statement, before the cascade.
+	// Only moderators ($6) may delete quotes created by somebody else ($7).
+	var query = `
+		WITH deleted AS (
+			DELETE FROM quote.quotes
+			WHERE id = $1 AND ($6::boolean OR created_by = NULLIF($7, ''))
+			RETURNING id, author, quote, created_by, updated_by, created_at
+		), written AS (
+			SELECT deleted.*, ARRAY(
+				SELECT tag FROM quote.quote_tags WHERE quote_id = deleted.id ORDER BY tag
+			) AS tags
+			FROM deleted
+		), ` + recordQuoteEvent(outbox.QuoteDeleted, webhooks.EventQuoteDeleted, "$3", "$4", "$5") + `
+		INSERT INTO quote.quote_writes (quote_id, action, api_key_id)
+		SELECT id, 'delete', $2::uuid FROM deleted`
+
+	ctx, finish := q.instrument(ctx, "DeleteQuoteByID", query)
+	defer finish(&err)
+
+	res, err := q.db.ExecContext(ctx, query, id, nullUUID(actor.KeyID), uuid.New(), q.events.Outbox, q.events.Webhooks,
+		actor.Moderator, actor.Subject)
+	if err != nil {
+		return fmt.Errorf("run sql query: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return service.ErrRepoNotFound
+	}
+
 	return nil
 }
 
+func (q *QuoteRepository) GetQuoteByID(ctx context.Context, id uuid.UUID) (_ *service.Quote, err error) {
+	const query = `SELECT ` + quoteColumns + ` FROM quote.quotes WHERE id = $1`
+
+	ctx, finish := q.instrument(ctx, "GetQuoteByID", query)
+	defer finish(&err)
+
+	var ret service.Quote
+
+	err = scanQuote(q.db.QueryRowContext(ctx, query, id), &ret)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, service.ErrRepoNotFound
+		}
+		return nil, fmt.Errorf("run sql query: %w", err)
+	}
+
+	return &ret, nil
+}
+
 func (q *QuoteRepository) GetQuotesWithFilter(ctx context.Context, authorFilter string) (_ []service.Quote, err error) {
-	var query = `SELECT id, author, quote FROM quote.quotes`
+	var query = `SELECT ` + quoteColumns + ` FROM quote.quotes`
 
 	args := make([]interface{}, 0)
 	if authorFilter != "" {
@@ -60,6 +295,9 @@ func (q *QuoteRepository) GetQuotesWithFilter(ctx context.Context, authorFilter
 		args = append(args, authorFilter)
 	}
 
+	ctx, finish := q.instrument(ctx, "GetQuotesWithFilter", query)
+	defer finish(&err)
+
 	rows, err := q.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("run sql query: %w", err)
@@ -73,7 +311,7 @@ func (q *QuoteRepository) GetQuotesWithFilter(ctx context.Context, authorFilter
 		quote service.Quote
 	)
 	for rows.Next() {
-		err = rows.Scan(&quote.ID, &quote.Author, &quote.Quote)
+		err = scanQuote(rows, &quote)
 		if err != nil {
 			return nil, fmt.Errorf("scan into row: %w", err)
 		}
@@ -84,15 +322,311 @@ func (q *QuoteRepository) GetQuotesWithFilter(ctx context.Context, authorFilter
 	return ret, nil
 }
 
-func (q *QuoteRepository) GetRandomQuote(ctx context.Context) (*service.Quote, error) {
-	const query = `SELECT id, author, quote FROM quote.quotes ORDER BY random() LIMIT 1`
+func (q *QuoteRepository) ListQuotes(ctx context.Context, filter service.QuoteFilter) (_ []service.Quote, err error) {
+	const query = `
+		SELECT ` + quoteColumns + `
+		FROM quote.quotes
+		WHERE ($1::text = '' OR author = $1)
+			AND ($2 = '' OR created_by = $2)
+			AND ($3::uuid IS NULL OR id > $3)
+			AND ($5 = '' OR id IN (SELECT quote_id FROM quote.quote_tags WHERE tag = $5))
+			AND ($6 = '' OR strpos(lower(author), lower($6)) > 0 OR strpos(lower(quote), lower($6)) > 0)
+		ORDER BY id
+		LIMIT $4`
+
+	ctx, finish := q.instrument(ctx, "ListQuotes", query)
+	defer finish(&err)
+
+	rows, err := q.db.QueryContext(ctx, query,
+		filter.Author, filter.CreatedBy, nullUUID(filter.AfterID), filter.Limit, filter.Tag, filter.Search)
+	if err != nil {
+		return nil, fmt.Errorf("run sql query: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, rows.Close())
+	}()
+
+	var (
+		ret   = make([]service.Quote, 0, filter.Limit)
+		quote service.Quote
+	)
+	for rows.Next() {
+		err = scanQuote(rows, &quote)
+		if err != nil {
+			return nil, fmt.Errorf("scan into row: %w", err)
+		}
+
+		ret = append(ret, quote)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return ret, nil
+}
+
+func (q *QuoteRepository) GetTagsByQuoteIDs(ctx context.Context, ids []uuid.UUID) (_ map[uuid.UUID][]string, err error) {
+	const query = `SELECT quote_id, tag FROM quote.quote_tags WHERE quote_id = ANY ($1::uuid[]) ORDER BY quote_id, tag`
+
+	ctx, finish := q.instrument(ctx, "GetTagsByQuoteIDs", query)
+	defer finish(&err)
+
+	rows, err := q.db.QueryContext(ctx, query, uuidStrings(ids))
+	if err != nil {
+		return nil, fmt.Errorf("run sql query: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, rows.Close())
+	}()
+
+	var (
+		ret = make(map[uuid.UUID][]string)
+		id  uuid.UUID
+		tag string
+	)
+	for rows.Next() {
+		err = rows.Scan(&id, &tag)
+		if err != nil {
+			return nil, fmt.Errorf("scan into row: %w", err)
+		}
+
+		ret[id] = append(ret[id], tag)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return ret, nil
+}
+
+func (q *QuoteRepository) CountQuotesByAuthors(ctx context.Context, authors []string) (_ map[string]int64, err error) {
+	const query = `SELECT author, count(*) FROM quote.quotes WHERE author = ANY ($1::text[]) GROUP BY author`
+
+	ctx, finish := q.instrument(ctx, "CountQuotesByAuthors", query)
+	defer finish(&err)
+
+	rows, err := q.db.QueryContext(ctx, query, authors)
+	if err != nil {
+		return nil, fmt.Errorf("run sql query: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, rows.Close())
+	}()
+
+	var (
+		ret    = make(map[string]int64, len(authors))
+		author string
+		count  int64
+	)
+	for rows.Next() {
+		err = rows.Scan(&author, &count)
+		if err != nil {
+			return nil, fmt.Errorf("scan into row: %w", err)
+		}
+
+		ret[author] = count
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return ret, nil
+}
+
+func (q *QuoteRepository) ListQuotesByAuthors(ctx context.Context, authors []string, limit int) (_ map[string][]service.Quote, err error) {
+	const query = `
+		SELECT ` + quoteColumns + `
+		FROM (
+			SELECT *, row_number() OVER (PARTITION BY author ORDER BY id) AS position
+			FROM quote.quotes
+			WHERE author = ANY ($1::text[])
+		) AS ranked
+		WHERE position <= $2
+		ORDER BY author, id`
+
+	ctx, finish := q.instrument(ctx, "ListQuotesByAuthors", query)
+	defer finish(&err)
+
+	rows, err := q.db.QueryContext(ctx, query, authors, limit)
+	if err != nil {
+		return nil, fmt.Errorf("run sql query: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, rows.Close())
+	}()
+
+	var (
+		ret   = make(map[string][]service.Quote, len(authors))
+		quote service.Quote
+	)
+	for rows.Next() {
+		err = scanQuote(rows, &quote)
+		if err != nil {
+			return nil, fmt.Errorf("scan into row: %w", err)
+		}
+
+		ret[quote.Author] = append(ret[quote.Author], quote)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return ret, nil
+}
+
+func (q *QuoteRepository) ListRecentQuotes(ctx context.Context, filter service.RecentQuoteFilter) (_ []service.Quote, err error) {
+	// The tags are aggregated in the same query, so a feed is built from one round trip.
+	const query = `
+		SELECT ` + quoteColumns + `, ARRAY(SELECT tag FROM quote.quote_tags t WHERE t.quote_id = quotes.id ORDER BY tag)
+		FROM quote.quotes
+		WHERE ($1::text = '' OR author = $1)
+			AND ($2::text = '' OR id IN (SELECT quote_id FROM quote.quote_tags WHERE tag = $2))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3`
+
+	ctx, finish := q.instrument(ctx, "ListRecentQuotes", query)
+	defer finish(&err)
+
+	rows, err := q.db.QueryContext(ctx, query, filter.Author, filter.Tag, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("run sql query: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, rows.Close())
+	}()
+
+	ret := make([]service.Quote, 0, filter.Limit)
+	for rows.Next() {
+		var quote service.Quote
+		err = rows.Scan(&quote.ID, &quote.Author, &quote.Quote, &quote.CreatedBy, &quote.UpdatedBy, &quote.CreatedAt,
+			q.typeMap.SQLScanner(&quote.Tags))
+		if err != nil {
+			return nil, fmt.Errorf("scan into row: %w", err)
+		}
+
+		ret = append(ret, quote)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return ret, nil
+}
+
+func (q *QuoteRepository) GetRandomQuote(ctx context.Context) (_ *service.Quote, err error) {
+	const query = `SELECT ` + quoteColumns + ` FROM quote.quotes ORDER BY random() LIMIT 1`
+
+	ctx, finish := q.instrument(ctx, "GetRandomQuote", query)
+	defer finish(&err)
 
 	var ret service.Quote
 
-	err := q.db.QueryRowContext(ctx, query).Scan(&ret.ID, &ret.Author, &ret.Quote)
+	err = scanQuote(q.db.QueryRowContext(ctx, query), &ret)
 	if err != nil {
 		return nil, fmt.Errorf("run sql query: %w", err)
 	}
 
 	return &ret, nil
 }
+
+func (q *QuoteRepository) GetRandomQuoteWithFilter(ctx context.Context, filter service.RandomQuoteFilter) (_ *service.Quote, err error) {
+	// false sorts before true, so the excluded quote is picked only if no other quote matches.
+	const query = `
+		SELECT ` + quoteColumns + `
+		FROM quote.quotes
+		WHERE ($1::text = '' OR author = $1)
+			AND ($2::text = '' OR id IN (SELECT quote_id FROM quote.quote_tags WHERE tag = $2))
+		ORDER BY id = $3, random()
+		LIMIT 1`
+
+	ctx, finish := q.instrument(ctx, "GetRandomQuoteWithFilter", query)
+	defer finish(&err)
+
+	var ret service.Quote
+
+	err = scanQuote(q.db.QueryRowContext(ctx, query, filter.Author, filter.Tag, filter.ExcludeID), &ret)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, service.ErrRepoNotFound
+		}
+		return nil, fmt.Errorf("run sql query: %w", err)
+	}
+
+	return &ret, nil
+}
+
+func (q *QuoteRepository) GetQuoteStats(ctx context.Context) (_ *service.QuoteStats, err error) {
+	const totalQuery = `SELECT count(*) FROM quote.quotes`
+	const bucketsQuery = `
+		SELECT bucket, count(*)
+		FROM (
+			SELECT CASE
+				WHEN count(*) = 1 THEN '1'
+				WHEN count(*) <= 5 THEN '2-5'
+				WHEN count(*) <= 10 THEN '6-10'
+				WHEN count(*) <= 50 THEN '11-50'
+				ELSE '51+'
+			END AS bucket
+			FROM quote.quotes
+			GROUP BY author
+		) AS authors
+		GROUP BY bucket`
+
+	ctx, finish := q.instrument(ctx, "GetQuoteStats", totalQuery+";"+bucketsQuery)
+	defer finish(&err)
+
+	var ret service.QuoteStats
+
+	err = q.db.QueryRowContext(ctx, totalQuery).Scan(&ret.Total)
+	if err != nil {
+		return nil, fmt.Errorf("run total sql query: %w", err)
+	}
+
+	rows, err := q.db.QueryContext(ctx, bucketsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("run buckets sql query: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, rows.Close())
+	}()
+
+	var bucket service.AuthorBucket
+	for rows.Next() {
+		err = rows.Scan(&bucket.Name, &bucket.Authors)
+		if err != nil {
+			return nil, fmt.Errorf("scan into row: %w", err)
+		}
+
+		ret.AuthorBuckets = append(ret.AuthorBuckets, bucket)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return &ret, nil
+}
+
+// quoteColumns are the columns scanned by scanQuote.
+const quoteColumns = `id, author, quote, COALESCE(created_by, ''), COALESCE(updated_by, ''), created_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanQuote(row rowScanner, quote *service.Quote) error {
+	return row.Scan(&quote.ID, &quote.Author, &quote.Quote, &quote.CreatedBy, &quote.UpdatedBy, &quote.CreatedAt)
+}
+
+// uuidStrings converts the IDs for uuid[] parameters.
+func uuidStrings(ids []uuid.UUID) []string {
+	ret := make([]string, len(ids))
+	for i, id := range ids {
+		ret[i] = id.String()
+	}
+
+	return ret
+}
+
+func nullUUID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: id != uuid.Nil}
+}
@@ -0,0 +1,94 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"github.com/BernsteinMondy/quote-service/src/internal/service"
+	"github.com/google/uuid"
+	"testing"
+	"time"
+)
+
+func TestQuoteRepository_EventRecording(t *testing.T) {
+	db := openTestDatabase(t)
+	ctx := context.Background()
+
+	testCases := []struct {
+		name       string
+		events     EventRecording
+		wantOutbox int
+	}{
+		{name: "Outbox enabled records the event", events: EventRecording{Outbox: true}, wantOutbox: 1},
+		{name: "Outbox disabled records nothing", events: EventRecording{}, wantOutbox: 0},
+	}
+
+	for _, tc := range testCases {
+		repo := NewQuoteRepository(db, nil, tc.events)
+		quote := &service.Quote{ID: uuid.New(), Author: "author", Quote: "quote", CreatedAt: time.Now()}
+
+		err := repo.CreateNewQuote(ctx, quote, service.Actor{})
+		if err != nil {
+			t.Fatalf("%s: CreateNewQuote() unexpected error = %v", tc.name, err)
+		}
+		err = repo.DeleteQuoteByID(ctx, quote.ID, service.Actor{Moderator: true})
+		if err != nil {
+			t.Fatalf("%s: DeleteQuoteByID() unexpected error = %v", tc.name, err)
+		}
+
+		var n int
+		err = db.QueryRowContext(ctx, `SELECT count(*) FROM quote.outbox_events WHERE aggregate_id = $1`, quote.ID).Scan(&n)
+		if err != nil {
+			t.Fatalf("%s: failed to count outbox events: %v", tc.name, err)
+		}
+		if n != 2*tc.wantOutbox {
+			t.Errorf("%s: got %d outbox events want %d", tc.name, n, 2*tc.wantOutbox)
+		}
+	}
+}
+
+func TestQuoteRepository_OwnerCondition(t *testing.T) {
+	db := openTestDatabase(t)
+	ctx := context.Background()
+	repo := NewQuoteRepository(db, nil, EventRecording{})
+
+	quote := &service.Quote{ID: uuid.New(), Author: "author", Quote: "quote", CreatedBy: "owner", CreatedAt: time.Now()}
+	err := repo.CreateNewQuote(ctx, quote, service.Actor{Subject: "owner"})
+	if err != nil {
+		t.Fatalf("CreateNewQuote() unexpected error = %v", err)
+	}
+
+	type testCase struct {
+		name    string
+		actor   service.Actor
+		wantErr error
+	}
+
+	testCases := []testCase{
+		{name: "Anonymous actor writes nothing", actor: service.Actor{}, wantErr: service.ErrRepoNotFound},
+		{name: "Other contributor writes nothing", actor: service.Actor{Subject: "other"}, wantErr: service.ErrRepoNotFound},
+		{name: "Creator updates the quote", actor: service.Actor{Subject: "owner"}},
+		{name: "Moderator updates the quote", actor: service.Actor{Subject: "moderator", Moderator: true}},
+	}
+
+	for _, tc := range testCases {
+		updated := *quote
+		updated.Quote, updated.UpdatedBy = "updated by "+tc.name, tc.actor.Subject
+		err = repo.UpdateQuote(ctx, &updated, tc.actor)
+		if !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: UpdateQuote() error = %v, want %v", tc.name, err, tc.wantErr)
+		}
+		err = repo.DeleteQuoteByID(ctx, quote.ID, tc.actor)
+		if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: DeleteQuoteByID() error = %v, want %v", tc.name, err, tc.wantErr)
+		}
+		if tc.wantErr == nil {
+			if err != nil {
+				t.Errorf("%s: DeleteQuoteByID() unexpected error = %v", tc.name, err)
+			}
+			err = repo.CreateNewQuote(ctx, quote, service.Actor{Subject: "owner"})
+			if err != nil {
+				t.Fatalf("%s: CreateNewQuote() unexpected error = %v", tc.name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"github.com/BernsteinMondy/quote-service/src/internal/ratelimit"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+const redisRateLimitKeyPrefix = "quote-service:rate-limit:"
+
+// takeTokenScript refills the bucket hash and takes a token atomically. Buckets expire once
+// they are full again, then they are indistinguishable from the buckets created on the next take.
+var takeTokenScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "updated_at")
+local tokens = tonumber(bucket[1])
+local updated_at = tonumber(bucket[2])
+if tokens == nil or updated_at == nil then
+	tokens = burst
+	updated_at = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - updated_at) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "updated_at", tostring(now))
+redis.call("PEXPIRE", KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+
+return {allowed, tostring(tokens)}
+`)
+
+// RedisRateLimitStore keeps the token buckets in Redis or a Redis compatible server, so the
+// limits are shared by all replicas. The clocks of the replicas must be in sync.
+type RedisRateLimitStore struct {
+	client redis.Scripter
+	now    func() time.Time
+}
+
+var _ ratelimit.Store = (*RedisRateLimitStore)(nil)
+
+func NewRedisRateLimitStore(client redis.Scripter) *RedisRateLimitStore {
+	return &RedisRateLimitStore{client: client, now: time.Now}
+}
+
+func (s *RedisRateLimitStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	now := float64(s.now().UnixMicro()) / 1e6
+
+	res, err := takeTokenScript.Run(ctx, s.client, []string{redisRateLimitKeyPrefix + key},
+		limit.Rate, limit.Burst, strconv.FormatFloat(now, 'f', -1, 64)).Slice()
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("run take token script: %w", err)
+	}
+	if len(res) != 2 {
+		return ratelimit.Result{}, fmt.Errorf("unexpected take token script result %v", res)
+	}
+
+	allowed, _ := res[0].(int64)
+	rawTokens, _ := res[1].(string)
+	tokens, err := strconv.ParseFloat(rawTokens, 64)
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("parse tokens: %w", err)
+	}
+
+	return ratelimit.NewResult(limit, tokens, allowed == 1), nil
+}
@@ -0,0 +1,57 @@
+package impl
+
+import (
+	"context"
+	"github.com/BernsteinMondy/quote-service/src/internal/ratelimit"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"testing"
+	"time"
+)
+
+func TestRedisRateLimitStore_Take(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer func() { _ = client.Close() }()
+
+	now := time.Unix(1_700_000_000, 0)
+	store := NewRedisRateLimitStore(client)
+	store.now = func() time.Time { return now }
+	limit := ratelimit.Limit{Rate: 2, Burst: 2}
+
+	type step struct {
+		advance    time.Duration
+		want       bool
+		remaining  int
+		retryAfter time.Duration
+	}
+
+	steps := []step{
+		{want: true, remaining: 1},
+		{want: true, remaining: 0},
+		{want: false, remaining: 0, retryAfter: 500 * time.Millisecond},
+		{advance: 250 * time.Millisecond, want: false, remaining: 0, retryAfter: 250 * time.Millisecond},
+		{advance: 250 * time.Millisecond, want: true, remaining: 0},
+	}
+
+	for i, s := range steps {
+		now = now.Add(s.advance)
+		res, err := store.Take(context.Background(), "client", limit)
+		if err != nil {
+			t.Fatalf("step %d: Take() unexpected error = %v", i, err)
+		}
+		if res.Allowed != s.want || res.Remaining != s.remaining || res.RetryAfter != s.retryAfter {
+			t.Errorf("step %d: Take() = %+v, want allowed %v, remaining %d, retry after %s", i, res, s.want, s.remaining, s.retryAfter)
+		}
+	}
+
+	ttl := server.TTL(redisRateLimitKeyPrefix + "client")
+	if ttl <= 0 || ttl > 3*time.Second {
+		t.Errorf("bucket expires in %s, want it to expire shortly after it is full", ttl)
+	}
+
+	server.FastForward(ttl)
+	if server.Exists(redisRateLimitKeyPrefix + "client") {
+		t.Error("bucket did not expire")
+	}
+}
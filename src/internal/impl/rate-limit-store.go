@@ -0,0 +1,80 @@
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/BernsteinMondy/quote-service/src/internal/ratelimit"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+const rateLimitSweepInterval = time.Minute
+
+// RateLimitStore keeps the token buckets in Postgres, so the limits are shared by all replicas.
+type RateLimitStore struct {
+	db        *sql.DB
+	lastSweep atomic.Int64
+}
+
+var _ ratelimit.Store = (*RateLimitStore)(nil)
+
+func NewRateLimitStore(db *sql.DB) *RateLimitStore {
+	return &RateLimitStore{db: db}
+}
+
+func (s *RateLimitStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	// refilled is the token count of the stored bucket b after refilling it up to now.
+	const refilled = `LEAST($2, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * $3)`
+	// The bucket is refilled and a token taken in one statement. The update is skipped if the
+	// refilled bucket holds less than one token, then no row is returned.
+	const takeQuery = `
+		INSERT INTO quote.rate_limits AS b (key, tokens, updated_at, full_at)
+		VALUES ($1, $2::float8 - 1, now(), now() + make_interval(secs => 1 / $3::float8))
+		ON CONFLICT (key) DO UPDATE
+		SET tokens     = ` + refilled + ` - 1,
+		    updated_at = now(),
+		    full_at    = now() + make_interval(secs => ($2 - ` + refilled + ` + 1) / $3)
+		WHERE ` + refilled + ` >= 1
+		RETURNING b.tokens`
+	const peekQuery = `
+		SELECT ` + refilled + `
+		FROM quote.rate_limits AS b WHERE key = $1`
+
+	s.sweep(ctx)
+
+	var tokens float64
+	err := s.db.QueryRowContext(ctx, takeQuery, key, limit.Burst, limit.Rate).Scan(&tokens)
+	if err == nil {
+		return ratelimit.NewResult(limit, tokens, true), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return ratelimit.Result{}, fmt.Errorf("run take sql query: %w", err)
+	}
+
+	err = s.db.QueryRowContext(ctx, peekQuery, key, limit.Burst, limit.Rate).Scan(&tokens)
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("run peek sql query: %w", err)
+	}
+
+	return ratelimit.NewResult(limit, tokens, false), nil
+}
+
+// sweep deletes full buckets at most once per rateLimitSweepInterval per replica, they are
+// indistinguishable from the buckets created on the next take.
+func (s *RateLimitStore) sweep(ctx context.Context) {
+	const query = `DELETE FROM quote.rate_limits WHERE full_at < now()`
+
+	last := s.lastSweep.Load()
+	now := time.Now().UnixNano()
+	if now-last < int64(rateLimitSweepInterval) || !s.lastSweep.CompareAndSwap(last, now) {
+		return
+	}
+
+	_, err := s.db.ExecContext(ctx, query)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to sweep rate limit buckets", slog.String("error", err.Error()))
+	}
+}
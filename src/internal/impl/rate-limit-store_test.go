@@ -0,0 +1,77 @@
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"github.com/BernsteinMondy/quote-service/migrations"
+	"github.com/BernsteinMondy/quote-service/src/internal/ratelimit"
+	"github.com/BernsteinMondy/quote-service/src/pkg/database"
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"os"
+	"testing"
+	"time"
+)
+
+// testDatabaseURLEnv names the Postgres connection string the store tests run against, they are
+// skipped if it is not set. The migrations are applied to the database.
+const testDatabaseURLEnv = "TEST_DATABASE_URL"
+
+func openTestDatabase(t *testing.T) *sql.DB {
+	t.Helper()
+
+	url := os.Getenv(testDatabaseURLEnv)
+	if url == "" {
+		t.Skip(testDatabaseURLEnv + " is not set")
+	}
+
+	db, err := sql.Open("pgx", url)
+	if err != nil {
+		t.Fatal("Failed to open database", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	migrator, err := database.NewMigrator(db, migrations.FS)
+	if err != nil {
+		t.Fatal("Failed to create migrator", err)
+	}
+	if err = migrator.Up(context.Background()); err != nil {
+		t.Fatal("Failed to migrate database", err)
+	}
+
+	return db
+}
+
+func TestRateLimitStore_Take(t *testing.T) {
+	store := NewRateLimitStore(openTestDatabase(t))
+	// The buckets refill with the clock of the database, so the rate is slow enough for the
+	// test not to observe a refill.
+	limit := ratelimit.Limit{Rate: 1.0 / 3600, Burst: 2}
+	key := "client:" + uuid.NewString()
+
+	type step struct {
+		key       string
+		want      bool
+		remaining int
+	}
+
+	steps := []step{
+		{key: key, want: true, remaining: 1},
+		{key: key, want: true, remaining: 0},
+		{key: key, want: false, remaining: 0},
+		{key: key + ":other", want: true, remaining: 1},
+	}
+
+	for i, s := range steps {
+		res, err := store.Take(context.Background(), s.key, limit)
+		if err != nil {
+			t.Fatalf("step %d: Take() unexpected error = %v", i, err)
+		}
+		if res.Allowed != s.want || res.Remaining != s.remaining {
+			t.Errorf("step %d: Take() = %+v, want allowed %v, remaining %d", i, res, s.want, s.remaining)
+		}
+		if !res.Allowed && (res.RetryAfter <= 0 || res.RetryAfter > time.Hour) {
+			t.Errorf("step %d: Take() retry after %s, want at most an hour", i, res.RetryAfter)
+		}
+	}
+}
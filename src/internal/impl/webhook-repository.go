@@ -0,0 +1,297 @@
+package impl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/BernsteinMondy/quote-service/src/internal/webhooks"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"time"
+)
+
+// WebhookRepository stores the webhooks and their deliveries. The deliveries are queued by the
+// statements of QuoteRepository writing the quotes.
+type WebhookRepository struct {
+	db      *sql.DB
+	typeMap *pgtype.Map
+}
+
+var _ webhooks.Repository = (*WebhookRepository)(nil)
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db, typeMap: pgtype.NewMap()}
+}
+
+func (w *WebhookRepository) CreateWebhook(ctx context.Context, webhook *webhooks.Webhook, secret string) error {
+	const query = `
+		INSERT INTO quote.webhooks (id, url, secret, events, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := w.db.ExecContext(ctx, query, webhook.ID, webhook.URL, secret, eventTypesToStrings(webhook.Events),
+		webhook.Active, webhook.CreatedAt, webhook.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("run sql query: %w", err)
+	}
+
+	return nil
+}
+
+func (w *WebhookRepository) GetWebhookByID(ctx context.Context, id uuid.UUID) (*webhooks.Webhook, error) {
+	const query = `SELECT ` + webhookColumns + ` FROM quote.webhooks WHERE id = $1`
+
+	webhook, err := w.scanWebhook(w.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, webhooks.ErrRepoNotFound
+		}
+		return nil, fmt.Errorf("run sql query: %w", err)
+	}
+
+	return webhook, nil
+}
+
+func (w *WebhookRepository) ListWebhooks(ctx context.Context) (_ []webhooks.Webhook, err error) {
+	const query = `SELECT ` + webhookColumns + ` FROM quote.webhooks ORDER BY created_at`
+
+	rows, err := w.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("run sql query: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, rows.Close())
+	}()
+
+	ret := make([]webhooks.Webhook, 0)
+	for rows.Next() {
+		webhook, err := w.scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan into row: %w", err)
+		}
+		ret = append(ret, *webhook)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return ret, nil
+}
+
+func (w *WebhookRepository) UpdateWebhook(ctx context.Context, webhook *webhooks.Webhook) error {
+	const query = `UPDATE quote.webhooks SET url = $2, events = $3, active = $4, updated_at = $5 WHERE id = $1`
+
+	res, err := w.db.ExecContext(ctx, query, webhook.ID, webhook.URL, eventTypesToStrings(webhook.Events), webhook.Active, webhook.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("run sql query: %w", err)
+	}
+
+	return requireRowsAffected(res, webhooks.ErrRepoNotFound)
+}
+
+func (w *WebhookRepository) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	const query = `DELETE FROM quote.webhooks WHERE id = $1`
+
+	res, err := w.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("run sql query: %w", err)
+	}
+
+	return requireRowsAffected(res, webhooks.ErrRepoNotFound)
+}
+
+func (w *WebhookRepository) ListDeliveries(ctx context.Context, webhookID uuid.UUID, filter webhooks.DeliveryFilter) (_ []webhooks.Delivery, err error) {
+	const query = `
+		SELECT ` + deliveryColumns + `
+		FROM quote.webhook_deliveries
+		WHERE webhook_id = $1 AND ($2::text = '' OR status = $2) AND ($3::bigint = 0 OR id < $3)
+		ORDER BY id DESC
+		LIMIT $4`
+
+	rows, err := w.db.QueryContext(ctx, query, webhookID, string(filter.Status), filter.BeforeID, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("run sql query: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, rows.Close())
+	}()
+
+	ret := make([]webhooks.Delivery, 0)
+	for rows.Next() {
+		var delivery webhooks.Delivery
+		err = scanDelivery(rows, &delivery)
+		if err != nil {
+			return nil, fmt.Errorf("scan into row: %w", err)
+		}
+		ret = append(ret, delivery)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return ret, nil
+}
+
+func (w *WebhookRepository) RetryDelivery(ctx context.Context, webhookID uuid.UUID, deliveryID int64) error {
+	// The attempts are reset, so the retried delivery gets the full backoff again.
+	const query = `
+		UPDATE quote.webhook_deliveries SET status = 'pending', attempts = 0, next_attempt_at = now()
+		WHERE id = $2 AND webhook_id = $1 AND status = 'dead'`
+
+	res, err := w.db.ExecContext(ctx, query, webhookID, deliveryID)
+	if err != nil {
+		return fmt.Errorf("run sql query: %w", err)
+	}
+
+	return requireRowsAffected(res, webhooks.ErrRepoNotFound)
+}
+
+func (w *WebhookRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) (_ []webhooks.Attempt, err error) {
+	// SKIP LOCKED lets the replicas claim disjoint batches, the lease moves the claimed deliveries
+	// out of the due ones until the attempt is completed or the dispatcher died.
+	const query = `
+		WITH claimed AS (
+			UPDATE quote.webhook_deliveries SET attempts = attempts + 1, next_attempt_at = now() + $2::bigint * interval '1 millisecond'
+			WHERE id IN (
+				SELECT d.id
+				FROM quote.webhook_deliveries d
+				JOIN quote.webhooks w ON w.id = d.webhook_id
+				WHERE d.status = 'pending' AND d.next_attempt_at <= now() AND w.active
+				ORDER BY d.next_attempt_at
+				LIMIT $1
+				FOR UPDATE OF d SKIP LOCKED
+			)
+			RETURNING ` + deliveryColumns + `
+		)
+		SELECT claimed.*, w.url, w.secret
+		FROM claimed
+		JOIN quote.webhooks w ON w.id = claimed.webhook_id`
+
+	rows, err := w.db.QueryContext(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("run sql query: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, rows.Close())
+	}()
+
+	ret := make([]webhooks.Attempt, 0)
+	for rows.Next() {
+		var attempt webhooks.Attempt
+		err = scanDelivery(rows, &attempt.Delivery, &attempt.URL, &attempt.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("scan into row: %w", err)
+		}
+		ret = append(ret, attempt)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate rows: %w", err)
+	}
+
+	return ret, nil
+}
+
+func (w *WebhookRepository) CompleteAttempt(ctx context.Context, deliveryID int64, result webhooks.AttemptResult) error {
+	const query = `
+		UPDATE quote.webhook_deliveries
+		SET status = $2::text,
+			next_attempt_at = CASE WHEN $2 = 'pending' THEN $3 ELSE next_attempt_at END,
+			last_status_code = NULLIF($4, 0),
+			last_error = NULLIF($5, ''),
+			delivered_at = CASE WHEN $2 = 'delivered' THEN now() END
+		WHERE id = $1`
+
+	res, err := w.db.ExecContext(ctx, query, deliveryID, string(result.Status), result.NextAttemptAt, result.StatusCode, result.Error)
+	if err != nil {
+		return fmt.Errorf("run sql query: %w", err)
+	}
+
+	return requireRowsAffected(res, webhooks.ErrRepoNotFound)
+}
+
+func (w *WebhookRepository) DeleteDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	const query = `DELETE FROM quote.webhook_deliveries WHERE status <> 'pending' AND created_at < $1`
+
+	res, err := w.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("run sql query: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get rows affected: %w", err)
+	}
+
+	return n, nil
+}
+
+// webhookColumns are the columns scanned by scanWebhook, the secret is read by ClaimDeliveries only.
+const webhookColumns = `id, url, events, active, created_at, updated_at`
+
+func (w *WebhookRepository) scanWebhook(row rowScanner) (*webhooks.Webhook, error) {
+	var (
+		webhook webhooks.Webhook
+		events  []string
+	)
+	err := row.Scan(&webhook.ID, &webhook.URL, w.typeMap.SQLScanner(&events), &webhook.Active, &webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.Events = make([]webhooks.EventType, len(events))
+	for i, event := range events {
+		webhook.Events[i] = webhooks.EventType(event)
+	}
+
+	return &webhook, nil
+}
+
+// deliveryColumns are the columns scanned by scanDelivery.
+const deliveryColumns = `id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+	COALESCE(last_status_code, 0), COALESCE(last_error, ''), created_at, delivered_at`
+
+// scanDelivery scans the deliveryColumns followed by extra.
+func scanDelivery(row rowScanner, delivery *webhooks.Delivery, extra ...any) error {
+	var (
+		payload     []byte
+		deliveredAt sql.NullTime
+	)
+	dest := append([]any{
+		&delivery.ID, &delivery.WebhookID, &delivery.EventID, &delivery.EventType, &payload, &delivery.Status,
+		&delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastStatusCode, &delivery.LastError, &delivery.CreatedAt,
+		&deliveredAt,
+	}, extra...)
+
+	err := row.Scan(dest...)
+	if err != nil {
+		return err
+	}
+
+	delivery.Payload = payload
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+
+	return nil
+}
+
+func eventTypesToStrings(events []webhooks.EventType) []string {
+	ret := make([]string, len(events))
+	for i, event := range events {
+		ret[i] = string(event)
+	}
+	return ret
+}
+
+// requireRowsAffected returns notFound if the statement changed no row.
+func requireRowsAffected(res sql.Result, notFound error) error {
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return notFound
+	}
+
+	return nil
+}
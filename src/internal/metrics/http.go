@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"strconv"
+	"time"
+)
+
+// HTTP holds the RED metrics of the HTTP server. Requests are labelled by the route
+// template rather than the raw path to keep the cardinality bounded.
+type HTTP struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+}
+
+func NewHTTP(reg prometheus.Registerer) *HTTP {
+	m := &HTTP{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of handled HTTP requests.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of handled HTTP requests.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_in_flight",
+			Help:      "Number of HTTP requests currently being handled.",
+		}, []string{"route"}),
+	}
+
+	reg.MustRegister(m.requests, m.duration, m.inFlight)
+
+	return m
+}
+
+// TrackInFlight increments the in-flight gauge of the route, the returned func decrements it.
+func (m *HTTP) TrackInFlight(route string) func() {
+	gauge := m.inFlight.WithLabelValues(route)
+	gauge.Inc()
+	return gauge.Dec
+}
+
+func (m *HTTP) ObserveRequest(method, route string, status int, duration time.Duration) {
+	statusLabel := strconv.Itoa(status)
+	m.requests.WithLabelValues(method, route, statusLabel).Inc()
+	m.duration.WithLabelValues(method, route, statusLabel).Observe(duration.Seconds())
+}
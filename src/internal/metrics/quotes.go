@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"context"
+	"github.com/BernsteinMondy/quote-service/src/internal/service"
+	"github.com/prometheus/client_golang/prometheus"
+	"log/slog"
+	"time"
+)
+
+const quoteStatsTimeout = 5 * time.Second
+
+type QuoteStatsSource interface {
+	GetQuoteStats(ctx context.Context) (*service.QuoteStats, error)
+}
+
+// QuoteStats collects the business gauges on every scrape.
+type QuoteStats struct {
+	source QuoteStatsSource
+
+	total   *prometheus.Desc
+	authors *prometheus.Desc
+}
+
+var _ prometheus.Collector = (*QuoteStats)(nil)
+
+func RegisterQuoteStats(reg prometheus.Registerer, source QuoteStatsSource) {
+	reg.MustRegister(&QuoteStats{
+		source: source,
+		total: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "quotes", "total"),
+			"Number of stored quotes.",
+			nil, nil,
+		),
+		authors: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "quotes", "authors"),
+			"Number of authors grouped by the number of their quotes.",
+			[]string{"quotes_bucket"}, nil,
+		),
+	})
+}
+
+func (c *QuoteStats) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.total
+	ch <- c.authors
+}
+
+func (c *QuoteStats) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), quoteStatsTimeout)
+	defer cancel()
+
+	stats, err := c.source.GetQuoteStats(ctx)
+	if err != nil {
+		slog.Error("Failed to collect quote stats", slog.String("error", err.Error()))
+		ch <- prometheus.NewInvalidMetric(c.total, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(stats.Total))
+	for _, bucket := range stats.AuthorBuckets {
+		ch <- prometheus.MustNewConstMetric(c.authors, prometheus.GaugeValue, float64(bucket.Authors), bucket.Name)
+	}
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+// Repository holds the query latency of the repository methods.
+type Repository struct {
+	duration *prometheus.HistogramVec
+}
+
+func NewRepository(reg prometheus.Registerer) *Repository {
+	m := &Repository{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "repository",
+			Name:      "query_duration_seconds",
+			Help:      "Latency of repository methods.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"method", "outcome"}),
+	}
+
+	reg.MustRegister(m.duration)
+
+	return m
+}
+
+func (m *Repository) ObserveQuery(method string, duration time.Duration, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+
+	m.duration.WithLabelValues(method, outcome).Observe(duration.Seconds())
+}
@@ -0,0 +1,63 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/nats-io/nats.go"
+	"time"
+)
+
+// DefaultNATSSubjectPrefix is prepended to the event types, e.g. "quotes.events.QuoteCreated".
+const DefaultNATSSubjectPrefix = "quotes.events."
+
+// natsFlushTimeout bounds the flush of a batch if ctx has no deadline.
+const natsFlushTimeout = 10 * time.Second
+
+// NATSPublisher publishes every event to the subject of its type. The event ID is sent in the
+// Nats-Msg-Id header, so JetStream streams capturing the subjects deduplicate repeated events.
+type NATSPublisher struct {
+	conn          *nats.Conn
+	subjectPrefix string
+}
+
+// NewNATSPublisher uses DefaultNATSSubjectPrefix if subjectPrefix is empty. The connection is not
+// closed by the publisher.
+func NewNATSPublisher(conn *nats.Conn, subjectPrefix string) *NATSPublisher {
+	if subjectPrefix == "" {
+		subjectPrefix = DefaultNATSSubjectPrefix
+	}
+
+	return &NATSPublisher{conn: conn, subjectPrefix: subjectPrefix}
+}
+
+func (p *NATSPublisher) Publish(ctx context.Context, events []Event) error {
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("marshal event: %w", err)
+		}
+
+		msg := nats.NewMsg(p.subjectPrefix + string(event.Type))
+		msg.Header.Set(nats.MsgIdHdr, event.ID.String())
+		msg.Data = data
+
+		err = p.conn.PublishMsg(msg)
+		if err != nil {
+			return fmt.Errorf("publish message: %w", err)
+		}
+	}
+
+	// The messages are buffered by the client, the round trip confirms the server received them.
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, natsFlushTimeout)
+		defer cancel()
+	}
+	err := p.conn.FlushWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("flush connection: %w", err)
+	}
+
+	return nil
+}
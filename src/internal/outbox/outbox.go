@@ -0,0 +1,141 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"log/slog"
+	"time"
+)
+
+// EventType names the domain events of the quotes.
+type EventType string
+
+const (
+	QuoteCreated EventType = "QuoteCreated"
+	QuoteUpdated EventType = "QuoteUpdated"
+	QuoteDeleted EventType = "QuoteDeleted"
+)
+
+// Event is a domain event recorded in the outbox in the same transaction as the write causing it.
+type Event struct {
+	// Sequence orders the events of the outbox, it increases with every event but may have gaps.
+	Sequence int64     `json:"sequence"`
+	ID       uuid.UUID `json:"id"`
+	Type     EventType `json:"type"`
+	// AggregateID is the ID of the quote.
+	AggregateID uuid.UUID `json:"aggregate_id"`
+	OccurredAt  time.Time `json:"occurred_at"`
+	// Data holds the quote with its tags, as written or before the delete.
+	Data json.RawMessage `json:"data"`
+}
+
+type Publisher interface {
+	// Publish must return nil only once the events are published. It is called again with the same
+	// events if it fails, so consumers must deduplicate the events by their ID.
+	Publish(ctx context.Context, events []Event) error
+}
+
+type Store interface {
+	// PublishPending must call publish with up to limit unpublished events in sequence order, and
+	// mark them published if it returns nil. The events must not be passed to another concurrent
+	// call meanwhile. It returns the number of published events.
+	PublishPending(ctx context.Context, limit int, publish func(ctx context.Context, events []Event) error) (int, error)
+	// DeletePublished deletes the events published before the time.
+	DeletePublished(ctx context.Context, before time.Time) (int64, error)
+}
+
+type RelayConfig struct {
+	// PollInterval is the delay between the polls for unpublished events while there are none.
+	PollInterval time.Duration
+	BatchSize    int
+	// Retention is how long published events are kept, zero keeps them forever.
+	Retention time.Duration
+}
+
+var DefaultRelayConfig = RelayConfig{
+	PollInterval: time.Second,
+	BatchSize:    100,
+	Retention:    7 * 24 * time.Hour,
+}
+
+// pruneInterval is the interval of the deletes of the events past the retention.
+const pruneInterval = time.Hour
+
+// Relay publishes the events of the outbox. Events are published at least once and in order,
+// unless the relays of several replicas publish consecutive batches concurrently.
+type Relay struct {
+	store     Store
+	publisher Publisher
+	cfg       RelayConfig
+}
+
+// NewRelay uses the defaults for the zero PollInterval and BatchSize of cfg.
+func NewRelay(store Store, publisher Publisher, cfg RelayConfig) *Relay {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultRelayConfig.PollInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultRelayConfig.BatchSize
+	}
+
+	return &Relay{store: store, publisher: publisher, cfg: cfg}
+}
+
+// Run publishes the events until ctx is done.
+func (r *Relay) Run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	var lastPrune time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		n, err := r.PublishPending(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to publish outbox events", slog.String("error", err.Error()))
+		}
+
+		if r.cfg.Retention > 0 && time.Since(lastPrune) >= pruneInterval {
+			lastPrune = time.Now()
+			err := r.prune(ctx)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to delete published outbox events", slog.String("error", err.Error()))
+			}
+		}
+
+		// A full batch suggests more events are unpublished.
+		delay := r.cfg.PollInterval
+		if err == nil && n == r.cfg.BatchSize {
+			delay = 0
+		}
+		timer.Reset(delay)
+	}
+}
+
+// PublishPending publishes one batch of events and returns its size.
+func (r *Relay) PublishPending(ctx context.Context) (int, error) {
+	n, err := r.store.PublishPending(ctx, r.cfg.BatchSize, r.publisher.Publish)
+	if err != nil {
+		return 0, fmt.Errorf("outbox store: publish pending: %w", err)
+	}
+
+	return n, nil
+}
+
+func (r *Relay) prune(ctx context.Context) error {
+	n, err := r.store.DeletePublished(ctx, time.Now().Add(-r.cfg.Retention))
+	if err != nil {
+		return fmt.Errorf("outbox store: delete published: %w", err)
+	}
+	if n > 0 {
+		slog.DebugContext(ctx, "Deleted published outbox events", slog.Int64("count", n))
+	}
+
+	return nil
+}
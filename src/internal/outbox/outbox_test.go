@@ -0,0 +1,265 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryStore struct {
+	mu        sync.Mutex
+	events    []Event
+	published map[int64]time.Time
+}
+
+func newMemoryStore(n int) *memoryStore {
+	store := &memoryStore{published: map[int64]time.Time{}}
+	for i := range n {
+		store.events = append(store.events, Event{
+			Sequence:    int64(i + 1),
+			ID:          uuid.New(),
+			Type:        QuoteCreated,
+			AggregateID: uuid.New(),
+			OccurredAt:  time.Now().UTC(),
+			Data:        json.RawMessage(`{"author":"test author"}`),
+		})
+	}
+	return store
+}
+
+func (m *memoryStore) PublishPending(ctx context.Context, limit int, publish func(context.Context, []Event) error) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var batch []Event
+	for _, event := range m.events {
+		if _, ok := m.published[event.Sequence]; !ok && len(batch) < limit {
+			batch = append(batch, event)
+		}
+	}
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	err := publish(ctx, batch)
+	if err != nil {
+		return 0, err
+	}
+	for _, event := range batch {
+		m.published[event.Sequence] = time.Now()
+	}
+	return len(batch), nil
+}
+
+func (m *memoryStore) DeletePublished(_ context.Context, before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	m.events = slices.DeleteFunc(m.events, func(event Event) bool {
+		publishedAt, ok := m.published[event.Sequence]
+		if ok && publishedAt.Before(before) {
+			n++
+			return true
+		}
+		return false
+	})
+	return n, nil
+}
+
+type recordingPublisher struct {
+	mu        sync.Mutex
+	sequences []int64
+	failures  int
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, events []Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("broker unavailable")
+	}
+	for _, event := range events {
+		p.sequences = append(p.sequences, event.Sequence)
+	}
+	return nil
+}
+
+func TestRelay_PublishPending(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore(5)
+	publisher := &recordingPublisher{failures: 1}
+	relay := NewRelay(store, publisher, RelayConfig{BatchSize: 2})
+
+	_, err := relay.PublishPending(ctx)
+	if err == nil {
+		t.Fatal("PublishPending() error = nil want the error of the publisher")
+	}
+
+	for _, want := range []int{2, 2, 1, 0} {
+		n, err := relay.PublishPending(ctx)
+		if err != nil || n != want {
+			t.Fatalf("PublishPending() = %d, %v want %d, nil", n, err, want)
+		}
+	}
+
+	if want := []int64{1, 2, 3, 4, 5}; !slices.Equal(publisher.sequences, want) {
+		t.Errorf("got published sequences %v want %v", publisher.sequences, want)
+	}
+}
+
+func TestMultiPublisher(t *testing.T) {
+	ctx := context.Background()
+	store := newMemoryStore(2)
+	first, second := &recordingPublisher{}, &recordingPublisher{failures: 1}
+	relay := NewRelay(store, MultiPublisher{first, second}, RelayConfig{})
+
+	_, err := relay.PublishPending(ctx)
+	if err == nil {
+		t.Fatal("PublishPending() error = nil want the error of the second publisher")
+	}
+	n, err := relay.PublishPending(ctx)
+	if err != nil || n != 2 {
+		t.Fatalf("PublishPending() = %d, %v want 2, nil", n, err)
+	}
+
+	if want := []int64{1, 2, 1, 2}; !slices.Equal(first.sequences, want) {
+		t.Errorf("got sequences %v published by the first publisher want %v", first.sequences, want)
+	}
+	if want := []int64{1, 2}; !slices.Equal(second.sequences, want) {
+		t.Errorf("got sequences %v published by the second publisher want %v", second.sequences, want)
+	}
+}
+
+func TestRelay_Run(t *testing.T) {
+	store := newMemoryStore(3)
+	publisher := &recordingPublisher{}
+	relay := NewRelay(store, publisher, RelayConfig{PollInterval: 10 * time.Millisecond, Retention: time.Nanosecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		relay.Run(ctx)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		publisher.mu.Lock()
+		n := len(publisher.sequences)
+		publisher.mu.Unlock()
+		if n == 3 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if len(publisher.sequences) != 3 {
+		t.Errorf("got %d published events want 3", len(publisher.sequences))
+	}
+}
+
+func TestFilePublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	store := newMemoryStore(3)
+
+	for range 2 {
+		publisher, err := OpenFilePublisher(path)
+		if err != nil {
+			t.Fatal("Failed to open file publisher", err)
+		}
+		_, err = NewRelay(store, publisher, RelayConfig{BatchSize: 2}).PublishPending(context.Background())
+		if err != nil {
+			t.Fatal("Failed to publish events", err)
+		}
+		err = publisher.Close()
+		if err != nil {
+			t.Fatal("Failed to close file publisher", err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal("Failed to open events file", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var sequences []int64
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event Event
+		err = json.Unmarshal(scanner.Bytes(), &event)
+		if err != nil {
+			t.Fatalf("Failed to decode line %q: %v", scanner.Text(), err)
+		}
+		sequences = append(sequences, event.Sequence)
+	}
+	if want := []int64{1, 2, 3}; !slices.Equal(sequences, want) {
+		t.Errorf("got sequences %v in the file want %v appended", sequences, want)
+	}
+}
+
+func TestNATSPublisher(t *testing.T) {
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: server.RANDOM_PORT, NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatal("Failed to create nats server", err)
+	}
+	go srv.Start()
+	defer srv.Shutdown()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("Nats server is not ready")
+	}
+
+	conn, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatal("Failed to connect to nats server", err)
+	}
+	defer conn.Close()
+
+	msgs := make(chan *nats.Msg, 8)
+	sub, err := conn.ChanSubscribe(DefaultNATSSubjectPrefix+">", msgs)
+	if err != nil {
+		t.Fatal("Failed to subscribe", err)
+	}
+	defer func() { _ = sub.Unsubscribe() }()
+
+	store := newMemoryStore(2)
+	store.events[1].Type = QuoteDeleted
+	n, err := NewRelay(store, NewNATSPublisher(conn, ""), RelayConfig{}).PublishPending(context.Background())
+	if err != nil || n != 2 {
+		t.Fatalf("PublishPending() = %d, %v want 2, nil", n, err)
+	}
+
+	for _, want := range store.events {
+		select {
+		case msg := <-msgs:
+			if msg.Subject != DefaultNATSSubjectPrefix+string(want.Type) {
+				t.Errorf("got subject %s want %s", msg.Subject, DefaultNATSSubjectPrefix+string(want.Type))
+			}
+			if msg.Header.Get(nats.MsgIdHdr) != want.ID.String() {
+				t.Errorf("got message id %s want the event id %s", msg.Header.Get(nats.MsgIdHdr), want.ID)
+			}
+			var event Event
+			err = json.Unmarshal(msg.Data, &event)
+			if err != nil || event.Sequence != want.Sequence {
+				t.Errorf("got message data %s want the event %d", msg.Data, want.Sequence)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for the message of event", want.Sequence)
+		}
+	}
+}
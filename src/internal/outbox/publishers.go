@@ -0,0 +1,122 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// MultiPublisher publishes the events to every publisher in turn. If one fails, the events are
+// published again to every publisher, which deduplicate them by their ID.
+type MultiPublisher []Publisher
+
+func (p MultiPublisher) Publish(ctx context.Context, events []Event) error {
+	for _, publisher := range p {
+		err := publisher.Publish(ctx, events)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LogPublisher logs every event, it suits development and debugging.
+type LogPublisher struct {
+	Logger *slog.Logger
+}
+
+func NewLogPublisher(logger *slog.Logger) *LogPublisher {
+	return &LogPublisher{Logger: logger}
+}
+
+func (p *LogPublisher) Publish(ctx context.Context, events []Event) error {
+	for _, event := range events {
+		p.Logger.InfoContext(ctx, "Outbox event",
+			slog.Int64("sequence", event.Sequence),
+			slog.String("event_id", event.ID.String()),
+			slog.String("event_type", string(event.Type)),
+			slog.String("aggregate_id", event.AggregateID.String()),
+			slog.Time("occurred_at", event.OccurredAt),
+			slog.String("data", string(event.Data)),
+		)
+	}
+
+	return nil
+}
+
+// WriterPublisher writes the events as newline delimited JSON, e.g. to stdout.
+type WriterPublisher struct {
+	mu sync.Mutex
+	w  io.Writer
+	// sync is called after every batch if set.
+	sync func() error
+}
+
+func NewWriterPublisher(w io.Writer) *WriterPublisher {
+	return &WriterPublisher{w: w}
+}
+
+func (p *WriterPublisher) Publish(_ context.Context, events []Event) error {
+	var buf []byte
+	for _, event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("marshal event: %w", err)
+		}
+		buf = append(append(buf, line...), '\n')
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// The batch is written at once, so a failed write repeats at most the lines of one batch.
+	_, err := p.w.Write(buf)
+	if err != nil {
+		return fmt.Errorf("write events: %w", err)
+	}
+	if p.sync != nil {
+		err = p.sync()
+		if err != nil {
+			return fmt.Errorf("sync events: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// FilePublisher appends the events to a newline delimited JSON file. The file is synced after
+// every batch, so the published events survive a crash.
+type FilePublisher struct {
+	*WriterPublisher
+	file *os.File
+}
+
+func OpenFilePublisher(path string) (*FilePublisher, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+
+	return &FilePublisher{
+		WriterPublisher: &WriterPublisher{w: file, sync: file.Sync},
+		file:            file,
+	}, nil
+}
+
+func (p *FilePublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	err := p.file.Close()
+	if err != nil && !errors.Is(err, os.ErrClosed) {
+		return err
+	}
+
+	return nil
+}
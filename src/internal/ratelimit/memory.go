@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"context"
+	"golang.org/x/time/rate"
+	"sync"
+	"time"
+)
+
+const memorySweepInterval = time.Minute
+
+// MemoryStore keeps the buckets in process, so every replica enforces the limits on its own.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*rate.Limiter
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*rate.Limiter),
+		now:     time.Now,
+	}
+}
+
+func (m *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	bucket, ok := m.buckets[key]
+	if !ok {
+		bucket = rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst)
+		m.buckets[key] = bucket
+	}
+
+	allowed := bucket.AllowN(now, 1)
+
+	return NewResult(limit, bucket.TokensAt(now), allowed), nil
+}
+
+// sweep drops full buckets, they are indistinguishable from the buckets created on the next take.
+func (m *MemoryStore) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < memorySweepInterval {
+		return
+	}
+	m.lastSweep = now
+
+	for key, bucket := range m.buckets {
+		if bucket.TokensAt(now) >= float64(bucket.Burst()) {
+			delete(m.buckets, key)
+		}
+	}
+}
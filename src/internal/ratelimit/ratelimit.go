@@ -0,0 +1,95 @@
+// Package ratelimit implements token-bucket rate limiting on top of pluggable bucket stores.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Class separates the budgets of a client, e.g. reads do not use up the budget for writes.
+type Class string
+
+const (
+	ClassRead  Class = "read"
+	ClassWrite Class = "write"
+	// ClassAuth is taken by requests with credentials before they are verified, it is keyed by
+	// the client IP.
+	ClassAuth Class = "auth"
+)
+
+type Limit struct {
+	// Rate is the number of tokens added to a bucket per second.
+	Rate float64
+	// Burst is the capacity of a bucket.
+	Burst int
+}
+
+type Result struct {
+	Allowed bool
+	// Limit is the capacity of the bucket, 0 if the class is not limited.
+	Limit     int
+	Remaining int
+	// RetryAfter is the time until the next token is available, 0 if the request is allowed.
+	RetryAfter time.Duration
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+}
+
+// NewResult builds the result for a bucket holding tokens after the take.
+func NewResult(limit Limit, tokens float64, allowed bool) Result {
+	ret := Result{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: max(0, int(math.Floor(tokens))),
+		Reset:     secondsToDuration((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !allowed {
+		ret.RetryAfter = secondsToDuration((1 - tokens) / limit.Rate)
+	}
+
+	return ret
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return max(0, time.Duration(seconds*float64(time.Second)))
+}
+
+// Store keeps the token buckets. Implementations must take tokens atomically, so a bucket
+// shared by several replicas is never overdrawn.
+type Store interface {
+	// Take removes a token from the bucket of key, a missing bucket is created full.
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+type Limiter struct {
+	store  Store
+	limits map[Class]Limit
+}
+
+// NewLimiter returns a limiter applying the limits per class, classes without a limit are not limited.
+func NewLimiter(store Store, limits map[Class]Limit) (*Limiter, error) {
+	for class, limit := range limits {
+		if limit.Rate <= 0 || limit.Burst < 1 {
+			return nil, fmt.Errorf("invalid %s limit: rate must be positive and burst at least 1", class)
+		}
+	}
+
+	return &Limiter{store: store, limits: limits}, nil
+}
+
+// Allow takes a token from the bucket of the client key in the class.
+func (l *Limiter) Allow(ctx context.Context, class Class, key string) (Result, error) {
+	limit, ok := l.limits[class]
+	if !ok {
+		return Result{Allowed: true}, nil
+	}
+
+	res, err := l.store.Take(ctx, string(class)+":"+key, limit)
+	if err != nil {
+		return Result{}, fmt.Errorf("take token: %w", err)
+	}
+
+	return res, nil
+}
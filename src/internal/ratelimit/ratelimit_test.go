@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_Take(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 2}
+
+	type step struct {
+		advance    time.Duration
+		want       bool
+		remaining  int
+		retryAfter time.Duration
+	}
+
+	steps := []step{
+		{want: true, remaining: 1},
+		{want: true, remaining: 0},
+		{want: false, remaining: 0, retryAfter: time.Second},
+		{advance: 500 * time.Millisecond, want: false, remaining: 0, retryAfter: 500 * time.Millisecond},
+		{advance: 500 * time.Millisecond, want: true, remaining: 0},
+		{advance: 10 * time.Second, want: true, remaining: 1},
+	}
+
+	for i, s := range steps {
+		now = now.Add(s.advance)
+		res, err := store.Take(context.Background(), "client", limit)
+		if err != nil {
+			t.Fatalf("step %d: Take() unexpected error = %v", i, err)
+		}
+		if res.Allowed != s.want || res.Remaining != s.remaining || res.RetryAfter != s.retryAfter || res.Limit != 2 {
+			t.Errorf("step %d: Take() = %+v, want allowed %v, remaining %d, retry after %s", i, res, s.want, s.remaining, s.retryAfter)
+		}
+	}
+
+	// Buckets are separate per key.
+	res, _ := store.Take(context.Background(), "other", limit)
+	if !res.Allowed || res.Remaining != 1 {
+		t.Errorf("Take() for another key = %+v, want a full bucket", res)
+	}
+
+	// Full buckets are swept.
+	now = now.Add(time.Hour)
+	_, _ = store.Take(context.Background(), "client", limit)
+	if len(store.buckets) != 1 {
+		t.Errorf("store holds %d buckets after sweep, want 1", len(store.buckets))
+	}
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	limiter, err := NewLimiter(NewMemoryStore(), map[Class]Limit{ClassWrite: {Rate: 1, Burst: 1}})
+	if err != nil {
+		t.Fatal("Failed to create limiter", err)
+	}
+
+	res, _ := limiter.Allow(context.Background(), ClassWrite, "client")
+	if !res.Allowed {
+		t.Error("First write was not allowed")
+	}
+	res, _ = limiter.Allow(context.Background(), ClassWrite, "client")
+	if res.Allowed {
+		t.Error("Second write was allowed")
+	}
+	res, _ = limiter.Allow(context.Background(), ClassRead, "client")
+	if !res.Allowed || res.Limit != 0 {
+		t.Errorf("Read without limit = %+v, want allowed without limit", res)
+	}
+
+	_, err = NewLimiter(NewMemoryStore(), map[Class]Limit{ClassRead: {Rate: 0, Burst: 1}})
+	if err == nil {
+		t.Error("NewLimiter() accepted a zero rate")
+	}
+}
@@ -0,0 +1,18 @@
+package service
+
+type QuoteEventType string
+
+const (
+	QuoteCreated QuoteEventType = "created"
+	QuoteUpdated QuoteEventType = "updated"
+	QuoteDeleted QuoteEventType = "deleted"
+)
+
+// QuoteEvent reports a committed write of a quote, deleted quotes are reported as they were before
+// the delete. The events are read from the domain event outbox, see outbox.Relay.
+type QuoteEvent struct {
+	// ID is the sequence of the event in the outbox, it increases with every event but may have gaps.
+	ID    int64
+	Type  QuoteEventType
+	Quote Quote
+}
@@ -4,61 +4,382 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/BernsteinMondy/quote-service/src/internal/auth"
 	"github.com/google/uuid"
+	"time"
 )
 
 var (
 	ErrAlreadyExists = errors.New("already exists")
+	ErrNotFound      = errors.New("not found")
+	// ErrForbidden is returned if the caller may not mutate the quote, see Actor.CanModify.
+	ErrForbidden = errors.New("forbidden")
 
 	ErrRepoAlreadyExists = errors.New("repository: already exists")
+	ErrRepoNotFound      = errors.New("repository: not found")
 )
 
 type QuoteRepository interface {
 	// CreateNewQuote must return ErrRepoAlreadyExists if the quote already exists.
-	CreateNewQuote(ctx context.Context, quote *Quote) error
-	DeleteQuoteByID(ctx context.Context, id uuid.UUID) error
+	// Writes must be recorded together with the actor performing them and their domain event, in the
+	// same transaction as the write. CreateNewQuote and UpdateQuote must replace the tags of the
+	// quote in the same transaction unless Tags is nil.
+	CreateNewQuote(ctx context.Context, quote *Quote, actor Actor) error
+	// CreateNewQuotes must create either all or none of the quotes.
+	CreateNewQuotes(ctx context.Context, quotes []Quote, actor Actor) error
+	// UpdateQuote and DeleteQuoteByID must write the quote only if the actor may modify it, see
+	// Actor.CanModify, checked in the same statement as the write. They must return ErrRepoNotFound
+	// if no quote was written.
+	UpdateQuote(ctx context.Context, quote *Quote, actor Actor) error
+	DeleteQuoteByID(ctx context.Context, id uuid.UUID, actor Actor) error
+	// GetQuoteByID must return ErrRepoNotFound if the quote does not exist.
+	GetQuoteByID(ctx context.Context, id uuid.UUID) (*Quote, error)
 	GetQuotesWithFilter(ctx context.Context, authorFilter string) ([]Quote, error)
+	// ListQuotes must return the quotes matching the filter ordered by ID.
+	ListQuotes(ctx context.Context, filter QuoteFilter) ([]Quote, error)
 	GetRandomQuote(ctx context.Context) (*Quote, error)
+	// GetRandomQuoteWithFilter must return ErrRepoNotFound if no quote matches the filter.
+	GetRandomQuoteWithFilter(ctx context.Context, filter RandomQuoteFilter) (*Quote, error)
+	GetQuoteStats(ctx context.Context) (*QuoteStats, error)
+	// ListRecentQuotes must return the quotes latest created first, together with their tags.
+	ListRecentQuotes(ctx context.Context, filter RecentQuoteFilter) ([]Quote, error)
+
+	// The batch methods below must omit the keys without results from the returned maps.
+
+	GetTagsByQuoteIDs(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]string, error)
+	CountQuotesByAuthors(ctx context.Context, authors []string) (map[string]int64, error)
+	// ListQuotesByAuthors must return at most limit quotes per author ordered by ID.
+	ListQuotesByAuthors(ctx context.Context, authors []string, limit int) (map[string][]Quote, error)
 }
 
 type Service struct {
 	QuoteRepository QuoteRepository
+	// Validation is applied to every quote written, invalid input results in a *ValidationError.
+	Validation ValidationPolicy
 }
 
 type Quote struct {
 	ID     uuid.UUID
 	Author string
 	Quote  string
+	// CreatedBy and UpdatedBy hold the subject of the principal, they are empty for
+	// quotes written before ownership was recorded.
+	CreatedBy string
+	UpdatedBy string
+	// CreatedAt is the time of the migration for quotes created before it was recorded.
+	CreatedAt time.Time
+	// Tags is nil unless the tags of the quote are written or loaded with the quotes, e.g. by
+	// ListRecentQuotes. They are loaded with GetTagsByQuoteIDs otherwise.
+	Tags []string
+}
+
+// Actor identifies the caller performing a write.
+type Actor struct {
+	// Subject is empty for anonymous callers.
+	Subject string
+	// KeyID is uuid.Nil unless the caller authenticated with an API key.
+	KeyID uuid.UUID
+	// Moderator is set for callers with the "quotes:moderate" or "admin" scope.
+	Moderator bool
+}
+
+// CanModify reports whether the actor may update or delete the quote. Contributors may only
+// modify their own quotes, moderators may modify any.
+func (a Actor) CanModify(quote *Quote) bool {
+	if a.Moderator {
+		return true
+	}
+
+	return a.Subject != "" && a.Subject == quote.CreatedBy
+}
+
+func actorFromContext(ctx context.Context) Actor {
+	principal := auth.PrincipalFromContext(ctx)
+	if principal == nil {
+		return Actor{}
+	}
+
+	return Actor{
+		Subject:   principal.Subject,
+		KeyID:     principal.KeyID,
+		Moderator: principal.HasScope(auth.ScopeQuotesModerate),
+	}
+}
+
+// MaxPageSize bounds the quotes returned by one ListQuotes call.
+const MaxPageSize = 1000
+
+// QuoteFilter selects a page of quotes ordered by ID.
+type QuoteFilter struct {
+	// Author, CreatedBy and Tag are ignored if empty.
+	Author    string
+	CreatedBy string
+	Tag       string
+	// Search matches the quotes with the text in their author or quote text case-insensitively,
+	// it is ignored if empty.
+	Search string
+	// AfterID starts the page after the quote with the ID, uuid.Nil starts at the first quote.
+	AfterID uuid.UUID
+	// Limit is clamped to MaxPageSize.
+	Limit int
+}
+
+// RandomQuoteFilter narrows the quotes a random quote is picked from.
+type RandomQuoteFilter struct {
+	// Author and Tag are ignored if empty.
+	Author string
+	Tag    string
+	// ExcludeID is picked only if it is the only quote matching the filter, e.g. to not repeat
+	// the previous quote.
+	ExcludeID uuid.UUID
+}
+
+// RecentQuoteFilter narrows the quotes of ListRecentQuotes.
+type RecentQuoteFilter struct {
+	// Author and Tag are ignored if empty.
+	Author string
+	Tag    string
+	// Limit is clamped to MaxRecentQuotes.
+	Limit int
+}
+
+// MaxRecentQuotes bounds the quotes returned by one ListRecentQuotes call.
+const MaxRecentQuotes = 100
+
+type QuoteStats struct {
+	Total int64
+	// AuthorBuckets groups the authors by the number of their quotes, e.g. "2-5".
+	AuthorBuckets []AuthorBucket
+}
+
+type AuthorBucket struct {
+	Name    string
+	Authors int64
 }
 
-func (s *Service) CreateNewQuote(ctx context.Context, author, quoteText string) error {
+// CreateNewQuote generates the ID of the quote if id is uuid.Nil. It returns ErrAlreadyExists
+// if a quote with the ID exists.
+func (s *Service) CreateNewQuote(ctx context.Context, id uuid.UUID, author, quoteText string) (_ *Quote, err error) {
+	ctx, endSpan := startSpan(ctx, "CreateNewQuote")
+	defer endSpan(&err)
+
+	return s.createNewQuote(ctx, id, author, quoteText, nil)
+}
+
+// CreateNewQuoteWithTags creates the quote together with its tags like CreateNewQuote does.
+func (s *Service) CreateNewQuoteWithTags(ctx context.Context, id uuid.UUID, author, quoteText string, tags []string) (_ *Quote, err error) {
+	ctx, endSpan := startSpan(ctx, "CreateNewQuoteWithTags")
+	defer endSpan(&err)
+
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return s.createNewQuote(ctx, id, author, quoteText, tags)
+}
+
+// createNewQuote creates the quote without tags if tags is nil.
+func (s *Service) createNewQuote(ctx context.Context, id uuid.UUID, author, quoteText string, tags []string) (*Quote, error) {
+	author, quoteText, tags, err := s.normalize(author, quoteText, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	if id == uuid.Nil {
+		id = uuid.New()
+	}
+
+	actor := actorFromContext(ctx)
 	quote := &Quote{
-		ID:     uuid.New(),
-		Author: author,
-		Quote:  quoteText,
+		ID:        id,
+		Author:    author,
+		Quote:     quoteText,
+		CreatedBy: actor.Subject,
+		UpdatedBy: actor.Subject,
+		CreatedAt: now(),
+		Tags:      tags,
 	}
 
-	err := s.QuoteRepository.CreateNewQuote(ctx, quote)
+	err = s.QuoteRepository.CreateNewQuote(ctx, quote, actor)
 	if err != nil {
 		if errors.Is(err, ErrRepoAlreadyExists) {
-			return ErrAlreadyExists
+			return nil, ErrAlreadyExists
 		}
-		return fmt.Errorf("quote repository: create new quote: %w", err)
+		return nil, fmt.Errorf("quote repository: create new quote: %w", err)
 	}
 
-	return nil
+	return quote, nil
 }
 
-func (s *Service) DeleteQuoteByID(ctx context.Context, id uuid.UUID) error {
-	err := s.QuoteRepository.DeleteQuoteByID(ctx, id)
+// normalize applies the validation policy to the fields of a quote, nil tags are left nil.
+func (s *Service) normalize(author, quoteText string, tags []string) (string, string, []string, error) {
+	var verr ValidationError
+	author, quoteText = s.Validation.normalize("", author, quoteText, &verr)
+	if tags != nil {
+		tags = s.Validation.normalizeTags(tags, &verr)
+	}
+	if len(verr.Fields) > 0 {
+		return "", "", nil, &verr
+	}
+
+	return author, quoteText, tags, nil
+}
+
+// ImportQuotes creates the quotes built from the IDs, authors and texts of quotes. IDs are
+// generated for quotes with uuid.Nil IDs, no quote is created if any of the IDs exists.
+func (s *Service) ImportQuotes(ctx context.Context, quotes []Quote) (_ []Quote, err error) {
+	ctx, endSpan := startSpan(ctx, "ImportQuotes")
+	defer endSpan(&err)
+
+	var verr ValidationError
+	actor := actorFromContext(ctx)
+	createdAt := now()
+	created := make([]Quote, len(quotes))
+	for i, quote := range quotes {
+		author, quoteText := s.Validation.normalize(fmt.Sprintf("quotes[%d].", i), quote.Author, quote.Quote, &verr)
+
+		id := quote.ID
+		if id == uuid.Nil {
+			id = uuid.New()
+		}
+		created[i] = Quote{
+			ID:        id,
+			Author:    author,
+			Quote:     quoteText,
+			CreatedBy: actor.Subject,
+			UpdatedBy: actor.Subject,
+			CreatedAt: createdAt,
+		}
+	}
+
+	if len(verr.Fields) > 0 {
+		return nil, &verr
+	}
+
+	err = s.QuoteRepository.CreateNewQuotes(ctx, created, actor)
+	if err != nil {
+		if errors.Is(err, ErrRepoAlreadyExists) {
+			return nil, ErrAlreadyExists
+		}
+		return nil, fmt.Errorf("quote repository: create new quotes: %w", err)
+	}
+
+	return created, nil
+}
+
+func (s *Service) UpdateQuote(ctx context.Context, id uuid.UUID, author, quoteText string) (_ *Quote, err error) {
+	ctx, endSpan := startSpan(ctx, "UpdateQuote")
+	defer endSpan(&err)
+
+	return s.updateQuote(ctx, id, author, quoteText, nil)
+}
+
+// UpdateQuoteWithTags replaces the tags of the quote as well, nil tags are kept.
+func (s *Service) UpdateQuoteWithTags(ctx context.Context, id uuid.UUID, author, quoteText string, tags []string) (_ *Quote, err error) {
+	ctx, endSpan := startSpan(ctx, "UpdateQuoteWithTags")
+	defer endSpan(&err)
+
+	return s.updateQuote(ctx, id, author, quoteText, tags)
+}
+
+// updateQuote keeps the tags of the quote if tags is nil.
+func (s *Service) updateQuote(ctx context.Context, id uuid.UUID, author, quoteText string, tags []string) (*Quote, error) {
+	author, quoteText, tags, err := s.normalize(author, quoteText, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	actor := actorFromContext(ctx)
+	quote, err := s.getModifiableQuote(ctx, id, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	quote.Author = author
+	quote.Quote = quoteText
+	quote.UpdatedBy = actor.Subject
+	quote.Tags = tags
+
+	err = s.QuoteRepository.UpdateQuote(ctx, quote, actor)
+	if err != nil {
+		if errors.Is(err, ErrRepoNotFound) {
+			return nil, s.notModified(ctx, id, actor)
+		}
+		return nil, fmt.Errorf("quote repository: update quote: %w", err)
+	}
+
+	return quote, nil
+}
+
+func (s *Service) DeleteQuoteByID(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, endSpan := startSpan(ctx, "DeleteQuoteByID")
+	defer endSpan(&err)
+
+	actor := actorFromContext(ctx)
+	_, err = s.getModifiableQuote(ctx, id, actor)
 	if err != nil {
+		return err
+	}
+
+	err = s.QuoteRepository.DeleteQuoteByID(ctx, id, actor)
+	if err != nil {
+		if errors.Is(err, ErrRepoNotFound) {
+			return s.notModified(ctx, id, actor)
+		}
 		return fmt.Errorf("quote repository: delete quote by id: %w", err)
 	}
 
 	return nil
 }
 
-func (s *Service) GetQuotesWithFilter(ctx context.Context, authorFilter string) ([]Quote, error) {
+// getModifiableQuote returns ErrNotFound if the quote does not exist and ErrForbidden if the
+// actor may not modify it.
+func (s *Service) getModifiableQuote(ctx context.Context, id uuid.UUID, actor Actor) (*Quote, error) {
+	quote, err := s.QuoteRepository.GetQuoteByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrRepoNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("quote repository: get quote by id: %w", err)
+	}
+
+	if !actor.CanModify(quote) {
+		return nil, ErrForbidden
+	}
+
+	return quote, nil
+}
+
+// notModified returns the error for a quote that was modifiable when it was read but was not
+// written, because it was deleted or its creator changed in between: ErrNotFound or ErrForbidden.
+func (s *Service) notModified(ctx context.Context, id uuid.UUID, actor Actor) error {
+	_, err := s.getModifiableQuote(ctx, id, actor)
+	if err != nil {
+		return err
+	}
+
+	return ErrForbidden
+}
+
+func (s *Service) GetQuoteByID(ctx context.Context, id uuid.UUID) (_ *Quote, err error) {
+	ctx, endSpan := startSpan(ctx, "GetQuoteByID")
+	defer endSpan(&err)
+
+	quote, err := s.QuoteRepository.GetQuoteByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrRepoNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("quote repository: get quote by id: %w", err)
+	}
+
+	return quote, nil
+}
+
+func (s *Service) GetQuotesWithFilter(ctx context.Context, authorFilter string) (_ []Quote, err error) {
+	ctx, endSpan := startSpan(ctx, "GetQuotesWithFilter")
+	defer endSpan(&err)
+
 	quotes, err := s.QuoteRepository.GetQuotesWithFilter(ctx, authorFilter)
 	if err != nil {
 		return nil, fmt.Errorf("quote repository: get quotes with filter: %w", err)
@@ -67,7 +388,70 @@ func (s *Service) GetQuotesWithFilter(ctx context.Context, authorFilter string)
 	return quotes, nil
 }
 
-func (s *Service) GetRandomQuote(ctx context.Context) (*Quote, error) {
+func (s *Service) ListQuotes(ctx context.Context, filter QuoteFilter) (_ []Quote, err error) {
+	ctx, endSpan := startSpan(ctx, "ListQuotes")
+	defer endSpan(&err)
+
+	if filter.Limit <= 0 || filter.Limit > MaxPageSize {
+		filter.Limit = MaxPageSize
+	}
+
+	quotes, err := s.QuoteRepository.ListQuotes(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("quote repository: list quotes: %w", err)
+	}
+
+	return quotes, nil
+}
+
+// GetTagsByQuoteIDs returns the tags of the quotes keyed by their IDs, quotes without tags are omitted.
+func (s *Service) GetTagsByQuoteIDs(ctx context.Context, ids []uuid.UUID) (_ map[uuid.UUID][]string, err error) {
+	ctx, endSpan := startSpan(ctx, "GetTagsByQuoteIDs")
+	defer endSpan(&err)
+
+	tags, err := s.QuoteRepository.GetTagsByQuoteIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("quote repository: get tags by quote ids: %w", err)
+	}
+
+	return tags, nil
+}
+
+// CountQuotesByAuthors returns the number of quotes of the authors, authors without quotes are omitted.
+func (s *Service) CountQuotesByAuthors(ctx context.Context, authors []string) (_ map[string]int64, err error) {
+	ctx, endSpan := startSpan(ctx, "CountQuotesByAuthors")
+	defer endSpan(&err)
+
+	counts, err := s.QuoteRepository.CountQuotesByAuthors(ctx, authors)
+	if err != nil {
+		return nil, fmt.Errorf("quote repository: count quotes by authors: %w", err)
+	}
+
+	return counts, nil
+}
+
+// ListQuotesByAuthors returns the first quotes of every author ordered by ID, limit is clamped
+// to MaxPageSize.
+func (s *Service) ListQuotesByAuthors(ctx context.Context, authors []string, limit int) (_ map[string][]Quote, err error) {
+	ctx, endSpan := startSpan(ctx, "ListQuotesByAuthors")
+	defer endSpan(&err)
+
+	if limit <= 0 || limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+
+	quotes, err := s.QuoteRepository.ListQuotesByAuthors(ctx, authors, limit)
+	if err != nil {
+		return nil, fmt.Errorf("quote repository: list quotes by authors: %w", err)
+	}
+
+	return quotes, nil
+}
+
+func (s *Service) GetRandomQuote(ctx context.Context) (_ *Quote, err error) {
+	ctx, endSpan := startSpan(ctx, "GetRandomQuote")
+	defer endSpan(&err)
+
 	quote, err := s.QuoteRepository.GetRandomQuote(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("quote repository: get random quote: %w", err)
@@ -76,8 +460,61 @@ func (s *Service) GetRandomQuote(ctx context.Context) (*Quote, error) {
 	return quote, nil
 }
 
-func New(quoteRepo QuoteRepository) *Service {
+// ListRecentQuotes returns the quotes latest created first together with their tags, e.g. for
+// feeds. The limit is clamped to MaxRecentQuotes.
+func (s *Service) ListRecentQuotes(ctx context.Context, filter RecentQuoteFilter) (_ []Quote, err error) {
+	ctx, endSpan := startSpan(ctx, "ListRecentQuotes")
+	defer endSpan(&err)
+
+	if filter.Limit <= 0 || filter.Limit > MaxRecentQuotes {
+		filter.Limit = MaxRecentQuotes
+	}
+
+	quotes, err := s.QuoteRepository.ListRecentQuotes(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("quote repository: list recent quotes: %w", err)
+	}
+
+	return quotes, nil
+}
+
+// GetRandomQuoteWithFilter returns ErrNotFound if no quote matches the filter.
+func (s *Service) GetRandomQuoteWithFilter(ctx context.Context, filter RandomQuoteFilter) (_ *Quote, err error) {
+	ctx, endSpan := startSpan(ctx, "GetRandomQuoteWithFilter")
+	defer endSpan(&err)
+
+	quote, err := s.QuoteRepository.GetRandomQuoteWithFilter(ctx, filter)
+	if err != nil {
+		if errors.Is(err, ErrRepoNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("quote repository: get random quote with filter: %w", err)
+	}
+
+	return quote, nil
+}
+
+func (s *Service) GetQuoteStats(ctx context.Context) (_ *QuoteStats, err error) {
+	ctx, endSpan := startSpan(ctx, "GetQuoteStats")
+	defer endSpan(&err)
+
+	stats, err := s.QuoteRepository.GetQuoteStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("quote repository: get quote stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+func New(quoteRepo QuoteRepository, validation ValidationPolicy) *Service {
 	return &Service{
 		QuoteRepository: quoteRepo,
+		Validation:      validation,
 	}
 }
+
+// now returns the current time with the microsecond precision of the database, so quotes returned
+// from writes equal the quotes read back.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
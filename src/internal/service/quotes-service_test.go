@@ -0,0 +1,561 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/BernsteinMondy/quote-service/src/internal/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type memoryQuoteRepository struct {
+	quotes map[uuid.UUID]Quote
+}
+
+func (m *memoryQuoteRepository) CreateNewQuote(_ context.Context, quote *Quote, _ Actor) error {
+	if _, ok := m.quotes[quote.ID]; ok {
+		return ErrRepoAlreadyExists
+	}
+	m.quotes[quote.ID] = *quote
+	return nil
+}
+
+func (m *memoryQuoteRepository) CreateNewQuotes(ctx context.Context, quotes []Quote, actor Actor) error {
+	for i := range quotes {
+		err := m.CreateNewQuote(ctx, &quotes[i], actor)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memoryQuoteRepository) UpdateQuote(_ context.Context, quote *Quote, actor Actor) error {
+	stored, ok := m.quotes[quote.ID]
+	if !ok || !actor.CanModify(&stored) {
+		return ErrRepoNotFound
+	}
+	updated := *quote
+	if updated.Tags == nil {
+		updated.Tags = stored.Tags
+	}
+	m.quotes[quote.ID] = updated
+	return nil
+}
+
+func (m *memoryQuoteRepository) DeleteQuoteByID(_ context.Context, id uuid.UUID, actor Actor) error {
+	stored, ok := m.quotes[id]
+	if !ok || !actor.CanModify(&stored) {
+		return ErrRepoNotFound
+	}
+	delete(m.quotes, id)
+	return nil
+}
+
+// racingQuoteRepository runs change after every read, like a concurrent write between the read
+// and the write of a quote.
+type racingQuoteRepository struct {
+	*memoryQuoteRepository
+	change func()
+}
+
+func (r *racingQuoteRepository) GetQuoteByID(ctx context.Context, id uuid.UUID) (*Quote, error) {
+	quote, err := r.memoryQuoteRepository.GetQuoteByID(ctx, id)
+	r.change()
+	return quote, err
+}
+
+func (m *memoryQuoteRepository) GetQuoteByID(_ context.Context, id uuid.UUID) (*Quote, error) {
+	quote, ok := m.quotes[id]
+	if !ok {
+		return nil, ErrRepoNotFound
+	}
+	return &quote, nil
+}
+
+func (m *memoryQuoteRepository) GetQuotesWithFilter(context.Context, string) ([]Quote, error) {
+	return nil, nil
+}
+
+func (m *memoryQuoteRepository) ListQuotes(_ context.Context, filter QuoteFilter) ([]Quote, error) {
+	ret := make([]Quote, 0)
+	for _, quote := range m.quotes {
+		if (filter.Author == "" || quote.Author == filter.Author) &&
+			(filter.CreatedBy == "" || quote.CreatedBy == filter.CreatedBy) &&
+			(filter.Tag == "" || slices.Contains(quote.Tags, filter.Tag)) &&
+			(filter.Search == "" || strings.Contains(strings.ToLower(quote.Author+"\n"+quote.Quote), strings.ToLower(filter.Search))) &&
+			bytes.Compare(quote.ID[:], filter.AfterID[:]) > 0 {
+			ret = append(ret, quote)
+		}
+	}
+	slices.SortFunc(ret, func(a, b Quote) int {
+		return bytes.Compare(a.ID[:], b.ID[:])
+	})
+
+	return ret[:min(len(ret), filter.Limit)], nil
+}
+
+func (m *memoryQuoteRepository) GetRandomQuote(context.Context) (*Quote, error) {
+	return nil, nil
+}
+
+func (m *memoryQuoteRepository) GetRandomQuoteWithFilter(_ context.Context, filter RandomQuoteFilter) (*Quote, error) {
+	var excluded *Quote
+	for _, quote := range m.quotes {
+		if (filter.Author != "" && quote.Author != filter.Author) || (filter.Tag != "" && !slices.Contains(quote.Tags, filter.Tag)) {
+			continue
+		}
+		if quote.ID == filter.ExcludeID {
+			excluded = &quote
+			continue
+		}
+		return &quote, nil
+	}
+	if excluded == nil {
+		return nil, ErrRepoNotFound
+	}
+	return excluded, nil
+}
+
+func (m *memoryQuoteRepository) ListRecentQuotes(_ context.Context, filter RecentQuoteFilter) ([]Quote, error) {
+	ret := make([]Quote, 0)
+	for _, quote := range m.quotes {
+		if (filter.Author == "" || quote.Author == filter.Author) && (filter.Tag == "" || slices.Contains(quote.Tags, filter.Tag)) {
+			if quote.Tags == nil {
+				quote.Tags = []string{}
+			}
+			ret = append(ret, quote)
+		}
+	}
+	slices.SortFunc(ret, func(a, b Quote) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return bytes.Compare(b.ID[:], a.ID[:])
+	})
+
+	return ret[:min(len(ret), filter.Limit)], nil
+}
+
+func (m *memoryQuoteRepository) GetQuoteStats(context.Context) (*QuoteStats, error) {
+	return &QuoteStats{}, nil
+}
+
+func (m *memoryQuoteRepository) GetTagsByQuoteIDs(_ context.Context, ids []uuid.UUID) (map[uuid.UUID][]string, error) {
+	ret := make(map[uuid.UUID][]string)
+	for _, id := range ids {
+		if tags := m.quotes[id].Tags; len(tags) > 0 {
+			ret[id] = tags
+		}
+	}
+	return ret, nil
+}
+
+func (m *memoryQuoteRepository) CountQuotesByAuthors(_ context.Context, authors []string) (map[string]int64, error) {
+	ret := make(map[string]int64)
+	for _, quote := range m.quotes {
+		if slices.Contains(authors, quote.Author) {
+			ret[quote.Author]++
+		}
+	}
+	return ret, nil
+}
+
+func (m *memoryQuoteRepository) ListQuotesByAuthors(ctx context.Context, authors []string, limit int) (map[string][]Quote, error) {
+	ret := make(map[string][]Quote)
+	for _, author := range authors {
+		quotes, _ := m.ListQuotes(ctx, QuoteFilter{Author: author, Limit: limit})
+		if len(quotes) > 0 {
+			ret[author] = quotes
+		}
+	}
+	return ret, nil
+}
+
+func TestService_Ownership(t *testing.T) {
+	owned := Quote{ID: uuid.New(), Author: "author", Quote: "quote", CreatedBy: "owner"}
+	legacy := Quote{ID: uuid.New(), Author: "author", Quote: "quote"}
+
+	type testCase struct {
+		name      string
+		principal *auth.Principal
+		quoteID   uuid.UUID
+		wantErr   error
+	}
+
+	testCases := []testCase{
+		{
+			name:      "Contributor modifies own quote",
+			principal: &auth.Principal{Subject: "owner", Scopes: []auth.Scope{auth.ScopeQuotesWrite, auth.ScopeQuotesDelete}},
+			quoteID:   owned.ID,
+		},
+		{
+			name:      "Contributor modifies quote of somebody else",
+			principal: &auth.Principal{Subject: "other", Scopes: []auth.Scope{auth.ScopeQuotesWrite, auth.ScopeQuotesDelete}},
+			quoteID:   owned.ID,
+			wantErr:   ErrForbidden,
+		},
+		{
+			name:      "Contributor modifies quote without recorded creator",
+			principal: &auth.Principal{Subject: "owner", Scopes: []auth.Scope{auth.ScopeQuotesWrite, auth.ScopeQuotesDelete}},
+			quoteID:   legacy.ID,
+			wantErr:   ErrForbidden,
+		},
+		{
+			name:      "Moderator modifies quote of somebody else",
+			principal: &auth.Principal{Subject: "moderator", Scopes: []auth.Scope{auth.ScopeQuotesModerate}},
+			quoteID:   owned.ID,
+		},
+		{
+			name:      "Admin modifies quote without recorded creator",
+			principal: &auth.Principal{Subject: "admin", Scopes: []auth.Scope{auth.ScopeAdmin}},
+			quoteID:   legacy.ID,
+		},
+		{
+			name:      "Anonymous caller modifies quote",
+			principal: nil,
+			quoteID:   owned.ID,
+			wantErr:   ErrForbidden,
+		},
+		{
+			name:      "Missing quote",
+			principal: &auth.Principal{Subject: "admin", Scopes: []auth.Scope{auth.ScopeAdmin}},
+			quoteID:   uuid.New(),
+			wantErr:   ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &memoryQuoteRepository{quotes: map[uuid.UUID]Quote{owned.ID: owned, legacy.ID: legacy}}
+			service := New(repo, DefaultValidationPolicy())
+			ctx := auth.WithPrincipal(context.Background(), tc.principal)
+
+			updated, err := service.UpdateQuote(ctx, tc.quoteID, "new author", "new quote")
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("UpdateQuote() error = %v, want %v", err, tc.wantErr)
+			}
+			if err == nil && (updated.UpdatedBy != tc.principal.Subject || repo.quotes[tc.quoteID].Quote != "new quote") {
+				t.Errorf("UpdateQuote() = %+v, want quote updated by %q", updated, tc.principal.Subject)
+			}
+
+			err = service.DeleteQuoteByID(ctx, tc.quoteID)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("DeleteQuoteByID() error = %v, want %v", err, tc.wantErr)
+			}
+			if _, ok := repo.quotes[tc.quoteID]; ok != errors.Is(err, ErrForbidden) {
+				t.Errorf("DeleteQuoteByID() error = %v, quote still exists = %v", err, ok)
+			}
+		})
+	}
+}
+
+func TestService_OwnershipChangedBeforeWrite(t *testing.T) {
+	owner := &auth.Principal{Subject: "owner", Scopes: []auth.Scope{auth.ScopeQuotesWrite, auth.ScopeQuotesDelete}}
+
+	type testCase struct {
+		name    string
+		change  func(repo *memoryQuoteRepository, id uuid.UUID)
+		wantErr error
+	}
+
+	testCases := []testCase{
+		{
+			name: "Quote taken over by somebody else",
+			change: func(repo *memoryQuoteRepository, id uuid.UUID) {
+				quote := repo.quotes[id]
+				quote.CreatedBy = "other"
+				repo.quotes[id] = quote
+			},
+			wantErr: ErrForbidden,
+		},
+		{
+			name: "Quote deleted",
+			change: func(repo *memoryQuoteRepository, id uuid.UUID) {
+				delete(repo.quotes, id)
+			},
+			wantErr: ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			quote := Quote{ID: uuid.New(), Author: "author", Quote: "quote", CreatedBy: "owner"}
+
+			for _, write := range []func(service *Service, ctx context.Context) error{
+				func(service *Service, ctx context.Context) error {
+					_, err := service.UpdateQuote(ctx, quote.ID, "new author", "new quote")
+					return err
+				},
+				func(service *Service, ctx context.Context) error {
+					return service.DeleteQuoteByID(ctx, quote.ID)
+				},
+			} {
+				memory := &memoryQuoteRepository{quotes: map[uuid.UUID]Quote{quote.ID: quote}}
+				repo := &racingQuoteRepository{memoryQuoteRepository: memory, change: func() {}}
+				repo.change = func() {
+					tc.change(memory, quote.ID)
+					repo.change = func() {}
+				}
+
+				err := write(New(repo, DefaultValidationPolicy()), auth.WithPrincipal(context.Background(), owner))
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("got error %v want %v", err, tc.wantErr)
+				}
+				if stored, ok := memory.quotes[quote.ID]; ok && stored.Quote != quote.Quote {
+					t.Errorf("quote was written: %+v", stored)
+				}
+			}
+		})
+	}
+}
+
+func TestService_CreateNewQuoteRecordsCreator(t *testing.T) {
+	repo := &memoryQuoteRepository{quotes: map[uuid.UUID]Quote{}}
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "owner"})
+
+	created, err := New(repo, DefaultValidationPolicy()).CreateNewQuote(ctx, uuid.Nil, "author", "quote")
+	if err != nil {
+		t.Fatal("CreateNewQuote() unexpected error", err)
+	}
+
+	stored, ok := repo.quotes[created.ID]
+	if !ok || !reflect.DeepEqual(stored, *created) {
+		t.Errorf("CreateNewQuote() = %+v, stored %+v", created, stored)
+	}
+	if created.ID == uuid.Nil || created.CreatedBy != "owner" || created.UpdatedBy != "owner" {
+		t.Errorf("CreateNewQuote() = %+v, want generated ID, created and updated by \"owner\"", created)
+	}
+}
+
+func TestService_JWTCannotModifyQuoteOfAPIKey(t *testing.T) {
+	const issuer = "https://issuer.example.com"
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("Failed to generate ec key", err)
+	}
+	jwks, err := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kty": "EC",
+		"kid": "ec-1",
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}}})
+	if err != nil {
+		t.Fatal("Failed to marshal jwks", err)
+	}
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	if err = os.WriteFile(jwksPath, jwks, 0o600); err != nil {
+		t.Fatal("Failed to write jwks", err)
+	}
+	validator, err := auth.NewJWTValidator(auth.JWTConfig{Issuer: issuer, Audience: "quote-service"}, auth.NewJWKS(jwksPath, time.Hour))
+	if err != nil {
+		t.Fatal("Failed to create validator", err)
+	}
+
+	keyPrincipal := &auth.Principal{Subject: "apikey:" + uuid.NewString(), Scopes: []auth.Scope{auth.ScopeQuotesWrite}}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss":   issuer,
+		"aud":   "quote-service",
+		"sub":   keyPrincipal.Subject,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "quotes:write quotes:delete",
+	})
+	token.Header["kid"] = "ec-1"
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal("Failed to sign token", err)
+	}
+	jwtPrincipal, err := validator.Authenticate(context.Background(), signed)
+	if err != nil {
+		t.Fatal("Authenticate() unexpected error", err)
+	}
+
+	repo := &memoryQuoteRepository{quotes: map[uuid.UUID]Quote{}}
+	service := New(repo, DefaultValidationPolicy())
+	created, err := service.CreateNewQuote(auth.WithPrincipal(context.Background(), keyPrincipal), uuid.Nil, "author", "quote")
+	if err != nil {
+		t.Fatal("CreateNewQuote() unexpected error", err)
+	}
+
+	ctx := auth.WithPrincipal(context.Background(), jwtPrincipal)
+	if _, err = service.UpdateQuote(ctx, created.ID, "author", "taken over"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("UpdateQuote() error = %v, want ErrForbidden", err)
+	}
+	if err = service.DeleteQuoteByID(ctx, created.ID); !errors.Is(err, ErrForbidden) {
+		t.Errorf("DeleteQuoteByID() error = %v, want ErrForbidden", err)
+	}
+}
+
+func TestService_CreateNewQuoteWithClientID(t *testing.T) {
+	repo := &memoryQuoteRepository{quotes: map[uuid.UUID]Quote{}}
+	service := New(repo, DefaultValidationPolicy())
+	id := uuid.New()
+
+	created, err := service.CreateNewQuote(context.Background(), id, "author", "quote")
+	if err != nil || created.ID != id {
+		t.Fatalf("CreateNewQuote() = %+v, %v, want quote with ID %s", created, err, id)
+	}
+
+	_, err = service.CreateNewQuote(context.Background(), id, "author", "other quote")
+	if !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("CreateNewQuote() with existing ID error = %v, want ErrAlreadyExists", err)
+	}
+	if repo.quotes[id].Quote != "quote" {
+		t.Errorf("CreateNewQuote() with existing ID overwrote the quote")
+	}
+}
+
+func TestService_ListQuotes(t *testing.T) {
+	repo := &memoryQuoteRepository{quotes: map[uuid.UUID]Quote{}}
+	for i := 0; i < MaxPageSize+5; i++ {
+		quote := Quote{ID: uuid.New(), Author: "author", Quote: "quote"}
+		if i%2 == 0 {
+			quote.CreatedBy = "owner"
+		}
+		repo.quotes[quote.ID] = quote
+	}
+	svc := New(repo, DefaultValidationPolicy())
+
+	quotes, err := svc.ListQuotes(context.Background(), QuoteFilter{})
+	if err != nil {
+		t.Fatal("ListQuotes returned error", err)
+	}
+	if len(quotes) != MaxPageSize {
+		t.Errorf("ListQuotes without limit returned %d quotes, want %d", len(quotes), MaxPageSize)
+	}
+
+	var pages [][]Quote
+	filter := QuoteFilter{CreatedBy: "owner", Limit: 200}
+	for {
+		page, err := svc.ListQuotes(context.Background(), filter)
+		if err != nil {
+			t.Fatal("ListQuotes returned error", err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		pages = append(pages, page)
+		filter.AfterID = page[len(page)-1].ID
+	}
+
+	seen := map[uuid.UUID]bool{}
+	for _, page := range pages {
+		for _, quote := range page {
+			if quote.CreatedBy != "owner" || seen[quote.ID] {
+				t.Fatalf("ListQuotes returned quote %v twice or not matching the filter", quote)
+			}
+			seen[quote.ID] = true
+		}
+	}
+	if len(seen) != (MaxPageSize+6)/2 || len(pages) != 3 {
+		t.Errorf("paging returned %d quotes in %d pages, want %d in 3", len(seen), len(pages), (MaxPageSize+6)/2)
+	}
+}
+
+func TestService_Tags(t *testing.T) {
+	repo := &memoryQuoteRepository{quotes: map[uuid.UUID]Quote{}}
+	svc := New(repo, DefaultValidationPolicy())
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "owner"})
+
+	_, err := svc.CreateNewQuoteWithTags(ctx, uuid.Nil, "", "quote", []string{"two words"})
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 2 {
+		t.Fatalf("CreateNewQuoteWithTags() error = %v, want violations of author and tags", err)
+	}
+
+	created, err := svc.CreateNewQuoteWithTags(ctx, uuid.Nil, "author", "quote", []string{"Life", "life", "love"})
+	if err != nil {
+		t.Fatal("CreateNewQuoteWithTags() unexpected error", err)
+	}
+	if !slices.Equal(created.Tags, []string{"life", "love"}) {
+		t.Errorf("CreateNewQuoteWithTags() tags = %q, want [life love]", created.Tags)
+	}
+
+	_, err = svc.UpdateQuote(ctx, created.ID, "author", "new quote")
+	if err != nil {
+		t.Fatal("UpdateQuote() unexpected error", err)
+	}
+	tags, _ := svc.GetTagsByQuoteIDs(ctx, []uuid.UUID{created.ID})
+	if !slices.Equal(tags[created.ID], []string{"life", "love"}) {
+		t.Errorf("UpdateQuote() changed the tags to %q", tags[created.ID])
+	}
+
+	_, err = svc.UpdateQuoteWithTags(ctx, created.ID, "author", "new quote", []string{})
+	if err != nil {
+		t.Fatal("UpdateQuoteWithTags() unexpected error", err)
+	}
+	tags, _ = svc.GetTagsByQuoteIDs(ctx, []uuid.UUID{created.ID})
+	if len(tags) != 0 {
+		t.Errorf("UpdateQuoteWithTags() without tags kept the tags %q", tags[created.ID])
+	}
+}
+
+func TestService_GetRandomQuoteWithFilter(t *testing.T) {
+	only := Quote{ID: uuid.New(), Author: "author", Quote: "quote"}
+	repo := &memoryQuoteRepository{quotes: map[uuid.UUID]Quote{only.ID: only}}
+	svc := New(repo, DefaultValidationPolicy())
+
+	quote, err := svc.GetRandomQuoteWithFilter(context.Background(), RandomQuoteFilter{Author: "author", ExcludeID: only.ID})
+	if err != nil || quote.ID != only.ID {
+		t.Errorf("GetRandomQuoteWithFilter() = %+v, %v, want the excluded quote as the only match", quote, err)
+	}
+
+	_, err = svc.GetRandomQuoteWithFilter(context.Background(), RandomQuoteFilter{Author: "other"})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetRandomQuoteWithFilter() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestService_ListRecentQuotes(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryQuoteRepository{quotes: map[uuid.UUID]Quote{}}
+	svc := New(repo, DefaultValidationPolicy())
+
+	var created []uuid.UUID
+	for i, tags := range [][]string{{"life"}, nil, {"life", "love"}} {
+		quote, err := svc.CreateNewQuoteWithTags(ctx, uuid.Nil, "author", "quote", tags)
+		if err != nil {
+			t.Fatal("Failed to create quote", err)
+		}
+		if quote.CreatedAt.IsZero() {
+			t.Fatalf("got zero created at for quote %d", i)
+		}
+		// The quotes are created within the same microsecond otherwise.
+		stored := repo.quotes[quote.ID]
+		stored.CreatedAt = stored.CreatedAt.Add(time.Duration(i) * time.Second)
+		repo.quotes[quote.ID] = stored
+		created = append(created, quote.ID)
+	}
+
+	quotes, err := svc.ListRecentQuotes(ctx, RecentQuoteFilter{Tag: "life"})
+	if err != nil {
+		t.Fatal("Failed to list recent quotes", err)
+	}
+	var ids []uuid.UUID
+	for _, quote := range quotes {
+		ids = append(ids, quote.ID)
+	}
+	if want := []uuid.UUID{created[2], created[0]}; !slices.Equal(ids, want) {
+		t.Errorf("got quotes %v want the tagged quotes latest first %v", ids, want)
+	}
+
+	quotes, err = svc.ListRecentQuotes(ctx, RecentQuoteFilter{Limit: MaxRecentQuotes + 1})
+	if err != nil || len(quotes) != 3 || quotes[2].Tags == nil {
+		t.Errorf("ListRecentQuotes() = %+v, %v want all quotes with their tags", quotes, err)
+	}
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+)
+
+var tracer = otel.Tracer("github.com/BernsteinMondy/quote-service/src/internal/service")
+
+// startSpan starts a child span for a Service method. The returned func ends the span,
+// it must be deferred with a pointer to the named error result of the method.
+func startSpan(ctx context.Context, method string) (context.Context, func(err *error)) {
+	ctx, span := tracer.Start(ctx, "Service."+method)
+	return ctx, func(err *error) {
+		if *err != nil {
+			span.RecordError(*err)
+			span.SetStatus(codes.Error, (*err).Error())
+		}
+		span.End()
+	}
+}
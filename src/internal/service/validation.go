@@ -0,0 +1,236 @@
+package service
+
+import (
+	"fmt"
+	"golang.org/x/text/unicode/norm"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	DefaultMaxQuoteLength  = 1000
+	DefaultMaxAuthorLength = 100
+
+	// MaxTags bounds the tags of a quote, MaxTagLength the characters of a tag.
+	MaxTags      = 10
+	MaxTagLength = 32
+)
+
+// FieldError is a violation of the validation policy by a single field.
+type FieldError struct {
+	// Field is the name of the field, e.g. "author" or "quotes[2].quote" for imports.
+	Field   string
+	Message string
+}
+
+// ValidationError lists every violation found in the input, not only the first one.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		msgs[i] = field.Field + ": " + field.Message
+	}
+
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) add(field, msg string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: msg})
+}
+
+// ValidationPolicy normalizes and validates the authors and texts of quotes.
+//
+// Both fields are NFC normalized and trimmed, and line breaks are normalized to "\n". Quote texts
+// may span lines, all other control and format characters but the joiners are rejected.
+// Authors are single lines of letters, digits and the punctuation of names, their inner
+// whitespace is collapsed. Lengths are counted in characters after normalization.
+type ValidationPolicy struct {
+	MaxQuoteLength  int
+	MaxAuthorLength int
+	// BannedWords are matched case-insensitively against the whole words of both fields.
+	BannedWords []string
+}
+
+func DefaultValidationPolicy() ValidationPolicy {
+	return ValidationPolicy{
+		MaxQuoteLength:  DefaultMaxQuoteLength,
+		MaxAuthorLength: DefaultMaxAuthorLength,
+	}
+}
+
+// Normalize returns the normalized author and quote text, or a *ValidationError.
+func (p ValidationPolicy) Normalize(author, quoteText string) (string, string, error) {
+	var verr ValidationError
+	author, quoteText = p.normalize("", author, quoteText, &verr)
+	if len(verr.Fields) > 0 {
+		return "", "", &verr
+	}
+
+	return author, quoteText, nil
+}
+
+// normalize adds the violations to verr with field names prefixed by prefix.
+func (p ValidationPolicy) normalize(prefix, author, quoteText string, verr *ValidationError) (string, string) {
+	author = strings.Join(strings.Fields(normalizeText(author)), " ")
+	quoteText = strings.TrimSpace(normalizeText(quoteText))
+
+	p.validateAuthor(prefix+"author", author, verr)
+	p.validateQuote(prefix+"quote", quoteText, verr)
+
+	return author, quoteText
+}
+
+// NormalizeTags returns the lower-cased, trimmed and deduplicated tags in their given order, or a
+// *ValidationError. Tags are single words of letters, digits and hyphens.
+func (p ValidationPolicy) NormalizeTags(tags []string) ([]string, error) {
+	var verr ValidationError
+	tags = p.normalizeTags(tags, &verr)
+	if len(verr.Fields) > 0 {
+		return nil, &verr
+	}
+
+	return tags, nil
+}
+
+// normalizeTags adds the violations to verr like normalize does.
+func (p ValidationPolicy) normalizeTags(tags []string, verr *ValidationError) []string {
+	if len(tags) > MaxTags {
+		verr.add("tags", fmt.Sprintf("must not hold more than %d tags", MaxTags))
+		return nil
+	}
+
+	ret := make([]string, 0, len(tags))
+	for i, tag := range tags {
+		field := fmt.Sprintf("tags[%d]", i)
+		tag = strings.ToLower(strings.TrimSpace(normalizeText(tag)))
+
+		if !validTag(field, tag, verr) {
+			continue
+		}
+		p.validateWords(field, tag, verr)
+		if !slices.Contains(ret, tag) {
+			ret = append(ret, tag)
+		}
+	}
+
+	return ret
+}
+
+func validTag(field, tag string, verr *ValidationError) bool {
+	if tag == "" {
+		verr.add(field, "must not be empty")
+		return false
+	}
+	if utf8.RuneCountInString(tag) > MaxTagLength {
+		verr.add(field, fmt.Sprintf("must not be longer than %d characters", MaxTagLength))
+		return false
+	}
+
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsMark(r) && !unicode.IsDigit(r) && r != '-' {
+			verr.add(field, fmt.Sprintf("must not contain %q", r))
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p ValidationPolicy) validateAuthor(field, author string, verr *ValidationError) {
+	if author == "" {
+		verr.add(field, "must not be empty")
+		return
+	}
+	if utf8.RuneCountInString(author) > p.MaxAuthorLength {
+		verr.add(field, fmt.Sprintf("must not be longer than %d characters", p.MaxAuthorLength))
+	}
+
+	hasLetter := false
+	for _, r := range author {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsMark(r), unicode.IsDigit(r), r == ' ', strings.ContainsRune(authorPunctuation, r):
+		default:
+			verr.add(field, fmt.Sprintf("must not contain %q", r))
+			return
+		}
+	}
+	if !hasLetter {
+		verr.add(field, "must contain a letter")
+		return
+	}
+
+	p.validateWords(field, author, verr)
+}
+
+// authorPunctuation is the punctuation allowed in author names, e.g. "J. R. R. Tolkien" or "O'Brien".
+const authorPunctuation = ".,'’-()&"
+
+func (p ValidationPolicy) validateQuote(field, quoteText string, verr *ValidationError) {
+	if quoteText == "" {
+		verr.add(field, "must not be empty")
+		return
+	}
+	if utf8.RuneCountInString(quoteText) > p.MaxQuoteLength {
+		verr.add(field, fmt.Sprintf("must not be longer than %d characters", p.MaxQuoteLength))
+	}
+
+	for _, r := range quoteText {
+		if r != '\n' && (unicode.IsControl(r) || isFormatControl(r)) {
+			verr.add(field, fmt.Sprintf("must not contain control character %U", r))
+			return
+		}
+	}
+
+	p.validateWords(field, quoteText, verr)
+}
+
+// validateWords splits the text into words at everything but letters, marks and digits. Format
+// characters are dropped first, so e.g. a zero-width joiner can not split a banned word.
+func (p ValidationPolicy) validateWords(field, text string, verr *ValidationError) {
+	if len(p.BannedWords) == 0 {
+		return
+	}
+
+	words := strings.FieldsFunc(foldWord(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsMark(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		for _, banned := range p.BannedWords {
+			if word == foldWord(banned) {
+				verr.add(field, fmt.Sprintf("must not contain the banned word %q", word))
+				return
+			}
+		}
+	}
+}
+
+// foldWord drops the format characters of s and returns it lower-cased and NFC normalized.
+func foldWord(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Cf, r) {
+			return -1
+		}
+		return r
+	}, s)
+	return norm.NFC.String(strings.ToLower(s))
+}
+
+func normalizeText(s string) string {
+	s = norm.NFC.String(s)
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\r", "\n")
+}
+
+// isFormatControl reports the invisible format characters, e.g. bidirectional overrides and
+// zero-width spaces, they can make a text render differently from its content. The zero-width
+// joiner and non-joiner are allowed, emoji sequences and some scripts need them.
+func isFormatControl(r rune) bool {
+	return unicode.Is(unicode.Cf, r) && r != '\u200d' && r != '\u200c'
+}
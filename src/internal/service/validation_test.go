@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidationPolicy_Normalize(t *testing.T) {
+	policy := DefaultValidationPolicy()
+	policy.BannedWords = []string{"Darn", "Cafe\u0301"}
+
+	type testCase struct {
+		name       string
+		author     string
+		quote      string
+		wantAuthor string
+		wantQuote  string
+		wantFields []FieldError
+	}
+
+	testCases := []testCase{
+		{
+			name:       "Whitespace is trimmed and collapsed in authors",
+			author:     "  J. R. R.\tTolkien ",
+			quote:      "\n Not all those who wander are lost. \r\n",
+			wantAuthor: "J. R. R. Tolkien",
+			wantQuote:  "Not all those who wander are lost.",
+		},
+		{
+			name:       "Texts are NFC normalized",
+			author:     "Rene\u0301 Descartes",
+			quote:      "Je pense, donc je suis.",
+			wantAuthor: "Ren\u00e9 Descartes",
+			wantQuote:  "Je pense, donc je suis.",
+		},
+		{
+			name:       "Line breaks in quotes are kept and normalized",
+			author:     "O'Brien",
+			quote:      "first line\r\nsecond line",
+			wantAuthor: "O'Brien",
+			wantQuote:  "first line\nsecond line",
+		},
+		{
+			name:   "Empty fields are reported together",
+			author: " ",
+			quote:  "\t",
+			wantFields: []FieldError{
+				{Field: "author", Message: "must not be empty"},
+				{Field: "quote", Message: "must not be empty"},
+			},
+		},
+		{
+			name:       "Too long fields are rejected",
+			author:     strings.Repeat("a", DefaultMaxAuthorLength+1),
+			quote:      strings.Repeat("é", DefaultMaxQuoteLength),
+			wantFields: []FieldError{{Field: "author", Message: "must not be longer than 100 characters"}},
+		},
+		{
+			name:       "Control characters in quotes are rejected",
+			author:     "author",
+			quote:      "null\x00byte",
+			wantFields: []FieldError{{Field: "quote", Message: "must not contain control character U+0000"}},
+		},
+		{
+			name:       "Bidirectional overrides in quotes are rejected",
+			author:     "author",
+			quote:      "abc\u202edef",
+			wantFields: []FieldError{{Field: "quote", Message: "must not contain control character U+202E"}},
+		},
+		{
+			name:       "Zero-width spaces in quotes are rejected",
+			author:     "author",
+			quote:      "Well, Da\u200brn it.",
+			wantFields: []FieldError{{Field: "quote", Message: "must not contain control character U+200B"}},
+		},
+		{
+			name:       "Emoji sequences with joiners are accepted",
+			author:     "author",
+			quote:      "\U0001F469\u200d\U0001F4BB ships it.",
+			wantAuthor: "author",
+			wantQuote:  "\U0001F469\u200d\U0001F4BB ships it.",
+		},
+		{
+			name:       "Joiners do not split banned words",
+			author:     "author",
+			quote:      "Well, Da\u200drn it.",
+			wantFields: []FieldError{{Field: "quote", Message: "must not contain the banned word \"darn\""}},
+		},
+		{
+			name:       "Banned words are matched after normalization",
+			author:     "author",
+			quote:      "Le CAF\u00c9.",
+			wantFields: []FieldError{{Field: "quote", Message: "must not contain the banned word \"caf\u00e9\""}},
+		},
+		{
+			name:       "Authors with symbols are rejected",
+			author:     "<script>",
+			quote:      "quote",
+			wantFields: []FieldError{{Field: "author", Message: "must not contain '<'"}},
+		},
+		{
+			name:       "Authors without letters are rejected",
+			author:     "1984",
+			quote:      "quote",
+			wantFields: []FieldError{{Field: "author", Message: "must contain a letter"}},
+		},
+		{
+			name:       "Banned words are matched case-insensitively as whole words",
+			author:     "Darnell",
+			quote:      "Well, DARN it.",
+			wantFields: []FieldError{{Field: "quote", Message: "must not contain the banned word \"darn\""}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			author, quote, err := policy.Normalize(tc.author, tc.quote)
+			if tc.wantFields != nil {
+				var verr *ValidationError
+				if !errors.As(err, &verr) {
+					t.Fatalf("Normalize() error = %v, want *ValidationError", err)
+				}
+				if !reflect.DeepEqual(verr.Fields, tc.wantFields) {
+					t.Errorf("Normalize() fields = %v, want %v", verr.Fields, tc.wantFields)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Normalize() unexpected error = %v", err)
+			}
+			if author != tc.wantAuthor || quote != tc.wantQuote {
+				t.Errorf("Normalize() = %q, %q, want %q, %q", author, quote, tc.wantAuthor, tc.wantQuote)
+			}
+		})
+	}
+}
+
+func TestService_ImportQuotesValidatesEveryQuote(t *testing.T) {
+	repo := &memoryQuoteRepository{quotes: map[uuid.UUID]Quote{}}
+
+	_, err := New(repo, DefaultValidationPolicy()).ImportQuotes(context.Background(), []Quote{
+		{Author: "author", Quote: "quote"},
+		{Author: "", Quote: "quote"},
+		{Author: "author", Quote: "bad\x07quote"},
+	})
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("ImportQuotes() error = %v, want *ValidationError", err)
+	}
+	want := []FieldError{
+		{Field: "quotes[1].author", Message: "must not be empty"},
+		{Field: "quotes[2].quote", Message: "must not contain control character U+0007"},
+	}
+	if !reflect.DeepEqual(verr.Fields, want) {
+		t.Errorf("ImportQuotes() fields = %v, want %v", verr.Fields, want)
+	}
+	if len(repo.quotes) != 0 {
+		t.Errorf("ImportQuotes() stored %d quotes of an invalid import", len(repo.quotes))
+	}
+}
+
+func TestValidationPolicy_NormalizeTags(t *testing.T) {
+	policy := DefaultValidationPolicy()
+	policy.BannedWords = []string{"darn"}
+
+	type testCase struct {
+		name       string
+		tags       []string
+		wantTags   []string
+		wantFields []FieldError
+	}
+
+	testCases := []testCase{
+		{
+			name:     "Tags are trimmed, lower-cased and deduplicated",
+			tags:     []string{" Life ", "science-fiction", "LIFE"},
+			wantTags: []string{"life", "science-fiction"},
+		},
+		{
+			name:     "No tags are valid",
+			tags:     nil,
+			wantTags: []string{},
+		},
+		{
+			name: "Invalid tags are reported together",
+			tags: []string{"", "two words", strings.Repeat("a", MaxTagLength+1), "Darn"},
+			wantFields: []FieldError{
+				{Field: "tags[0]", Message: "must not be empty"},
+				{Field: "tags[1]", Message: "must not contain ' '"},
+				{Field: "tags[2]", Message: "must not be longer than 32 characters"},
+				{Field: "tags[3]", Message: "must not contain the banned word \"darn\""},
+			},
+		},
+		{
+			name:       "Too many tags are rejected",
+			tags:       strings.Fields(strings.Repeat("tag ", MaxTags+1)),
+			wantFields: []FieldError{{Field: "tags", Message: "must not hold more than 10 tags"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tags, err := policy.NormalizeTags(tc.tags)
+			if tc.wantFields != nil {
+				var verr *ValidationError
+				if !errors.As(err, &verr) {
+					t.Fatalf("NormalizeTags() error = %v, want *ValidationError", err)
+				}
+				if !reflect.DeepEqual(verr.Fields, tc.wantFields) {
+					t.Errorf("NormalizeTags() fields = %v, want %v", verr.Fields, tc.wantFields)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("NormalizeTags() unexpected error = %v", err)
+			}
+			if !reflect.DeepEqual(tags, tc.wantTags) {
+				t.Errorf("NormalizeTags() = %q, want %q", tags, tc.wantTags)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package slashcmd
+
+import (
+	"github.com/BernsteinMondy/quote-service/src/internal/service"
+	"strings"
+)
+
+// ResponseType tells the platform who sees the response.
+type ResponseType string
+
+const (
+	// InChannel responses are posted to the channel, Ephemeral ones are shown to the user only.
+	InChannel ResponseType = "in_channel"
+	Ephemeral ResponseType = "ephemeral"
+)
+
+// Response is the Block Kit message answering a command. Text is the fallback of clients not
+// rendering the blocks, e.g. notifications and Mattermost.
+type Response struct {
+	ResponseType ResponseType `json:"response_type"`
+	Text         string       `json:"text"`
+	Blocks       []Block      `json:"blocks,omitempty"`
+}
+
+// Block is a "section" block with Text or a "context" block with Elements.
+type Block struct {
+	Type     string `json:"type"`
+	Text     *Text  `json:"text,omitempty"`
+	Elements []Text `json:"elements,omitempty"`
+}
+
+type Text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// ErrorResponse answers commands the service failed to run.
+func ErrorResponse() *Response {
+	return ephemeral("Sorry, the quotes are unavailable right now. Please try again later.")
+}
+
+func ephemeral(text string) *Response {
+	return &Response{
+		ResponseType: Ephemeral,
+		Text:         text,
+		Blocks:       []Block{section(text)},
+	}
+}
+
+// quoteResponse shows the quote below the optional intro.
+func quoteResponse(responseType ResponseType, intro string, quote *service.Quote) *Response {
+	resp := &Response{ResponseType: responseType, Text: quoteText(quote)}
+	if intro != "" {
+		resp.Text = intro + "\n" + resp.Text
+		resp.Blocks = append(resp.Blocks, section(intro))
+	}
+	resp.Blocks = append(resp.Blocks, quoteBlock(quote), Block{
+		Type:     "context",
+		Elements: []Text{{Type: "mrkdwn", Text: "Quote `" + quote.ID.String() + "`"}},
+	})
+	return resp
+}
+
+func quoteBlock(quote *service.Quote) Block {
+	return section(quoteText(quote))
+}
+
+// quoteText renders the quote as a block quote followed by the author.
+func quoteText(quote *service.Quote) string {
+	lines := strings.Split(escape(quote.Quote), "\n")
+	for i, line := range lines {
+		lines[i] = "> " + line
+	}
+	return strings.Join(lines, "\n") + "\n— *" + escape(quote.Author) + "*"
+}
+
+func section(text string) Block {
+	return Block{Type: "section", Text: &Text{Type: "mrkdwn", Text: text}}
+}
+
+// mrkdwnEscaper escapes the control characters of Slack's mrkdwn, so quotes cannot mention users
+// or channels or inject links.
+var mrkdwnEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func escape(text string) string {
+	return mrkdwnEscaper.Replace(text)
+}
@@ -0,0 +1,96 @@
+package slashcmd
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// Kind names the subcommands.
+type Kind string
+
+const (
+	Random   Kind = "random"
+	ByAuthor Kind = "by"
+	Search   Kind = "search"
+	Add      Kind = "add"
+	Help     Kind = "help"
+)
+
+// Command is the parsed text of a slash command.
+type Command struct {
+	Kind Kind
+	// Author is set for ByAuthor and Add, Quote for Add and Query for Search.
+	Author string
+	Quote  string
+	Query  string
+}
+
+var (
+	errMissingAuthor = errors.New("`by` needs an author")
+	errMissingQuery  = errors.New("`search` needs a text to search for")
+	errInvalidAdd    = errors.New("`add` needs a quoted text followed by a dash and the author")
+	errUnknown       = errors.New("unknown subcommand")
+)
+
+// openingQuotes maps the quotation marks accepted around the text of `add` to their closing
+// marks, clients often replace the straight ones.
+var openingQuotes = map[rune]rune{'"': '"', '“': '”', '„': '“', '«': '»'}
+
+// authorDashes separate the quoted text of `add` from the author.
+var authorDashes = []string{"—", "–", "--", "-"}
+
+// Parse parses the text following the slash command. The errors are meant for the user.
+func Parse(text string) (Command, error) {
+	text = strings.TrimSpace(text)
+	name, rest, _ := strings.Cut(text, " ")
+	rest = strings.TrimSpace(rest)
+
+	switch Kind(strings.ToLower(name)) {
+	case "", Random:
+		return Command{Kind: Random}, nil
+	case Help:
+		return Command{Kind: Help}, nil
+	case ByAuthor:
+		if rest == "" {
+			return Command{}, errMissingAuthor
+		}
+		return Command{Kind: ByAuthor, Author: rest}, nil
+	case Search:
+		if rest == "" {
+			return Command{}, errMissingQuery
+		}
+		return Command{Kind: Search, Query: rest}, nil
+	case Add:
+		return parseAdd(rest)
+	default:
+		return Command{}, errUnknown
+	}
+}
+
+// parseAdd parses `"<text>" — <author>`.
+func parseAdd(text string) (Command, error) {
+	opening, size := utf8.DecodeRuneInString(text)
+	closing, ok := openingQuotes[opening]
+	if !ok {
+		return Command{}, errInvalidAdd
+	}
+
+	end := strings.LastIndex(text[size:], string(closing))
+	if end < 0 {
+		return Command{}, errInvalidAdd
+	}
+	quote := strings.TrimSpace(text[size : size+end])
+	rest := strings.TrimSpace(text[size+end+len(string(closing)):])
+
+	for _, dash := range authorDashes {
+		if author, ok := strings.CutPrefix(rest, dash); ok {
+			author = strings.TrimSpace(author)
+			if quote == "" || author == "" {
+				return Command{}, errInvalidAdd
+			}
+			return Command{Kind: Add, Quote: quote, Author: author}, nil
+		}
+	}
+	return Command{}, errInvalidAdd
+}
@@ -0,0 +1,52 @@
+package slashcmd
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    Command
+		wantErr error
+	}{
+		{text: "", want: Command{Kind: Random}},
+		{text: "  RANDOM ", want: Command{Kind: Random}},
+		{text: "help", want: Command{Kind: Help}},
+		{text: "by Ada Lovelace", want: Command{Kind: ByAuthor, Author: "Ada Lovelace"}},
+		{text: "by  ", wantErr: errMissingAuthor},
+		{text: "search the  engine", want: Command{Kind: Search, Query: "the  engine"}},
+		{text: "search", wantErr: errMissingQuery},
+		{
+			text: `add "The engine weaves algebraic patterns." — Ada Lovelace`,
+			want: Command{Kind: Add, Quote: "The engine weaves algebraic patterns.", Author: "Ada Lovelace"},
+		},
+		{
+			text: "add “Stay hungry, stay foolish.” – Steve Jobs",
+			want: Command{Kind: Add, Quote: "Stay hungry, stay foolish.", Author: "Steve Jobs"},
+		},
+		{
+			text: `add "Say "hi" - twice" -- Anonymous`,
+			want: Command{Kind: Add, Quote: `Say "hi" - twice`, Author: "Anonymous"},
+		},
+		{text: `add "Quote" Author`, wantErr: errInvalidAdd},
+		{text: `add Quote - Author`, wantErr: errInvalidAdd},
+		{text: `add "Quote`, wantErr: errInvalidAdd},
+		{text: `add "" - Author`, wantErr: errInvalidAdd},
+		{text: `add "Quote" -`, wantErr: errInvalidAdd},
+		{text: "delete everything", wantErr: errUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := Parse(tt.text)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package slashcmd
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SignatureHeader and TimestampHeader carry the signature of Slack requests.
+	SignatureHeader = "X-Slack-Signature"
+	TimestampHeader = "X-Slack-Request-Timestamp"
+	// signatureVersion prefixes the signed base string and the signature.
+	signatureVersion = "v0"
+	// DefaultReplayWindow bounds the age of signed requests, like Slack recommends.
+	DefaultReplayWindow = 5 * time.Minute
+)
+
+var (
+	ErrMissingSignature = errors.New("missing request signature")
+	ErrStaleRequest     = errors.New("request timestamp outside the replay window")
+	ErrInvalidSignature = errors.New("invalid request signature")
+)
+
+// Platform names the chat platform a verified request came from.
+type Platform string
+
+const (
+	Slack      Platform = "slack"
+	Mattermost Platform = "mattermost"
+)
+
+type VerifierConfig struct {
+	// SigningSecret verifies the signatures of Slack requests, empty rejects them.
+	SigningSecret string
+	// MattermostToken verifies the "Authorization: Token <token>" header Mattermost sends, as
+	// Mattermost does not sign its requests. Empty rejects Mattermost requests.
+	MattermostToken string
+	// ReplayWindow defaults to DefaultReplayWindow.
+	ReplayWindow time.Duration
+}
+
+// Verifier authenticates the slash command requests of Slack and Mattermost.
+type Verifier struct {
+	cfg VerifierConfig
+	now func() time.Time
+}
+
+func NewVerifier(cfg VerifierConfig) *Verifier {
+	if cfg.ReplayWindow <= 0 {
+		cfg.ReplayWindow = DefaultReplayWindow
+	}
+
+	return &Verifier{cfg: cfg, now: time.Now}
+}
+
+// Verify checks the request against the Slack signature of the raw body or the Mattermost token,
+// it returns the platform of the request.
+func (v *Verifier) Verify(header http.Header, body []byte) (Platform, error) {
+	if token, ok := strings.CutPrefix(header.Get("Authorization"), "Token "); ok {
+		if v.cfg.MattermostToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(v.cfg.MattermostToken)) != 1 {
+			return "", ErrInvalidSignature
+		}
+		return Mattermost, nil
+	}
+
+	signature, rawTimestamp := header.Get(SignatureHeader), header.Get(TimestampHeader)
+	if signature == "" || rawTimestamp == "" {
+		return "", ErrMissingSignature
+	}
+	if v.cfg.SigningSecret == "" {
+		return "", ErrInvalidSignature
+	}
+
+	timestamp, err := strconv.ParseInt(rawTimestamp, 10, 64)
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+	if age := v.now().Sub(time.Unix(timestamp, 0)); age > v.cfg.ReplayWindow || age < -v.cfg.ReplayWindow {
+		return "", ErrStaleRequest
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(Sign(v.cfg.SigningSecret, rawTimestamp, body))) {
+		return "", ErrInvalidSignature
+	}
+	return Slack, nil
+}
+
+// Sign returns the Slack signature of the body sent at the timestamp, e.g. "v0=<hex hmac>".
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signatureVersion + ":" + timestamp + ":"))
+	mac.Write(body)
+	return signatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+}
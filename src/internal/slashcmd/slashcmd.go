@@ -0,0 +1,143 @@
+package slashcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/BernsteinMondy/quote-service/src/internal/auth"
+	"github.com/BernsteinMondy/quote-service/src/internal/service"
+	"github.com/google/uuid"
+	"net/http"
+	"strings"
+)
+
+// MaxSearchResults bounds the quotes listed by `search`.
+const MaxSearchResults = 5
+
+type QuoteService interface {
+	GetRandomQuote(ctx context.Context) (*service.Quote, error)
+	GetRandomQuoteWithFilter(ctx context.Context, filter service.RandomQuoteFilter) (*service.Quote, error)
+	ListQuotes(ctx context.Context, filter service.QuoteFilter) ([]service.Quote, error)
+	CreateNewQuote(ctx context.Context, id uuid.UUID, author, quote string) (*service.Quote, error)
+}
+
+// Request holds the fields of a slash command request used by the commands, Slack and Mattermost
+// send them under the same form keys.
+type Request struct {
+	Platform Platform
+	// Command is the slash command typed, e.g. "/quote".
+	Command string
+	Text    string
+	TeamID  string
+	UserID  string
+}
+
+// Commands runs the slash commands against the quote service.
+type Commands struct {
+	service  QuoteService
+	verifier *Verifier
+}
+
+func New(service QuoteService, verifier *Verifier) *Commands {
+	return &Commands{service: service, verifier: verifier}
+}
+
+// Verify authenticates the request like Verifier.Verify.
+func (c *Commands) Verify(header http.Header, body []byte) (Platform, error) {
+	return c.verifier.Verify(header, body)
+}
+
+// Run answers the command. Mistakes of the user are answered with an ephemeral response, the
+// error is returned only if the service failed.
+func (c *Commands) Run(ctx context.Context, req Request) (*Response, error) {
+	cmd, err := Parse(req.Text)
+	if err != nil {
+		return usageResponse(req.Command, "Sorry, "+err.Error()+"."), nil
+	}
+
+	switch cmd.Kind {
+	case Random:
+		quote, err := c.service.GetRandomQuote(ctx)
+		if errors.Is(err, service.ErrNotFound) {
+			return ephemeral("There are no quotes yet."), nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("quote service: get random quote: %w", err)
+		}
+		return quoteResponse(InChannel, "", quote), nil
+	case ByAuthor:
+		quote, err := c.service.GetRandomQuoteWithFilter(ctx, service.RandomQuoteFilter{Author: cmd.Author})
+		if errors.Is(err, service.ErrNotFound) {
+			return ephemeral("There are no quotes by " + escape(cmd.Author) + "."), nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("quote service: get random quote with filter: %w", err)
+		}
+		return quoteResponse(InChannel, "", quote), nil
+	case Search:
+		quotes, err := c.service.ListQuotes(ctx, service.QuoteFilter{Search: cmd.Query, Limit: MaxSearchResults})
+		if err != nil {
+			return nil, fmt.Errorf("quote service: list quotes: %w", err)
+		}
+		return searchResponse(cmd.Query, quotes), nil
+	case Add:
+		quote, err := c.service.CreateNewQuote(auth.WithPrincipal(ctx, req.Principal()), uuid.Nil, cmd.Author, cmd.Quote)
+		if err != nil {
+			var verr *service.ValidationError
+			if errors.As(err, &verr) {
+				return validationResponse(verr), nil
+			}
+			return nil, fmt.Errorf("quote service: create new quote: %w", err)
+		}
+		return quoteResponse(Ephemeral, "Added the quote:", quote), nil
+	default:
+		return usageResponse(req.Command, ""), nil
+	}
+}
+
+// Principal is the chat user sending the command, it owns the quotes added with it.
+func (r Request) Principal() *auth.Principal {
+	return &auth.Principal{
+		Subject: string(r.Platform) + ":" + r.TeamID + ":" + r.UserID,
+		Scopes:  []auth.Scope{auth.ScopeQuotesWrite},
+	}
+}
+
+func usageResponse(command, intro string) *Response {
+	if command == "" {
+		command = "/quote"
+	}
+
+	lines := []string{
+		fmt.Sprintf("`%s` or `%s random` posts a random quote", command, command),
+		fmt.Sprintf("`%s by <author>` posts a random quote by the author", command),
+		fmt.Sprintf("`%s search <text>` lists the quotes containing the text", command),
+		fmt.Sprintf("`%s add \"<text>\" — <author>` adds a quote", command),
+	}
+	text := escape(strings.Join(lines, "\n"))
+	if intro != "" {
+		text = intro + "\n" + text
+	}
+	return ephemeral(text)
+}
+
+func validationResponse(verr *service.ValidationError) *Response {
+	lines := []string{"The quote was not added:"}
+	for _, field := range verr.Fields {
+		lines = append(lines, fmt.Sprintf("• %s %s", field.Field, escape(field.Message)))
+	}
+	return ephemeral(strings.Join(lines, "\n"))
+}
+
+func searchResponse(query string, quotes []service.Quote) *Response {
+	if len(quotes) == 0 {
+		return ephemeral("No quotes contain " + escape(query) + ".")
+	}
+
+	resp := ephemeral(fmt.Sprintf("Quotes containing %s:", escape(query)))
+	for i := range quotes {
+		resp.Blocks = append(resp.Blocks, quoteBlock(&quotes[i]))
+		resp.Text += "\n" + quoteText(&quotes[i])
+	}
+	return resp
+}
@@ -0,0 +1,193 @@
+package slashcmd
+
+import (
+	"context"
+	"errors"
+	"github.com/BernsteinMondy/quote-service/src/internal/auth"
+	"github.com/BernsteinMondy/quote-service/src/internal/service"
+	"github.com/google/uuid"
+	"strings"
+	"testing"
+)
+
+type fakeQuoteService struct {
+	quotes []service.Quote
+	err    error
+	// randomFilter, filter and createdBy record the last calls.
+	randomFilter service.RandomQuoteFilter
+	filter       service.QuoteFilter
+	createdBy    *auth.Principal
+}
+
+func (f *fakeQuoteService) GetRandomQuote(context.Context) (*service.Quote, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if len(f.quotes) == 0 {
+		return nil, service.ErrNotFound
+	}
+	return &f.quotes[0], nil
+}
+
+func (f *fakeQuoteService) GetRandomQuoteWithFilter(ctx context.Context, filter service.RandomQuoteFilter) (*service.Quote, error) {
+	f.randomFilter = filter
+	return f.GetRandomQuote(ctx)
+}
+
+func (f *fakeQuoteService) ListQuotes(_ context.Context, filter service.QuoteFilter) ([]service.Quote, error) {
+	f.filter = filter
+	return f.quotes, f.err
+}
+
+func (f *fakeQuoteService) CreateNewQuote(ctx context.Context, id uuid.UUID, author, quote string) (*service.Quote, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.createdBy = auth.PrincipalFromContext(ctx)
+	return &service.Quote{ID: uuid.MustParse("0f0e4b9c-5d1d-4c59-9a57-3b4c6f1f3a8e"), Author: author, Quote: quote}, nil
+}
+
+var quoteFixture = service.Quote{
+	ID:     uuid.MustParse("f48a5cda-ed11-4403-acaf-a770c05a9d6f"),
+	Author: "Ada <Lovelace>",
+	Quote:  "The engine & the loom\n<@U123> weave",
+}
+
+func TestCommands_Run(t *testing.T) {
+	tests := []struct {
+		name     string
+		service  *fakeQuoteService
+		text     string
+		wantType ResponseType
+		wantText []string
+		wantErr  bool
+	}{
+		{
+			name:     "random quote is posted to the channel escaped",
+			service:  &fakeQuoteService{quotes: []service.Quote{quoteFixture}},
+			text:     "",
+			wantType: InChannel,
+			wantText: []string{"> The engine &amp; the loom\n> &lt;@U123&gt; weave\n— *Ada &lt;Lovelace&gt;*"},
+		},
+		{
+			name:     "random quote without quotes",
+			service:  &fakeQuoteService{},
+			text:     "random",
+			wantType: Ephemeral,
+			wantText: []string{"There are no quotes yet."},
+		},
+		{
+			name:     "quote by an unknown author",
+			service:  &fakeQuoteService{},
+			text:     "by <!channel>",
+			wantType: Ephemeral,
+			wantText: []string{"There are no quotes by &lt;!channel&gt;."},
+		},
+		{
+			name:     "search lists the quotes",
+			service:  &fakeQuoteService{quotes: []service.Quote{quoteFixture, quoteFixture}},
+			text:     "search loom",
+			wantType: Ephemeral,
+			wantText: []string{"Quotes containing loom:", "— *Ada &lt;Lovelace&gt;*"},
+		},
+		{
+			name:     "search without results",
+			service:  &fakeQuoteService{},
+			text:     "search loom",
+			wantType: Ephemeral,
+			wantText: []string{"No quotes contain loom."},
+		},
+		{
+			name:     "add answers the user only",
+			service:  &fakeQuoteService{},
+			text:     `add "Stay hungry." — Steve Jobs`,
+			wantType: Ephemeral,
+			wantText: []string{"Added the quote:\n> Stay hungry.\n— *Steve Jobs*"},
+		},
+		{
+			name: "add with an invalid quote lists the fields",
+			service: &fakeQuoteService{err: &service.ValidationError{Fields: []service.FieldError{
+				{Field: "quote", Message: "must be at most 1000 characters"},
+			}}},
+			text:     `add "Stay hungry." — Steve Jobs`,
+			wantType: Ephemeral,
+			wantText: []string{"The quote was not added:\n• quote must be at most 1000 characters"},
+		},
+		{
+			name:     "malformed command shows the usage",
+			service:  &fakeQuoteService{},
+			text:     "by",
+			wantType: Ephemeral,
+			wantText: []string{"Sorry, `by` needs an author.", "`/quote by &lt;author&gt;`"},
+		},
+		{
+			name:     "help shows the usage",
+			service:  &fakeQuoteService{},
+			text:     "help",
+			wantType: Ephemeral,
+			wantText: []string{"`/quote search &lt;text&gt;`"},
+		},
+		{
+			name:    "service failure",
+			service: &fakeQuoteService{err: errors.New("connection refused")},
+			text:    "random",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commands := New(tt.service, NewVerifier(VerifierConfig{}))
+			resp, err := commands.Run(context.Background(), Request{Platform: Slack, Command: "/quote", Text: tt.text})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if resp.ResponseType != tt.wantType {
+				t.Errorf("expected response type %q, got %q", tt.wantType, resp.ResponseType)
+			}
+			if len(resp.Blocks) == 0 {
+				t.Error("expected blocks")
+			}
+			for _, want := range tt.wantText {
+				if !strings.Contains(resp.Text, want) {
+					t.Errorf("expected text to contain %q, got %q", want, resp.Text)
+				}
+			}
+		})
+	}
+}
+
+func TestCommands_RunPassesArguments(t *testing.T) {
+	fake := &fakeQuoteService{quotes: []service.Quote{quoteFixture}}
+	commands := New(fake, NewVerifier(VerifierConfig{}))
+	ctx := context.Background()
+
+	if _, err := commands.Run(ctx, Request{Text: "by Ada Lovelace"}); err != nil {
+		t.Fatalf("by: %v", err)
+	}
+	if fake.randomFilter.Author != "Ada Lovelace" {
+		t.Errorf("expected author filter %q, got %q", "Ada Lovelace", fake.randomFilter.Author)
+	}
+
+	if _, err := commands.Run(ctx, Request{Text: "search loom"}); err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if fake.filter.Search != "loom" || fake.filter.Limit != MaxSearchResults {
+		t.Errorf("unexpected filter %+v", fake.filter)
+	}
+
+	req := Request{Platform: Mattermost, Text: `add "Quote" - Author`, TeamID: "T1", UserID: "U2"}
+	if _, err := commands.Run(ctx, req); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if fake.createdBy == nil || fake.createdBy.Subject != "mattermost:T1:U2" {
+		t.Errorf("expected the quote to be created by mattermost:T1:U2, got %+v", fake.createdBy)
+	}
+}
@@ -0,0 +1,84 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogHandler(t *testing.T) {
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+	})
+
+	type testCase struct {
+		name          string
+		ctx           context.Context
+		wantTraceID   bool
+		wantRequestID bool
+	}
+
+	testCases := []testCase{
+		{
+			name:        "Record logged with span in context carries trace and span IDs",
+			ctx:         trace.ContextWithSpanContext(context.Background(), spanCtx),
+			wantTraceID: true,
+		},
+		{
+			name: "Record logged without span in context carries no trace ID",
+			ctx:  context.Background(),
+		},
+		{
+			name:          "Record logged with request ID in context carries it",
+			ctx:           WithRequestID(context.Background(), "3f2c9a"),
+			wantRequestID: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(NewLogHandler(slog.NewTextHandler(&buf, nil))).With(slog.String("component", "test"))
+
+			logger.InfoContext(tc.ctx, "message")
+
+			got := buf.String()
+			hasTraceID := strings.Contains(got, "trace_id=4bf92f3577b34da6a3ce929d0e0e4736") &&
+				strings.Contains(got, "span_id=00f067aa0ba902b7")
+			if hasTraceID != tc.wantTraceID {
+				t.Errorf("log line %q: has trace ID = %v, want %v", got, hasTraceID, tc.wantTraceID)
+			}
+			if hasRequestID := strings.Contains(got, "request_id=3f2c9a"); hasRequestID != tc.wantRequestID {
+				t.Errorf("log line %q: has request ID = %v, want %v", got, hasRequestID, tc.wantRequestID)
+			}
+		})
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(&buf, LogConfig{Level: slog.LevelWarn, Format: "json"})
+	if err != nil {
+		t.Fatal("Failed to create logger", err)
+	}
+
+	logger.InfoContext(context.Background(), "filtered")
+	logger.WarnContext(WithRequestID(context.Background(), "3f2c9a"), "logged")
+
+	got := buf.String()
+	if strings.Contains(got, "filtered") {
+		t.Errorf("log output %q: contains record below the level", got)
+	}
+	if !strings.Contains(got, `"msg":"logged"`) || !strings.Contains(got, `"request_id":"3f2c9a"`) {
+		t.Errorf("log output %q: does not contain the JSON record with request ID", got)
+	}
+
+	_, err = NewLogger(&buf, LogConfig{Format: "xml"})
+	if err == nil {
+		t.Error("NewLogger returned no error for unknown format")
+	}
+}
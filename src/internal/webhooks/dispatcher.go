@@ -0,0 +1,273 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Headers sent with every delivery besides the SignatureHeader.
+const (
+	WebhookIDHeader  = "X-Webhook-ID"
+	EventHeader      = "X-Webhook-Event"
+	EventIDHeader    = "X-Webhook-Event-ID"
+	DeliveryIDHeader = "X-Webhook-Delivery"
+)
+
+// maxErrorBodyBytes bounds the response body recorded as the error of a failed attempt.
+const maxErrorBodyBytes = 512
+
+type DispatcherConfig struct {
+	// PollInterval is the delay between the polls for due deliveries while there are none.
+	PollInterval time.Duration
+	// BatchSize is the number of deliveries attempted at once.
+	BatchSize int
+	// MaxAttempts is the number of attempts before a delivery is dead.
+	MaxAttempts int
+	// BaseDelay is the delay before the second attempt, it doubles with every attempt after that
+	// up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Timeout bounds one attempt.
+	Timeout time.Duration
+	// Retention is how long delivered and dead deliveries are kept, zero keeps them forever.
+	Retention time.Duration
+}
+
+var DefaultDispatcherConfig = DispatcherConfig{
+	PollInterval: time.Second,
+	BatchSize:    20,
+	MaxAttempts:  8,
+	BaseDelay:    10 * time.Second,
+	MaxDelay:     time.Hour,
+	Timeout:      10 * time.Second,
+	Retention:    7 * 24 * time.Hour,
+}
+
+// pruneInterval is the interval of the deletes of the deliveries past the retention.
+const pruneInterval = time.Hour
+
+// Dispatcher sends the queued deliveries. Deliveries are sent at least once, receivers deduplicate
+// them by the EventIDHeader.
+type Dispatcher struct {
+	repo   Repository
+	client *http.Client
+	cfg    DispatcherConfig
+	now    func() time.Time
+}
+
+// NewDispatcher uses the defaults for the zero fields of cfg but Retention. A nil client is replaced
+// by one not following redirects, so a 3xx response is a failed attempt, and refusing to connect to
+// addresses that are not public, whatever the host name of the webhook resolves to.
+func NewDispatcher(repo Repository, client *http.Client, cfg DispatcherConfig) *Dispatcher {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultDispatcherConfig.PollInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultDispatcherConfig.BatchSize
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultDispatcherConfig.MaxAttempts
+	}
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = DefaultDispatcherConfig.BaseDelay
+	}
+	if cfg.MaxDelay < cfg.BaseDelay {
+		cfg.MaxDelay = max(cfg.BaseDelay, DefaultDispatcherConfig.MaxDelay)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultDispatcherConfig.Timeout
+	}
+	if client == nil {
+		client = &http.Client{
+			Transport: publicTransport(),
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+
+	return &Dispatcher{repo: repo, client: client, cfg: cfg, now: time.Now}
+}
+
+// Run dispatches the due deliveries until ctx is done. Attempts in flight are completed before Run
+// returns.
+func (d *Dispatcher) Run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	var lastPrune time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		n, err := d.DispatchDue(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to dispatch webhook deliveries", slog.String("error", err.Error()))
+		}
+
+		if d.cfg.Retention > 0 && time.Since(lastPrune) >= pruneInterval {
+			lastPrune = time.Now()
+			err := d.prune(ctx)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to delete webhook deliveries", slog.String("error", err.Error()))
+			}
+		}
+
+		// A full batch suggests more deliveries are due.
+		delay := d.cfg.PollInterval
+		if err == nil && n == d.cfg.BatchSize {
+			delay = 0
+		}
+		timer.Reset(delay)
+	}
+}
+
+// DispatchDue attempts one batch of due deliveries and returns its size.
+func (d *Dispatcher) DispatchDue(ctx context.Context) (int, error) {
+	// The lease outlasts the attempts of the batch, so no other replica claims them meanwhile.
+	attempts, err := d.repo.ClaimDeliveries(ctx, d.cfg.BatchSize, 2*d.cfg.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("webhook repository: claim deliveries: %w", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, attempt := range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.attempt(ctx, attempt)
+		}()
+	}
+	wg.Wait()
+
+	return len(attempts), nil
+}
+
+func (d *Dispatcher) prune(ctx context.Context) error {
+	n, err := d.repo.DeleteDeliveries(ctx, d.now().Add(-d.cfg.Retention))
+	if err != nil {
+		return fmt.Errorf("webhook repository: delete deliveries: %w", err)
+	}
+	if n > 0 {
+		slog.DebugContext(ctx, "Deleted webhook deliveries", slog.Int64("count", n))
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, attempt Attempt) {
+	statusCode, err := d.send(ctx, attempt)
+
+	result := AttemptResult{Status: StatusDelivered, StatusCode: statusCode}
+	if err != nil {
+		result.Error = err.Error()
+		if attempt.Attempts >= d.cfg.MaxAttempts {
+			result.Status = StatusDead
+		} else {
+			result.Status = StatusPending
+			result.NextAttemptAt = d.now().Add(d.backoff(attempt.Attempts))
+		}
+	}
+
+	logger := slog.With(
+		slog.Int64("delivery_id", attempt.ID),
+		slog.String("webhook_id", attempt.WebhookID.String()),
+		slog.String("event_type", string(attempt.EventType)),
+		slog.Int("attempt", attempt.Attempts),
+	)
+	switch result.Status {
+	case StatusDead:
+		logger.WarnContext(ctx, "Webhook delivery failed for the last time", slog.String("error", result.Error))
+	case StatusPending:
+		logger.InfoContext(ctx, "Webhook delivery failed", slog.String("error", result.Error), slog.Time("next_attempt_at", result.NextAttemptAt))
+	}
+
+	// The result of an attempt made is recorded even if the dispatcher is stopping.
+	err = d.repo.CompleteAttempt(context.WithoutCancel(ctx), attempt.ID, result)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to record the webhook delivery attempt", slog.String("error", err.Error()))
+	}
+}
+
+// send returns the status code of the response, or 0 if there was none.
+func (d *Dispatcher) send(ctx context.Context, attempt Attempt) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, attempt.URL, bytes.NewReader(attempt.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "quote-service-webhooks")
+	req.Header.Set(WebhookIDHeader, attempt.WebhookID.String())
+	req.Header.Set(EventHeader, string(attempt.EventType))
+	req.Header.Set(EventIDHeader, attempt.EventID.String())
+	req.Header.Set(DeliveryIDHeader, strconv.FormatInt(attempt.ID, 10))
+	req.Header.Set(SignatureHeader, Sign(attempt.Secret, d.now(), attempt.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		if len(body) == 0 {
+			return resp.StatusCode, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		}
+		return resp.StatusCode, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, body)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay after the failed attempt number n.
+func (d *Dispatcher) backoff(n int) time.Duration {
+	delay := d.cfg.BaseDelay
+	for i := 1; i < n && delay < d.cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, d.cfg.MaxDelay)
+}
+
+// publicTransport checks the address of every connection once the host name is resolved, so a
+// webhook can not reach internal services by a host name resolving to them. It does not use the
+// proxy of the environment, as the address of the proxy would be checked instead.
+func publicTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("parse address: %w", err)
+			}
+			if !isPublicAddr(addrPort.Addr()) {
+				return fmt.Errorf("address %s is not public", addrPort.Addr())
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return transport
+}
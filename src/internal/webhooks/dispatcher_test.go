@@ -0,0 +1,341 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type memoryRepository struct {
+	mu         sync.Mutex
+	url        string
+	secret     string
+	deliveries []*Delivery
+}
+
+func (m *memoryRepository) CreateWebhook(context.Context, *Webhook, string) error { return nil }
+func (m *memoryRepository) GetWebhookByID(context.Context, uuid.UUID) (*Webhook, error) {
+	return nil, ErrRepoNotFound
+}
+func (m *memoryRepository) ListWebhooks(context.Context) ([]Webhook, error) { return nil, nil }
+func (m *memoryRepository) UpdateWebhook(context.Context, *Webhook) error   { return nil }
+func (m *memoryRepository) DeleteWebhook(context.Context, uuid.UUID) error  { return nil }
+func (m *memoryRepository) ListDeliveries(context.Context, uuid.UUID, DeliveryFilter) ([]Delivery, error) {
+	return nil, nil
+}
+
+func (m *memoryRepository) RetryDelivery(_ context.Context, _ uuid.UUID, deliveryID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, delivery := range m.deliveries {
+		if delivery.ID == deliveryID && delivery.Status == StatusDead {
+			delivery.Status = StatusPending
+			delivery.Attempts = 0
+			delivery.NextAttemptAt = time.Time{}
+			return nil
+		}
+	}
+	return ErrRepoNotFound
+}
+
+func (m *memoryRepository) ClaimDeliveries(_ context.Context, limit int, lease time.Duration) ([]Attempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var ret []Attempt
+	now := time.Now()
+	for _, delivery := range m.deliveries {
+		if len(ret) == limit {
+			break
+		}
+		if delivery.Status != StatusPending || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		delivery.Attempts++
+		delivery.NextAttemptAt = now.Add(lease)
+		ret = append(ret, Attempt{Delivery: *delivery, URL: m.url, Secret: m.secret})
+	}
+	return ret, nil
+}
+
+func (m *memoryRepository) CompleteAttempt(_ context.Context, deliveryID int64, result AttemptResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, delivery := range m.deliveries {
+		if delivery.ID == deliveryID {
+			delivery.Status = result.Status
+			delivery.LastStatusCode = result.StatusCode
+			delivery.LastError = result.Error
+			if result.Status == StatusPending {
+				delivery.NextAttemptAt = result.NextAttemptAt
+			}
+			return nil
+		}
+	}
+	return ErrRepoNotFound
+}
+
+func (m *memoryRepository) DeleteDeliveries(_ context.Context, before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	m.deliveries = slices.DeleteFunc(m.deliveries, func(delivery *Delivery) bool {
+		deleted := delivery.Status != StatusPending && delivery.CreatedAt.Before(before)
+		if deleted {
+			n++
+		}
+		return deleted
+	})
+	return n, nil
+}
+
+func (m *memoryRepository) delivery(id int64) Delivery {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, delivery := range m.deliveries {
+		if delivery.ID == id {
+			return *delivery
+		}
+	}
+	return Delivery{}
+}
+
+// dueNow makes the pending deliveries due, skipping their backoff.
+func (m *memoryRepository) dueNow() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, delivery := range m.deliveries {
+		delivery.NextAttemptAt = time.Time{}
+	}
+}
+
+func TestDispatcher_DispatchDue(t *testing.T) {
+	const secret = "whsec_test"
+
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	var (
+		mu       sync.Mutex
+		requests []received
+		failing  = true
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, received{header: r.Header.Clone(), body: body})
+		if failing && r.Header.Get(EventHeader) == string(EventQuoteDeleted) {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}
+	}))
+	defer receiver.Close()
+
+	webhookID := uuid.New()
+	repo := &memoryRepository{
+		url:    receiver.URL,
+		secret: secret,
+		deliveries: []*Delivery{
+			{ID: 1, WebhookID: webhookID, EventID: uuid.New(), EventType: EventQuoteCreated, Payload: json.RawMessage(`{"type":"quote.created"}`), Status: StatusPending},
+			{ID: 2, WebhookID: webhookID, EventID: uuid.New(), EventType: EventQuoteDeleted, Payload: json.RawMessage(`{"type":"quote.deleted"}`), Status: StatusPending},
+		},
+	}
+	// The receiver listens on a loopback address, which the default client refuses.
+	dispatcher := NewDispatcher(repo, receiver.Client(), DispatcherConfig{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: 90 * time.Second})
+	ctx := context.Background()
+
+	n, err := dispatcher.DispatchDue(ctx)
+	if err != nil || n != 2 {
+		t.Fatalf("DispatchDue() = %d, %v want 2, nil", n, err)
+	}
+
+	delivered := repo.delivery(1)
+	if delivered.Status != StatusDelivered || delivered.LastStatusCode != http.StatusOK {
+		t.Errorf("got delivery %+v want it delivered", delivered)
+	}
+	failed := repo.delivery(2)
+	if failed.Status != StatusPending || failed.LastStatusCode != http.StatusServiceUnavailable || failed.LastError == "" {
+		t.Errorf("got delivery %+v want it pending with the failure", failed)
+	}
+	if delay := time.Until(failed.NextAttemptAt); delay < 55*time.Second || delay > time.Minute {
+		t.Errorf("got next attempt in %v want the base delay of a minute", delay)
+	}
+
+	for _, req := range requests {
+		if err := Verify(secret, req.header.Get(SignatureHeader), req.body, time.Now(), time.Minute); err != nil {
+			t.Errorf("Verify() of the %s delivery error = %v", req.header.Get(EventHeader), err)
+		}
+		if req.header.Get(WebhookIDHeader) != webhookID.String() || req.header.Get(EventIDHeader) == "" || req.header.Get(DeliveryIDHeader) == "" {
+			t.Errorf("got headers %v want the webhook, event and delivery ids", req.header)
+		}
+	}
+
+	n, err = dispatcher.DispatchDue(ctx)
+	if err != nil || n != 0 {
+		t.Fatalf("DispatchDue() before the next attempt = %d, %v want 0, nil", n, err)
+	}
+
+	for range 2 {
+		repo.dueNow()
+		_, err = dispatcher.DispatchDue(ctx)
+		if err != nil {
+			t.Fatal("Failed to dispatch deliveries", err)
+		}
+	}
+	if dead := repo.delivery(2); dead.Status != StatusDead || dead.Attempts != 3 {
+		t.Errorf("got delivery %+v want it dead after 3 attempts", dead)
+	}
+
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+	err = repo.RetryDelivery(ctx, webhookID, 2)
+	if err != nil {
+		t.Fatal("Failed to retry delivery", err)
+	}
+	_, err = dispatcher.DispatchDue(ctx)
+	if err != nil {
+		t.Fatal("Failed to dispatch deliveries", err)
+	}
+	if retried := repo.delivery(2); retried.Status != StatusDelivered {
+		t.Errorf("got delivery %+v want it delivered after the retry", retried)
+	}
+}
+
+func TestDispatcher_RefusesPrivateAddresses(t *testing.T) {
+	var received atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		received.Add(1)
+	}))
+	defer receiver.Close()
+
+	// The host name resolves to the loopback address of the receiver.
+	url := strings.Replace(receiver.URL, "127.0.0.1", "localhost", 1)
+	repo := &memoryRepository{
+		url: url,
+		deliveries: []*Delivery{
+			{ID: 1, WebhookID: uuid.New(), EventID: uuid.New(), EventType: EventQuoteCreated, Payload: json.RawMessage(`{}`), Status: StatusPending},
+		},
+	}
+	dispatcher := NewDispatcher(repo, nil, DispatcherConfig{})
+
+	_, err := dispatcher.DispatchDue(context.Background())
+	if err != nil {
+		t.Fatal("Failed to dispatch deliveries", err)
+	}
+
+	delivery := repo.delivery(1)
+	if delivery.Status != StatusPending || !strings.Contains(delivery.LastError, "is not public") {
+		t.Errorf("got delivery %+v want it pending with the refused address", delivery)
+	}
+	if n := received.Load(); n != 0 {
+		t.Errorf("got %d requests to the receiver want none", n)
+	}
+}
+
+func TestDispatcher_prune(t *testing.T) {
+	now := time.Now()
+	repo := &memoryRepository{
+		deliveries: []*Delivery{
+			{ID: 1, Status: StatusDelivered, CreatedAt: now.Add(-48 * time.Hour)},
+			{ID: 2, Status: StatusDead, CreatedAt: now.Add(-48 * time.Hour)},
+			{ID: 3, Status: StatusPending, CreatedAt: now.Add(-48 * time.Hour)},
+			{ID: 4, Status: StatusDelivered, CreatedAt: now.Add(-time.Hour)},
+		},
+	}
+	dispatcher := NewDispatcher(repo, nil, DispatcherConfig{Retention: 24 * time.Hour})
+
+	err := dispatcher.prune(context.Background())
+	if err != nil {
+		t.Fatal("Failed to prune deliveries", err)
+	}
+
+	var kept []int64
+	for _, delivery := range repo.deliveries {
+		kept = append(kept, delivery.ID)
+	}
+	if !slices.Equal(kept, []int64{3, 4}) {
+		t.Errorf("got deliveries %v kept want [3 4]", kept)
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	testCases := []struct {
+		url     string
+		wantErr bool
+	}{
+		{url: "https://example.com/hook"},
+		{url: "http://203.0.113.10:8080/hook"},
+		{url: "/hook", wantErr: true},
+		{url: "ftp://example.com/hook", wantErr: true},
+		{url: "http://localhost:8080/hook", wantErr: true},
+		{url: "http://127.0.0.1/hook", wantErr: true},
+		{url: "http://10.0.0.5/hook", wantErr: true},
+		{url: "http://169.254.169.254/latest/meta-data", wantErr: true},
+		{url: "http://[::1]/hook", wantErr: true},
+		{url: "http://[::ffff:192.168.1.1]/hook", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		err := ValidateURL(tc.url)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ValidateURL(%q) error = %v, wantErr %v", tc.url, err, tc.wantErr)
+		}
+	}
+}
+
+func TestDispatcher_backoff(t *testing.T) {
+	dispatcher := NewDispatcher(&memoryRepository{}, nil, DispatcherConfig{BaseDelay: 10 * time.Second, MaxDelay: time.Minute})
+
+	want := []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute, time.Minute}
+	for i, delay := range want {
+		if got := dispatcher.backoff(i + 1); got != delay {
+			t.Errorf("backoff(%d) = %v want %v", i+1, got, delay)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"id":"1"}`)
+	header := Sign("secret", now, body)
+
+	testCases := []struct {
+		name    string
+		secret  string
+		header  string
+		body    []byte
+		wantErr bool
+	}{
+		{name: "valid signature", secret: "secret", header: header, body: body},
+		{name: "another secret", secret: "other", header: header, body: body, wantErr: true},
+		{name: "modified body", secret: "secret", header: header, body: []byte(`{"id":"2"}`), wantErr: true},
+		{name: "expired timestamp", secret: "secret", header: Sign("secret", now.Add(-time.Hour), body), body: body, wantErr: true},
+		{name: "malformed header", secret: "secret", header: "v1=abc", body: body, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Verify(tc.secret, tc.header, tc.body, now, 5*time.Minute)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventQuoteCreated EventType = "quote.created"
+	EventQuoteUpdated EventType = "quote.updated"
+	EventQuoteDeleted EventType = "quote.deleted"
+)
+
+var knownEvents = []EventType{EventQuoteCreated, EventQuoteUpdated, EventQuoteDeleted}
+
+// secretPrefix marks the signing secrets, so they are recognized in configuration files.
+const secretPrefix = "whsec_"
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("delivery not found")
+
+	ErrRepoNotFound = errors.New("repository: not found")
+)
+
+type Webhook struct {
+	ID  uuid.UUID
+	URL string
+	// Events the webhook is subscribed to, it receives every event if empty.
+	Events    []EventType
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type DeliveryStatus string
+
+const (
+	// StatusPending deliveries are attempted at NextAttemptAt.
+	StatusPending   DeliveryStatus = "pending"
+	StatusDelivered DeliveryStatus = "delivered"
+	// StatusDead deliveries failed every attempt, they are retried only on request.
+	StatusDead DeliveryStatus = "dead"
+)
+
+var knownStatuses = []DeliveryStatus{StatusPending, StatusDelivered, StatusDead}
+
+// Delivery is one event queued for one webhook in the same transaction as the write causing it.
+type Delivery struct {
+	ID        int64
+	WebhookID uuid.UUID
+	// EventID is the same for the deliveries of one event to different webhooks.
+	EventID       uuid.UUID
+	EventType     EventType
+	Payload       json.RawMessage
+	Status        DeliveryStatus
+	Attempts      int
+	NextAttemptAt time.Time
+	// LastStatusCode is 0 if the last attempt got no response, LastError is empty if it succeeded.
+	LastStatusCode int
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+}
+
+// Attempt is a delivery claimed by the dispatcher together with the webhook it is sent to.
+type Attempt struct {
+	Delivery
+	URL    string
+	Secret string
+}
+
+// AttemptResult completes an attempt, NextAttemptAt is used for pending deliveries only.
+type AttemptResult struct {
+	Status        DeliveryStatus
+	NextAttemptAt time.Time
+	StatusCode    int
+	Error         string
+}
+
+type DeliveryFilter struct {
+	// Status is ignored if empty.
+	Status DeliveryStatus
+	// BeforeID starts the page before the delivery with the ID, 0 starts at the latest delivery.
+	BeforeID int64
+	Limit    int
+}
+
+type Repository interface {
+	CreateWebhook(ctx context.Context, webhook *Webhook, secret string) error
+	// GetWebhookByID, UpdateWebhook and DeleteWebhook must return ErrRepoNotFound if there is no
+	// webhook with the ID.
+	GetWebhookByID(ctx context.Context, id uuid.UUID) (*Webhook, error)
+	ListWebhooks(ctx context.Context) ([]Webhook, error)
+	UpdateWebhook(ctx context.Context, webhook *Webhook) error
+	DeleteWebhook(ctx context.Context, id uuid.UUID) error
+	// ListDeliveries must return the deliveries of the webhook latest first.
+	ListDeliveries(ctx context.Context, webhookID uuid.UUID, filter DeliveryFilter) ([]Delivery, error)
+	// RetryDelivery must make a dead delivery pending again, it must return ErrRepoNotFound if the
+	// webhook has no dead delivery with the ID.
+	RetryDelivery(ctx context.Context, webhookID uuid.UUID, deliveryID int64) error
+
+	// ClaimDeliveries must return up to limit pending deliveries of active webhooks due for an
+	// attempt, with their attempts incremented. They must not be claimed again before lease passed.
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]Attempt, error)
+	CompleteAttempt(ctx context.Context, deliveryID int64, result AttemptResult) error
+	// DeleteDeliveries deletes the delivered and dead deliveries created before the time.
+	DeleteDeliveries(ctx context.Context, before time.Time) (int64, error)
+}
+
+// ParseEventTypes returns an error for unknown event types.
+func ParseEventTypes(raw []string) ([]EventType, error) {
+	ret := make([]EventType, 0, len(raw))
+	for _, s := range raw {
+		event := EventType(s)
+		if !slices.Contains(knownEvents, event) {
+			return nil, fmt.Errorf("unknown event type %q", s)
+		}
+		if !slices.Contains(ret, event) {
+			ret = append(ret, event)
+		}
+	}
+
+	return ret, nil
+}
+
+// ParseDeliveryStatus returns an error for unknown statuses.
+func ParseDeliveryStatus(raw string) (DeliveryStatus, error) {
+	status := DeliveryStatus(raw)
+	if !slices.Contains(knownStatuses, status) {
+		return "", fmt.Errorf("unknown delivery status %q", raw)
+	}
+
+	return status, nil
+}
+
+// ValidateURL accepts absolute http and https URLs, unless their host is localhost or an address
+// that is not public. Host names resolving to such addresses are rejected by the Dispatcher.
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http or https url")
+	}
+
+	host := u.Hostname()
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.New("url must not point to localhost")
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !isPublicAddr(addr) {
+		return fmt.Errorf("url must not point to the address %s", addr)
+	}
+
+	return nil
+}
+
+// isPublicAddr reports whether deliveries may be sent to the address. Loopback, private,
+// link-local, unspecified and multicast addresses are not public.
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
+
+// sharedAddressSpace is the carrier-grade NAT range of RFC 6598, it is as internal as the
+// private ranges.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+type Service struct {
+	Repository Repository
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{Repository: repo}
+}
+
+// MaxDeliveriesPageSize bounds the deliveries returned by one ListDeliveries call.
+const MaxDeliveriesPageSize = 100
+
+// CreateWebhook returns the created webhook together with the secret signing its deliveries, the
+// secret is not returned anywhere else. The url and events must be validated by the caller.
+func (s *Service) CreateWebhook(ctx context.Context, rawURL string, events []EventType) (*Webhook, string, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return nil, "", fmt.Errorf("generate secret: %w", err)
+	}
+	encodedSecret := secretPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	now := time.Now().UTC()
+	webhook := &Webhook{
+		ID:        uuid.New(),
+		URL:       rawURL,
+		Events:    events,
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err = s.Repository.CreateWebhook(ctx, webhook, encodedSecret)
+	if err != nil {
+		return nil, "", fmt.Errorf("webhook repository: create webhook: %w", err)
+	}
+
+	return webhook, encodedSecret, nil
+}
+
+func (s *Service) GetWebhook(ctx context.Context, id uuid.UUID) (*Webhook, error) {
+	webhook, err := s.Repository.GetWebhookByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrRepoNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("webhook repository: get webhook by id: %w", err)
+	}
+
+	return webhook, nil
+}
+
+func (s *Service) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	webhooks, err := s.Repository.ListWebhooks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("webhook repository: list webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// UpdateWebhook replaces the url, events and active state of the webhook, the secret is kept.
+func (s *Service) UpdateWebhook(ctx context.Context, id uuid.UUID, rawURL string, events []EventType, active bool) (*Webhook, error) {
+	webhook, err := s.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.URL = rawURL
+	webhook.Events = events
+	webhook.Active = active
+	webhook.UpdatedAt = time.Now().UTC()
+
+	err = s.Repository.UpdateWebhook(ctx, webhook)
+	if err != nil {
+		if errors.Is(err, ErrRepoNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("webhook repository: update webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+// DeleteWebhook deletes the deliveries of the webhook as well.
+func (s *Service) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	err := s.Repository.DeleteWebhook(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrRepoNotFound) {
+			return ErrWebhookNotFound
+		}
+		return fmt.Errorf("webhook repository: delete webhook: %w", err)
+	}
+
+	return nil
+}
+
+// ListDeliveries returns the delivery log of the webhook latest first, the limit is clamped to
+// MaxDeliveriesPageSize.
+func (s *Service) ListDeliveries(ctx context.Context, webhookID uuid.UUID, filter DeliveryFilter) ([]Delivery, error) {
+	_, err := s.GetWebhook(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.Limit <= 0 || filter.Limit > MaxDeliveriesPageSize {
+		filter.Limit = MaxDeliveriesPageSize
+	}
+
+	deliveries, err := s.Repository.ListDeliveries(ctx, webhookID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("webhook repository: list deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// RetryDelivery queues a dead delivery again, it returns ErrDeliveryNotFound unless the webhook
+// has a dead delivery with the ID.
+func (s *Service) RetryDelivery(ctx context.Context, webhookID uuid.UUID, deliveryID int64) error {
+	err := s.Repository.RetryDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		if errors.Is(err, ErrRepoNotFound) {
+			return ErrDeliveryNotFound
+		}
+		return fmt.Errorf("webhook repository: retry delivery: %w", err)
+	}
+
+	return nil
+}
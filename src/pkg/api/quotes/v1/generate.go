@@ -0,0 +1,4 @@
+// Package quotesv1 holds the code generated from proto/quotes/v1/quotes.proto.
+package quotesv1
+
+//go:generate protoc -I ../../../../../proto --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative quotes/v1/quotes.proto
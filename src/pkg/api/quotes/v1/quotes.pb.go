@@ -0,0 +1,680 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: quotes/v1/quotes.proto
+
+package quotesv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Quote struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Id     string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Author string                 `protobuf:"bytes,2,opt,name=author,proto3" json:"author,omitempty"`
+	Quote  string                 `protobuf:"bytes,3,opt,name=quote,proto3" json:"quote,omitempty"`
+	// created_by and updated_by are empty for quotes written before ownership was recorded.
+	CreatedBy     string `protobuf:"bytes,4,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	UpdatedBy     string `protobuf:"bytes,5,opt,name=updated_by,json=updatedBy,proto3" json:"updated_by,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Quote) Reset() {
+	*x = Quote{}
+	mi := &file_quotes_v1_quotes_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Quote) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Quote) ProtoMessage() {}
+
+func (x *Quote) ProtoReflect() protoreflect.Message {
+	mi := &file_quotes_v1_quotes_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Quote.ProtoReflect.Descriptor instead.
+func (*Quote) Descriptor() ([]byte, []int) {
+	return file_quotes_v1_quotes_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Quote) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Quote) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *Quote) GetQuote() string {
+	if x != nil {
+		return x.Quote
+	}
+	return ""
+}
+
+func (x *Quote) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+func (x *Quote) GetUpdatedBy() string {
+	if x != nil {
+		return x.UpdatedBy
+	}
+	return ""
+}
+
+type CreateQuoteRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// id is generated if empty.
+	Id            string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Author        string `protobuf:"bytes,2,opt,name=author,proto3" json:"author,omitempty"`
+	Quote         string `protobuf:"bytes,3,opt,name=quote,proto3" json:"quote,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateQuoteRequest) Reset() {
+	*x = CreateQuoteRequest{}
+	mi := &file_quotes_v1_quotes_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateQuoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateQuoteRequest) ProtoMessage() {}
+
+func (x *CreateQuoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_quotes_v1_quotes_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateQuoteRequest.ProtoReflect.Descriptor instead.
+func (*CreateQuoteRequest) Descriptor() ([]byte, []int) {
+	return file_quotes_v1_quotes_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreateQuoteRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *CreateQuoteRequest) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *CreateQuoteRequest) GetQuote() string {
+	if x != nil {
+		return x.Quote
+	}
+	return ""
+}
+
+type GetQuoteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetQuoteRequest) Reset() {
+	*x = GetQuoteRequest{}
+	mi := &file_quotes_v1_quotes_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetQuoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetQuoteRequest) ProtoMessage() {}
+
+func (x *GetQuoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_quotes_v1_quotes_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetQuoteRequest.ProtoReflect.Descriptor instead.
+func (*GetQuoteRequest) Descriptor() ([]byte, []int) {
+	return file_quotes_v1_quotes_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetQuoteRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListQuotesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// author and created_by are ignored if empty.
+	Author    string `protobuf:"bytes,1,opt,name=author,proto3" json:"author,omitempty"`
+	CreatedBy string `protobuf:"bytes,2,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	// page_size defaults to 100 and is at most 1000.
+	PageSize int32 `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// page_token is the next_page_token of the previous page, empty for the first page.
+	PageToken     string `protobuf:"bytes,4,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListQuotesRequest) Reset() {
+	*x = ListQuotesRequest{}
+	mi := &file_quotes_v1_quotes_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListQuotesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListQuotesRequest) ProtoMessage() {}
+
+func (x *ListQuotesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_quotes_v1_quotes_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListQuotesRequest.ProtoReflect.Descriptor instead.
+func (*ListQuotesRequest) Descriptor() ([]byte, []int) {
+	return file_quotes_v1_quotes_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListQuotesRequest) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *ListQuotesRequest) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+func (x *ListQuotesRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListQuotesRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListQuotesResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Quotes []*Quote               `protobuf:"bytes,1,rep,name=quotes,proto3" json:"quotes,omitempty"`
+	// next_page_token is empty on the last page.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListQuotesResponse) Reset() {
+	*x = ListQuotesResponse{}
+	mi := &file_quotes_v1_quotes_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListQuotesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListQuotesResponse) ProtoMessage() {}
+
+func (x *ListQuotesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_quotes_v1_quotes_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListQuotesResponse.ProtoReflect.Descriptor instead.
+func (*ListQuotesResponse) Descriptor() ([]byte, []int) {
+	return file_quotes_v1_quotes_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListQuotesResponse) GetQuotes() []*Quote {
+	if x != nil {
+		return x.Quotes
+	}
+	return nil
+}
+
+func (x *ListQuotesResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type GetRandomQuoteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRandomQuoteRequest) Reset() {
+	*x = GetRandomQuoteRequest{}
+	mi := &file_quotes_v1_quotes_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRandomQuoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRandomQuoteRequest) ProtoMessage() {}
+
+func (x *GetRandomQuoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_quotes_v1_quotes_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRandomQuoteRequest.ProtoReflect.Descriptor instead.
+func (*GetRandomQuoteRequest) Descriptor() ([]byte, []int) {
+	return file_quotes_v1_quotes_proto_rawDescGZIP(), []int{5}
+}
+
+type UpdateQuoteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Author        string                 `protobuf:"bytes,2,opt,name=author,proto3" json:"author,omitempty"`
+	Quote         string                 `protobuf:"bytes,3,opt,name=quote,proto3" json:"quote,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateQuoteRequest) Reset() {
+	*x = UpdateQuoteRequest{}
+	mi := &file_quotes_v1_quotes_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateQuoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateQuoteRequest) ProtoMessage() {}
+
+func (x *UpdateQuoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_quotes_v1_quotes_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateQuoteRequest.ProtoReflect.Descriptor instead.
+func (*UpdateQuoteRequest) Descriptor() ([]byte, []int) {
+	return file_quotes_v1_quotes_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *UpdateQuoteRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateQuoteRequest) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *UpdateQuoteRequest) GetQuote() string {
+	if x != nil {
+		return x.Quote
+	}
+	return ""
+}
+
+type DeleteQuoteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteQuoteRequest) Reset() {
+	*x = DeleteQuoteRequest{}
+	mi := &file_quotes_v1_quotes_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteQuoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteQuoteRequest) ProtoMessage() {}
+
+func (x *DeleteQuoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_quotes_v1_quotes_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteQuoteRequest.ProtoReflect.Descriptor instead.
+func (*DeleteQuoteRequest) Descriptor() ([]byte, []int) {
+	return file_quotes_v1_quotes_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DeleteQuoteRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteQuoteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteQuoteResponse) Reset() {
+	*x = DeleteQuoteResponse{}
+	mi := &file_quotes_v1_quotes_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteQuoteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteQuoteResponse) ProtoMessage() {}
+
+func (x *DeleteQuoteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_quotes_v1_quotes_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteQuoteResponse.ProtoReflect.Descriptor instead.
+func (*DeleteQuoteResponse) Descriptor() ([]byte, []int) {
+	return file_quotes_v1_quotes_proto_rawDescGZIP(), []int{8}
+}
+
+type ExportQuotesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// author and created_by are ignored if empty.
+	Author        string `protobuf:"bytes,1,opt,name=author,proto3" json:"author,omitempty"`
+	CreatedBy     string `protobuf:"bytes,2,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportQuotesRequest) Reset() {
+	*x = ExportQuotesRequest{}
+	mi := &file_quotes_v1_quotes_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportQuotesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportQuotesRequest) ProtoMessage() {}
+
+func (x *ExportQuotesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_quotes_v1_quotes_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportQuotesRequest.ProtoReflect.Descriptor instead.
+func (*ExportQuotesRequest) Descriptor() ([]byte, []int) {
+	return file_quotes_v1_quotes_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ExportQuotesRequest) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *ExportQuotesRequest) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+var File_quotes_v1_quotes_proto protoreflect.FileDescriptor
+
+const file_quotes_v1_quotes_proto_rawDesc = "" +
+	"\n" +
+	"\x16quotes/v1/quotes.proto\x12\tquotes.v1\"\x83\x01\n" +
+	"\x05Quote\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06author\x18\x02 \x01(\tR\x06author\x12\x14\n" +
+	"\x05quote\x18\x03 \x01(\tR\x05quote\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\x04 \x01(\tR\tcreatedBy\x12\x1d\n" +
+	"\n" +
+	"updated_by\x18\x05 \x01(\tR\tupdatedBy\"R\n" +
+	"\x12CreateQuoteRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06author\x18\x02 \x01(\tR\x06author\x12\x14\n" +
+	"\x05quote\x18\x03 \x01(\tR\x05quote\"!\n" +
+	"\x0fGetQuoteRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x86\x01\n" +
+	"\x11ListQuotesRequest\x12\x16\n" +
+	"\x06author\x18\x01 \x01(\tR\x06author\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\x02 \x01(\tR\tcreatedBy\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x04 \x01(\tR\tpageToken\"f\n" +
+	"\x12ListQuotesResponse\x12(\n" +
+	"\x06quotes\x18\x01 \x03(\v2\x10.quotes.v1.QuoteR\x06quotes\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\x17\n" +
+	"\x15GetRandomQuoteRequest\"R\n" +
+	"\x12UpdateQuoteRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06author\x18\x02 \x01(\tR\x06author\x12\x14\n" +
+	"\x05quote\x18\x03 \x01(\tR\x05quote\"$\n" +
+	"\x12DeleteQuoteRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x15\n" +
+	"\x13DeleteQuoteResponse\"L\n" +
+	"\x13ExportQuotesRequest\x12\x16\n" +
+	"\x06author\x18\x01 \x01(\tR\x06author\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\x02 \x01(\tR\tcreatedBy2\xeb\x03\n" +
+	"\fQuoteService\x12>\n" +
+	"\vCreateQuote\x12\x1d.quotes.v1.CreateQuoteRequest\x1a\x10.quotes.v1.Quote\x128\n" +
+	"\bGetQuote\x12\x1a.quotes.v1.GetQuoteRequest\x1a\x10.quotes.v1.Quote\x12I\n" +
+	"\n" +
+	"ListQuotes\x12\x1c.quotes.v1.ListQuotesRequest\x1a\x1d.quotes.v1.ListQuotesResponse\x12D\n" +
+	"\x0eGetRandomQuote\x12 .quotes.v1.GetRandomQuoteRequest\x1a\x10.quotes.v1.Quote\x12>\n" +
+	"\vUpdateQuote\x12\x1d.quotes.v1.UpdateQuoteRequest\x1a\x10.quotes.v1.Quote\x12L\n" +
+	"\vDeleteQuote\x12\x1d.quotes.v1.DeleteQuoteRequest\x1a\x1e.quotes.v1.DeleteQuoteResponse\x12B\n" +
+	"\fExportQuotes\x12\x1e.quotes.v1.ExportQuotesRequest\x1a\x10.quotes.v1.Quote0\x01BHZFgithub.com/BernsteinMondy/quote-service/src/pkg/api/quotes/v1;quotesv1b\x06proto3"
+
+var (
+	file_quotes_v1_quotes_proto_rawDescOnce sync.Once
+	file_quotes_v1_quotes_proto_rawDescData []byte
+)
+
+func file_quotes_v1_quotes_proto_rawDescGZIP() []byte {
+	file_quotes_v1_quotes_proto_rawDescOnce.Do(func() {
+		file_quotes_v1_quotes_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_quotes_v1_quotes_proto_rawDesc), len(file_quotes_v1_quotes_proto_rawDesc)))
+	})
+	return file_quotes_v1_quotes_proto_rawDescData
+}
+
+var file_quotes_v1_quotes_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_quotes_v1_quotes_proto_goTypes = []any{
+	(*Quote)(nil),                 // 0: quotes.v1.Quote
+	(*CreateQuoteRequest)(nil),    // 1: quotes.v1.CreateQuoteRequest
+	(*GetQuoteRequest)(nil),       // 2: quotes.v1.GetQuoteRequest
+	(*ListQuotesRequest)(nil),     // 3: quotes.v1.ListQuotesRequest
+	(*ListQuotesResponse)(nil),    // 4: quotes.v1.ListQuotesResponse
+	(*GetRandomQuoteRequest)(nil), // 5: quotes.v1.GetRandomQuoteRequest
+	(*UpdateQuoteRequest)(nil),    // 6: quotes.v1.UpdateQuoteRequest
+	(*DeleteQuoteRequest)(nil),    // 7: quotes.v1.DeleteQuoteRequest
+	(*DeleteQuoteResponse)(nil),   // 8: quotes.v1.DeleteQuoteResponse
+	(*ExportQuotesRequest)(nil),   // 9: quotes.v1.ExportQuotesRequest
+}
+var file_quotes_v1_quotes_proto_depIdxs = []int32{
+	0, // 0: quotes.v1.ListQuotesResponse.quotes:type_name -> quotes.v1.Quote
+	1, // 1: quotes.v1.QuoteService.CreateQuote:input_type -> quotes.v1.CreateQuoteRequest
+	2, // 2: quotes.v1.QuoteService.GetQuote:input_type -> quotes.v1.GetQuoteRequest
+	3, // 3: quotes.v1.QuoteService.ListQuotes:input_type -> quotes.v1.ListQuotesRequest
+	5, // 4: quotes.v1.QuoteService.GetRandomQuote:input_type -> quotes.v1.GetRandomQuoteRequest
+	6, // 5: quotes.v1.QuoteService.UpdateQuote:input_type -> quotes.v1.UpdateQuoteRequest
+	7, // 6: quotes.v1.QuoteService.DeleteQuote:input_type -> quotes.v1.DeleteQuoteRequest
+	9, // 7: quotes.v1.QuoteService.ExportQuotes:input_type -> quotes.v1.ExportQuotesRequest
+	0, // 8: quotes.v1.QuoteService.CreateQuote:output_type -> quotes.v1.Quote
+	0, // 9: quotes.v1.QuoteService.GetQuote:output_type -> quotes.v1.Quote
+	4, // 10: quotes.v1.QuoteService.ListQuotes:output_type -> quotes.v1.ListQuotesResponse
+	0, // 11: quotes.v1.QuoteService.GetRandomQuote:output_type -> quotes.v1.Quote
+	0, // 12: quotes.v1.QuoteService.UpdateQuote:output_type -> quotes.v1.Quote
+	8, // 13: quotes.v1.QuoteService.DeleteQuote:output_type -> quotes.v1.DeleteQuoteResponse
+	0, // 14: quotes.v1.QuoteService.ExportQuotes:output_type -> quotes.v1.Quote
+	8, // [8:15] is the sub-list for method output_type
+	1, // [1:8] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_quotes_v1_quotes_proto_init() }
+func file_quotes_v1_quotes_proto_init() {
+	if File_quotes_v1_quotes_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_quotes_v1_quotes_proto_rawDesc), len(file_quotes_v1_quotes_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_quotes_v1_quotes_proto_goTypes,
+		DependencyIndexes: file_quotes_v1_quotes_proto_depIdxs,
+		MessageInfos:      file_quotes_v1_quotes_proto_msgTypes,
+	}.Build()
+	File_quotes_v1_quotes_proto = out.File
+	file_quotes_v1_quotes_proto_goTypes = nil
+	file_quotes_v1_quotes_proto_depIdxs = nil
+}
@@ -0,0 +1,371 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: quotes/v1/quotes.proto
+
+package quotesv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	QuoteService_CreateQuote_FullMethodName    = "/quotes.v1.QuoteService/CreateQuote"
+	QuoteService_GetQuote_FullMethodName       = "/quotes.v1.QuoteService/GetQuote"
+	QuoteService_ListQuotes_FullMethodName     = "/quotes.v1.QuoteService/ListQuotes"
+	QuoteService_GetRandomQuote_FullMethodName = "/quotes.v1.QuoteService/GetRandomQuote"
+	QuoteService_UpdateQuote_FullMethodName    = "/quotes.v1.QuoteService/UpdateQuote"
+	QuoteService_DeleteQuote_FullMethodName    = "/quotes.v1.QuoteService/DeleteQuote"
+	QuoteService_ExportQuotes_FullMethodName   = "/quotes.v1.QuoteService/ExportQuotes"
+)
+
+// QuoteServiceClient is the client API for QuoteService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// QuoteService mirrors the /quotes HTTP endpoints.
+//
+// Credentials are sent in the "x-api-key" or "authorization" ("Bearer <jwt>") metadata, writes
+// need the "quotes:write" scope and deletes the "quotes:delete" scope. Invalid input results in
+// INVALID_ARGUMENT with a google.rpc.BadRequest detail listing the invalid fields.
+type QuoteServiceClient interface {
+	CreateQuote(ctx context.Context, in *CreateQuoteRequest, opts ...grpc.CallOption) (*Quote, error)
+	GetQuote(ctx context.Context, in *GetQuoteRequest, opts ...grpc.CallOption) (*Quote, error)
+	ListQuotes(ctx context.Context, in *ListQuotesRequest, opts ...grpc.CallOption) (*ListQuotesResponse, error)
+	GetRandomQuote(ctx context.Context, in *GetRandomQuoteRequest, opts ...grpc.CallOption) (*Quote, error)
+	// UpdateQuote and DeleteQuote result in PERMISSION_DENIED unless the caller created the quote
+	// or has the "quotes:moderate" scope.
+	UpdateQuote(ctx context.Context, in *UpdateQuoteRequest, opts ...grpc.CallOption) (*Quote, error)
+	DeleteQuote(ctx context.Context, in *DeleteQuoteRequest, opts ...grpc.CallOption) (*DeleteQuoteResponse, error)
+	// ExportQuotes streams every quote matching the filters ordered by ID.
+	ExportQuotes(ctx context.Context, in *ExportQuotesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Quote], error)
+}
+
+type quoteServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewQuoteServiceClient(cc grpc.ClientConnInterface) QuoteServiceClient {
+	return &quoteServiceClient{cc}
+}
+
+func (c *quoteServiceClient) CreateQuote(ctx context.Context, in *CreateQuoteRequest, opts ...grpc.CallOption) (*Quote, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Quote)
+	err := c.cc.Invoke(ctx, QuoteService_CreateQuote_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *quoteServiceClient) GetQuote(ctx context.Context, in *GetQuoteRequest, opts ...grpc.CallOption) (*Quote, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Quote)
+	err := c.cc.Invoke(ctx, QuoteService_GetQuote_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *quoteServiceClient) ListQuotes(ctx context.Context, in *ListQuotesRequest, opts ...grpc.CallOption) (*ListQuotesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListQuotesResponse)
+	err := c.cc.Invoke(ctx, QuoteService_ListQuotes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *quoteServiceClient) GetRandomQuote(ctx context.Context, in *GetRandomQuoteRequest, opts ...grpc.CallOption) (*Quote, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Quote)
+	err := c.cc.Invoke(ctx, QuoteService_GetRandomQuote_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *quoteServiceClient) UpdateQuote(ctx context.Context, in *UpdateQuoteRequest, opts ...grpc.CallOption) (*Quote, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Quote)
+	err := c.cc.Invoke(ctx, QuoteService_UpdateQuote_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *quoteServiceClient) DeleteQuote(ctx context.Context, in *DeleteQuoteRequest, opts ...grpc.CallOption) (*DeleteQuoteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteQuoteResponse)
+	err := c.cc.Invoke(ctx, QuoteService_DeleteQuote_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *quoteServiceClient) ExportQuotes(ctx context.Context, in *ExportQuotesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Quote], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &QuoteService_ServiceDesc.Streams[0], QuoteService_ExportQuotes_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExportQuotesRequest, Quote]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type QuoteService_ExportQuotesClient = grpc.ServerStreamingClient[Quote]
+
+// QuoteServiceServer is the server API for QuoteService service.
+// All implementations must embed UnimplementedQuoteServiceServer
+// for forward compatibility.
+//
+// QuoteService mirrors the /quotes HTTP endpoints.
+//
+// Credentials are sent in the "x-api-key" or "authorization" ("Bearer <jwt>") metadata, writes
+// need the "quotes:write" scope and deletes the "quotes:delete" scope. Invalid input results in
+// INVALID_ARGUMENT with a google.rpc.BadRequest detail listing the invalid fields.
+type QuoteServiceServer interface {
+	CreateQuote(context.Context, *CreateQuoteRequest) (*Quote, error)
+	GetQuote(context.Context, *GetQuoteRequest) (*Quote, error)
+	ListQuotes(context.Context, *ListQuotesRequest) (*ListQuotesResponse, error)
+	GetRandomQuote(context.Context, *GetRandomQuoteRequest) (*Quote, error)
+	// UpdateQuote and DeleteQuote result in PERMISSION_DENIED unless the caller created the quote
+	// or has the "quotes:moderate" scope.
+	UpdateQuote(context.Context, *UpdateQuoteRequest) (*Quote, error)
+	DeleteQuote(context.Context, *DeleteQuoteRequest) (*DeleteQuoteResponse, error)
+	// ExportQuotes streams every quote matching the filters ordered by ID.
+	ExportQuotes(*ExportQuotesRequest, grpc.ServerStreamingServer[Quote]) error
+	mustEmbedUnimplementedQuoteServiceServer()
+}
+
+// UnimplementedQuoteServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedQuoteServiceServer struct{}
+
+func (UnimplementedQuoteServiceServer) CreateQuote(context.Context, *CreateQuoteRequest) (*Quote, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateQuote not implemented")
+}
+func (UnimplementedQuoteServiceServer) GetQuote(context.Context, *GetQuoteRequest) (*Quote, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetQuote not implemented")
+}
+func (UnimplementedQuoteServiceServer) ListQuotes(context.Context, *ListQuotesRequest) (*ListQuotesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListQuotes not implemented")
+}
+func (UnimplementedQuoteServiceServer) GetRandomQuote(context.Context, *GetRandomQuoteRequest) (*Quote, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRandomQuote not implemented")
+}
+func (UnimplementedQuoteServiceServer) UpdateQuote(context.Context, *UpdateQuoteRequest) (*Quote, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateQuote not implemented")
+}
+func (UnimplementedQuoteServiceServer) DeleteQuote(context.Context, *DeleteQuoteRequest) (*DeleteQuoteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteQuote not implemented")
+}
+func (UnimplementedQuoteServiceServer) ExportQuotes(*ExportQuotesRequest, grpc.ServerStreamingServer[Quote]) error {
+	return status.Errorf(codes.Unimplemented, "method ExportQuotes not implemented")
+}
+func (UnimplementedQuoteServiceServer) mustEmbedUnimplementedQuoteServiceServer() {}
+func (UnimplementedQuoteServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeQuoteServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to QuoteServiceServer will
+// result in compilation errors.
+type UnsafeQuoteServiceServer interface {
+	mustEmbedUnimplementedQuoteServiceServer()
+}
+
+func RegisterQuoteServiceServer(s grpc.ServiceRegistrar, srv QuoteServiceServer) {
+	// If the following call pancis, it indicates UnimplementedQuoteServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&QuoteService_ServiceDesc, srv)
+}
+
+func _QuoteService_CreateQuote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateQuoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuoteServiceServer).CreateQuote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QuoteService_CreateQuote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuoteServiceServer).CreateQuote(ctx, req.(*CreateQuoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QuoteService_GetQuote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetQuoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuoteServiceServer).GetQuote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QuoteService_GetQuote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuoteServiceServer).GetQuote(ctx, req.(*GetQuoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QuoteService_ListQuotes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListQuotesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuoteServiceServer).ListQuotes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QuoteService_ListQuotes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuoteServiceServer).ListQuotes(ctx, req.(*ListQuotesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QuoteService_GetRandomQuote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRandomQuoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuoteServiceServer).GetRandomQuote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QuoteService_GetRandomQuote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuoteServiceServer).GetRandomQuote(ctx, req.(*GetRandomQuoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QuoteService_UpdateQuote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateQuoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuoteServiceServer).UpdateQuote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QuoteService_UpdateQuote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuoteServiceServer).UpdateQuote(ctx, req.(*UpdateQuoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QuoteService_DeleteQuote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteQuoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuoteServiceServer).DeleteQuote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QuoteService_DeleteQuote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuoteServiceServer).DeleteQuote(ctx, req.(*DeleteQuoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QuoteService_ExportQuotes_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportQuotesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(QuoteServiceServer).ExportQuotes(m, &grpc.GenericServerStream[ExportQuotesRequest, Quote]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type QuoteService_ExportQuotesServer = grpc.ServerStreamingServer[Quote]
+
+// QuoteService_ServiceDesc is the grpc.ServiceDesc for QuoteService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var QuoteService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "quotes.v1.QuoteService",
+	HandlerType: (*QuoteServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateQuote",
+			Handler:    _QuoteService_CreateQuote_Handler,
+		},
+		{
+			MethodName: "GetQuote",
+			Handler:    _QuoteService_GetQuote_Handler,
+		},
+		{
+			MethodName: "ListQuotes",
+			Handler:    _QuoteService_ListQuotes_Handler,
+		},
+		{
+			MethodName: "GetRandomQuote",
+			Handler:    _QuoteService_GetRandomQuote_Handler,
+		},
+		{
+			MethodName: "UpdateQuote",
+			Handler:    _QuoteService_UpdateQuote_Handler,
+		},
+		{
+			MethodName: "DeleteQuote",
+			Handler:    _QuoteService_DeleteQuote_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportQuotes",
+			Handler:       _QuoteService_ExportQuotes_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "quotes/v1/quotes.proto",
+}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	initialPingBackoff = 100 * time.Millisecond
+	maxPingBackoff     = 5 * time.Second
+)
+
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// WaitForConnection pings the database with exponential backoff until it responds
+// or the timeout expires.
+func WaitForConnection(ctx context.Context, db Pinger, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	backoff := initialPingBackoff
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+
+		slog.Warn("Database is not reachable yet",
+			slog.Int("attempt", attempt),
+			slog.Duration("retry_in", backoff),
+			slog.String("error", err.Error()),
+		)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database is not reachable after %d attempts: %w", attempt, errors.Join(err, ctx.Err()))
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, maxPingBackoff)
+	}
+}
+
+// LogPoolStats periodically logs the connection pool statistics until ctx is done.
+func LogPoolStats(ctx context.Context, db *sql.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		stats := db.Stats()
+		slog.Info("Database pool stats",
+			slog.Int("max_open_connections", stats.MaxOpenConnections),
+			slog.Int("open_connections", stats.OpenConnections),
+			slog.Int("in_use", stats.InUse),
+			slog.Int("idle", stats.Idle),
+			slog.Int64("wait_count", stats.WaitCount),
+			slog.Duration("wait_duration", stats.WaitDuration),
+			slog.Int64("max_idle_closed", stats.MaxIdleClosed),
+			slog.Int64("max_idle_time_closed", stats.MaxIdleTimeClosed),
+			slog.Int64("max_lifetime_closed", stats.MaxLifetimeClosed),
+		)
+	}
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type flakyPinger struct {
+	failures int
+	calls    int
+}
+
+func (p *flakyPinger) PingContext(context.Context) error {
+	p.calls++
+	if p.calls <= p.failures {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func TestWaitForConnection(t *testing.T) {
+	type testCase struct {
+		name      string
+		pinger    *flakyPinger
+		timeout   time.Duration
+		wantErr   bool
+		wantCalls int
+		// wantMinCalls replaces wantCalls where the number of pings depends on the scheduling of
+		// the backoff sleeps.
+		wantMinCalls int
+	}
+
+	testCases := []testCase{
+		{
+			name:      "Reachable database results in a single ping",
+			pinger:    &flakyPinger{},
+			timeout:   time.Second,
+			wantCalls: 1,
+		},
+		{
+			name:      "Database becoming reachable is retried with backoff",
+			pinger:    &flakyPinger{failures: 2},
+			timeout:   time.Second,
+			wantCalls: 3,
+		},
+		{
+			name:         "Database not reachable within timeout results in error",
+			pinger:       &flakyPinger{failures: 100},
+			timeout:      250 * time.Millisecond,
+			wantErr:      true,
+			wantMinCalls: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := WaitForConnection(context.Background(), tc.pinger, tc.timeout)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("WaitForConnection() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantMinCalls > 0 {
+				if tc.pinger.calls < tc.wantMinCalls {
+					t.Errorf("WaitForConnection() pinged %d times, want at least %d", tc.pinger.calls, tc.wantMinCalls)
+				}
+			} else if tc.pinger.calls != tc.wantCalls {
+				t.Errorf("WaitForConnection() pinged %d times, want %d", tc.pinger.calls, tc.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"log/slog"
+	"time"
+)
+
+// Listen calls handle with the payload of every notification sent on channel until ctx is done.
+// It holds a connection of its own, which is re-established retryInterval after it failed.
+// Notifications sent while the connection is down are lost.
+func Listen(ctx context.Context, cfg Config, channel string, retryInterval time.Duration, handle func(payload string)) {
+	for {
+		err := listen(ctx, cfg, channel, handle)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Warn("Listening for notifications failed",
+			slog.String("channel", channel),
+			slog.String("error", err.Error()),
+			slog.Duration("retry_in", retryInterval),
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+func listen(ctx context.Context, cfg Config, channel string, handle func(payload string)) error {
+	conn, err := pgx.Connect(ctx, connString(cfg))
+	if err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = conn.Close(closeCtx)
+	}()
+
+	_, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize())
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	slog.Info("Listening for notifications", slog.String("channel", channel))
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("wait for notification: %w", err)
+		}
+		handle(notification.Payload)
+	}
+}
@@ -0,0 +1,126 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"time"
+)
+
+// Migrator applies goose migrations from fsys.
+//
+// Every operation holds a Postgres session-level advisory lock, so several replicas
+// migrating on startup at the same time apply each migration exactly once.
+type Migrator struct {
+	provider *goose.Provider
+}
+
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+func NewMigrator(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, fmt.Errorf("new postgres session locker: %w", err)
+	}
+
+	provider, err := goose.NewProvider(goose.DialectPostgres, db, fsys, goose.WithSessionLocker(locker))
+	if err != nil {
+		return nil, fmt.Errorf("new goose provider: %w", err)
+	}
+
+	return &Migrator{provider: provider}, nil
+}
+
+func (m *Migrator) Up(ctx context.Context) error {
+	results, err := m.provider.Up(ctx)
+	logMigrationResults(results...)
+	if err != nil {
+		return fmt.Errorf("migrate up: %w", err)
+	}
+
+	return nil
+}
+
+func (m *Migrator) Down(ctx context.Context) error {
+	result, err := m.provider.Down(ctx)
+	if result != nil {
+		logMigrationResults(result)
+	}
+	if err != nil {
+		return fmt.Errorf("migrate down: %w", err)
+	}
+
+	return nil
+}
+
+// Redo rolls back the most recently applied migration and applies it again.
+func (m *Migrator) Redo(ctx context.Context) error {
+	err := m.Down(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := m.provider.UpByOne(ctx)
+	if result != nil {
+		logMigrationResults(result)
+	}
+	if err != nil {
+		return fmt.Errorf("migrate up by one: %w", err)
+	}
+
+	return nil
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	statuses, err := m.provider.Status(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("migration status: %w", err)
+	}
+
+	ret := make([]MigrationStatus, len(statuses))
+	for i, status := range statuses {
+		ret[i] = MigrationStatus{
+			Version:   status.Source.Version,
+			Name:      filepath.Base(status.Source.Path),
+			Applied:   status.State == goose.StateApplied,
+			AppliedAt: status.AppliedAt,
+		}
+	}
+
+	return ret, nil
+}
+
+// Versions returns the version of the database schema and the latest known migration version.
+func (m *Migrator) Versions(ctx context.Context) (current, latest int64, err error) {
+	current, latest, err = m.provider.GetVersions(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("get versions: %w", err)
+	}
+
+	return current, latest, nil
+}
+
+func logMigrationResults(results ...*goose.MigrationResult) {
+	for _, result := range results {
+		attrs := []any{
+			slog.String("direction", result.Direction),
+			slog.String("migration", filepath.Base(result.Source.Path)),
+			slog.Duration("duration", result.Duration),
+		}
+		if result.Error != nil {
+			slog.Error("Migration failed", append(attrs, slog.String("error", result.Error.Error()))...)
+			continue
+		}
+		slog.Info("Migration applied", attrs...)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	_ "github.com/jackc/pgx/v5/stdlib"
+	"time"
 )
 
 type Config struct {
@@ -13,14 +14,34 @@ type Config struct {
 	Host     string
 	Port     int
 	SSLMode  string
+
+	// Pool settings, zero values keep the database/sql defaults.
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
 }
 
+// NewSQLDatabase does not establish a connection, use WaitForConnection to make sure the database is reachable.
 func NewSQLDatabase(cfg Config) (*sql.DB, error) {
 	db, err := sql.Open("pgx", connString(cfg))
 	if err != nil {
 		return nil, fmt.Errorf("sql.Open() returned error: %w", err)
 	}
 
+	if cfg.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
+
 	return db, nil
 }
 